@@ -0,0 +1,166 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// redactedValue replaces the values of redacted attributes.
+const redactedValue = "[REDACTED]"
+
+func (cfg AnonymizeAttributesConfig) validate() error {
+	hashed := make(map[string]bool, len(cfg.Hash))
+	for i, key := range cfg.Hash {
+		if key == "" {
+			return fmt.Errorf("anonymize_attributes.hash[%d] must not be empty", i)
+		}
+		hashed[key] = true
+	}
+	for i, key := range cfg.Redact {
+		if key == "" {
+			return fmt.Errorf("anonymize_attributes.redact[%d] must not be empty", i)
+		}
+		if hashed[key] {
+			return fmt.Errorf("anonymize_attributes: attribute %q is both hashed and redacted", key)
+		}
+	}
+	return nil
+}
+
+// attributeAnonymizer replaces the values of hashed and redacted attributes.
+type attributeAnonymizer struct {
+	hash   map[string]bool
+	redact map[string]bool
+	salt   string
+}
+
+// anonymizer returns the anonymizer of cfg, or nil when no attribute is
+// hashed or redacted.
+func (cfg AnonymizeAttributesConfig) anonymizer() *attributeAnonymizer {
+	if len(cfg.Hash) == 0 && len(cfg.Redact) == 0 {
+		return nil
+	}
+	return &attributeAnonymizer{hash: keySet(cfg.Hash), redact: keySet(cfg.Redact), salt: string(cfg.Salt)}
+}
+
+// attributes replaces the values of the hashed and redacted keys of attrs.
+func (a *attributeAnonymizer) attributes(attrs pcommon.Map) {
+	for k, v := range attrs.All() {
+		switch {
+		case a.redact[k]:
+			v.SetStr(redactedValue)
+		case a.hash[k]:
+			sum := sha256.Sum256([]byte(a.salt + v.AsString()))
+			v.SetStr(hex.EncodeToString(sum[:]))
+		}
+	}
+}
+
+// traces returns td with its attributes anonymized. td is not modified,
+// since it is shared with other components of the pipeline.
+func (a *attributeAnonymizer) traces(td ptrace.Traces) ptrace.Traces {
+	if a == nil {
+		return td
+	}
+	out := ptrace.NewTraces()
+	td.CopyTo(out)
+	for _, rs := range out.ResourceSpans().All() {
+		a.attributes(rs.Resource().Attributes())
+		for _, ss := range rs.ScopeSpans().All() {
+			a.attributes(ss.Scope().Attributes())
+			for _, span := range ss.Spans().All() {
+				a.attributes(span.Attributes())
+				for _, event := range span.Events().All() {
+					a.attributes(event.Attributes())
+				}
+				for _, link := range span.Links().All() {
+					a.attributes(link.Attributes())
+				}
+			}
+		}
+	}
+	return out
+}
+
+// metrics returns md with its attributes anonymized. md is not modified.
+func (a *attributeAnonymizer) metrics(md pmetric.Metrics) pmetric.Metrics {
+	if a == nil {
+		return md
+	}
+	out := pmetric.NewMetrics()
+	md.CopyTo(out)
+	for _, rm := range out.ResourceMetrics().All() {
+		a.attributes(rm.Resource().Attributes())
+		for _, sm := range rm.ScopeMetrics().All() {
+			a.attributes(sm.Scope().Attributes())
+			for _, metric := range sm.Metrics().All() {
+				a.dataPoints(metric)
+			}
+		}
+	}
+	return out
+}
+
+// dataPoints anonymizes the attributes of the data points of metric and of
+// their exemplars.
+func (a *attributeAnonymizer) dataPoints(metric pmetric.Metric) {
+	exemplars := func(exemplars pmetric.ExemplarSlice) {
+		for _, e := range exemplars.All() {
+			a.attributes(e.FilteredAttributes())
+		}
+	}
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for _, dp := range metric.Gauge().DataPoints().All() {
+			a.attributes(dp.Attributes())
+			exemplars(dp.Exemplars())
+		}
+	case pmetric.MetricTypeSum:
+		for _, dp := range metric.Sum().DataPoints().All() {
+			a.attributes(dp.Attributes())
+			exemplars(dp.Exemplars())
+		}
+	case pmetric.MetricTypeHistogram:
+		for _, dp := range metric.Histogram().DataPoints().All() {
+			a.attributes(dp.Attributes())
+			exemplars(dp.Exemplars())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for _, dp := range metric.ExponentialHistogram().DataPoints().All() {
+			a.attributes(dp.Attributes())
+			exemplars(dp.Exemplars())
+		}
+	case pmetric.MetricTypeSummary:
+		for _, dp := range metric.Summary().DataPoints().All() {
+			a.attributes(dp.Attributes())
+		}
+	}
+}
+
+// logs returns ld with its attributes anonymized. ld is not modified.
+func (a *attributeAnonymizer) logs(ld plog.Logs) plog.Logs {
+	if a == nil {
+		return ld
+	}
+	out := plog.NewLogs()
+	ld.CopyTo(out)
+	for _, rl := range out.ResourceLogs().All() {
+		a.attributes(rl.Resource().Attributes())
+		for _, sl := range rl.ScopeLogs().All() {
+			a.attributes(sl.Scope().Attributes())
+			for _, lr := range sl.LogRecords().All() {
+				a.attributes(lr.Attributes())
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAnonymizerNil(t *testing.T) {
+	assert.Nil(t, AnonymizeAttributesConfig{Salt: "s"}.anonymizer())
+
+	var a *attributeAnonymizer
+	td := ptrace.NewTraces()
+	assert.Equal(t, td, a.traces(td))
+}
+
+func TestAnonymizeTraces(t *testing.T) {
+	a := AnonymizeAttributesConfig{Hash: []string{"enduser.id"}, Redact: []string{"client.address"}, Salt: "pepper"}.anonymizer()
+
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("client.address", "10.0.0.1")
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.Attributes().PutStr("enduser.id", "alice")
+	span.Attributes().PutStr("http.route", "/users")
+	span.Events().AppendEmpty().Attributes().PutInt("enduser.id", 42)
+	span.Links().AppendEmpty().Attributes().PutStr("client.address", "10.0.0.2")
+
+	out := a.traces(td)
+	outSpan := out.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, map[string]any{"client.address": redactedValue}, out.ResourceSpans().At(0).Resource().Attributes().AsRaw())
+	assert.Equal(t, map[string]any{"enduser.id": sha256Hex("pepperalice"), "http.route": "/users"}, outSpan.Attributes().AsRaw())
+	assert.Equal(t, map[string]any{"enduser.id": sha256Hex("pepper42")}, outSpan.Events().At(0).Attributes().AsRaw())
+	assert.Equal(t, map[string]any{"client.address": redactedValue}, outSpan.Links().At(0).Attributes().AsRaw())
+
+	v, _ := span.Attributes().Get("enduser.id")
+	assert.Equal(t, "alice", v.Str(), "input is not modified")
+}
+
+func TestAnonymizeMetrics(t *testing.T) {
+	a := AnonymizeAttributesConfig{Hash: []string{"enduser.id"}}.anonymizer()
+
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	dp := metrics.AppendEmpty().SetEmptySum().DataPoints().AppendEmpty()
+	dp.Attributes().PutStr("enduser.id", "alice")
+	dp.Exemplars().AppendEmpty().FilteredAttributes().PutStr("enduser.id", "bob")
+	metrics.AppendEmpty().SetEmptySummary().DataPoints().AppendEmpty().Attributes().PutStr("enduser.id", "carol")
+
+	out := a.metrics(md).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	outDP := out.At(0).Sum().DataPoints().At(0)
+	assert.Equal(t, map[string]any{"enduser.id": sha256Hex("alice")}, outDP.Attributes().AsRaw())
+	assert.Equal(t, map[string]any{"enduser.id": sha256Hex("bob")}, outDP.Exemplars().At(0).FilteredAttributes().AsRaw())
+	assert.Equal(t, map[string]any{"enduser.id": sha256Hex("carol")}, out.At(1).Summary().DataPoints().At(0).Attributes().AsRaw())
+}
+
+func TestAnonymizeLogs(t *testing.T) {
+	a := AnonymizeAttributesConfig{Redact: []string{"client.address"}}.anonymizer()
+
+	ld := plog.NewLogs()
+	sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	sl.Scope().Attributes().PutStr("client.address", "10.0.0.1")
+	lr := sl.LogRecords().AppendEmpty()
+	lr.Attributes().PutEmptyMap("client.address").PutStr("ip", "10.0.0.1")
+	lr.Body().SetStr("client.address")
+
+	outSL := a.logs(ld).ResourceLogs().At(0).ScopeLogs().At(0)
+	assert.Equal(t, map[string]any{"client.address": redactedValue}, outSL.Scope().Attributes().AsRaw())
+	assert.Equal(t, map[string]any{"client.address": redactedValue}, outSL.LogRecords().At(0).Attributes().AsRaw())
+	assert.Equal(t, pcommon.ValueTypeStr, outSL.LogRecords().At(0).Body().Type())
+}
@@ -0,0 +1,94 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// attributePatterns caches the compiled attribute filter patterns, since
+// the filters are built from the configuration for every batch.
+var attributePatterns sync.Map
+
+func (cfg AttributeFiltersConfig) validate(field string) error {
+	if err := cfg.Resource.validate(field + ".resource"); err != nil {
+		return err
+	}
+	if err := cfg.Scope.validate(field + ".scope"); err != nil {
+		return err
+	}
+	return cfg.Record.validate(field + ".record")
+}
+
+func (cfg AttributeFilterConfig) validate(field string) error {
+	for name, keys := range map[string][]string{"include": cfg.Include, "exclude": cfg.Exclude} {
+		for i, key := range keys {
+			if key == "" {
+				return fmt.Errorf("%s.%s[%d] must not be empty", field, name, i)
+			}
+		}
+	}
+	for name, patterns := range map[string][]string{"include_patterns": cfg.IncludePatterns, "exclude_patterns": cfg.ExcludePatterns} {
+		for i, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("%s.%s[%d]: %w", field, name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// filters returns the conversion options of the filters.
+func (cfg AttributeFiltersConfig) filters() rowconv.AttributeFilters {
+	return rowconv.AttributeFilters{
+		Resource: cfg.Resource.filter(),
+		Scope:    cfg.Scope.filter(),
+		Record:   cfg.Record.filter(),
+	}
+}
+
+// filter returns the conversion option of the filter, or nil when it keeps
+// every attribute.
+func (cfg AttributeFilterConfig) filter() *rowconv.AttributeFilter {
+	if len(cfg.Include) == 0 && len(cfg.IncludePatterns) == 0 && len(cfg.Exclude) == 0 && len(cfg.ExcludePatterns) == 0 {
+		return nil
+	}
+	return &rowconv.AttributeFilter{
+		Include:         keySet(cfg.Include),
+		Exclude:         keySet(cfg.Exclude),
+		IncludePatterns: compilePatterns(cfg.IncludePatterns),
+		ExcludePatterns: compilePatterns(cfg.ExcludePatterns),
+	}
+}
+
+func keySet(keys []string) map[string]bool {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// compilePatterns compiles patterns validated by Config.Validate.
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	if len(patterns) == 0 {
+		return nil
+	}
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, ok := attributePatterns.Load(pattern)
+		if !ok {
+			re, _ = attributePatterns.LoadOrStore(pattern, regexp.MustCompile(pattern))
+		}
+		compiled[i] = re.(*regexp.Regexp)
+	}
+	return compiled
+}
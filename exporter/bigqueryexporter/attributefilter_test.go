@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeFilterConfigFilter(t *testing.T) {
+	assert.Nil(t, AttributeFilterConfig{}.filter())
+
+	filter := AttributeFilterConfig{
+		Include:         []string{"service.name"},
+		IncludePatterns: []string{`^k8s\.`},
+		Exclude:         []string{"k8s.pod.uid"},
+		ExcludePatterns: []string{`\.secret$`},
+	}.filter()
+	require.NotNil(t, filter)
+	assert.Equal(t, map[string]bool{"service.name": true}, filter.Include)
+	assert.Equal(t, map[string]bool{"k8s.pod.uid": true}, filter.Exclude)
+	require.Len(t, filter.IncludePatterns, 1)
+	assert.Equal(t, `^k8s\.`, filter.IncludePatterns[0].String())
+	require.Len(t, filter.ExcludePatterns, 1)
+	// Patterns are compiled once.
+	assert.Same(t, filter.IncludePatterns[0], compilePatterns([]string{`^k8s\.`})[0])
+
+	filters := AttributeFiltersConfig{Record: AttributeFilterConfig{Exclude: []string{"a"}}}.filters()
+	assert.Nil(t, filters.Resource)
+	assert.Nil(t, filters.Scope)
+	assert.NotNil(t, filters.Record)
+}
+
+func TestAttributeFiltersConfigValidate(t *testing.T) {
+	require.NoError(t, AttributeFiltersConfig{}.validate("traces.attribute_filters"))
+	require.NoError(t, AttributeFiltersConfig{Resource: AttributeFilterConfig{Exclude: []string{"host.id"}, ExcludePatterns: []string{`^process\.`}}}.validate("traces.attribute_filters"))
+
+	err := AttributeFiltersConfig{Scope: AttributeFilterConfig{Include: []string{""}}}.validate("traces.attribute_filters")
+	require.EqualError(t, err, "traces.attribute_filters.scope.include[0] must not be empty")
+
+	err = AttributeFiltersConfig{Record: AttributeFilterConfig{ExcludePatterns: []string{"ok", "("}}}.validate("logs.attribute_filters")
+	require.ErrorContains(t, err, "logs.attribute_filters.record.exclude_patterns[1]: error parsing regexp")
+}
@@ -0,0 +1,51 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// Values of the per-signal attributes_encoding setting.
+const (
+	attributesEncodingJSON     = "json"
+	attributesEncodingKeyValue = "key_value"
+)
+
+// withKeyValueAttributes returns schema with the attribute columns of
+// rowconv.KeyValueAttributeColumns as repeated key/value RECORD columns when
+// encoding is key_value. schema is not modified.
+func withKeyValueAttributes(schema bigquery.Schema, encoding string) bigquery.Schema {
+	if encoding != attributesEncodingKeyValue {
+		return schema
+	}
+	out := make(bigquery.Schema, 0, len(schema))
+	for _, field := range schema {
+		if slices.Contains(rowconv.KeyValueAttributeColumns, field.Name) {
+			field = &bigquery.FieldSchema{
+				Name:        field.Name,
+				Description: field.Description,
+				Type:        bigquery.RecordFieldType,
+				Repeated:    true,
+				Schema:      rowconv.KeyValueFields,
+			}
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+func validateAttributesEncoding(field, value string) error {
+	switch value {
+	case "", attributesEncodingJSON, attributesEncodingKeyValue:
+		return nil
+	default:
+		return fmt.Errorf("%s %q is not supported, must be one of %s, %s", field, value, attributesEncodingJSON, attributesEncodingKeyValue)
+	}
+}
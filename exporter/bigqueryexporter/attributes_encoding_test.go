@@ -0,0 +1,89 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"math"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWithKeyValueAttributes(t *testing.T) {
+	assert.Equal(t, rowconv.TracesSchema, withKeyValueAttributes(rowconv.TracesSchema, attributesEncodingJSON))
+
+	schema := tracesTableSchema(defaultSchemaPreset, TracesConfig{AttributesEncoding: attributesEncodingKeyValue})
+	for _, name := range []string{"resource_attributes", "span_attributes"} {
+		field := schemaField(schema, name)
+		assert.Equal(t, bigquery.RecordFieldType, field.Type, name)
+		assert.True(t, field.Repeated, name)
+		assert.Equal(t, rowconv.KeyValueFields, field.Schema, name)
+	}
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(rowconv.TracesSchema, "span_attributes").Type, "input schema is not modified")
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(schema, "events").Type)
+
+	// compat stores the json_value subfield as STRING.
+	compat := schemaField(logsTableSchema("compat", LogsConfig{AttributesEncoding: attributesEncodingKeyValue}), "log_attributes")
+	assert.Equal(t, bigquery.RecordFieldType, compat.Type)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(compat.Schema, "json_value").Type)
+
+	assert.Equal(t, bigquery.RecordFieldType, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{AttributesEncoding: attributesEncodingKeyValue}), "datapoint_attributes").Type)
+}
+
+func TestAttributesEncodingRowOptions(t *testing.T) {
+	assert.False(t, TracesConfig{}.rowOptions().KeyValueAttributes)
+	assert.True(t, TracesConfig{AttributesEncoding: attributesEncodingKeyValue}.rowOptions().KeyValueAttributes)
+	assert.True(t, MetricsConfig{AttributesEncoding: attributesEncodingKeyValue}.rowOptions().KeyValueAttributes)
+	assert.True(t, LogsConfig{AttributesEncoding: attributesEncodingKeyValue}.rowOptions().KeyValueAttributes)
+}
+
+func TestEncodeRowKeyValueAttributes(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "svc")
+	attrs := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().Attributes()
+	attrs.PutInt("count", 3)
+	attrs.PutDouble("ratio", 1)
+	attrs.PutDouble("nan", math.NaN())
+	attrs.PutBool("ok", true)
+	attrs.PutEmptyMap("map").PutStr("k", "v")
+
+	cfg := TracesConfig{AttributesEncoding: attributesEncodingKeyValue}
+	desc, _, err := schemaDescriptor(tracesTableSchema(defaultSchemaPreset, cfg))
+	require.NoError(t, err)
+	rows := rowconv.Traces(td, cfg.rowOptions())
+	require.Len(t, rows, 1)
+	b, err := encodeRow(desc, rows[0])
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(b, msg))
+
+	resource := nestedField(msg, "resource_attributes").List()
+	require.Equal(t, 1, resource.Len())
+	assert.Equal(t, "service.name", nestedField(resource.Get(0).Message(), "key").String())
+	assert.Equal(t, "svc", nestedField(resource.Get(0).Message(), "string_value").String())
+
+	span := nestedField(msg, "span_attributes").List()
+	require.Equal(t, 5, span.Len())
+	records := map[string]protoreflect.Message{}
+	for i := range span.Len() {
+		record := span.Get(i).Message()
+		records[nestedField(record, "key").String()] = record
+	}
+	assert.Equal(t, int64(3), nestedField(records["count"], "int_value").Int())
+	assert.False(t, records["count"].Has(records["count"].Descriptor().Fields().ByName("double_value")))
+	assert.Equal(t, 1.0, nestedField(records["ratio"], "double_value").Float())
+	assert.False(t, records["ratio"].Has(records["ratio"].Descriptor().Fields().ByName("int_value")))
+	assert.True(t, math.IsNaN(nestedField(records["nan"], "double_value").Float()))
+	assert.True(t, nestedField(records["ok"], "bool_value").Bool())
+	assert.JSONEq(t, `{"k":"v"}`, nestedField(records["map"], "json_value").String())
+}
@@ -0,0 +1,151 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+const (
+	// emulatorHostEnv is the host:port of the BigQuery emulator's REST API,
+	// emulatorGRPCHostEnv the one of its Storage API.
+	emulatorHostEnv     = "BIGQUERY_EMULATOR_HOST"
+	emulatorGRPCHostEnv = "BIGQUERY_EMULATOR_GRPC_HOST"
+	// emulatorProject is the project the emulator is started with.
+	emulatorProject = "bench"
+
+	benchmarkBatchSize = 500
+)
+
+// benchmarkSignal converts and exports a batch of one signal.
+type benchmarkSignal struct {
+	name   string
+	schema func(preset string) bigquery.Schema
+	rows   func() []rowconv.Row
+	push   func(ctx context.Context, e *bigQueryExporter) error
+}
+
+func benchmarkSignals() []benchmarkSignal {
+	td := testdata.GenerateTracesManySpansSameResource(benchmarkBatchSize)
+	md := testdata.GenerateMetricsManyMetricsSameResource(benchmarkBatchSize)
+	ld := testdata.GenerateLogsManyLogRecordsSameResource(benchmarkBatchSize)
+	return []benchmarkSignal{
+		{
+			name:   "traces",
+			schema: func(preset string) bigquery.Schema { return tracesTableSchema(preset, TracesConfig{}) },
+			rows:   func() []rowconv.Row { return rowconv.Traces(td, rowconv.TracesOptions{}) },
+			push:   func(ctx context.Context, e *bigQueryExporter) error { return e.pushTraces(ctx, td) },
+		},
+		{
+			name:   "metrics",
+			schema: func(preset string) bigquery.Schema { return metricsTableSchema(preset, MetricsConfig{}) },
+			rows:   func() []rowconv.Row { return rowconv.Metrics(md, rowconv.MetricsOptions{}) },
+			push:   func(ctx context.Context, e *bigQueryExporter) error { return e.pushMetrics(ctx, md) },
+		},
+		{
+			name:   "logs",
+			schema: func(preset string) bigquery.Schema { return tableSchema(rowconv.LogsSchema, preset, "") },
+			rows:   func() []rowconv.Row { return rowconv.Logs(ld, rowconv.LogsOptions{}) },
+			push:   func(ctx context.Context, e *bigQueryExporter) error { return e.pushLogs(ctx, ld) },
+		},
+	}
+}
+
+// reportRowsPerSecond reports the throughput of a benchmark that handled
+// rows rows per iteration.
+func reportRowsPerSecond(b *testing.B, rows int) {
+	b.ReportMetric(float64(rows*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+// BenchmarkEncode measures converting a batch into rows and encoding them
+// for the Storage Write API, for every signal and schema preset.
+func BenchmarkEncode(b *testing.B) {
+	for _, signal := range benchmarkSignals() {
+		for _, preset := range schemaPresetNames() {
+			b.Run(signal.name+"/"+preset, func(b *testing.B) {
+				desc, _, err := schemaDescriptor(signal.schema(preset))
+				require.NoError(b, err)
+				rows := len(signal.rows())
+				b.ReportAllocs()
+				for b.Loop() {
+					if _, err := encodeRows(desc, signal.rows()); err != nil {
+						b.Fatal(err)
+					}
+				}
+				reportRowsPerSecond(b, rows)
+			})
+		}
+	}
+}
+
+// BenchmarkEmulatorExport measures exporting batches end to end, from pdata
+// to rows acknowledged by the BigQuery emulator, for every write mode and
+// schema preset. It is skipped unless BIGQUERY_EMULATOR_HOST and
+// BIGQUERY_EMULATOR_GRPC_HOST point at an emulator started with the project
+// "bench".
+func BenchmarkEmulatorExport(b *testing.B) {
+	restHost, grpcHost := os.Getenv(emulatorHostEnv), os.Getenv(emulatorGRPCHostEnv)
+	if restHost == "" || grpcHost == "" {
+		b.Skipf("skipping emulator benchmark; set %s and %s to run", emulatorHostEnv, emulatorGRPCHostEnv)
+	}
+	restOpts := []option.ClientOption{option.WithEndpoint("http://" + restHost), option.WithoutAuthentication()}
+	writeOpts := []option.ClientOption{
+		option.WithEndpoint(grpcHost),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+	client, err := bigquery.NewClient(b.Context(), emulatorProject, restOpts...)
+	require.NoError(b, err)
+	b.Cleanup(func() { _ = client.Close() })
+
+	for _, mode := range []string{writeModeDefault, writeModePending} {
+		for _, preset := range schemaPresetNames() {
+			b.Run(mode+"/"+preset, func(b *testing.B) {
+				datasetID := temporaryDatasetID()
+				require.NoError(b, client.Dataset(datasetID).Create(b.Context(), &bigquery.DatasetMetadata{}))
+				b.Cleanup(func() { _ = client.Dataset(datasetID).DeleteWithContents(context.Background()) })
+
+				cfg := createDefaultConfig()
+				cfg.Dataset.Project = emulatorProject
+				cfg.Dataset.ID = datasetID
+				cfg.SchemaPreset = preset
+				cfg.Write.Mode = mode
+				cfg.StartupRetry.Enabled = false
+				exp := newBigQueryExporter(b.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+				exp.restOpts = restOpts
+				exp.writeOpts = writeOpts
+				require.NoError(b, exp.Start(b.Context(), nil))
+				b.Cleanup(func() { _ = exp.Shutdown(context.Background()) })
+
+				signals := benchmarkSignals()
+				rows := 0
+				for _, signal := range signals {
+					rows += len(signal.rows())
+				}
+				b.ReportAllocs()
+				for b.Loop() {
+					for _, signal := range signals {
+						if err := signal.push(b.Context(), exp); err != nil {
+							b.Fatalf("push %s: %v", signal.name, err)
+						}
+					}
+				}
+				reportRowsPerSecond(b, rows)
+			})
+		}
+	}
+}
@@ -5,50 +5,221 @@ package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-coll
 
 import (
 	"context"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"math/rand/v2"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"cloud.google.com/go/bigquery"
 	"cloud.google.com/go/bigquery/storage/managedwriter"
+	gcemetadata "cloud.google.com/go/compute/metadata"
 	"go.opentelemetry.io/collector/component"
-	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
-	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
 )
 
 type bigQueryExporter struct {
-	cfg             *Config
-	logger          *zap.Logger
+	cfg          *Config
+	logger       *zap.Logger
+	buildInfo    component.BuildInfo
+	telemetrySet component.TelemetrySettings
+	telemetry    *metadata.TelemetryBuilder
+	// credentials are the client options of every configured credentials,
+	// shared by the destinations written with them.
+	credentials     map[CredentialsConfig]*destinationCredentials
 	project         string
-	client          *bigquery.Client
-	writeClient     *managedwriter.Client
+	clients         map[destination]*projectClients
 	tracesAppender  *storageAppender
 	metricsAppender *storageAppender
 	logsAppender    *storageAppender
+	// wideEventsAppender is only set when wide_events is enabled. The
+	// traces, metrics and logs appenders then are all this appender.
+	wideEventsAppender *storageAppender
+	// entitiesAppender is only set when logs.entity_events is enabled.
+	entitiesAppender *storageAppender
+	// spanEventsAppender and spanLinksAppender are only set when
+	// traces.child_tables is enabled.
+	spanEventsAppender *storageAppender
+	spanLinksAppender  *storageAppender
+	// The shards are only set for tables whose name is a time-shard
+	// template. The appenders above then write to the shard that was
+	// current during start.
+	tracesShards     *tableShards
+	metricsShards    *tableShards
+	logsShards       *tableShards
+	entitiesShards   *tableShards
+	spanEventsShards *tableShards
+	spanLinksShards  *tableShards
+	// The dual-write tables are only set when dual_write is enabled.
+	tracesDualWrite  dualWriteTable
+	metricsDualWrite dualWriteTable
+	logsDualWrite    dualWriteTable
+	// statistics and statisticsAppender are only set when statistics.enabled
+	// is true.
+	statistics         *appendStatistics
+	statisticsAppender *storageAppender
+	statisticsDone     chan struct{}
+	statisticsWG       sync.WaitGroup
+	// resources and resourcesAppender are only set when
+	// normalize_resources.enabled is true.
+	resources         *resourceCache
+	resourcesAppender *storageAppender
+	// collector holds the values of the collector columns. It is nil
+	// unless collector_columns is enabled.
+	collector row
+	// anonymizer is nil unless anonymize_attributes lists attributes.
+	anonymizer *attributeAnonymizer
+	// The truncations are nil unless max_column_bytes is set for a column of
+	// their table.
+	tracesTruncation     *columnTruncation
+	spanEventsTruncation *columnTruncation
+	spanLinksTruncation  *columnTruncation
+	metricsTruncation    *columnTruncation
+	logsTruncation       *columnTruncation
+	// The computed values are nil unless computed_columns are set for the
+	// signal.
+	tracesComputed  rowconv.SpanValues
+	metricsComputed rowconv.DataPointValues
+	logsComputed    rowconv.LogValues
+	// The promoted attributes carry the cardinality guards of the signal,
+	// which keep their distinct values across pushes.
+	tracesPromoted  []rowconv.PromotedAttribute
+	metricsPromoted []rowconv.PromotedAttribute
+	logsPromoted    []rowconv.PromotedAttribute
+	// restOpts and writeOpts are appended to the options of the BigQuery and
+	// Storage Write clients. Benchmarks use them to reach the emulator.
+	restOpts  []option.ClientOption
+	writeOpts []option.ClientOption
+	// capabilities are the features detected per project and dataset by the
+	// capability probe. It is empty unless probe_capabilities is enabled.
+	capabilities map[string]datasetCapabilities
+	// memory bounds the rows held by all pushes. It is nil when
+	// memory_limit_mib is not set.
+	memory *memoryLimiter
+	// users counts the signal exporters sharing this exporter.
+	users atomic.Int32
+}
+
+// destination identifies the clients of a table: the project the table is
+// in and the credentials it is written with.
+type destination struct {
+	project     string
+	credentials CredentialsConfig
+}
+
+// projectClients are the clients used for the tables of one destination.
+type projectClients struct {
+	client *bigquery.Client
+	// writeClient is replaced when rotated credentials are reloaded.
+	writeClient atomic.Pointer[managedwriter.Client]
+	// retired are the replaced write clients still used by appenders that
+	// could not be moved to writeClient yet. Only the goroutine watching
+	// the credentials of the destination uses them until shutdown.
+	retired []*retiredClient
+}
+
+// retiredClient is a replaced write client and the appenders still using
+// it. It is closed once they all moved to the current client.
+type retiredClient struct {
+	client    *managedwriter.Client
+	appenders []*storageAppender
 }
 
 type row = map[string]bigquery.Value
 
 type signalTarget struct {
 	name     string
+	project  string
+	dataset  string
 	tableID  string
 	schema   bigquery.Schema
 	appender **storageAppender
+	// credentials are those the table is written with.
+	credentials CredentialsConfig
+	// partitioning is how the table is partitioned when it is created.
+	partitioning PartitioningConfig
+	// clusteringFields are the columns the table is clustered by when it is
+	// created.
+	clusteringFields []string
+	// watermark is set when rows of the table carry a watermark column.
+	watermark bool
+	// eventDate is the time zone of the event_date column, or nil when rows
+	// of the table do not carry one.
+	eventDate *time.Location
+	// retention is added to the event time of rows for their expires_at
+	// column. Rows carry no expires_at column when it is zero.
+	retention time.Duration
+	// primaryKey is set when rows are written as upserts, and is the
+	// primary key of the table. maxStaleness is then the max_staleness
+	// option the table is created with, or zero.
+	primaryKey   []string
+	maxStaleness time.Duration
+	// template is the configured table name when it is a time-shard
+	// template, in which case tableID is the current shard. shards is then
+	// where the exporter keeps the shards of the table.
+	template string
+	shards   **tableShards
+	// columnNames renames columns of the table, see withColumnNames.
+	columnNames map[string]string
 }
 
-func newBigQueryExporter(_ context.Context, cfg *Config, logger *zap.Logger) *bigQueryExporter {
-	return &bigQueryExporter{cfg: cfg, logger: logger}
+func (t signalTarget) destination() destination {
+	return destination{project: t.project, credentials: t.credentials}
+}
+
+func newBigQueryExporter(_ context.Context, cfg *Config, set exporter.Settings) *bigQueryExporter {
+	cfg = cfg.withPromotePresets()
+	var collector row
+	if cfg.CollectorColumns {
+		collector = collectorIdentity(set)
+	}
+	e := &bigQueryExporter{
+		cfg:          cfg,
+		logger:       set.Logger,
+		buildInfo:    set.BuildInfo,
+		telemetrySet: set.TelemetrySettings,
+		collector:    collector,
+		anonymizer:   cfg.AnonymizeAttributes.anonymizer(),
+		// The default preset tells which columns hold JSON, whatever preset
+		// the tables use.
+		tracesTruncation:     newColumnTruncation(cfg.Traces.MaxColumnBytes, tracesTableSchema(defaultSchemaPreset, cfg.Traces)),
+		spanEventsTruncation: newColumnTruncation(cfg.Traces.MaxColumnBytes, spanEventsTableSchema(defaultSchemaPreset, cfg.Traces)),
+		spanLinksTruncation:  newColumnTruncation(cfg.Traces.MaxColumnBytes, spanLinksTableSchema(defaultSchemaPreset, cfg.Traces)),
+		metricsTruncation:    newColumnTruncation(cfg.Metrics.MaxColumnBytes, metricsTableSchema(defaultSchemaPreset, cfg.Metrics)),
+		logsTruncation:       newColumnTruncation(cfg.Logs.MaxColumnBytes, logsTableSchema(defaultSchemaPreset, cfg.Logs)),
+	}
+	e.tracesPromoted = e.guardedPromotedAttributes("traces", cfg.Traces.PromotedAttributes)
+	e.metricsPromoted = e.guardedPromotedAttributes("metrics", cfg.Metrics.PromotedAttributes)
+	e.logsPromoted = e.guardedPromotedAttributes("logs", cfg.Logs.PromotedAttributes)
+	return e
+}
+
+// metadataProjectID returns the project ID reported by the GCE/GKE metadata
+// server. It is a variable so tests can replace it.
+var metadataProjectID = func(ctx context.Context) (string, error) {
+	if !gcemetadata.OnGCEWithContext(ctx) {
+		return "", errors.New("not running on GCE")
+	}
+	return gcemetadata.ProjectIDWithContext(ctx)
 }
 
 // resolveProject returns the configured project ID, or detects it from
-// environment variables / Application Default Credentials when not set.
+// environment variables, Application Default Credentials or the compute
+// metadata server when not set.
 func (e *bigQueryExporter) resolveProject(ctx context.Context) (string, error) {
 	if e.cfg.Dataset.Project != "" {
 		return e.cfg.Dataset.Project, nil
@@ -58,40 +229,145 @@ func (e *bigQueryExporter) resolveProject(ctx context.Context) (string, error) {
 			return v, nil
 		}
 	}
-	creds, err := google.FindDefaultCredentials(ctx, bigquery.Scope)
-	if err != nil {
-		return "", fmt.Errorf("dataset.project not set and unable to detect from ADC: %w", err)
+	creds, credsErr := findCredentials(ctx, e.cfg.Dataset.Credentials, e.cfg.Scopes)
+	if credsErr == nil && creds.ProjectID != "" {
+		return creds.ProjectID, nil
 	}
-	if creds.ProjectID == "" {
-		return "", errors.New("dataset.project not set and ADC credentials do not contain a project ID")
+	// Credentials such as workload identity federation or user credentials do
+	// not carry a project, but on GCE and GKE the metadata server knows it.
+	if project, err := metadataProjectID(ctx); err == nil && project != "" {
+		return project, nil
 	}
-	return creds.ProjectID, nil
+	if credsErr != nil {
+		return "", fmt.Errorf("dataset.project not set and unable to detect from ADC or the metadata server: %w", credsErr)
+	}
+	return "", errors.New("dataset.project not set and neither ADC credentials nor the metadata server provide a project ID")
 }
 
-func (e *bigQueryExporter) start(ctx context.Context, _ component.Host) error {
+func (e *bigQueryExporter) Start(ctx context.Context, _ component.Host) error {
 	project, err := e.resolveProject(ctx)
 	if err != nil {
 		return err
 	}
 	e.project = project
-
-	e.client, err = bigquery.NewClient(ctx, e.project)
+	e.telemetry, err = metadata.NewTelemetryBuilder(e.telemetrySet)
 	if err != nil {
-		return fmt.Errorf("create BigQuery client: %w", err)
+		return fmt.Errorf("create telemetry builder: %w", err)
 	}
-	e.writeClient, err = newStorageWriteClient(ctx, e.project)
-	if err != nil {
-		return fmt.Errorf("create BigQuery Storage Write client: %w", err)
+	if e.tracesComputed, err = spanValues(e.cfg.Traces.ComputedColumns, e.telemetrySet); err != nil {
+		return err
+	}
+	if e.metricsComputed, err = dataPointValues(e.cfg.Metrics.ComputedColumns, e.telemetrySet); err != nil {
+		return err
+	}
+	if e.logsComputed, err = logValues(e.cfg.Logs.ComputedColumns, e.telemetrySet); err != nil {
+		return err
+	}
+
+	if !e.canManageTables() {
+		e.logger.Info("Configured OAuth scopes do not allow table management; skipping dataset and table checks, tables must already exist",
+			zap.Strings("scopes", e.cfg.Scopes))
+	}
+	e.credentials = make(map[CredentialsConfig]*destinationCredentials)
+	e.clients = make(map[destination]*projectClients)
+	if e.cfg.Statistics.Enabled {
+		e.statistics = newAppendStatistics()
 	}
-	dataset := e.client.Dataset(e.cfg.Dataset.ID)
-	if _, metadataErr := dataset.Metadata(ctx); metadataErr != nil {
-		return fmt.Errorf("dataset %s does not exist (dataset auto-creation is disabled): %w", e.cfg.Dataset.ID, metadataErr)
+	e.memory = newMemoryLimiter(e.cfg.MemoryLimitMiB)
+	if e.cfg.NormalizeResources.Enabled {
+		e.resources = newResourceCache(e.cfg.NormalizeResources.CacheSize)
 	}
+	// Datasets are checked and probed first, since the probed capabilities
+	// decide the schemas of the tables.
+	checkedDatasets := make(map[string]bool)
+	e.capabilities = make(map[string]datasetCapabilities)
 	for _, target := range e.signalTargets() {
-		*target.appender, err = e.initTableAndAppender(ctx, target.tableID, target.schema, target.name)
+		clients, err := e.projectClients(ctx, target.destination())
 		if err != nil {
 			return err
 		}
+		if err := e.checkDataset(ctx, clients, target, checkedDatasets); err != nil {
+			return err
+		}
+	}
+	for _, target := range e.signalTargets() {
+		var err error
+		*target.appender, err = e.initTableAndAppender(ctx, e.clients[target.destination()], target)
+		if err != nil {
+			return e.explainScopeError(err)
+		}
+		if target.template != "" {
+			*target.shards = newTableShards(e.logger, target.template, target.tableID, *target.appender, e.cfg.Dataset.ShardWindow, func(ctx context.Context, tableID string) (*storageAppender, error) {
+				shard := target
+				shard.tableID = tableID
+				return e.initTableAndAppender(ctx, e.clients[shard.destination()], shard)
+			})
+		}
+	}
+	if e.cfg.WideEvents.Enabled {
+		// Spans, data points and log records are all appended to the wide
+		// events table.
+		e.tracesAppender, e.metricsAppender, e.logsAppender = e.wideEventsAppender, e.wideEventsAppender, e.wideEventsAppender
+	}
+
+	if e.cfg.CreateViews && e.canManageTables() {
+		for _, target := range e.signalTargets() {
+			if err := e.ensureView(ctx, e.clients[target.destination()].client, target); err != nil {
+				return e.explainScopeError(err)
+			}
+		}
+	}
+
+	if e.cfg.CreateUDFs && e.canManageTables() {
+		datasets := make(map[string]bool)
+		for _, target := range e.signalTargets() {
+			key := target.project + "." + target.dataset
+			if datasets[key] {
+				continue
+			}
+			datasets[key] = true
+			if err := e.ensureUDFs(ctx, e.clients[target.destination()].client, target); err != nil {
+				return e.explainScopeError(err)
+			}
+		}
+	}
+
+	if e.cfg.Metrics.Rollup.Enabled && e.canManageTables() {
+		for _, target := range e.signalTargets() {
+			if target.name != "metrics" {
+				continue
+			}
+			if err := e.ensureRollupView(ctx, e.clients[target.destination()].client, target); err != nil {
+				return e.explainScopeError(err)
+			}
+		}
+	}
+
+	if e.cfg.Write.WarmUp {
+		e.warmUp(ctx)
+	}
+
+	if e.cfg.PreflightAuthCheck {
+		if err := e.preflightAuthCheck(ctx); err != nil {
+			return err
+		}
+	}
+
+	if e.statistics != nil {
+		e.statisticsDone = make(chan struct{})
+		e.statisticsWG.Add(1)
+		go e.statisticsLoop()
+	}
+	for creds, dc := range e.credentials {
+		if dc.reloader != nil {
+			dc.reloader.watch(func(reloaded bool) {
+				if reloaded {
+					e.reconnectWriteClients(creds)
+					return
+				}
+				e.moveRetiredAppenders(creds)
+			})
+		}
 	}
 
 	e.logger.Info("BigQuery exporter started", zap.String("project", e.project), zap.String("dataset", e.cfg.Dataset.ID))
@@ -99,132 +375,826 @@ func (e *bigQueryExporter) start(ctx context.Context, _ component.Host) error {
 }
 
 func (e *bigQueryExporter) signalTargets() []signalTarget {
-	return []signalTarget{
-		{name: "traces", tableID: e.cfg.Dataset.Table.Trace, schema: tracesSchema, appender: &e.tracesAppender},
-		{name: "metrics", tableID: e.cfg.Dataset.Table.Metric, schema: metricsSchema, appender: &e.metricsAppender},
-		{name: "logs", tableID: e.cfg.Dataset.Table.Log, schema: logsSchema, appender: &e.logsAppender},
+	preset := e.cfg.SchemaPreset
+	tracesCfg := e.cfg.Traces
+	tracesDataset := e.targetDataset(tracesCfg.Dataset)
+	tracesCfg.JSONColumns = e.jsonColumns(e.targetProject(tracesCfg.Project), tracesDataset, tracesCfg.JSONColumns)
+	metricsDataset := e.targetDataset(e.cfg.Metrics.Dataset)
+	metricsCfg := e.cfg.Metrics
+	metricsCfg.JSONColumns = e.jsonColumns(e.targetProject(metricsCfg.Project), metricsDataset, metricsCfg.JSONColumns)
+	logsDataset := e.targetDataset(e.cfg.Logs.Dataset)
+	logsCfg := e.cfg.Logs
+	logsCfg.JSONColumns = e.jsonColumns(e.targetProject(logsCfg.Project), logsDataset, logsCfg.JSONColumns)
+	eventDate := e.cfg.EventDate.location()
+	tableNames := map[string]string{
+		"traces":      e.cfg.Dataset.Table.Trace,
+		"metrics":     e.cfg.Dataset.Table.Metric,
+		"logs":        e.cfg.Dataset.Table.Log,
+		"entities":    e.cfg.Dataset.Table.Entity,
+		"span_events": e.cfg.Dataset.Table.SpanEvent,
+		"span_links":  e.cfg.Dataset.Table.SpanLink,
+		"resources":   e.cfg.Dataset.Table.Resource,
+		"wide_events": e.cfg.Dataset.Table.WideEvents,
+	}
+	targets := []signalTarget{
+		{
+			name:             "traces",
+			project:          e.targetProject(e.cfg.Traces.Project),
+			credentials:      e.targetCredentials(e.cfg.Traces.Credentials),
+			dataset:          tracesDataset,
+			schema:           signalSchema(e.cfg, preset, "traces", tracesCfg.JSONColumns),
+			appender:         &e.tracesAppender,
+			shards:           &e.tracesShards,
+			clusteringFields: e.cfg.Traces.ClusteringFields,
+			partitioning:     e.cfg.Traces.Partitioning,
+			watermark:        e.cfg.Watermark,
+			eventDate:        eventDate,
+			retention:        e.cfg.Traces.RowRetention,
+			primaryKey:       e.cfg.Traces.Upsert.primaryKey("traces"),
+			maxStaleness:     e.cfg.Traces.Upsert.MaxStaleness,
+			columnNames:      e.cfg.Traces.ColumnNames,
+		},
+		{
+			name:             "metrics",
+			project:          e.targetProject(e.cfg.Metrics.Project),
+			credentials:      e.targetCredentials(e.cfg.Metrics.Credentials),
+			dataset:          metricsDataset,
+			schema:           signalSchema(e.cfg, preset, "metrics", metricsCfg.JSONColumns),
+			appender:         &e.metricsAppender,
+			shards:           &e.metricsShards,
+			clusteringFields: e.cfg.Metrics.ClusteringFields,
+			partitioning:     e.cfg.Metrics.Partitioning,
+			watermark:        e.cfg.Watermark,
+			eventDate:        eventDate,
+			retention:        e.cfg.Metrics.RowRetention,
+			primaryKey:       e.cfg.Metrics.Upsert.primaryKey("metrics"),
+			maxStaleness:     e.cfg.Metrics.Upsert.MaxStaleness,
+			columnNames:      e.cfg.Metrics.ColumnNames,
+		},
+		{
+			name:             "logs",
+			project:          e.targetProject(e.cfg.Logs.Project),
+			credentials:      e.targetCredentials(e.cfg.Logs.Credentials),
+			dataset:          logsDataset,
+			schema:           signalSchema(e.cfg, preset, "logs", logsCfg.JSONColumns),
+			appender:         &e.logsAppender,
+			shards:           &e.logsShards,
+			clusteringFields: e.cfg.Logs.ClusteringFields,
+			partitioning:     e.cfg.Logs.partitioning(),
+			watermark:        e.cfg.Watermark,
+			eventDate:        eventDate,
+			retention:        e.cfg.Logs.RowRetention,
+			columnNames:      e.cfg.Logs.ColumnNames,
+		},
+	}
+	if e.cfg.WideEvents.Enabled {
+		targets = []signalTarget{e.wideEventsTarget(targets)}
+	}
+	targets = append(targets, e.dualWriteTargets(targets, tableNames)...)
+	if e.cfg.Logs.EntityEvents {
+		targets = append(targets, signalTarget{
+			name:         "entities",
+			project:      e.targetProject(e.cfg.Logs.Project),
+			credentials:  e.targetCredentials(e.cfg.Logs.Credentials),
+			dataset:      logsDataset,
+			schema:       tableSchema(rowconv.EntitiesSchema, preset, logsCfg.JSONColumns),
+			appender:     &e.entitiesAppender,
+			shards:       &e.entitiesShards,
+			partitioning: PartitioningConfig{Granularity: string(bigquery.DayPartitioningType)},
+		})
+	}
+	if e.cfg.Traces.ChildTables {
+		// BigQuery cannot cluster by BYTES columns.
+		childTableClustering := []string{"trace_id", "span_id"}
+		if e.cfg.IDColumns == idColumnsBytes {
+			childTableClustering = nil
+		}
+		targets = append(targets,
+			signalTarget{
+				name:             "span_events",
+				project:          e.targetProject(e.cfg.Traces.Project),
+				credentials:      e.targetCredentials(e.cfg.Traces.Credentials),
+				dataset:          tracesDataset,
+				schema:           withIDColumns(spanEventsTableSchema(preset, tracesCfg), e.cfg.IDColumns),
+				appender:         &e.spanEventsAppender,
+				shards:           &e.spanEventsShards,
+				clusteringFields: childTableClustering,
+				partitioning:     PartitioningConfig{Field: "event_timestamp", Granularity: string(bigquery.DayPartitioningType)},
+			},
+			signalTarget{
+				name:             "span_links",
+				project:          e.targetProject(e.cfg.Traces.Project),
+				credentials:      e.targetCredentials(e.cfg.Traces.Credentials),
+				dataset:          tracesDataset,
+				schema:           withIDColumns(spanLinksTableSchema(preset, tracesCfg), e.cfg.IDColumns),
+				appender:         &e.spanLinksAppender,
+				shards:           &e.spanLinksShards,
+				clusteringFields: childTableClustering,
+				partitioning:     PartitioningConfig{Field: "span_start_time", Granularity: string(bigquery.DayPartitioningType)},
+			},
+		)
+	}
+	if e.cfg.NormalizeResources.Enabled {
+		targets = append(targets, signalTarget{
+			name:             resourcesSignal,
+			project:          e.project,
+			credentials:      e.cfg.Dataset.Credentials,
+			dataset:          e.cfg.Dataset.ID,
+			schema:           tableSchema(resourcesSchema, preset, e.jsonColumns(e.project, e.cfg.Dataset.ID, "")),
+			appender:         &e.resourcesAppender,
+			clusteringFields: []string{resourceHashColumn},
+			partitioning:     PartitioningConfig{Granularity: string(bigquery.DayPartitioningType)},
+		})
 	}
+	for i := range targets {
+		targets[i].tableID, targets[i].template = tableName(tableNames[targets[i].name])
+		targets[i] = targets[i].withColumnNames()
+	}
+	if e.cfg.Statistics.Enabled {
+		targets = append(targets, signalTarget{
+			name:         statisticsSignal,
+			project:      e.project,
+			credentials:  e.cfg.Dataset.Credentials,
+			dataset:      e.cfg.Dataset.ID,
+			tableID:      e.cfg.Dataset.Table.Statistics,
+			schema:       statisticsSchema,
+			appender:     &e.statisticsAppender,
+			partitioning: PartitioningConfig{Field: "hour", Granularity: string(bigquery.DayPartitioningType)},
+		})
+	}
+	return targets
+}
+
+// tableName returns the ID of a configured table and, when the name is a
+// time-shard template, the template. The ID is then the current shard.
+func tableName(name string) (tableID, template string) {
+	if !isTableTemplate(name) {
+		return name, ""
+	}
+	tableID, err := expandTableTemplate(name, time.Now())
+	if err != nil {
+		// Templates are validated with the configuration.
+		return name, name
+	}
+	return tableID, name
 }
 
-func (e *bigQueryExporter) initTableAndAppender(
-	ctx context.Context,
-	tableID string,
-	schema bigquery.Schema,
-	signal string,
-) (*storageAppender, error) {
-	table := e.client.Dataset(e.cfg.Dataset.ID).Table(tableID)
-	if _, err := table.Metadata(ctx); err != nil {
-		if err := table.Create(ctx, &bigquery.TableMetadata{
-			Schema:           schema,
-			TimePartitioning: &bigquery.TimePartitioning{Type: bigquery.DayPartitioningType},
-		}); err != nil {
-			return nil, fmt.Errorf("create %s table %s: %w", signal, tableID, err)
+// targetProject returns the per-signal project override, or the resolved
+// dataset project when it is not set.
+func (e *bigQueryExporter) targetProject(override string) string {
+	if override != "" {
+		return override
+	}
+	return e.project
+}
+
+// targetCredentials returns the per-signal credentials override, or
+// dataset.credentials when it is not set.
+func (e *bigQueryExporter) targetCredentials(override CredentialsConfig) CredentialsConfig {
+	if override != (CredentialsConfig{}) {
+		return override
+	}
+	return e.cfg.Dataset.Credentials
+}
+
+// targetDataset returns the per-signal dataset override, or dataset.id when
+// it is not set.
+func (e *bigQueryExporter) targetDataset(override string) string {
+	if override != "" {
+		return override
+	}
+	return e.cfg.Dataset.ID
+}
+
+// projectClients returns the clients for dest, creating them and checking
+// that the dataset exists in its project on first use.
+func (e *bigQueryExporter) projectClients(ctx context.Context, dest destination) (*projectClients, error) {
+	if clients, ok := e.clients[dest]; ok {
+		return clients, nil
+	}
+	ctx, err := e.proxyContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := e.destinationCredentials(ctx, dest.credentials)
+	if err != nil {
+		return nil, err
+	}
+	restOpts, err := e.controlPlaneClientOptions(ctx, creds.opts)
+	if err != nil {
+		return nil, err
+	}
+	clients := &projectClients{}
+	clients.client, err = bigquery.NewClient(ctx, dest.project, append(restOpts, e.restOpts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("create BigQuery client for project %s: %w", dest.project, err)
+	}
+	e.clients[dest] = clients
+	writeOpts, err := e.storageWriteClientOptions(ctx, creds.opts)
+	if err != nil {
+		return nil, err
+	}
+	writeClient, err := newStorageWriteClient(ctx, dest.project, append(writeOpts, e.writeOpts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("create BigQuery Storage Write client for project %s: %w", dest.project, err)
+	}
+	clients.writeClient.Store(writeClient)
+	return clients, nil
+}
+
+// checkDataset verifies that the dataset of target exists, once per project
+// and dataset.
+func (e *bigQueryExporter) checkDataset(ctx context.Context, clients *projectClients, target signalTarget, checked map[string]bool) error {
+	key := target.project + "." + target.dataset
+	if !e.canManageTables() || checked[key] {
+		return nil
+	}
+	var md *bigquery.DatasetMetadata
+	err := e.retryControlPlane(ctx, "get dataset metadata", func(ctx context.Context) error {
+		var err error
+		md, err = clients.client.Dataset(target.dataset).Metadata(ctx)
+		return err
+	})
+	if err != nil && !e.cfg.Dataset.AutoCreate {
+		return e.explainScopeError(fmt.Errorf("dataset %s does not exist (dataset auto-creation is disabled): %w", key, err))
+	}
+	if err != nil {
+		if md, err = e.createDataset(ctx, clients.client, target); err != nil {
+			return e.explainScopeError(err)
 		}
-		e.logger.Info("Created table", zap.String("signal", signal), zap.String("table", tableID))
 	}
+	if expected := e.cfg.Dataset.ExpectedLocation; expected != "" && !strings.EqualFold(md.Location, expected) {
+		return fmt.Errorf("dataset %s is in location %s, but dataset.expected_location is %s", key, md.Location, expected)
+	}
+	checked[key] = true
+	if e.cfg.ProbeCapabilities {
+		e.capabilities[key] = e.probeCapabilities(ctx, clients.client, key, md)
+	}
+	return nil
+}
 
-	appender, err := newStorageAppender(ctx, e.writeClient, e.project, e.cfg.Dataset.ID, tableID, schema)
+// createDataset creates the dataset of target with the configured location
+// and default expirations, and returns its metadata.
+func (e *bigQueryExporter) createDataset(ctx context.Context, client *bigquery.Client, target signalTarget) (*bigquery.DatasetMetadata, error) {
+	key := target.project + "." + target.dataset
+	dataset := client.Dataset(target.dataset)
+	err := e.retryControlPlane(ctx, "create dataset", func(ctx context.Context) error {
+		return dataset.Create(ctx, &bigquery.DatasetMetadata{
+			Location:                   e.cfg.Dataset.Location,
+			DefaultTableExpiration:     e.cfg.Dataset.DefaultTableExpiration,
+			DefaultPartitionExpiration: e.cfg.Dataset.DefaultPartitionExpiration,
+		})
+	})
+	switch {
+	case isAlreadyExists(err):
+		e.logger.Info("Dataset was created concurrently, e.g. by another collector", zap.String("dataset", key))
+	case err != nil:
+		return nil, fmt.Errorf("create dataset %s: %w", key, err)
+	default:
+		e.logger.Info("Created dataset", zap.String("dataset", key), zap.String("location", e.cfg.Dataset.Location),
+			zap.Duration("default_table_expiration", e.cfg.Dataset.DefaultTableExpiration),
+			zap.Duration("default_partition_expiration", e.cfg.Dataset.DefaultPartitionExpiration))
+	}
+	var md *bigquery.DatasetMetadata
+	err = e.retryControlPlane(ctx, "get dataset metadata", func(ctx context.Context) error {
+		var err error
+		md, err = dataset.Metadata(ctx)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("create %s storage appender for table %s: %w", signal, tableID, err)
+		return nil, fmt.Errorf("get metadata of dataset %s: %w", key, err)
 	}
-	return appender, nil
+	return md, nil
 }
 
-func (e *bigQueryExporter) shutdown(_ context.Context) error {
-	for _, target := range e.signalTargets() {
-		if err := closeAppender(target.name, *target.appender); err != nil {
-			return err
+func (e *bigQueryExporter) initTableAndAppender(ctx context.Context, clients *projectClients, target signalTarget) (*storageAppender, error) {
+	if e.canManageTables() {
+		if err := e.ensureTable(ctx, clients.client, target); err != nil {
+			return nil, err
 		}
 	}
 
-	if e.writeClient != nil {
-		if err := e.writeClient.Close(); err != nil {
-			return fmt.Errorf("close BigQuery Storage Write client: %w", err)
+	writerSchema := target.schema
+	if len(target.primaryKey) > 0 {
+		writerSchema = withChangeType(writerSchema)
+	}
+	appender, err := newStorageAppender(ctx, clients.writeClient.Load(), e.logger, e.telemetry, target.project, target.dataset, target.tableID, writerSchema, e.cfg.Write)
+	if err != nil {
+		return nil, fmt.Errorf("create %s storage appender for table %s: %w", target.name, target.tableID, err)
+	}
+	if len(target.primaryKey) > 0 {
+		appender.changeType = changeTypeUpsert
+	}
+	if target.watermark {
+		appender.watermarks = newWatermarks()
+	}
+	appender.eventDates = target.eventDate
+	appender.retention = target.retention
+	appender.columnNames = target.columnNames
+	if target.name != statisticsSignal {
+		appender.degradation = newSlimDegradation(e.logger, appender.tableRef, e.cfg.AdaptiveSlim)
+	}
+	if e.canManageTables() {
+		table := clients.client.Dataset(target.dataset).Table(target.tableID)
+		appender.tableSchema = func(ctx context.Context) (bigquery.Schema, error) {
+			md, err := table.Metadata(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return md.Schema, nil
 		}
 	}
-	if e.client != nil {
-		if err := e.client.Close(); err != nil {
-			return fmt.Errorf("close BigQuery client: %w", err)
+	if e.cfg.AutoCreateTables && e.canManageTables() {
+		appender.recreateTable = func(ctx context.Context) error {
+			return e.ensureTable(ctx, clients.client, target)
 		}
 	}
+	if target.name != statisticsSignal {
+		appender.statistics = e.statistics.table(target.name, fmt.Sprintf("%s.%s.%s", target.project, target.dataset, target.tableID))
+	}
+	return appender, nil
+}
 
-	e.logger.Info("BigQuery exporter shut down")
+// ensureTable creates the table if its metadata cannot be read, and updates
+// the expiration of an existing table when dataset.update_table_expiration
+// is set.
+func (e *bigQueryExporter) ensureTable(ctx context.Context, client *bigquery.Client, target signalTarget) error {
+	table := client.Dataset(target.dataset).Table(target.tableID)
+	var md *bigquery.TableMetadata
+	err := e.retryControlPlane(ctx, "get table metadata", func(ctx context.Context) error {
+		var err error
+		md, err = table.Metadata(ctx)
+		return err
+	})
+	if err == nil {
+		return e.checkExistingTable(ctx, table, target, md)
+	}
+	if !e.cfg.AutoCreateTables {
+		return fmt.Errorf("%s table %s does not exist (table auto-creation is disabled): %w", target.name, target.tableID, err)
+	}
+	if jitter := e.cfg.TableCreateJitter; jitter > 0 {
+		// Replicas starting together would otherwise all try to create the
+		// table at once; the later ones find it created after the delay.
+		if err := sleepContext(ctx, rand.N(jitter)); err != nil {
+			return err
+		}
+		if md, err := table.Metadata(ctx); err == nil {
+			return e.checkExistingTable(ctx, table, target, md)
+		}
+	}
+	err = e.retryControlPlane(ctx, "create table", func(ctx context.Context) error {
+		return table.Create(ctx, &bigquery.TableMetadata{
+			Schema:            target.schema,
+			TimePartitioning:  target.partitioning.timePartitioning(),
+			RangePartitioning: target.partitioning.rangePartitioning(),
+			Clustering:        newClustering(target.clusteringFields),
+			ExpirationTime:    e.tableExpirationTime(time.Now()),
+			Labels:            withSchemaVersion(e.cfg.Dataset.TableLabels),
+			Description:       e.cfg.Dataset.TableDescription,
+			EncryptionConfig:  e.encryptionConfig(),
+			TableConstraints:  tableConstraints(target.primaryKey),
+			MaxStaleness:      maxStaleness(target.maxStaleness),
+		})
+	})
+	if isAlreadyExists(err) {
+		e.logger.Info("Table was created concurrently, e.g. by another collector",
+			zap.String("signal", target.name), zap.String("table", target.tableID))
+		err = e.retryControlPlane(ctx, "get table metadata", func(ctx context.Context) error {
+			var err error
+			md, err = table.Metadata(ctx)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("get metadata of %s table %s: %w", target.name, target.tableID, err)
+		}
+		return e.checkExistingTable(ctx, table, target, md)
+	}
+	if err != nil {
+		return fmt.Errorf("create %s table %s: %w", target.name, target.tableID, err)
+	}
+	e.logger.Info("Created table", zap.String("signal", target.name), zap.String("project", target.project), zap.String("dataset", target.dataset), zap.String("table", target.tableID))
 	return nil
 }
 
-func closeAppender(signal string, appender *storageAppender) error {
-	if appender == nil {
-		return nil
+// checkExistingTable compares an existing table with target, fails when
+// the table has columns the exporter's rows cannot be written to, warns
+// about settings that are only applied when tables are created and updates
+// what can be updated.
+func (e *bigQueryExporter) checkExistingTable(ctx context.Context, table *bigquery.Table, target signalTarget, md *bigquery.TableMetadata) error {
+	diff := diffSchemas(target.schema, md.Schema)
+	if e.cfg.AutoCreateTables {
+		// Missing columns are added with allow_schema_update, or reported
+		// below.
+		diff = diff.incompatible()
 	}
-	if err := appender.stream.Close(); err != nil && !errors.Is(err, io.EOF) {
-		return fmt.Errorf("close %s appender: %w", signal, err)
+	if len(diff) > 0 {
+		return fmt.Errorf("%s table %s has an incompatible schema: %w", target.name, target.tableID, diff)
+	}
+	if err := checkPrimaryKey(md, target.primaryKey); err != nil {
+		return fmt.Errorf("%s table %s: %w", target.name, target.tableID, err)
+	}
+	if existing := clusteringFields(md.Clustering); len(target.clusteringFields) > 0 && !slices.Equal(existing, target.clusteringFields) {
+		e.logger.Warn("Existing table is clustered differently than configured; clustering is only applied when tables are created",
+			zap.String("signal", target.name), zap.String("table", target.tableID),
+			zap.Strings("clustering_fields", existing), zap.Strings("configured_clustering_fields", target.clusteringFields))
+	}
+	if !samePartitioning(md, target.partitioning) {
+		e.logger.Warn("Existing table is partitioned differently than configured; partitioning is only applied when tables are created",
+			zap.String("signal", target.name), zap.String("table", target.tableID),
+			zap.Any("time_partitioning", md.TimePartitioning), zap.Any("range_partitioning", md.RangePartitioning),
+			zap.Any("configured_partitioning", target.partitioning))
+	}
+	if key := e.cfg.Dataset.KMSKeyName; key != "" && (md.EncryptionConfig == nil || md.EncryptionConfig.KMSKeyName != key) {
+		e.logger.Warn("Existing table is not protected by the configured KMS key; the key is only applied when tables are created",
+			zap.String("signal", target.name), zap.String("table", target.tableID), zap.String("kms_key_name", key))
+	}
+	if columns := missingPolicyTags(target.schema, md.Schema); len(columns) > 0 {
+		e.logger.Warn("Existing table lacks configured policy tags; policy tags are only applied when tables are created",
+			zap.String("signal", target.name), zap.String("table", target.tableID), zap.Strings("columns", columns))
+	}
+	if columns := missingCollations(target.schema, md.Schema); len(columns) > 0 {
+		e.logger.Warn("Existing table has columns without the configured collation; collation is only applied when tables are created",
+			zap.String("signal", target.name), zap.String("table", target.tableID), zap.Strings("columns", columns))
+	}
+	if _, missing := mergeMissingColumns(target.schema, md.Schema); len(missing) > 0 && e.cfg.AllowSchemaUpdate && e.cfg.SchemaSnapshot.Enabled {
+		// Migrations only add columns of the target schema, so missing
+		// covers every column added below.
+		if err := e.snapshotTable(ctx, table, target); err != nil {
+			return err
+		}
+	}
+	md, err := e.migrateTable(ctx, table, target, md)
+	if err != nil {
+		return err
+	}
+	if _, missing := mergeMissingColumns(target.schema, md.Schema); len(missing) > 0 {
+		if !e.cfg.AllowSchemaUpdate {
+			e.logger.Warn("Existing table lacks columns written by the exporter; appends may fail until they are added, see allow_schema_update",
+				zap.String("signal", target.name), zap.String("table", target.tableID), zap.Strings("columns", missing))
+		} else if err := e.addMissingColumns(ctx, table, target, md); err != nil {
+			return err
+		}
+	}
+	if update, ok := e.tableUpdate(md, time.Now()); ok {
+		return e.updateTable(ctx, table, target, update)
 	}
 	return nil
 }
 
-func (e *bigQueryExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
-	rows := tracesToRows(td)
-	if len(rows) == 0 {
+// isAlreadyExists reports whether a create call failed because the table or
+// view exists, e.g. because another collector created it concurrently.
+func isAlreadyExists(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
+
+// samePartitioning reports whether a table is partitioned as configured.
+// Unpartitioned tables are not reported.
+func samePartitioning(md *bigquery.TableMetadata, cfg PartitioningConfig) bool {
+	if existing := md.RangePartitioning; existing != nil {
+		want := cfg.rangePartitioning()
+		return want != nil && existing.Field == want.Field && existing.Range != nil && *existing.Range == *want.Range
+	}
+	if existing := md.TimePartitioning; existing != nil {
+		return cfg.Range.Interval == 0 && existing.Type == bigquery.TimePartitioningType(cfg.Granularity) && existing.Field == cfg.Field
+	}
+	return true
+}
+
+func newClustering(fields []string) *bigquery.Clustering {
+	if len(fields) == 0 {
 		return nil
 	}
-	if err := appendStorageRows(ctx, e.tracesAppender, rows); err != nil {
-		return fmt.Errorf("append traces rows: %w", err)
+	return &bigquery.Clustering{Fields: fields}
+}
+
+func clusteringFields(clustering *bigquery.Clustering) []string {
+	if clustering == nil {
+		return nil
 	}
-	return nil
+	return clustering.Fields
 }
 
-func (e *bigQueryExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
-	rows := metricsToRows(md)
-	if len(rows) == 0 {
+// tableExpirationTime returns the expiration time of tables created or
+// updated at now, or the zero time when tables do not expire.
+func (e *bigQueryExporter) tableExpirationTime(now time.Time) time.Time {
+	if e.cfg.Dataset.TableExpiration <= 0 {
+		return time.Time{}
+	}
+	return now.Add(e.cfg.Dataset.TableExpiration)
+}
+
+func (e *bigQueryExporter) encryptionConfig() *bigquery.EncryptionConfig {
+	if e.cfg.Dataset.KMSKeyName == "" {
 		return nil
 	}
-	if err := appendStorageRows(ctx, e.metricsAppender, rows); err != nil {
-		return fmt.Errorf("append metrics rows: %w", err)
+	return &bigquery.EncryptionConfig{KMSKeyName: e.cfg.Dataset.KMSKeyName}
+}
+
+// maxSchemaUpdateAttempts bounds how often adding columns is attempted when
+// other collectors change the table concurrently.
+const maxSchemaUpdateAttempts = 3
+
+// addMissingColumns adds the columns of the target schema that the existing
+// table lacks. The update is conditional on the table's etag, so columns
+// added concurrently by another collector are not overwritten; the metadata
+// is read again and the missing columns recomputed in that case.
+func (e *bigQueryExporter) addMissingColumns(ctx context.Context, table *bigquery.Table, target signalTarget, md *bigquery.TableMetadata) error {
+	for attempt := 1; ; attempt++ {
+		schema, missing := mergeMissingColumns(target.schema, md.Schema)
+		if len(missing) == 0 {
+			return nil
+		}
+		err := e.retryControlPlane(ctx, "update table schema", func(ctx context.Context) error {
+			_, err := table.Update(ctx, bigquery.TableMetadataToUpdate{Schema: schema}, md.ETag)
+			return err
+		})
+		if err == nil {
+			e.logger.Info("Added columns to existing table", zap.String("signal", target.name), zap.String("table", target.tableID), zap.Strings("columns", missing))
+			return nil
+		}
+		var apiErr *googleapi.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusPreconditionFailed || attempt == maxSchemaUpdateAttempts {
+			return fmt.Errorf("add columns %s to %s table %s: %w", strings.Join(missing, ", "), target.name, target.tableID, err)
+		}
+		err = e.retryControlPlane(ctx, "get table metadata", func(ctx context.Context) error {
+			var err error
+			md, err = table.Metadata(ctx)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("get %s table %s metadata: %w", target.name, target.tableID, err)
+		}
+	}
+}
+
+// tableUpdate returns the changes applied to an existing table during start,
+// and false when there is nothing to change.
+func (e *bigQueryExporter) tableUpdate(md *bigquery.TableMetadata, now time.Time) (bigquery.TableMetadataToUpdate, bool) {
+	var update bigquery.TableMetadataToUpdate
+	changed := false
+	if e.cfg.Dataset.UpdateTableExpiration {
+		update.ExpirationTime = e.tableExpirationTime(now)
+		changed = true
 	}
+	if e.cfg.Dataset.UpdateTableMetadata {
+		for key, value := range e.cfg.Dataset.TableLabels {
+			if existing, ok := md.Labels[key]; !ok || existing != value {
+				update.SetLabel(key, value)
+				changed = true
+			}
+		}
+		if description := e.cfg.Dataset.TableDescription; description != "" && description != md.Description {
+			update.Description = description
+			changed = true
+		}
+	}
+	return update, changed
+}
+
+// updateTable updates the metadata of an existing table. The update is
+// unconditional, since collectors sharing the table may update it
+// concurrently.
+func (e *bigQueryExporter) updateTable(ctx context.Context, table *bigquery.Table, target signalTarget, update bigquery.TableMetadataToUpdate) error {
+	err := e.retryControlPlane(ctx, "update table", func(ctx context.Context) error {
+		_, err := table.Update(ctx, update, "")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("update %s table %s: %w", target.name, target.tableID, err)
+	}
+	e.logger.Debug("Updated table metadata", zap.String("signal", target.name), zap.String("table", target.tableID))
 	return nil
 }
 
-func (e *bigQueryExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
-	rows := logsToRows(ld)
-	if len(rows) == 0 {
-		return nil
+// warmUp warms up every stream. Failures are only logged, since the first
+// batch goes through the regular retry path anyway.
+func (e *bigQueryExporter) warmUp(ctx context.Context) {
+	for _, target := range e.signalTargets() {
+		start := time.Now()
+		if err := (*target.appender).warmUp(ctx); err != nil {
+			e.logger.Warn("Storage Write warm-up append failed", zap.String("signal", target.name), zap.Error(err))
+			continue
+		}
+		e.logger.Debug("Storage Write stream warmed up", zap.String("signal", target.name), zap.Duration("duration", time.Since(start)))
 	}
-	if err := appendStorageRows(ctx, e.logsAppender, rows); err != nil {
-		return fmt.Errorf("append logs rows: %w", err)
+}
+
+func (e *bigQueryExporter) Shutdown(ctx context.Context) error {
+	// No streams are reconnected while the appenders close.
+	for _, dc := range e.credentials {
+		dc.reloader.stop()
+	}
+	for _, target := range e.signalTargets() {
+		if target.name == statisticsSignal {
+			continue
+		}
+		if target.shards != nil {
+			if err := (*target.shards).close(ctx); err != nil {
+				return fmt.Errorf("close %s table shards: %w", target.name, err)
+			}
+		}
+		if err := closeAppender(ctx, target.name, *target.appender); err != nil {
+			return err
+		}
+	}
+	// The statistics table is closed last, so that it includes the final
+	// commits of the other tables.
+	if e.statisticsDone != nil {
+		close(e.statisticsDone)
+		e.statisticsWG.Wait()
+		if err := e.flushStatistics(ctx); err != nil {
+			e.logger.Warn("Failed to write append statistics on shutdown", zap.Error(err))
+		}
+	}
+	if err := closeAppender(ctx, statisticsSignal, e.statisticsAppender); err != nil {
+		return err
+	}
+
+	for dest, clients := range e.clients {
+		if err := clients.close(); err != nil {
+			return fmt.Errorf("close clients for project %s: %w", dest.project, err)
+		}
 	}
+	if e.telemetry != nil {
+		e.telemetry.Shutdown()
+	}
+
+	e.logger.Info("BigQuery exporter shut down")
 	return nil
 }
 
-func marshalJSON(v any) string {
-	b, _ := json.Marshal(v)
-	return string(b)
+func (c *projectClients) close() error {
+	// Appenders still using a retired client are closed by now.
+	for _, retired := range c.retired {
+		if err := retired.client.Close(); err != nil {
+			return fmt.Errorf("close previous BigQuery Storage Write client: %w", err)
+		}
+	}
+	c.retired = nil
+	if writeClient := c.writeClient.Load(); writeClient != nil {
+		if err := writeClient.Close(); err != nil {
+			return fmt.Errorf("close BigQuery Storage Write client: %w", err)
+		}
+	}
+	if err := c.client.Close(); err != nil {
+		return fmt.Errorf("close BigQuery client: %w", err)
+	}
+	return nil
 }
 
-func traceIDToHex(id pcommon.TraceID) string {
-	return hex.EncodeToString(id[:])
+func closeAppender(ctx context.Context, signal string, appender *storageAppender) error {
+	if appender == nil {
+		return nil
+	}
+	if err := appender.close(ctx); err != nil {
+		return fmt.Errorf("close %s appender: %w", signal, err)
+	}
+	return nil
 }
 
-func spanIDToHex(id pcommon.SpanID) string {
-	if id.IsEmpty() {
-		return ""
+// pushTraces appends spans and, with traces.child_tables, their events and
+// links to their tables concurrently.
+func (e *bigQueryExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	releaseMemory, err := e.memory.acquireTraces(ctx, td, e.anonymizer != nil)
+	if err != nil {
+		return fmt.Errorf("append traces rows: %w", err)
+	}
+	defer releaseMemory()
+	td = e.anonymizer.traces(td)
+	opts := e.cfg.Traces.rowOptions()
+	opts.ComputedValues = e.tracesComputed
+	opts.PromotedAttributes = e.tracesPromoted
+	rows, err := applyEmptyValues(ctx, e.tracesAppender, rowconv.Traces(td, opts), tracesRequiredColumns, e.cfg.Traces.EmptyValues)
+	if err != nil {
+		return fmt.Errorf("append traces rows: %w", err)
+	}
+	e.tracesTruncation.truncate(rows)
+	if e.cfg.IDColumns == idColumnsBytes {
+		setBytesIDs(rows)
+	}
+	if e.resources != nil && len(rows) > 0 {
+		if err := e.appendResources(ctx, rows); err != nil {
+			return fmt.Errorf("append traces rows: %w", err)
+		}
+	}
+	var appends tableAppends
+	if e.cfg.Traces.ChildTables {
+		// Child rows are filtered and truncated with the settings of the
+		// traces section, like the events and links columns they replace.
+		if rows := rowconv.SpanEvents(td, e.cfg.Traces.IncludeEventNames, opts.AttributeFilters.Record); len(rows) > 0 {
+			e.spanEventsTruncation.truncate(rows)
+			if e.cfg.IDColumns == idColumnsBytes {
+				setBytesIDs(rows)
+			}
+			appendEvents := func(ctx context.Context) error {
+				return appendTableRows(ctx, e.spanEventsAppender, e.spanEventsShards, rows, "event_timestamp")
+			}
+			appends.start(ctx, "span event", appendEvents, appendEvents)
+		}
+		if rows := rowconv.SpanLinks(td, opts.AttributeFilters.Record); len(rows) > 0 {
+			e.spanLinksTruncation.truncate(rows)
+			if e.cfg.IDColumns == idColumnsBytes {
+				setBytesIDs(rows)
+			}
+			appendLinks := func(ctx context.Context) error {
+				return appendTableRows(ctx, e.spanLinksAppender, e.spanLinksShards, rows, "span_start_time")
+			}
+			appends.start(ctx, "span link", appendLinks, appendLinks)
+		}
+	}
+
+	if len(rows) > 0 {
+		if e.cfg.WideEvents.Enabled {
+			setSignalTypes(rows, "traces")
+		}
+		appends.start(ctx, "traces", func(ctx context.Context) error {
+			return e.appendSignalRows(ctx, e.tracesAppender, e.tracesShards, &e.tracesDualWrite, rows, "start_time")
+		}, retrySignalRows(e.tracesAppender, e.tracesShards, rows, "start_time"))
 	}
-	return hex.EncodeToString(id[:])
+	return e.waitTableAppends(ctx, &appends)
 }
 
-func attributesToJSON(attrs pcommon.Map) string {
-	if attrs.Len() == 0 {
-		return "{}"
+func (e *bigQueryExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
+	releaseMemory, err := e.memory.acquireMetrics(ctx, md, e.anonymizer != nil)
+	if err != nil {
+		return fmt.Errorf("append metrics rows: %w", err)
+	}
+	defer releaseMemory()
+	md = e.anonymizer.metrics(md)
+	opts := e.cfg.Metrics.rowOptions()
+	opts.ExemplarSpanIDsAsBytes = e.cfg.IDColumns == idColumnsBytes
+	opts.ComputedValues = e.metricsComputed
+	opts.PromotedAttributes = e.metricsPromoted
+	rows, err := applyEmptyValues(ctx, e.metricsAppender, rowconv.Metrics(md, opts), metricsRequiredColumns, e.cfg.Metrics.EmptyValues)
+	if err != nil {
+		return fmt.Errorf("append metrics rows: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if e.cfg.Metrics.Upsert.Enabled {
+		setSeriesIDs(rows)
 	}
-	return marshalJSON(attrs.AsRaw())
+	// Truncated after the series IDs, which hash the full attributes.
+	e.metricsTruncation.truncate(rows)
+	if e.resources != nil {
+		// Series IDs hash the resource columns, so they are set first.
+		if err := e.appendResources(ctx, rows); err != nil {
+			return fmt.Errorf("append metrics rows: %w", err)
+		}
+	}
+	if e.cfg.WideEvents.Enabled {
+		setSignalTypes(rows, "metrics")
+	}
+	if err := e.appendSignalRows(ctx, e.metricsAppender, e.metricsShards, &e.metricsDualWrite, rows, "datapoint_timestamp"); err != nil {
+		return fmt.Errorf("append metrics rows: %w", err)
+	}
+	return nil
 }
 
-func scopeToJSON(scope pcommon.InstrumentationScope) string {
-	m := map[string]any{
-		"name":    scope.Name(),
-		"version": scope.Version(),
+// pushLogs appends log records and entity events to their tables
+// concurrently, so a slow entity table does not delay log records.
+func (e *bigQueryExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
+	releaseMemory, err := e.memory.acquireLogs(ctx, ld, e.anonymizer != nil)
+	if err != nil {
+		return fmt.Errorf("append logs rows: %w", err)
+	}
+	defer releaseMemory()
+	ld = e.anonymizer.logs(ld)
+	opts := e.cfg.Logs.rowOptions()
+	opts.ComputedValues = e.logsComputed
+	opts.PromotedAttributes = e.logsPromoted
+	rows := rowconv.Logs(ld, opts)
+	if len(rows) > 0 {
+		e.logsTruncation.truncate(rows)
+		if e.cfg.IDColumns == idColumnsBytes {
+			setBytesIDs(rows)
+		}
+		if e.resources != nil {
+			if err := e.appendResources(ctx, rows); err != nil {
+				return fmt.Errorf("append logs rows: %w", err)
+			}
+		}
 	}
-	if scope.Attributes().Len() > 0 {
-		m["attributes"] = scope.Attributes().AsRaw()
+	var appends tableAppends
+	if e.cfg.Logs.EntityEvents {
+		if rows := rowconv.EntityEvents(ld); len(rows) > 0 {
+			appendEntities := func(ctx context.Context) error {
+				return appendTableRows(ctx, e.entitiesAppender, e.entitiesShards, rows, "event_timestamp")
+			}
+			appends.start(ctx, "entity event", appendEntities, appendEntities)
+		}
+	}
+
+	if len(rows) > 0 {
+		if e.cfg.WideEvents.Enabled {
+			setSignalTypes(rows, "logs")
+		}
+		columns := e.cfg.Logs.timestampColumns()
+		appends.start(ctx, "logs", func(ctx context.Context) error {
+			return e.appendSignalRows(ctx, e.logsAppender, e.logsShards, &e.logsDualWrite, rows, columns...)
+		}, retrySignalRows(e.logsAppender, e.logsShards, rows, columns...))
 	}
-	return marshalJSON(m)
+	return e.waitTableAppends(ctx, &appends)
 }
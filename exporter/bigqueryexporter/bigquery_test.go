@@ -0,0 +1,411 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestResolveProject(t *testing.T) {
+	for _, key := range []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT", "GCP_PROJECT"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filepath.Join(t.TempDir(), "missing.json"))
+
+	original := metadataProjectID
+	t.Cleanup(func() { metadataProjectID = original })
+
+	newExporter := func(project string) *bigQueryExporter {
+		cfg := createDefaultConfig()
+		cfg.Dataset.Project = project
+		return newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	}
+
+	t.Run("configured project", func(t *testing.T) {
+		metadataProjectID = func(context.Context) (string, error) {
+			t.Fatal("metadata server must not be queried")
+			return "", nil
+		}
+		project, err := newExporter("configured").resolveProject(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "configured", project)
+	})
+
+	t.Run("environment variable", func(t *testing.T) {
+		t.Setenv("GCLOUD_PROJECT", "from-env")
+		project, err := newExporter("").resolveProject(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", project)
+	})
+
+	t.Run("metadata server", func(t *testing.T) {
+		metadataProjectID = func(context.Context) (string, error) { return "from-metadata", nil }
+		project, err := newExporter("").resolveProject(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "from-metadata", project)
+	})
+
+	t.Run("not detectable", func(t *testing.T) {
+		metadataProjectID = func(context.Context) (string, error) { return "", errors.New("not running on GCE") }
+		_, err := newExporter("").resolveProject(t.Context())
+		assert.ErrorContains(t, err, "unable to detect from ADC or the metadata server")
+	})
+}
+
+func TestSignalTargetsProjectOverride(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Traces.Project = "analytics-project"
+	cfg.Logs.Project = "security-project"
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.project = "default-project"
+
+	projects := map[string]string{}
+	for _, target := range exp.signalTargets() {
+		projects[target.name] = target.project
+	}
+	assert.Equal(t, map[string]string{
+		"traces":  "analytics-project",
+		"metrics": "default-project",
+		"logs":    "security-project",
+	}, projects)
+}
+
+func TestSignalTargetsCredentialsOverride(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.Credentials.File = "/etc/bigquery/platform.json"
+	cfg.Logs.Project = "security-project"
+	cfg.Logs.Credentials.ImpersonateServiceAccount = "writer@security-project.iam.gserviceaccount.com"
+	cfg.Logs.EntityEvents = true
+	cfg.Statistics.Enabled = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.project = "default-project"
+
+	destinations := map[string]destination{}
+	for _, target := range exp.signalTargets() {
+		destinations[target.name] = target.destination()
+	}
+	platform := destination{project: "default-project", credentials: cfg.Dataset.Credentials}
+	security := destination{project: "security-project", credentials: cfg.Logs.Credentials}
+	assert.Equal(t, map[string]destination{
+		"traces":         platform,
+		"metrics":        platform,
+		"logs":           security,
+		"entities":       security,
+		statisticsSignal: platform,
+	}, destinations)
+}
+
+func TestSignalTargetsDatasetOverride(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.Traces.Dataset = "otel_traces"
+	cfg.Logs.Dataset = "otel_audit"
+	cfg.Logs.EntityEvents = true
+	cfg.Statistics.Enabled = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	datasets := map[string]string{}
+	for _, target := range exp.signalTargets() {
+		datasets[target.name] = target.dataset
+	}
+	assert.Equal(t, map[string]string{
+		"traces":         "otel_traces",
+		"metrics":        "otel",
+		"logs":           "otel_audit",
+		"entities":       "otel_audit",
+		statisticsSignal: "otel",
+	}, datasets)
+}
+
+func TestSignalTargetsChildTables(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.Traces.Dataset = "otel_traces"
+	cfg.Traces.ChildTables = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	targets := map[string]signalTarget{}
+	for _, target := range exp.signalTargets() {
+		targets[target.name] = target
+	}
+	assert.Nil(t, schemaField(targets["traces"].schema, "events"))
+	assert.Nil(t, schemaField(targets["traces"].schema, "links"))
+	require.Contains(t, targets, "span_events")
+	assert.Equal(t, "trace_event", targets["span_events"].tableID)
+	assert.Equal(t, "otel_traces", targets["span_events"].dataset)
+	assert.Equal(t, "event_timestamp", targets["span_events"].partitioning.Field)
+	require.Contains(t, targets, "span_links")
+	assert.Equal(t, "trace_link", targets["span_links"].tableID)
+	assert.Equal(t, "otel_traces", targets["span_links"].dataset)
+	assert.Equal(t, "span_start_time", targets["span_links"].partitioning.Field)
+}
+
+func TestSignalTargetsUpsert(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Metrics.Upsert = UpsertConfig{Enabled: true, MaxStaleness: time.Hour}
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	targets := map[string]signalTarget{}
+	for _, target := range exp.signalTargets() {
+		targets[target.name] = target
+	}
+	assert.Nil(t, targets["traces"].primaryKey)
+	assert.Equal(t, []string{"series_id"}, targets["metrics"].primaryKey)
+	assert.Equal(t, time.Hour, targets["metrics"].maxStaleness)
+	assert.NotNil(t, schemaField(targets["metrics"].schema, "series_id"))
+	assert.Nil(t, schemaField(targets["metrics"].schema, changeTypeColumn))
+}
+
+func TestTableExpirationTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	cfg := createDefaultConfig()
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	assert.True(t, exp.tableExpirationTime(now).IsZero())
+
+	cfg.Dataset.TableExpiration = 24 * time.Hour
+	assert.Equal(t, now.Add(24*time.Hour), exp.tableExpirationTime(now))
+}
+
+func TestEncryptionConfig(t *testing.T) {
+	cfg := createDefaultConfig()
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	assert.Nil(t, exp.encryptionConfig())
+
+	cfg.Dataset.KMSKeyName = "projects/p/locations/us/keyRings/r/cryptoKeys/k"
+	assert.Equal(t, &bigquery.EncryptionConfig{KMSKeyName: "projects/p/locations/us/keyRings/r/cryptoKeys/k"}, exp.encryptionConfig())
+}
+
+func TestTableUpdate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	cfg := createDefaultConfig()
+	cfg.Dataset.TableLabels = map[string]string{"team": "observability", "env": "prod"}
+	cfg.Dataset.TableDescription = "OpenTelemetry spans"
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	_, ok := exp.tableUpdate(&bigquery.TableMetadata{}, now)
+	assert.False(t, ok, "existing tables are only updated when configured")
+
+	cfg.Dataset.UpdateTableMetadata = true
+	update, ok := exp.tableUpdate(&bigquery.TableMetadata{
+		Labels:      map[string]string{"team": "observability", "env": "dev", "owner": "me"},
+		Description: "OpenTelemetry spans",
+	}, now)
+	require.True(t, ok)
+	want := bigquery.TableMetadataToUpdate{}
+	want.SetLabel("env", "prod")
+	assert.Equal(t, want, update)
+
+	_, ok = exp.tableUpdate(&bigquery.TableMetadata{
+		Labels:      map[string]string{"team": "observability", "env": "prod"},
+		Description: "OpenTelemetry spans",
+	}, now)
+	assert.False(t, ok, "up to date tables are not updated")
+
+	cfg.Dataset.TableExpiration = time.Hour
+	cfg.Dataset.UpdateTableExpiration = true
+	update, ok = exp.tableUpdate(&bigquery.TableMetadata{
+		Labels: map[string]string{"team": "observability", "env": "prod"},
+	}, now)
+	require.True(t, ok)
+	assert.Equal(t, now.Add(time.Hour), update.ExpirationTime)
+	assert.Equal(t, "OpenTelemetry spans", update.Description)
+}
+
+// racingTable serves a table that another collector creates concurrently:
+// it is missing for the first missing gets, and creating it fails with 409.
+type racingTable struct {
+	mu       sync.Mutex
+	missing  int
+	requests []string
+}
+
+func (f *racingTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method)
+	switch {
+	case r.Method == http.MethodPost:
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":{"code":409,"message":"Already Exists: Table p:d.log"}}`))
+	case f.missing > 0:
+		f.missing--
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Not found"}}`))
+	default:
+		_, _ = w.Write([]byte(`{"type":"TABLE","tableReference":{"projectId":"p","datasetId":"d","tableId":"log"},"schema":{"fields":[{"name":"body","type":"STRING"}]}}`))
+	}
+}
+
+// newFakeBigQueryClient starts a server with handler and returns a BigQuery
+// client connected to it.
+func newFakeBigQueryClient(t *testing.T, handler http.Handler) *bigquery.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := bigquery.NewClient(t.Context(), "p", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func newRacingTableClient(t *testing.T, missing int) (*bigquery.Client, *racingTable) {
+	fake := &racingTable{missing: missing}
+	client := newFakeBigQueryClient(t, fake)
+	return client, fake
+}
+
+func TestEnsureTableCreatedConcurrently(t *testing.T) {
+	client, fake := newRacingTableClient(t, 1)
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	target := signalTarget{name: "logs", project: "p", dataset: "d", tableID: "log", schema: bigquery.Schema{{Name: "body", Type: bigquery.StringFieldType}}}
+
+	require.NoError(t, exp.ensureTable(t.Context(), client, target))
+	assert.Equal(t, []string{http.MethodGet, http.MethodPost, http.MethodGet}, fake.requests)
+}
+
+func TestEnsureTableCreateJitter(t *testing.T) {
+	client, fake := newRacingTableClient(t, 1)
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	cfg.TableCreateJitter = time.Millisecond
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	target := signalTarget{name: "logs", project: "p", dataset: "d", tableID: "log", schema: bigquery.Schema{{Name: "body", Type: bigquery.StringFieldType}}}
+
+	// The table exists after the delay, so it is not created.
+	require.NoError(t, exp.ensureTable(t.Context(), client, target))
+	assert.Equal(t, []string{http.MethodGet, http.MethodGet}, fake.requests)
+}
+
+func TestEnsureTableIncompatibleSchema(t *testing.T) {
+	client, _ := newRacingTableClient(t, 0)
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	target := signalTarget{name: "logs", project: "p", dataset: "d", tableID: "log", schema: bigquery.Schema{
+		{Name: "body", Type: bigquery.JSONFieldType},
+		{Name: "severity_text", Type: bigquery.StringFieldType},
+	}}
+
+	// Missing columns are only reported when tables are auto-created, other
+	// differences fail the start.
+	err := exp.ensureTable(t.Context(), client, target)
+	require.ErrorContains(t, err, "logs table log has an incompatible schema: 1 column(s) differ")
+	assert.ErrorContains(t, err, "body     STRING NULLABLE   JSON NULLABLE")
+	assert.NotContains(t, err.Error(), "severity_text")
+
+	exp.cfg.AutoCreateTables = false
+	assert.ErrorContains(t, exp.ensureTable(t.Context(), client, target), "severity_text   (missing)")
+}
+
+func TestCheckDatasetAutoCreate(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created map[string]any
+	)
+	client := newFakeBigQueryClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_ = json.NewEncoder(w).Encode(created)
+		case created == nil:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Not found: Dataset p:d"}}`))
+		default:
+			_ = json.NewEncoder(w).Encode(created)
+		}
+	}))
+
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	target := signalTarget{name: "logs", project: "p", dataset: "d"}
+	require.ErrorContains(t, exp.checkDataset(t.Context(), &projectClients{client: client}, target, map[string]bool{}), "dataset auto-creation is disabled")
+
+	exp.cfg.Dataset.AutoCreate = true
+	exp.cfg.Dataset.Location = "EU"
+	exp.cfg.Dataset.DefaultTableExpiration = 720 * time.Hour
+	exp.cfg.Dataset.DefaultPartitionExpiration = 168 * time.Hour
+	checked := map[string]bool{}
+	require.NoError(t, exp.checkDataset(t.Context(), &projectClients{client: client}, target, checked))
+	assert.True(t, checked["p.d"])
+	assert.Equal(t, "EU", created["location"])
+	assert.Equal(t, "2592000000", created["defaultTableExpirationMs"])
+	assert.Equal(t, "604800000", created["defaultPartitionExpirationMs"])
+}
+
+func TestCheckDatasetExpectedLocation(t *testing.T) {
+	client := newFakeBigQueryClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"datasetReference":{"projectId":"p","datasetId":"d"},"location":"europe-west1"}`))
+	}))
+
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	cfg.Dataset.ExpectedLocation = "EUROPE-WEST1"
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	target := signalTarget{name: "logs", project: "p", dataset: "d"}
+	require.NoError(t, exp.checkDataset(t.Context(), &projectClients{client: client}, target, map[string]bool{}))
+
+	exp.cfg.Dataset.ExpectedLocation = "US"
+	checked := map[string]bool{}
+	require.EqualError(t, exp.checkDataset(t.Context(), &projectClients{client: client}, target, checked), "dataset p.d is in location europe-west1, but dataset.expected_location is US")
+	assert.Empty(t, checked)
+}
+
+func TestIsAlreadyExists(t *testing.T) {
+	assert.True(t, isAlreadyExists(fmt.Errorf("create: %w", &googleapi.Error{Code: http.StatusConflict})))
+	assert.False(t, isAlreadyExists(&googleapi.Error{Code: http.StatusNotFound}))
+	assert.False(t, isAlreadyExists(nil))
+}
+
+func TestSamePartitioning(t *testing.T) {
+	byDay := PartitioningConfig{Field: "log_timestamp", Granularity: "DAY"}
+	bySeverity := PartitioningConfig{Field: "severity_number", Granularity: "DAY", Range: RangePartitioningConfig{End: 25, Interval: 4}}
+	timeTable := &bigquery.TableMetadata{TimePartitioning: &bigquery.TimePartitioning{Type: bigquery.DayPartitioningType, Field: "log_timestamp"}}
+	rangeTable := &bigquery.TableMetadata{RangePartitioning: &bigquery.RangePartitioning{
+		Field: "severity_number",
+		Range: &bigquery.RangePartitioningRange{End: 25, Interval: 4},
+	}}
+
+	assert.True(t, samePartitioning(timeTable, byDay))
+	assert.False(t, samePartitioning(timeTable, bySeverity))
+	assert.True(t, samePartitioning(rangeTable, bySeverity))
+	assert.False(t, samePartitioning(rangeTable, byDay))
+	bySeverity.Range.Interval = 5
+	assert.False(t, samePartitioning(rangeTable, bySeverity))
+	assert.True(t, samePartitioning(&bigquery.TableMetadata{}, bySeverity))
+}
+
+func TestPartitioningConfigRange(t *testing.T) {
+	cfg := PartitioningConfig{Field: "severity_number", Granularity: "DAY", Range: RangePartitioningConfig{Start: 1, End: 25, Interval: 4}}
+	assert.Nil(t, cfg.timePartitioning())
+	assert.Equal(t, &bigquery.RangePartitioning{
+		Field: "severity_number",
+		Range: &bigquery.RangePartitioningRange{Start: 1, End: 25, Interval: 4},
+	}, cfg.rangePartitioning())
+
+	cfg.Range = RangePartitioningConfig{}
+	assert.Nil(t, cfg.rangePartitioning())
+	assert.Equal(t, &bigquery.TimePartitioning{Type: bigquery.DayPartitioningType, Field: "severity_number"}, cfg.timePartitioning())
+}
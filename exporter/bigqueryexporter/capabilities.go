@@ -0,0 +1,82 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+)
+
+// jsonProbeQuery is dry-run in a dataset's location to detect whether the
+// JSON type can be used there. Dry runs are free and read no data.
+const jsonProbeQuery = "SELECT PARSE_JSON('{}') AS probe"
+
+// datasetCapabilities are the features detected for a dataset when
+// probe_capabilities is enabled. The zero value assumes every feature is
+// available.
+type datasetCapabilities struct {
+	// noJSON is set when the JSON type cannot be used in the dataset.
+	noJSON bool
+}
+
+// probeCapabilities detects the features of the dataset described by md.
+// Probe failures are only logged, since the probe is advisory.
+func (e *bigQueryExporter) probeCapabilities(ctx context.Context, client *bigquery.Client, key string, md *bigquery.DatasetMetadata) datasetCapabilities {
+	var caps datasetCapabilities
+	err := e.retryControlPlane(ctx, "probe JSON type", func(ctx context.Context) error {
+		q := client.Query(jsonProbeQuery)
+		q.DryRun = true
+		q.Location = md.Location
+		_, err := q.Run(ctx)
+		return err
+	})
+	var apiErr *googleapi.Error
+	switch {
+	case err == nil:
+	case errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest && slices.ContainsFunc(apiErr.Errors, func(item googleapi.ErrorItem) bool {
+		return item.Reason == "invalidQuery"
+	}):
+		caps.noJSON = true
+		e.logger.Warn("The JSON type is not available in the dataset; JSON columns of its tables are created as STRING unless json_columns is set",
+			zap.String("dataset", key), zap.String("location", md.Location), zap.Error(err))
+	default:
+		e.logger.Warn("Dataset capability probe failed; assuming all features are available",
+			zap.String("dataset", key), zap.Error(err))
+	}
+
+	billingModel := md.StorageBillingModel
+	if billingModel == bigquery.LogicalStorageBillingModel {
+		billingModel = "LOGICAL"
+	}
+	fields := []zap.Field{
+		zap.String("dataset", key),
+		zap.String("location", md.Location),
+		zap.Bool("json_type", !caps.noJSON),
+		zap.String("storage_billing_model", billingModel),
+	}
+	if md.DefaultEncryptionConfig != nil {
+		fields = append(fields, zap.String("default_kms_key_name", md.DefaultEncryptionConfig.KMSKeyName))
+		if e.cfg.Dataset.KMSKeyName == "" {
+			fields = append(fields, zap.String("note", "created tables inherit the dataset's default KMS key"))
+		}
+	}
+	e.logger.Info("Probed dataset capabilities", fields...)
+	return caps
+}
+
+// jsonColumns returns the json_columns setting used for a table in the
+// given dataset. An unset setting becomes string when the probe found the
+// JSON type unavailable there.
+func (e *bigQueryExporter) jsonColumns(project, dataset, configured string) string {
+	if configured == "" && e.capabilities[project+"."+dataset].noJSON {
+		return jsonColumnsString
+	}
+	return configured
+}
@@ -0,0 +1,42 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestSignalTargetsWithoutJSONType(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.Logs.Dataset = "otel_logs"
+	cfg.Metrics.JSONColumns = jsonColumnsJSON
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.project = "p"
+	exp.capabilities = map[string]datasetCapabilities{
+		"p.otel": {noJSON: true},
+	}
+
+	schemas := map[string]bigquery.Schema{}
+	for _, target := range exp.signalTargets() {
+		schemas[target.name] = target.schema
+	}
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schemas["traces"], "span_attributes").Type)
+	// An explicit json_columns setting is kept.
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(schemas["metrics"], "datapoint_attributes").Type)
+	// Other datasets are not affected.
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(schemas["logs"], "log_attributes").Type)
+}
+
+func TestJSONColumnsNotProbed(t *testing.T) {
+	exp := newBigQueryExporter(t.Context(), createDefaultConfig(), exportertest.NewNopSettings(metadata.Type))
+	assert.Empty(t, exp.jsonColumns("p", "d", ""))
+	assert.Equal(t, jsonColumnsString, exp.jsonColumns("p", "d", jsonColumnsString))
+}
@@ -0,0 +1,308 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	gax "github.com/googleapis/gax-go/v2"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	insertDataScope    = "https://www.googleapis.com/auth/bigquery.insertdata"
+)
+
+// clientOptions returns the options shared by the BigQuery and Storage Write
+// clients authenticating with credentialOpts.
+func (e *bigQueryExporter) clientOptions(credentialOpts []option.ClientOption) []option.ClientOption {
+	opts := []option.ClientOption{
+		option.WithUserAgent(e.userAgent()),
+		option.WithScopes(e.cfg.Scopes...),
+	}
+	return append(opts, credentialOpts...)
+}
+
+// canManageTables reports whether the configured scopes allow reading dataset
+// and table metadata and creating tables. Narrower scopes such as
+// bigquery.insertdata only allow writing rows into existing tables.
+func (e *bigQueryExporter) canManageTables() bool {
+	return slices.ContainsFunc(e.cfg.Scopes, func(scope string) bool {
+		return scope == bigquery.Scope || scope == cloudPlatformScope
+	})
+}
+
+// explainScopeError adds the configured scopes to errors caused by a token
+// that was not granted the scopes needed for an operation.
+func (e *bigQueryExporter) explainScopeError(err error) error {
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "insufficient authentication scopes") && !strings.Contains(msg, "access_token_scope_insufficient") {
+		return err
+	}
+	return fmt.Errorf("access token lacks the required OAuth scopes (configured scopes: %s): %w", strings.Join(e.cfg.Scopes, ", "), err)
+}
+
+// controlPlaneClientOptions returns the options for the REST client used for
+// dataset and table metadata. When proxy_url or tls is set, requests are sent
+// through an authenticated transport built on a customized base transport;
+// proxy_url does not affect the gRPC write path.
+func (e *bigQueryExporter) controlPlaneClientOptions(ctx context.Context, credentialOpts []option.ClientOption) ([]option.ClientOption, error) {
+	opts := e.clientOptions(credentialOpts)
+	tlsCfg, err := e.loadTLSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.ProxyURL == "" && tlsCfg == nil {
+		return opts, nil
+	}
+
+	base, err := e.proxyTransport()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		base.TLSClientConfig = tlsCfg
+	}
+
+	transport, err := htransport.NewTransport(ctx, base, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create BigQuery HTTP transport: %w", err)
+	}
+	return append(opts, option.WithHTTPClient(&http.Client{Transport: transport})), nil
+}
+
+// proxyTransport returns a clone of the default transport that sends
+// requests through proxy_url when it is set.
+func (e *bigQueryExporter) proxyTransport() (*http.Transport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if e.cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(e.cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy_url: %w", err)
+		}
+		base.Proxy = http.ProxyURL(proxyURL)
+	}
+	return base, nil
+}
+
+// proxyContext returns ctx carrying an HTTP client that sends requests
+// through proxy_url. The OAuth2 libraries fetch tokens with it, and
+// credential loading uses it for Secret Manager and service account
+// impersonation, so credentials work behind an egress-only proxy. ctx is
+// returned unchanged when proxy_url is not set.
+func (e *bigQueryExporter) proxyContext(ctx context.Context) (context.Context, error) {
+	if e.cfg.ProxyURL == "" {
+		return ctx, nil
+	}
+	transport, err := e.proxyTransport()
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport}), nil
+}
+
+// storageWriteClientOptions returns the options for the gRPC Storage Write client.
+func (e *bigQueryExporter) storageWriteClientOptions(ctx context.Context, credentialOpts []option.ClientOption) ([]option.ClientOption, error) {
+	opts := e.clientOptions(credentialOpts)
+	tlsCfg, err := e.loadTLSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg))))
+	}
+	write := e.cfg.Write
+	if write.Keepalive.Time > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    write.Keepalive.Time,
+			Timeout: write.Keepalive.Timeout,
+		})))
+	}
+	var callOpts []grpc.CallOption
+	if write.MaxSendMessageSizeMiB > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(write.MaxSendMessageSizeMiB<<20))
+	}
+	if write.MaxRecvMessageSizeMiB > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(write.MaxRecvMessageSizeMiB<<20))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithDefaultCallOptions(callOpts...)))
+	}
+	if write.Compression == gzip.Name {
+		opts = append(opts, managedwriter.WithDefaultAppendRowsCallOption(gax.WithGRPCOptions(grpc.UseCompressor(gzip.Name))))
+	}
+	return opts, nil
+}
+
+// loadTLSConfig returns the custom TLS configuration for BigQuery endpoints,
+// or nil when the system roots and no client certificate should be used.
+func (e *bigQueryExporter) loadTLSConfig(ctx context.Context) (*tls.Config, error) {
+	if !e.cfg.TLS.HasValue() {
+		return nil, nil
+	}
+	tlsCfg, err := e.cfg.TLS.Get().LoadTLSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load tls configuration: %w", err)
+	}
+	return tlsCfg, nil
+}
+
+// userAgent identifies the collector build, followed by the configured suffix.
+func (e *bigQueryExporter) userAgent() string {
+	ua := fmt.Sprintf("%s/%s (%s/%s)", e.buildInfo.Command, e.buildInfo.Version, runtime.GOOS, runtime.GOARCH)
+	if suffix := strings.TrimSpace(e.cfg.UserAgentSuffix); suffix != "" {
+		ua += " " + suffix
+	}
+	return ua
+}
+
+// preflightAuthCheck acquires an access token for every configured
+// credentials and performs a cheap authenticated call per table, so that
+// misconfigured credentials fail start instead of surfacing at the first
+// append behind exporterhelper retries.
+func (e *bigQueryExporter) preflightAuthCheck(ctx context.Context) error {
+	checked := make(map[CredentialsConfig]bool)
+	for _, target := range e.signalTargets() {
+		if checked[target.credentials] {
+			continue
+		}
+		checked[target.credentials] = true
+		ts, err := newTokenSource(ctx, target.credentials, e.cfg.Scopes)
+		if err != nil {
+			return fmt.Errorf("preflight auth check: %w", err)
+		}
+		if _, err = ts.Token(); err != nil {
+			return fmt.Errorf("preflight auth check: obtain access token for %s: %w", target.name, err)
+		}
+	}
+	for _, target := range e.signalTargets() {
+		name := managedwriter.TableParentFromParts(target.project, target.dataset, target.tableID) + "/streams/_default"
+		if _, err := e.clients[target.destination()].writeClient.Load().GetWriteStream(ctx, &storagepb.GetWriteStreamRequest{Name: name}); err != nil {
+			return e.explainScopeError(fmt.Errorf("preflight auth check: get %s write stream: %w", target.name, err))
+		}
+	}
+	e.logger.Debug("Preflight auth check succeeded")
+	return nil
+}
+
+// reconnectWriteClients replaces the Storage Write client of every
+// destination written with creds and moves the appenders of its tables to
+// the new client, so that streams authenticated with rotated credentials
+// are reopened. The previous client is retired until all of its appenders
+// have moved, see moveRetiredAppenders.
+func (e *bigQueryExporter) reconnectWriteClients(creds CredentialsConfig) {
+	ctx, cancel := e.reconnectContext()
+	defer cancel()
+	for dest, clients := range e.clients {
+		if dest.credentials != creds {
+			continue
+		}
+		writeOpts, err := e.storageWriteClientOptions(ctx, e.credentials[creds].opts)
+		if err != nil {
+			e.logger.Warn("Failed to reconnect Storage Write streams with rotated credentials", zap.String("project", dest.project), zap.Error(err))
+			continue
+		}
+		// Like its streams, the client lives as long as the context it is
+		// created with.
+		next, err := newStorageWriteClient(context.WithoutCancel(ctx), dest.project, append(writeOpts, e.writeOpts...)...)
+		if err != nil {
+			e.logger.Warn("Failed to reconnect Storage Write streams with rotated credentials", zap.String("project", dest.project), zap.Error(err))
+			continue
+		}
+		// Shards opened from now on use next, so the appenders are listed
+		// after the swap. Appenders of a client retired before are moved
+		// with that client.
+		retired := &retiredClient{client: clients.writeClient.Swap(next)}
+		for _, appender := range e.destinationAppenders(dest) {
+			if !slices.ContainsFunc(clients.retired, func(r *retiredClient) bool { return slices.Contains(r.appenders, appender) }) {
+				retired.appenders = append(retired.appenders, appender)
+			}
+		}
+		clients.retired = append(clients.retired, retired)
+		tables := len(retired.appenders)
+		e.moveAppenders(ctx, dest, clients)
+		e.logger.Info("Reconnected Storage Write streams with rotated credentials", zap.String("project", dest.project), zap.Int("tables", tables))
+	}
+}
+
+// moveRetiredAppenders retries moving the appenders of destinations written
+// with creds that are still using a retired client.
+func (e *bigQueryExporter) moveRetiredAppenders(creds CredentialsConfig) {
+	ctx, cancel := e.reconnectContext()
+	defer cancel()
+	for dest, clients := range e.clients {
+		if dest.credentials == creds && len(clients.retired) > 0 {
+			e.moveAppenders(ctx, dest, clients)
+		}
+	}
+}
+
+// moveAppenders moves the appenders of the retired clients of dest to its
+// current client, and closes the retired clients no appender uses anymore.
+// Appenders that fail to move, e.g. because their pending rows are not
+// committed yet, are retried with the next check of the credentials.
+func (e *bigQueryExporter) moveAppenders(ctx context.Context, dest destination, clients *projectClients) {
+	current := clients.writeClient.Load()
+	clients.retired = slices.DeleteFunc(clients.retired, func(retired *retiredClient) bool {
+		retired.appenders = slices.DeleteFunc(retired.appenders, func(appender *storageAppender) bool {
+			if err := appender.reconnect(ctx, current); err != nil {
+				e.logger.Warn("Failed to reconnect Storage Write stream with rotated credentials, retrying later", zap.String("table", appender.tableRef), zap.Error(err))
+				return false
+			}
+			return true
+		})
+		if len(retired.appenders) > 0 {
+			return false
+		}
+		if err := retired.client.Close(); err != nil {
+			e.logger.Debug("Failed to close previous Storage Write client", zap.String("project", dest.project), zap.Error(err))
+		}
+		return true
+	})
+}
+
+// reconnectContext returns the context streams are reconnected with, bounded
+// by the exporter timeout unless it is disabled.
+func (e *bigQueryExporter) reconnectContext() (context.Context, context.CancelFunc) {
+	if e.cfg.TimeoutConfig.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), e.cfg.TimeoutConfig.Timeout)
+}
+
+// destinationAppenders returns the distinct appenders, including those of
+// open shards, writing to tables of dest.
+func (e *bigQueryExporter) destinationAppenders(dest destination) []*storageAppender {
+	var appenders []*storageAppender
+	for _, target := range e.signalTargets() {
+		if target.destination() != dest {
+			continue
+		}
+		if *target.appender != nil && !slices.Contains(appenders, *target.appender) {
+			appenders = append(appenders, *target.appender)
+		}
+		if target.shards != nil && *target.shards != nil {
+			appenders = append(appenders, (*target.shards).appenders()...)
+		}
+	}
+	return appenders
+}
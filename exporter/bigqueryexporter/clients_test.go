@@ -0,0 +1,241 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/config/configtls"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestUserAgent(t *testing.T) {
+	set := exportertest.NewNopSettings(metadata.Type)
+	set.BuildInfo = component.BuildInfo{Command: "otelcol-contrib", Version: "1.2.3"}
+	platform := " (" + runtime.GOOS + "/" + runtime.GOARCH + ")"
+
+	cfg := createDefaultConfig()
+	exp := newBigQueryExporter(t.Context(), cfg, set)
+	assert.Equal(t, "otelcol-contrib/1.2.3"+platform, exp.userAgent())
+
+	cfg.UserAgentSuffix = " fleet-eu-west "
+	assert.Equal(t, "otelcol-contrib/1.2.3"+platform+" fleet-eu-west", exp.userAgent())
+}
+
+func TestControlPlaneClientOptions(t *testing.T) {
+	cfg := createDefaultConfig()
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	opts, err := exp.controlPlaneClientOptions(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Len(t, opts, len(exp.clientOptions(nil)))
+
+	cfg.ProxyURL = "http://%zz"
+	_, err = exp.controlPlaneClientOptions(t.Context(), nil)
+	assert.ErrorContains(t, err, "parse proxy_url")
+}
+
+// startRecordingProxy starts an HTTP proxy recording the requests it
+// receives as "METHOD host". It answers token requests for oauth2.test
+// itself and refuses to tunnel anything else.
+func startRecordingProxy(t *testing.T) (string, func() []string) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.Host)
+		mu.Unlock()
+		if r.Host != "oauth2.test" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"proxied","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+// writeSignedServiceAccountKey writes a service account key with a real
+// private key whose tokens are requested from oauth2.test.
+func writeSignedServiceAccountKey(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "p",
+		"private_key_id": "0123456789abcdef",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "writer@p.iam.gserviceaccount.com",
+		"token_uri":      "http://oauth2.test/token",
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestProxyContext(t *testing.T) {
+	proxyURL, requests := startRecordingProxy(t)
+	path := writeSignedServiceAccountKey(t)
+	cfg := createDefaultConfig()
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	ctx, err := exp.proxyContext(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, t.Context(), ctx)
+
+	cfg.ProxyURL = proxyURL
+	ctx, err = exp.proxyContext(t.Context())
+	require.NoError(t, err)
+
+	// Tokens of the credentials file are fetched through the proxy.
+	ts, err := newTokenSource(ctx, CredentialsConfig{File: path}, []string{bigquery.Scope})
+	require.NoError(t, err)
+	tok, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "proxied", tok.AccessToken)
+	assert.Equal(t, []string{"POST oauth2.test"}, requests())
+
+	// So are the IAM credentials calls impersonating a service account.
+	ts, err = newTokenSource(ctx, CredentialsConfig{File: path, ImpersonateServiceAccount: "reader@p.iam.gserviceaccount.com"}, []string{bigquery.Scope})
+	require.NoError(t, err)
+	_, err = ts.Token()
+	require.Error(t, err)
+	assert.Contains(t, requests(), "CONNECT iamcredentials.googleapis.com:443")
+
+	// And the Secret Manager calls reading a credentials secret.
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+	_, err = accessSecretVersion(ctx, "projects/p/secrets/key/versions/latest")
+	require.Error(t, err)
+	assert.Contains(t, requests(), "CONNECT secretmanager.googleapis.com:443")
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	cfg := createDefaultConfig()
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	tlsCfg, err := exp.loadTLSConfig(t.Context())
+	require.NoError(t, err)
+	assert.Nil(t, tlsCfg)
+
+	opts, err := exp.storageWriteClientOptions(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Len(t, opts, len(exp.clientOptions(nil)))
+
+	custom := configtls.NewDefaultClientConfig()
+	custom.ServerName = "bigquery.p.googleapis.com"
+	cfg.TLS = configoptional.Some(custom)
+	tlsCfg, err = exp.loadTLSConfig(t.Context())
+	require.NoError(t, err)
+	require.NotNil(t, tlsCfg)
+	assert.Equal(t, "bigquery.p.googleapis.com", tlsCfg.ServerName)
+
+	opts, err = exp.storageWriteClientOptions(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Len(t, opts, len(exp.clientOptions(nil))+1)
+
+	custom.CAFile = "testdata/does-not-exist.pem"
+	cfg.TLS = configoptional.Some(custom)
+	_, err = exp.storageWriteClientOptions(t.Context(), nil)
+	assert.ErrorContains(t, err, "load tls configuration")
+	_, err = exp.controlPlaneClientOptions(t.Context(), nil)
+	assert.ErrorContains(t, err, "load tls configuration")
+}
+
+func TestStorageWriteChannelOptions(t *testing.T) {
+	cfg := createDefaultConfig()
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	cfg.Write.Keepalive = KeepaliveConfig{Time: time.Minute, Timeout: 10 * time.Second}
+	opts, err := exp.storageWriteClientOptions(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Len(t, opts, len(exp.clientOptions(nil))+1)
+
+	cfg.Write.MaxSendMessageSizeMiB = 16
+	cfg.Write.MaxRecvMessageSizeMiB = 8
+	opts, err = exp.storageWriteClientOptions(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Len(t, opts, len(exp.clientOptions(nil))+2)
+
+	cfg.Write.Compression = "gzip"
+	opts, err = exp.storageWriteClientOptions(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Len(t, opts, len(exp.clientOptions(nil))+3)
+}
+
+func TestCanManageTables(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		want   bool
+	}{
+		{scopes: []string{bigquery.Scope}, want: true},
+		{scopes: []string{cloudPlatformScope}, want: true},
+		{scopes: []string{insertDataScope}, want: false},
+		{scopes: []string{insertDataScope, bigquery.Scope}, want: true},
+	}
+	for _, tt := range tests {
+		cfg := createDefaultConfig()
+		cfg.Scopes = tt.scopes
+		exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+		assert.Equal(t, tt.want, exp.canManageTables(), tt.scopes)
+	}
+}
+
+func TestExplainScopeError(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Scopes = []string{insertDataScope}
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	scopeErr := status.Error(codes.PermissionDenied, "Request had insufficient authentication scopes.")
+	err := exp.explainScopeError(scopeErr)
+	require.ErrorIs(t, err, scopeErr)
+	assert.ErrorContains(t, err, "configured scopes: "+insertDataScope)
+
+	other := errors.New("dataset not found")
+	assert.Equal(t, other, exp.explainScopeError(other))
+}
+
+func TestPreflightAuthCheckWithoutCredentials(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filepath.Join(t.TempDir(), "missing.json"))
+
+	cfg := createDefaultConfig()
+	cfg.PreflightAuthCheck = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	err := exp.preflightAuthCheck(t.Context())
+	assert.ErrorContains(t, err, "preflight auth check: find default credentials")
+}
@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"os"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/exporter"
+)
+
+// collectorFields are the columns identifying the collector that wrote a
+// row, added with collector_columns.
+var collectorFields = bigquery.Schema{
+	{Name: "collector_host_name", Type: bigquery.StringFieldType, Required: false},
+	{Name: "collector_instance_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "collector_version", Type: bigquery.StringFieldType, Required: false},
+}
+
+// withCollectorColumns returns schema with the collectorFields appended when
+// enabled.
+func withCollectorColumns(schema bigquery.Schema, enabled bool) bigquery.Schema {
+	if !enabled {
+		return schema
+	}
+	return slices.Concat(schema, collectorFields)
+}
+
+// collectorIdentity returns the values of the collectorFields: the host
+// name, the service.instance.id of the collector's own resource and the
+// collector version. Values that are unknown are left out, so their
+// columns are NULL.
+func collectorIdentity(set exporter.Settings) row {
+	identity := row{}
+	if hostname, err := os.Hostname(); err == nil {
+		identity["collector_host_name"] = hostname
+	}
+	if id, ok := set.Resource.Attributes().Get("service.instance.id"); ok {
+		identity["collector_instance_id"] = id.AsString()
+	}
+	if set.BuildInfo.Version != "" {
+		identity["collector_version"] = set.BuildInfo.Version
+	}
+	return identity
+}
+
+// setCollectorColumns sets the collector columns of rows to identity.
+func setCollectorColumns(rows []row, identity row) {
+	for _, r := range rows {
+		for column, v := range identity {
+			r[column] = v
+		}
+	}
+}
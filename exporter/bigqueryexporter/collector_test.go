@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestWithCollectorColumns(t *testing.T) {
+	assert.Nil(t, schemaField(withCollectorColumns(logsTableSchema(defaultSchemaPreset, LogsConfig{}), false), "collector_version"))
+	schema := withCollectorColumns(logsTableSchema(defaultSchemaPreset, LogsConfig{}), true)
+	for _, field := range collectorFields {
+		assert.NotNil(t, schemaField(schema, field.Name), field.Name)
+	}
+}
+
+func TestCollectorIdentity(t *testing.T) {
+	set := exportertest.NewNopSettings(metadata.Type)
+	set.BuildInfo.Version = "1.2.3"
+	set.Resource.Attributes().PutStr("service.instance.id", "0f9c1e7a")
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	identity := collectorIdentity(set)
+	assert.Equal(t, row{"collector_host_name": hostname, "collector_instance_id": "0f9c1e7a", "collector_version": "1.2.3"}, identity)
+
+	rows := []row{{"body": "a"}}
+	setCollectorColumns(rows, identity)
+	assert.Equal(t, "1.2.3", rows[0]["collector_version"])
+	assert.Equal(t, "a", rows[0]["body"])
+
+	set = exportertest.NewNopSettings(metadata.Type)
+	set.BuildInfo.Version = ""
+	assert.NotContains(t, collectorIdentity(set), "collector_instance_id")
+	assert.NotContains(t, collectorIdentity(set), "collector_version")
+}
+
+func TestSignalTargetsCollectorColumns(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.CollectorColumns = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	require.NotNil(t, exp.collector)
+	for _, target := range exp.signalTargets() {
+		assert.NotNil(t, schemaField(target.schema, "collector_instance_id"), target.name)
+	}
+
+	cfg.CollectorColumns = false
+	assert.Nil(t, newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type)).collector)
+}
@@ -0,0 +1,111 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// validateColumnNames checks that names renames columns of schema to
+// distinct identifiers no column of schema has, so columns cannot be
+// swapped.
+func validateColumnNames(field string, names map[string]string, schema bigquery.Schema) error {
+	renamed := make(map[string]string, len(names))
+	for _, column := range slices.Sorted(maps.Keys(names)) {
+		name := names[column]
+		if !slices.ContainsFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == column }) {
+			return fmt.Errorf("%s: %q is not a column of the table", field, column)
+		}
+		if err := validateIdentifier(fmt.Sprintf("%s[%s]", field, column), name); err != nil {
+			return err
+		}
+		upper := strings.ToUpper(name)
+		if slices.ContainsFunc(reservedColumnPrefixes, func(prefix string) bool { return strings.HasPrefix(upper, prefix) }) {
+			return fmt.Errorf("%s: name %q of column %q uses a prefix BigQuery reserves", field, name, column)
+		}
+		// BigQuery column names are case-insensitive.
+		if other, ok := renamed[strings.ToLower(name)]; ok {
+			return fmt.Errorf("%s: columns %q and %q are both renamed to %q", field, other, column, name)
+		}
+		renamed[strings.ToLower(name)] = column
+	}
+	for _, f := range schema {
+		if column, ok := renamed[strings.ToLower(f.Name)]; ok {
+			return fmt.Errorf("%s: column %q is renamed to %q, which is the name of a column", field, column, names[column])
+		}
+	}
+	return nil
+}
+
+// withColumnNames returns target with its columns renamed as set in
+// columnNames, including those it is partitioned and clustered by and its
+// primary key. Every other setting refers to the exporter's column names.
+func (target signalTarget) withColumnNames() signalTarget {
+	if len(target.columnNames) == 0 {
+		return target
+	}
+	rename := func(column string) string {
+		if name, ok := target.columnNames[column]; ok {
+			return name
+		}
+		return column
+	}
+	schema := make(bigquery.Schema, len(target.schema))
+	for i, field := range target.schema {
+		renamed := *field
+		renamed.Name = rename(field.Name)
+		schema[i] = &renamed
+	}
+	target.schema = schema
+	if target.partitioning.Field != "" {
+		target.partitioning.Field = rename(target.partitioning.Field)
+	}
+	target.clusteringFields = renameAll(target.clusteringFields, rename)
+	target.primaryKey = renameAll(target.primaryKey, rename)
+	return target
+}
+
+func renameAll(columns []string, rename func(string) string) []string {
+	if columns == nil {
+		return nil
+	}
+	renamed := make([]string, len(columns))
+	for i, column := range columns {
+		renamed[i] = rename(column)
+	}
+	return renamed
+}
+
+// renameColumns renames the columns of rows in place. Renaming is
+// idempotent, since no column is renamed to the name of another column.
+func renameColumns(rows []row, names map[string]string) {
+	if len(names) == 0 {
+		return
+	}
+	for _, r := range rows {
+		for column, name := range names {
+			if v, ok := r[column]; ok {
+				r[name] = v
+				delete(r, column)
+			}
+		}
+	}
+}
+
+// restoreColumnNames undoes renameColumns, so rows can be appended again.
+func restoreColumnNames(rows []row, names map[string]string) {
+	for _, r := range rows {
+		for column, name := range names {
+			if v, ok := r[name]; ok {
+				r[column] = v
+				delete(r, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestValidateColumnNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   map[string]string
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "renamed", names: map[string]string{"log_timestamp": "timestamp", "body": "message"}},
+		{name: "swapped", names: map[string]string{"body": "severity_text", "severity_text": "body"}, wantErr: "which is the name of a column"},
+		{name: "unchanged", names: map[string]string{"body": "body"}, wantErr: "which is the name of a column"},
+		{name: "unknown column", names: map[string]string{"message": "body"}, wantErr: `"message" is not a column of the table`},
+		{name: "invalid name", names: map[string]string{"body": "log message"}, wantErr: "logs.column_names[body] must match"},
+		{name: "reserved prefix", names: map[string]string{"body": "_PARTITION_body"}, wantErr: "uses a prefix BigQuery reserves"},
+		{name: "same name", names: map[string]string{"body": "message", "severity_text": "Message"}, wantErr: `are both renamed to "Message"`},
+		{name: "name of another column", names: map[string]string{"body": "Severity_Text"}, wantErr: "which is the name of a column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateColumnNames("logs.column_names", tt.names, rowconv.LogsSchema)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRenameColumns(t *testing.T) {
+	names := map[string]string{"body": "message", "log_timestamp": "timestamp"}
+	rows := []row{{"body": "hello", "severity_text": "INFO"}}
+	renameColumns(rows, names)
+	want := []row{{"message": "hello", "severity_text": "INFO"}}
+	assert.Equal(t, want, rows)
+	// Rows appended again, e.g. to another shard, are unchanged.
+	renameColumns(rows, names)
+	assert.Equal(t, want, rows)
+}
+
+func TestSignalTargetsColumnNames(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Metrics.ColumnNames = map[string]string{"datapoint_timestamp": "ts", "series_id": "series", "metric_name": "name"}
+	cfg.Metrics.ClusteringFields = []string{"metric_name"}
+	cfg.Metrics.Partitioning = PartitioningConfig{Field: "datapoint_timestamp", Granularity: "DAY"}
+	cfg.Metrics.Upsert.Enabled = true
+	cfg.DualWrite.Enabled = true
+	cfg.DualWrite.SchemaPreset = "slim"
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	targets := map[string]signalTarget{}
+	for _, target := range exp.signalTargets() {
+		targets[target.name] = target
+	}
+	for _, name := range []string{"metrics", "metrics" + dualWriteSuffix} {
+		metrics := targets[name]
+		assert.Nil(t, schemaField(metrics.schema, "datapoint_timestamp"), name)
+		assert.NotNil(t, schemaField(metrics.schema, "ts"), name)
+		assert.True(t, schemaField(metrics.schema, "name").Required, name)
+		assert.Equal(t, "ts", metrics.partitioning.Field, name)
+		assert.Equal(t, []string{"name"}, metrics.clusteringFields, name)
+		assert.Equal(t, []string{"series"}, metrics.primaryKey, name)
+	}
+	assert.NotNil(t, schemaField(targets["logs"].schema, "log_timestamp"))
+	// The canonical schema is not modified.
+	assert.NotNil(t, schemaField(rowconv.MetricsSchema, "datapoint_timestamp"))
+}
+
+func TestMigrationColumnsRenamed(t *testing.T) {
+	target := signalTarget{
+		name:        "metrics",
+		schema:      tableSchema(rowconv.MetricsSchema, defaultSchemaPreset, ""),
+		columnNames: map[string]string{"has_sum": "sum_present"},
+	}
+	columns := migrationColumns(pendingMigrations(unlabeledSchemaVersion), target.withColumnNames())
+	assert.Equal(t, []string{"sum_present", "has_min", "has_max"}, fieldNames(columns))
+}
@@ -0,0 +1,258 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+)
+
+// fieldType returns the type of the computed column.
+func (cfg ComputedColumnConfig) fieldType() bigquery.FieldType {
+	if cfg.Type == "" {
+		return bigquery.StringFieldType
+	}
+	return promotedTypes[cfg.Type]
+}
+
+// computedColumns returns the conversion options of computed.
+func computedColumns(computed []ComputedColumnConfig) []rowconv.ComputedColumn {
+	if len(computed) == 0 {
+		return nil
+	}
+	out := make([]rowconv.ComputedColumn, len(computed))
+	for i, c := range computed {
+		out[i] = rowconv.ComputedColumn{Column: c.Column, Type: c.fieldType()}
+	}
+	return out
+}
+
+// validateComputedColumns validates the computed columns of a signal and
+// parses their expressions with parse. Like promoted columns, computed
+// columns must not collide with each other or with any column the table
+// may have, reserved, which includes the promoted columns.
+func validateComputedColumns(field string, computed []ComputedColumnConfig, reserved bigquery.Schema, parse func(string) error) error {
+	columns := make(map[string]bool, len(computed))
+	for i, c := range computed {
+		entry := fmt.Sprintf("%s[%d]", field, i)
+		if err := validateColumnName(entry+".column", c.Column); err != nil {
+			return err
+		}
+		if _, ok := promotedTypes[c.Type]; !ok && c.Type != "" {
+			return fmt.Errorf("%s.type %q must be one of STRING, INT64, FLOAT64, BOOL", entry, c.Type)
+		}
+		// BigQuery column names are case-insensitive.
+		if columns[strings.ToLower(c.Column)] {
+			return fmt.Errorf("%s.column %q is computed more than once", entry, c.Column)
+		}
+		columns[strings.ToLower(c.Column)] = true
+		if slices.ContainsFunc(reserved, func(f *bigquery.FieldSchema) bool { return strings.EqualFold(f.Name, c.Column) }) {
+			return fmt.Errorf("%s.column %q collides with a column of the exporter or a promoted attribute", entry, c.Column)
+		}
+		if c.Expression == "" {
+			return fmt.Errorf("%s.expression is required", entry)
+		}
+		if err := parse(c.Expression); err != nil {
+			return fmt.Errorf("%s.expression: %w", entry, err)
+		}
+	}
+	return nil
+}
+
+// computedExpression evaluates the expression of a computed column.
+type computedExpression[K any] func(ctx context.Context, tCtx K) (any, error)
+
+// parseComputedExpression parses expression as an OTTL value expression or,
+// failing that, as a condition such as a comparison, whose value is a
+// boolean.
+func parseComputedExpression[K any](parser ottl.Parser[K], expression string) (computedExpression[K], error) {
+	value, err := parser.ParseValueExpression(expression)
+	if err == nil {
+		return value.Eval, nil
+	}
+	condition, condErr := parser.ParseCondition(expression)
+	if condErr != nil {
+		// Most expressions are values, so their error is the helpful one.
+		return nil, err
+	}
+	return func(ctx context.Context, tCtx K) (any, error) {
+		return condition.Eval(ctx, tCtx)
+	}, nil
+}
+
+// computedValues evaluates the expressions of the computed columns of a
+// signal whose OTTL transform context is K.
+type computedValues[K any] struct {
+	columns     []string
+	expressions []computedExpression[K]
+	logger      *zap.Logger
+}
+
+func newComputedValues[K any](parser ottl.Parser[K], computed []ComputedColumnConfig, logger *zap.Logger) (*computedValues[K], error) {
+	c := &computedValues[K]{logger: logger}
+	for _, column := range computed {
+		expression, err := parseComputedExpression(parser, column.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("parse expression of computed column %q: %w", column.Column, err)
+		}
+		c.columns = append(c.columns, column.Column)
+		c.expressions = append(c.expressions, expression)
+	}
+	return c, nil
+}
+
+// values returns the values of the computed columns for tCtx. Expressions
+// that fail leave their column NULL.
+func (c *computedValues[K]) values(tCtx K) []pcommon.Value {
+	values := make([]pcommon.Value, len(c.expressions))
+	for i, expression := range c.expressions {
+		v, err := expression(context.Background(), tCtx)
+		if err != nil {
+			c.logger.Debug("Failed to evaluate computed column", zap.String("column", c.columns[i]), zap.Error(err))
+			values[i] = pcommon.NewValueEmpty()
+			continue
+		}
+		values[i] = computedValue(v)
+	}
+	return values
+}
+
+// computedValue converts the result of an OTTL expression to a value.
+// Results of other types than those of pdata, such as enums and IDs, are
+// converted to their integer or string form.
+func computedValue(v any) pcommon.Value {
+	switch v := v.(type) {
+	case nil:
+		return pcommon.NewValueEmpty()
+	case pcommon.Value:
+		return v
+	case pcommon.Map:
+		value := pcommon.NewValueMap()
+		v.CopyTo(value.Map())
+		return value
+	case pcommon.Slice:
+		value := pcommon.NewValueSlice()
+		v.CopyTo(value.Slice())
+		return value
+	case ottl.Enum:
+		return pcommon.NewValueInt(int64(v))
+	case fmt.Stringer:
+		return pcommon.NewValueStr(v.String())
+	}
+	value := pcommon.NewValueEmpty()
+	if err := value.FromRaw(v); err != nil {
+		return pcommon.NewValueStr(fmt.Sprint(v))
+	}
+	return value
+}
+
+// newSpanParser returns the parser of traces.computed_columns expressions,
+// which may use the standard OTTL converters.
+func newSpanParser(set component.TelemetrySettings) (ottl.Parser[*ottlspan.TransformContext], error) {
+	return ottlspan.NewParser(ottlfuncs.StandardConverters[*ottlspan.TransformContext](), set)
+}
+
+// newDataPointParser returns the parser of metrics.computed_columns
+// expressions.
+func newDataPointParser(set component.TelemetrySettings) (ottl.Parser[*ottldatapoint.TransformContext], error) {
+	return ottldatapoint.NewParser(ottlfuncs.StandardConverters[*ottldatapoint.TransformContext](), set)
+}
+
+// newLogParser returns the parser of logs.computed_columns expressions.
+func newLogParser(set component.TelemetrySettings) (ottl.Parser[*ottllog.TransformContext], error) {
+	return ottllog.NewParser(ottlfuncs.StandardConverters[*ottllog.TransformContext](), set)
+}
+
+// parseFunc returns a function parsing computed column expressions with
+// the parser newParser returns, for validating configurations.
+func parseFunc[K any](newParser func(component.TelemetrySettings) (ottl.Parser[K], error)) func(string) error {
+	return func(expression string) error {
+		parser, err := newParser(component.TelemetrySettings{Logger: zap.NewNop()})
+		if err != nil {
+			return err
+		}
+		_, err = parseComputedExpression(parser, expression)
+		return err
+	}
+}
+
+// spanValues returns the values of the traces.computed_columns, or nil
+// when there are none.
+func spanValues(computed []ComputedColumnConfig, set component.TelemetrySettings) (rowconv.SpanValues, error) {
+	if len(computed) == 0 {
+		return nil, nil
+	}
+	parser, err := newSpanParser(set)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newComputedValues(parser, computed, set.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("traces.computed_columns: %w", err)
+	}
+	return func(rs ptrace.ResourceSpans, ss ptrace.ScopeSpans, span ptrace.Span) []pcommon.Value {
+		tCtx := ottlspan.NewTransformContextPtr(rs, ss, span)
+		defer tCtx.Close()
+		return c.values(tCtx)
+	}, nil
+}
+
+// dataPointValues returns the values of the metrics.computed_columns, or
+// nil when there are none.
+func dataPointValues(computed []ComputedColumnConfig, set component.TelemetrySettings) (rowconv.DataPointValues, error) {
+	if len(computed) == 0 {
+		return nil, nil
+	}
+	parser, err := newDataPointParser(set)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newComputedValues(parser, computed, set.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("metrics.computed_columns: %w", err)
+	}
+	return func(rm pmetric.ResourceMetrics, sm pmetric.ScopeMetrics, metric pmetric.Metric, dataPoint any) []pcommon.Value {
+		tCtx := ottldatapoint.NewTransformContextPtr(rm, sm, metric, dataPoint)
+		defer tCtx.Close()
+		return c.values(tCtx)
+	}, nil
+}
+
+// logValues returns the values of the logs.computed_columns, or nil when
+// there are none.
+func logValues(computed []ComputedColumnConfig, set component.TelemetrySettings) (rowconv.LogValues, error) {
+	if len(computed) == 0 {
+		return nil, nil
+	}
+	parser, err := newLogParser(set)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newComputedValues(parser, computed, set.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("logs.computed_columns: %w", err)
+	}
+	return func(rl plog.ResourceLogs, sl plog.ScopeLogs, lr plog.LogRecord) []pcommon.Value {
+		tCtx := ottllog.NewTransformContextPtr(rl, sl, lr)
+		defer tCtx.Close()
+		return c.values(tCtx)
+	}, nil
+}
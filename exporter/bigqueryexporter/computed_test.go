@@ -0,0 +1,130 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+)
+
+func TestComputedColumnsSchema(t *testing.T) {
+	cfg := TracesConfig{ComputedColumns: []ComputedColumnConfig{
+		{Column: "tenant", Expression: `resource.attributes["X-Tenant"]`},
+		{Column: "is_error", Expression: "status.code == STATUS_CODE_ERROR", Type: "BOOL"},
+	}}
+	schema := tracesTableSchema(defaultSchemaPreset, cfg)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "tenant").Type)
+	assert.Equal(t, bigquery.BooleanFieldType, schemaField(schema, "is_error").Type)
+	assert.Equal(t, []rowconv.ComputedColumn{
+		{Column: "tenant", Type: bigquery.StringFieldType},
+		{Column: "is_error", Type: bigquery.BooleanFieldType},
+	}, cfg.rowOptions().ComputedColumns)
+}
+
+func TestSpanValues(t *testing.T) {
+	computed := []ComputedColumnConfig{
+		{Column: "tenant", Expression: `resource.attributes["X-Tenant"]`},
+		{Column: "is_error", Expression: "status.code == STATUS_CODE_ERROR", Type: "BOOL"},
+		{Column: "route", Expression: `Concat([kind.string, name], " ")`},
+		{Column: "failing", Expression: `Int(name)`, Type: "INT64"},
+	}
+	values, err := spanValues(computed, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("X-Tenant", "acme")
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("GET /users")
+	span.SetKind(ptrace.SpanKindServer)
+	span.Status().SetCode(ptrace.StatusCodeError)
+
+	cfg := TracesConfig{ComputedColumns: computed}
+	opts := cfg.rowOptions()
+	opts.ComputedValues = values
+	rows := rowconv.Traces(td, opts)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "acme", rows[0]["tenant"])
+	assert.Equal(t, true, rows[0]["is_error"])
+	assert.Equal(t, "Server GET /users", rows[0]["route"])
+	assert.Nil(t, rows[0]["failing"])
+
+	values, err = spanValues(nil, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	assert.Nil(t, values)
+	_, err = spanValues([]ComputedColumnConfig{{Column: "bad", Expression: "Unknown(name)"}}, componenttest.NewNopTelemetrySettings())
+	assert.ErrorContains(t, err, "traces.computed_columns")
+}
+
+func TestDataPointValues(t *testing.T) {
+	values, err := dataPointValues([]ComputedColumnConfig{
+		{Column: "series", Expression: `Concat([metric.name, attributes["host"]], "@")`},
+	}, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("cpu")
+	m.SetEmptyGauge().DataPoints().AppendEmpty().Attributes().PutStr("host", "a")
+
+	cfg := MetricsConfig{ComputedColumns: []ComputedColumnConfig{{Column: "series"}}}
+	opts := cfg.rowOptions()
+	opts.ComputedValues = values
+	rows := rowconv.Metrics(md, opts)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "cpu@a", rows[0]["series"])
+}
+
+func TestLogValues(t *testing.T) {
+	values, err := logValues([]ComputedColumnConfig{
+		{Column: "is_error", Expression: "severity_number >= SEVERITY_NUMBER_ERROR"},
+		{Column: "severity", Expression: "severity_number", Type: "INT64"},
+	}, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().SetSeverityNumber(plog.SeverityNumberError)
+
+	cfg := LogsConfig{ComputedColumns: []ComputedColumnConfig{{Column: "is_error"}, {Column: "severity", Type: "INT64"}}}
+	opts := cfg.rowOptions()
+	opts.ComputedValues = values
+	rows := rowconv.Logs(ld, opts)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "true", rows[0]["is_error"])
+	assert.Equal(t, int64(plog.SeverityNumberError), rows[0]["severity"])
+}
+
+func TestComputedValue(t *testing.T) {
+	m := pcommon.NewMap()
+	m.PutStr("a", "b")
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "nil", value: nil, want: nil},
+		{name: "string", value: "a", want: "a"},
+		{name: "int", value: int64(1), want: int64(1)},
+		{name: "enum", value: ottl.Enum(2), want: int64(2)},
+		{name: "map", value: m, want: map[string]any{"a": "b"}},
+		{name: "trace id", value: pcommon.TraceID{1}, want: "01000000000000000000000000000000"},
+		{name: "value", value: pcommon.NewValueBool(true), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, computedValue(tt.value).AsRaw())
+		})
+	}
+}
@@ -6,25 +6,1073 @@ package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-coll
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/config/configopaque"
 	"go.opentelemetry.io/collector/config/configoptional"
 	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/config/configtls"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
+	"google.golang.org/grpc/encoding/gzip"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
 )
 
 const maxIdentifierLength = 1024
 
+// minKeepaliveTime is the smallest keepalive time gRPC clients use; smaller
+// values are raised to it.
+const minKeepaliveTime = 10 * time.Second
+
 var bigQueryIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 
+// BigQuery label keys and values: lowercase letters, digits, underscores and
+// dashes, at most 63 characters. Keys must start with a letter.
+var (
+	labelKeyPattern   = regexp.MustCompile(`^\p{Ll}[\p{Ll}\p{Lo}\p{N}_-]{0,62}$`)
+	labelValuePattern = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}_-]{0,63}$`)
+)
+
+// maxTableLabels is the number of labels BigQuery accepts on a table.
+const maxTableLabels = 64
+
+var kmsKeyNamePattern = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+var policyTagPattern = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/taxonomies/[^/]+/policyTags/[^/]+$`)
+
+var secretVersionPattern = regexp.MustCompile(`^projects/[^/]+/secrets/[^/]+/versions/[^/]+$`)
+
 // Config defines configuration for the BigQuery exporter.
 type Config struct {
 	Dataset       DatasetConfig                                            `mapstructure:"dataset"`
 	TimeoutConfig exporterhelper.TimeoutConfig                             `mapstructure:",squash"`
 	BackOffConfig configretry.BackOffConfig                                `mapstructure:"retry_on_failure"`
 	QueueConfig   configoptional.Optional[exporterhelper.QueueBatchConfig] `mapstructure:"sending_queue"`
+
+	// StartupRetry configures retries of the dataset and table calls made
+	// during start, separately from retry_on_failure for exports.
+	StartupRetry configretry.BackOffConfig `mapstructure:"startup_retry"`
+
+	// TableCreateJitter delays the creation of a missing table by a random
+	// duration up to this value and checks again whether it exists, so
+	// replicas starting together do not all try to create it.
+	TableCreateJitter time.Duration `mapstructure:"table_create_jitter"`
+
+	// UserAgentSuffix is appended to the user-agent sent by the BigQuery and
+	// Storage Write clients, after the collector build information.
+	UserAgentSuffix string `mapstructure:"user_agent_suffix"`
+
+	// ProxyURL is the HTTP(S) proxy used by the REST client that reads and
+	// creates datasets and tables, and to fetch OAuth tokens, impersonate
+	// service accounts and read credentials secrets. Storage Write traffic
+	// does not use it.
+	ProxyURL string `mapstructure:"proxy_url"`
+
+	// TLS configures a custom CA bundle and client certificate for both the
+	// REST and gRPC connections, for example when BigQuery is reached through
+	// a TLS-intercepting gateway or a Private Service Connect endpoint.
+	TLS configoptional.Optional[configtls.ClientConfig] `mapstructure:"tls"`
+
+	// Scopes are the OAuth scopes requested for the credentials. The default
+	// bigquery scope allows managing tables; narrower scopes such as
+	// bigquery.insertdata only allow writing rows into existing tables.
+	Scopes []string `mapstructure:"scopes"`
+
+	// PreflightAuthCheck acquires a token and performs an authenticated call
+	// for every table during start, failing start on credential problems.
+	PreflightAuthCheck bool `mapstructure:"preflight_auth_check"`
+
+	// AutoCreateTables creates missing tables during start. When disabled the
+	// exporter issues no DDL and start fails unless every table exists with
+	// a compatible schema.
+	AutoCreateTables bool `mapstructure:"auto_create_tables"`
+
+	// AllowSchemaUpdate adds the columns the exporter writes to existing
+	// tables that lack them during start, e.g. after an upgrade added
+	// columns. Added columns are NULLABLE.
+	AllowSchemaUpdate bool `mapstructure:"allow_schema_update"`
+
+	// SchemaSnapshot takes a snapshot of existing tables before
+	// allow_schema_update changes their schema.
+	SchemaSnapshot SchemaSnapshotConfig `mapstructure:"schema_snapshot"`
+
+	// ProbeCapabilities detects features of each dataset during start, such
+	// as the availability of the JSON type, and adapts the schemas of the
+	// tables to them.
+	ProbeCapabilities bool `mapstructure:"probe_capabilities"`
+
+	// IDColumns is "string" or "bytes" and selects whether the trace and
+	// span ID columns of the traces, logs, span events and span links tables
+	// hold hex strings or the raw 16 and 8 byte IDs.
+	IDColumns string `mapstructure:"id_columns"`
+
+	// Watermark adds a watermark column to the traces, metrics and logs
+	// tables holding the oldest event timestamp of the batches being
+	// appended to the table when the row's batch started. It is best-effort
+	// and does not cover batches waiting to be appended.
+	Watermark bool `mapstructure:"watermark"`
+
+	// CollectorColumns adds collector_host_name, collector_instance_id and
+	// collector_version columns to the traces, metrics and logs tables,
+	// identifying the collector that wrote every row.
+	CollectorColumns bool `mapstructure:"collector_columns"`
+
+	// EventDate adds an event_date DATE column to the traces, metrics and
+	// logs tables, which partitioning and clustering may use to align days
+	// with a time zone other than UTC.
+	EventDate EventDateConfig `mapstructure:"event_date"`
+
+	// CreateViews creates helper views next to the traces, metrics and logs
+	// tables during start: a per-trace summary, the data points with a
+	// single value column and the log records of the last 24 hours.
+	CreateViews bool `mapstructure:"create_views"`
+
+	// CreateUDFs creates SQL functions extracting typed attribute values
+	// from the JSON attribute columns in every dataset the exporter writes
+	// to during start.
+	CreateUDFs bool `mapstructure:"create_udfs"`
+
+	// SchemaPreset selects a named schema preset for all signal tables.
+	SchemaPreset string `mapstructure:"schema_preset"`
+
+	// DualWrite additionally writes traces, metrics and logs to a second
+	// table per signal with a different schema, so consumers can migrate
+	// between schemas gradually.
+	DualWrite DualWriteConfig `mapstructure:"dual_write"`
+
+	// Write configures how rows are written with the Storage Write API.
+	Write WriteConfig `mapstructure:"write"`
+
+	// MemoryLimitMiB bounds the memory of the rows held by the exporter
+	// between conversion and acknowledgement, across all tables, estimated
+	// from the OTLP size of the exported data. Exports wait for memory to
+	// be released before converting their data once it is reached. Zero
+	// disables the limit.
+	MemoryLimitMiB int `mapstructure:"memory_limit_mib"`
+
+	// Traces configures how spans are converted into rows.
+	Traces TracesConfig `mapstructure:"traces"`
+
+	// Metrics configures the destination of metric rows.
+	Metrics MetricsConfig `mapstructure:"metrics"`
+
+	// Logs configures how log records are converted into rows.
+	Logs LogsConfig `mapstructure:"logs"`
+
+	// Statistics configures the append statistics table.
+	Statistics StatisticsConfig `mapstructure:"statistics"`
+
+	// NormalizeResources configures writing the resources of spans, data
+	// points and log records to a table of their own.
+	NormalizeResources NormalizeResourcesConfig `mapstructure:"normalize_resources"`
+
+	// ResourceHash adds the resource_hash column of normalize_resources to
+	// the traces, metrics and logs tables while keeping their
+	// resource_attributes and resource_schema_url columns, so rows can be
+	// grouped by resource and joined across tables without parsing JSON.
+	ResourceHash bool `mapstructure:"resource_hash"`
+
+	// WideEvents configures writing spans, data points and log records into
+	// one table.
+	WideEvents WideEventsConfig `mapstructure:"wide_events"`
+
+	// PromotePresets name sets of well-known attributes promoted to columns
+	// of the traces, metrics and logs tables in addition to their
+	// promoted_attributes, e.g. kubernetes.
+	PromotePresets []string `mapstructure:"promote_presets"`
+
+	// AnonymizeAttributes configures attributes that are hashed or redacted
+	// before they are written, e.g. personal data.
+	AnonymizeAttributes AnonymizeAttributesConfig `mapstructure:"anonymize_attributes"`
+
+	// AdaptiveSlim configures writing slim rows while appends fail with
+	// quota errors.
+	AdaptiveSlim AdaptiveSlimConfig `mapstructure:"adaptive_slim"`
+}
+
+// AdaptiveSlimConfig configures the temporary switch of a table to the
+// columns of the slim preset under quota pressure, so the core columns keep
+// being written instead of whole batches failing.
+type AdaptiveSlimConfig struct {
+	// Enabled writes rows without span events, span links and exemplars
+	// once appends to a table fail with quota errors repeatedly.
+	Enabled bool `mapstructure:"enabled"`
+	// Threshold is the number of consecutive appends failing with a quota
+	// error after which the table switches to slim rows.
+	Threshold int `mapstructure:"threshold"`
+	// Cooldown is how long after the last quota error slim rows are
+	// written before the table returns to the full columns.
+	Cooldown time.Duration `mapstructure:"cooldown"`
+}
+
+// SchemaSnapshotConfig configures the table snapshots taken before the
+// exporter adds columns to or migrates an existing table, so the table can
+// be restored if a change misbehaves.
+type SchemaSnapshotConfig struct {
+	// Enabled takes the snapshots.
+	Enabled bool `mapstructure:"enabled"`
+	// Suffix is appended to the table name to name its snapshot. It may
+	// contain the placeholders %Y, %m, %d and %H, which are replaced with
+	// the UTC time of the snapshot.
+	Suffix string `mapstructure:"suffix"`
+	// Retention is how long snapshots are kept. Zero keeps them until they
+	// are deleted.
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+func (cfg SchemaSnapshotConfig) validate(allowSchemaUpdate bool) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if !allowSchemaUpdate {
+		return errors.New("schema_snapshot requires allow_schema_update")
+	}
+	if cfg.Suffix == "" {
+		return errors.New("schema_snapshot.suffix is required")
+	}
+	if err := validateTableName("schema_snapshot.suffix", "t"+cfg.Suffix); err != nil {
+		return err
+	}
+	if cfg.Retention < 0 {
+		return errors.New("schema_snapshot.retention must not be negative")
+	}
+	return nil
+}
+
+// NormalizeResourcesConfig configures the resource table, which holds the
+// attributes and schema URL of every distinct resource once, so signal rows
+// only carry the resource_hash identifying their resource.
+type NormalizeResourcesConfig struct {
+	// Enabled writes resources to dataset.resource_table and replaces the
+	// resource_attributes and resource_schema_url columns of the traces,
+	// metrics and logs tables by resource_hash.
+	Enabled bool `mapstructure:"enabled"`
+	// CacheSize is the number of written resources remembered, so they are
+	// not written again. Resources are written again once it is exceeded.
+	CacheSize int `mapstructure:"cache_size"`
+}
+
+// WideEventsConfig configures the wide events table, which holds the rows
+// of all signals so they can be queried and managed as one table.
+type WideEventsConfig struct {
+	// Enabled writes spans, data points and log records to
+	// dataset.wide_events_table instead of the traces, metrics and logs
+	// tables. Its schema is the union of their schemas plus the signal_type
+	// column.
+	Enabled bool `mapstructure:"enabled"`
+	// ClusteringFields are the columns the table is clustered by when it is
+	// created.
+	ClusteringFields []string `mapstructure:"clustering_fields"`
+}
+
+func (cfg NormalizeResourcesConfig) validate() error {
+	if cfg.Enabled && cfg.CacheSize <= 0 {
+		return errors.New("normalize_resources.cache_size must be positive")
+	}
+	return nil
+}
+
+// AnonymizeAttributesConfig configures the attribute keys whose values are
+// replaced before they are written. It applies to the resource, scope,
+// span, span event, span link, data point, exemplar and log record
+// attributes of every signal, including the raw_otlp and promoted
+// attribute columns.
+type AnonymizeAttributesConfig struct {
+	// Hash lists the attribute keys whose values are replaced by the hex
+	// encoded SHA-256 hash of the salt followed by the value, so rows can
+	// still be grouped and joined by them.
+	Hash []string `mapstructure:"hash"`
+	// Redact lists the attribute keys whose values are replaced by
+	// "[REDACTED]".
+	Redact []string `mapstructure:"redact"`
+	// Salt is prepended to values before hashing them, so hashes of values
+	// with few possibilities cannot be reversed with a lookup table.
+	Salt configopaque.String `mapstructure:"salt"`
+}
+
+// StatisticsConfig configures the table of hourly append statistics written
+// by the exporter itself.
+type StatisticsConfig struct {
+	// Enabled writes the rows, bytes and failures appended to every table per
+	// hour to dataset.statistics_table.
+	Enabled bool `mapstructure:"enabled"`
+	// FlushInterval is how often the counts gathered since the previous
+	// flush are written.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// TracesConfig configures the conversion of spans.
+type TracesConfig struct {
+	// Project overrides dataset.project for the traces table.
+	Project string `mapstructure:"project"`
+	// Dataset overrides dataset.id for the traces table, e.g. to apply a
+	// different retention or access policy per signal.
+	Dataset string `mapstructure:"dataset"`
+	// Credentials overrides dataset.credentials for the traces table, e.g.
+	// when its project only accepts writes from its own service account.
+	Credentials CredentialsConfig `mapstructure:"credentials"`
+	// JSONColumns is "json" or "string" and overrides whether the traces
+	// table's JSON columns are created as JSON or as STRING with the same
+	// serialized content. The schema preset decides when empty.
+	JSONColumns string `mapstructure:"json_columns"`
+	// AttributesEncoding is "json" or "key_value" and selects whether the
+	// resource and span attribute columns hold JSON objects or repeated
+	// key/value records with a value field per attribute type. Defaults to
+	// json.
+	AttributesEncoding string `mapstructure:"attributes_encoding"`
+	// EnumEncoding is "string" or "int64" and selects whether the kind and
+	// status_code columns hold the names of the span kind and status code,
+	// e.g. SERVER and ERROR, or their OTLP enum values as INT64. Defaults to
+	// string.
+	EnumEncoding string `mapstructure:"enum_encoding"`
+	// ScopeColumns is "alongside" or "instead" and adds scope_name and
+	// scope_version columns holding the name and version of the
+	// instrumentation scope, alongside or instead of the
+	// instrumentation_scope column. They are not written when empty.
+	ScopeColumns string `mapstructure:"scope_columns"`
+	// IncludeEventNames limits the events column to span events with one of
+	// these names, e.g. exception. All events are kept when empty.
+	IncludeEventNames []string `mapstructure:"include_event_names"`
+	// StatusClass adds a status_class column holding the class of the
+	// span's HTTP response status code, e.g. "5xx".
+	StatusClass bool `mapstructure:"status_class"`
+	// FlagColumns adds sampled and has_remote_parent columns decoded from
+	// the span's flags, so queries need no bit operations on flags.
+	FlagColumns bool `mapstructure:"flag_columns"`
+	// CountColumns adds events_count and links_count columns holding the
+	// number of events and links of the span, so queries can skip spans
+	// without parsing the events and links columns.
+	CountColumns bool `mapstructure:"count_columns"`
+	// SpanHierarchy adds depth and is_leaf columns to spans whose parents up
+	// to the root span are exported in the same batch, e.g. after the
+	// groupbytrace processor.
+	SpanHierarchy bool `mapstructure:"span_hierarchy"`
+	// ChildTables writes span events and links as rows of
+	// dataset.trace_event_table and dataset.trace_link_table instead of the
+	// events and links columns of the traces table.
+	ChildTables bool `mapstructure:"child_tables"`
+	// ClusteringFields are the columns the traces table is clustered by when
+	// the exporter creates it, in order of priority. At most four columns.
+	ClusteringFields []string `mapstructure:"clustering_fields"`
+	// Partitioning configures how the traces table is partitioned when the
+	// exporter creates it.
+	Partitioning PartitioningConfig `mapstructure:"partitioning"`
+	// RowRetention is added to the event time of rows for the expires_at
+	// column of the traces table, which scheduled deletions can use for a
+	// retention finer than partition expiration. Zero omits the column.
+	RowRetention time.Duration `mapstructure:"row_retention"`
+	// PolicyTags maps columns of the traces table to the Data Catalog policy
+	// tag applied to them when the exporter creates it, so column-level
+	// security can restrict access to them.
+	PolicyTags map[string]string `mapstructure:"policy_tags"`
+	// Collation maps STRING columns of the traces table to the collation
+	// applied to them when the exporter creates it, e.g. "und:ci" to compare
+	// values case-insensitively.
+	Collation map[string]string `mapstructure:"collation"`
+	// EmptyValues configures spans with an empty name, trace ID or span ID.
+	EmptyValues EmptyValuesConfig `mapstructure:"empty_values"`
+	// Upsert writes spans as change data capture upserts keyed by trace_id
+	// and span_id, so re-exported spans replace their earlier copy.
+	Upsert UpsertConfig `mapstructure:"upsert"`
+	// PromotedAttributes are resource or span attributes copied into
+	// columns of their own.
+	PromotedAttributes []PromotedAttributeConfig `mapstructure:"promoted_attributes"`
+	// ComputedColumns are columns holding the values of OTTL expressions
+	// evaluated on the spans with their resource and scope.
+	ComputedColumns []ComputedColumnConfig `mapstructure:"computed_columns"`
+	// AttributeFilters drop resource, scope or span attributes before
+	// they are serialized, e.g. large or sensitive ones.
+	AttributeFilters AttributeFiltersConfig `mapstructure:"attribute_filters"`
+	// RawOTLP adds a raw_otlp BYTES column holding every span as OTLP
+	// protobuf with its resource and scope, so it can be replayed without
+	// reconstructing it from the other columns.
+	RawOTLP bool `mapstructure:"raw_otlp"`
+	// MaxColumnBytes maps STRING, JSON and RECORD columns of the traces table
+	// to the maximum size of their values in bytes. Longer values are
+	// truncated and a truncated column flags the rows, so pathological spans
+	// cannot exceed the append request limit. With ChildTables the columns
+	// of the span event and link tables can be limited as well.
+	MaxColumnBytes map[string]int `mapstructure:"max_column_bytes"`
+	// ColumnNames renames columns of the traces table, e.g. to write to an
+	// existing table with a schema of its own. Other settings refer to the
+	// exporter's column names.
+	ColumnNames map[string]string `mapstructure:"column_names"`
+}
+
+// MetricsConfig configures the destination of metric data points.
+type MetricsConfig struct {
+	// Project overrides dataset.project for the metrics table.
+	Project string `mapstructure:"project"`
+	// Dataset overrides dataset.id for the metrics table, e.g. to apply a
+	// different retention or access policy per signal.
+	Dataset string `mapstructure:"dataset"`
+	// Credentials overrides dataset.credentials for the metrics table, e.g.
+	// when its project only accepts writes from its own service account.
+	Credentials CredentialsConfig `mapstructure:"credentials"`
+	// JSONColumns is "json" or "string" and overrides whether the metrics
+	// table's JSON columns are created as JSON or as STRING with the same
+	// serialized content. The schema preset decides when empty.
+	JSONColumns string `mapstructure:"json_columns"`
+	// AttributesEncoding is "json" or "key_value" and selects whether the
+	// resource and data point attribute columns hold JSON objects or repeated
+	// key/value records with a value field per attribute type. Defaults to
+	// json.
+	AttributesEncoding string `mapstructure:"attributes_encoding"`
+	// ScopeColumns is "alongside" or "instead" and adds scope_name and
+	// scope_version columns holding the name and version of the
+	// instrumentation scope, alongside or instead of the
+	// instrumentation_scope column. They are not written when empty.
+	ScopeColumns string `mapstructure:"scope_columns"`
+	// ClusteringFields are the columns the metrics table is clustered by when
+	// the exporter creates it, in order of priority. At most four columns.
+	ClusteringFields []string `mapstructure:"clustering_fields"`
+	// Partitioning configures how the metrics table is partitioned when the
+	// exporter creates it.
+	Partitioning PartitioningConfig `mapstructure:"partitioning"`
+	// RowRetention is added to the event time of rows for the expires_at
+	// column of the metrics table, which scheduled deletions can use for a
+	// retention finer than partition expiration. Zero omits the column.
+	RowRetention time.Duration `mapstructure:"row_retention"`
+	// PolicyTags maps columns of the metrics table to the Data Catalog policy
+	// tag applied to them when the exporter creates it, so column-level
+	// security can restrict access to them.
+	PolicyTags map[string]string `mapstructure:"policy_tags"`
+	// Collation maps STRING columns of the metrics table to the collation
+	// applied to them when the exporter creates it, e.g. "und:ci" to compare
+	// values case-insensitively.
+	Collation map[string]string `mapstructure:"collation"`
+	// EmptyValues configures data points of metrics with an empty name.
+	EmptyValues EmptyValuesConfig `mapstructure:"empty_values"`
+	// Rollup configures a materialized view pre-aggregating the metrics
+	// table.
+	Rollup RollupConfig `mapstructure:"rollup"`
+	// Upsert writes data points as change data capture upserts keyed by a
+	// series_id column, so the table keeps the latest data point per time
+	// series.
+	Upsert UpsertConfig `mapstructure:"upsert"`
+	// PromotedAttributes are resource or data point attributes copied into
+	// columns of their own.
+	PromotedAttributes []PromotedAttributeConfig `mapstructure:"promoted_attributes"`
+	// ComputedColumns are columns holding the values of OTTL expressions
+	// evaluated on the data points with their resource and scope.
+	ComputedColumns []ComputedColumnConfig `mapstructure:"computed_columns"`
+	// AttributeFilters drop resource, scope or data point attributes before
+	// they are serialized, e.g. large or sensitive ones.
+	AttributeFilters AttributeFiltersConfig `mapstructure:"attribute_filters"`
+	// ExponentialHistogramColumns adds columns holding the scale, zero
+	// count and positive and negative buckets of exponential histogram data
+	// points, which are otherwise only part of the bucket_counts JSON.
+	ExponentialHistogramColumns bool `mapstructure:"exponential_histogram_columns"`
+	// RawOTLP adds a raw_otlp BYTES column holding every data point as OTLP
+	// protobuf with its resource and scope, so it can be replayed without
+	// reconstructing it from the other columns.
+	RawOTLP bool `mapstructure:"raw_otlp"`
+	// ExemplarSpans adds an exemplar_spans column holding the trace_id and
+	// span_id of the exemplars of every data point, encoded like the columns
+	// of the traces table, so exemplars join to their spans in one query.
+	ExemplarSpans bool `mapstructure:"exemplar_spans"`
+	// NormalizeUnits adds normalized_unit, unit_scale and normalized_value
+	// columns converting the units of time, information and ratio metrics
+	// to their UCUM base unit, e.g. ms to s and KiBy to By, so metrics
+	// recorded in different units can be aggregated together.
+	NormalizeUnits bool `mapstructure:"normalize_units"`
+	// MetricNames transforms metric names before they are written to
+	// metric_name, so they match the conventions of existing models.
+	MetricNames MetricNamesConfig `mapstructure:"metric_names"`
+	// GroupDataPoints writes one row per metric holding its data points in a
+	// repeated datapoints RECORD column, rather than one row per data point
+	// repeating the metric, resource and scope columns.
+	GroupDataPoints bool `mapstructure:"group_datapoints"`
+	// MaxColumnBytes maps STRING, JSON and RECORD columns of the metrics table
+	// to the maximum size of their values in bytes. Longer values are
+	// truncated and a truncated column flags the rows, so pathological data points
+	// cannot exceed the append request limit.
+	MaxColumnBytes map[string]int `mapstructure:"max_column_bytes"`
+	// ColumnNames renames columns of the metrics table, e.g. to write to an
+	// existing table with a schema of its own. Other settings refer to the
+	// exporter's column names.
+	ColumnNames map[string]string `mapstructure:"column_names"`
+}
+
+// DualWriteConfig configures the dual-write tables, which receive the same
+// rows as the traces, metrics and logs tables in a different schema.
+type DualWriteConfig struct {
+	// Enabled writes to the dual-write tables.
+	Enabled bool `mapstructure:"enabled"`
+	// TableSuffix is appended to the names of the traces, metrics and logs
+	// tables to name their dual-write tables, which are created in the same
+	// project and dataset.
+	TableSuffix string `mapstructure:"table_suffix"`
+	// SchemaPreset is the schema preset of the dual-write tables. schema_preset
+	// applies when empty.
+	SchemaPreset string `mapstructure:"schema_preset"`
+	// JSONColumns is "json" or "string" and overrides whether the JSON
+	// columns of the dual-write tables are created as JSON or as STRING. The
+	// schema preset decides when empty.
+	JSONColumns string `mapstructure:"json_columns"`
+}
+
+// preset returns the schema preset of the dual-write tables.
+func (cfg DualWriteConfig) preset(primary string) string {
+	if cfg.SchemaPreset == "" {
+		return primary
+	}
+	return cfg.SchemaPreset
+}
+
+func (cfg *Config) validateDualWrite() error {
+	dual := cfg.DualWrite
+	if !dual.Enabled {
+		return nil
+	}
+	if dual.TableSuffix == "" {
+		return errors.New("dual_write.table_suffix is required")
+	}
+	if dual.SchemaPreset == "" && dual.JSONColumns == "" {
+		return errors.New("dual_write requires schema_preset or json_columns to differ from the primary tables")
+	}
+	if _, ok := schemaPresets[dual.SchemaPreset]; dual.SchemaPreset != "" && !ok {
+		return fmt.Errorf("dual_write.schema_preset %q is not supported, must be one of %s", dual.SchemaPreset, strings.Join(schemaPresetNames(), ", "))
+	}
+	if err := validateJSONColumns("dual_write.json_columns", dual.JSONColumns); err != nil {
+		return err
+	}
+	for _, name := range []string{cfg.Dataset.Table.Trace, cfg.Dataset.Table.Metric, cfg.Dataset.Table.Log} {
+		if err := validateTableName("dual_write.table_suffix", name+dual.TableSuffix); err != nil {
+			return err
+		}
+	}
+	// The dual-write tables are created with the clustering and partitioning
+	// of the primary tables, which must exist in their schemas too.
+	preset := dual.preset(cfg.SchemaPreset)
+	tracesSchema := signalSchema(cfg, preset, "traces", dual.JSONColumns)
+	metricsSchema := signalSchema(cfg, preset, "metrics", dual.JSONColumns)
+	logsSchema := signalSchema(cfg, preset, "logs", dual.JSONColumns)
+	if err := validateClusteringFields("dual_write: traces.clustering_fields", cfg.Traces.ClusteringFields, tracesSchema); err != nil {
+		return err
+	}
+	if err := cfg.Traces.Partitioning.validate("dual_write: traces.partitioning", tracesSchema); err != nil {
+		return err
+	}
+	if err := validateClusteringFields("dual_write: metrics.clustering_fields", cfg.Metrics.ClusteringFields, metricsSchema); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.Partitioning.validate("dual_write: metrics.partitioning", metricsSchema); err != nil {
+		return err
+	}
+	if err := validateClusteringFields("dual_write: logs.clustering_fields", cfg.Logs.ClusteringFields, logsSchema); err != nil {
+		return err
+	}
+	return cfg.Logs.partitioning().validate("dual_write: logs.partitioning", logsSchema)
+}
+
+// EventDateConfig configures the event_date column, the date of a row's
+// event timestamp in a time zone.
+type EventDateConfig struct {
+	// Enabled adds the event_date column.
+	Enabled bool `mapstructure:"enabled"`
+	// TimeZone is the IANA name of the time zone dates are taken in, e.g.
+	// America/New_York.
+	TimeZone string `mapstructure:"time_zone"`
+}
+
+// validateWideEvents checks that wide_events is not combined with options
+// of the traces, metrics and logs tables it replaces, and that the schemas
+// of these tables can be merged into the wide events table.
+func (cfg *Config) validateWideEvents(schemas ...bigquery.Schema) error {
+	if !cfg.WideEvents.Enabled {
+		return nil
+	}
+	defaultPartitioning := PartitioningConfig{Granularity: string(bigquery.DayPartitioningType)}
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"dual_write", cfg.DualWrite.Enabled},
+		{"create_views", cfg.CreateViews},
+		{"metrics.rollup", cfg.Metrics.Rollup.Enabled},
+		{"traces.upsert", cfg.Traces.Upsert.Enabled},
+		{"metrics.upsert", cfg.Metrics.Upsert.Enabled},
+		{"traces.project", cfg.Traces.Project != ""},
+		{"metrics.project", cfg.Metrics.Project != ""},
+		{"logs.project", cfg.Logs.Project != ""},
+		{"traces.dataset", cfg.Traces.Dataset != ""},
+		{"metrics.dataset", cfg.Metrics.Dataset != ""},
+		{"logs.dataset", cfg.Logs.Dataset != ""},
+		{"traces.credentials", cfg.Traces.Credentials != (CredentialsConfig{})},
+		{"metrics.credentials", cfg.Metrics.Credentials != (CredentialsConfig{})},
+		{"logs.credentials", cfg.Logs.Credentials != (CredentialsConfig{})},
+		{"traces.clustering_fields", len(cfg.Traces.ClusteringFields) > 0},
+		{"metrics.clustering_fields", len(cfg.Metrics.ClusteringFields) > 0},
+		{"logs.clustering_fields", len(cfg.Logs.ClusteringFields) > 0},
+		{"traces.partitioning", cfg.Traces.Partitioning != defaultPartitioning},
+		{"metrics.partitioning", cfg.Metrics.Partitioning != defaultPartitioning},
+		{"logs.partitioning", cfg.Logs.partitioning() != defaultPartitioning},
+		{"traces.row_retention", cfg.Traces.RowRetention > 0},
+		{"metrics.row_retention", cfg.Metrics.RowRetention > 0},
+		{"logs.row_retention", cfg.Logs.RowRetention > 0},
+		{"traces.column_names", len(cfg.Traces.ColumnNames) > 0},
+		{"metrics.column_names", len(cfg.Metrics.ColumnNames) > 0},
+		{"logs.column_names", len(cfg.Logs.ColumnNames) > 0},
+	} {
+		if option.set {
+			return fmt.Errorf("wide_events cannot be used with %s", option.name)
+		}
+	}
+	schema, err := wideEventsSchema(schemas...)
+	if err != nil {
+		return fmt.Errorf("wide_events: %w", err)
+	}
+	return validateClusteringFields("wide_events.clustering_fields", cfg.WideEvents.ClusteringFields, schema)
+}
+
+func (cfg EventDateConfig) validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if _, err := time.LoadLocation(cfg.TimeZone); err != nil || cfg.TimeZone == "" {
+		return fmt.Errorf("event_date.time_zone %q is not a known time zone", cfg.TimeZone)
+	}
+	return nil
+}
+
+// location returns the time zone of the event_date column, or nil when it
+// is disabled.
+func (cfg EventDateConfig) location() *time.Location {
+	if !cfg.Enabled {
+		return nil
+	}
+	loc, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// RollupConfig configures the materialized view that aggregates the data
+// points of the metrics table per time bucket, metric and resource, so
+// dashboards over high-frequency metrics scan far fewer rows.
+type RollupConfig struct {
+	// Enabled creates the materialized view <metric_table>_rollup during
+	// start.
+	Enabled bool `mapstructure:"enabled"`
+	// Granularity is the size of the time buckets, one of MINUTE, HOUR or
+	// DAY.
+	Granularity string `mapstructure:"granularity"`
+	// RefreshInterval is how often BigQuery refreshes the view at most.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+func (cfg RollupConfig) validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	switch cfg.Granularity {
+	case "MINUTE", "HOUR", "DAY":
+	default:
+		return fmt.Errorf("metrics.rollup.granularity %q is not supported, must be one of MINUTE, HOUR, DAY", cfg.Granularity)
+	}
+	if cfg.RefreshInterval < time.Minute || cfg.RefreshInterval > 7*24*time.Hour {
+		return errors.New("metrics.rollup.refresh_interval must be between 1m and 168h")
+	}
+	return nil
+}
+
+// MetricNamesConfig configures the transformation of metric names. The
+// raw_otlp column keeps the original names.
+type MetricNamesConfig struct {
+	// Normalization is sanitize to replace every character other than
+	// letters, digits and underscores by an underscore, or prometheus to
+	// follow the Prometheus naming conventions, including unit and _total
+	// suffixes. Names are kept when empty.
+	Normalization string `mapstructure:"normalization"`
+	// Prefix is prepended to the normalized names, e.g. otel_.
+	Prefix string `mapstructure:"prefix"`
+}
+
+func (cfg MetricNamesConfig) validate() error {
+	switch cfg.Normalization {
+	case "", metricNamesSanitize, metricNamesPrometheus:
+	default:
+		return fmt.Errorf("metrics.metric_names.normalization %q is not supported, must be one of %s, %s", cfg.Normalization, metricNamesSanitize, metricNamesPrometheus)
+	}
+	return nil
+}
+
+// LogsConfig configures the conversion of log records.
+type LogsConfig struct {
+	// Project overrides dataset.project for the logs table.
+	Project string `mapstructure:"project"`
+	// Dataset overrides dataset.id for the logs table, e.g. to apply a
+	// different retention or access policy per signal.
+	Dataset string `mapstructure:"dataset"`
+	// Credentials overrides dataset.credentials for the logs table, e.g.
+	// when its project only accepts writes from its own service account.
+	Credentials CredentialsConfig `mapstructure:"credentials"`
+	// JSONColumns is "json" or "string" and overrides whether the logs
+	// table's JSON columns are created as JSON or as STRING with the same
+	// serialized content. The schema preset decides when empty.
+	JSONColumns string `mapstructure:"json_columns"`
+	// AttributesEncoding is "json" or "key_value" and selects whether the
+	// resource and log record attribute columns hold JSON objects or repeated
+	// key/value records with a value field per attribute type. Defaults to
+	// json.
+	AttributesEncoding string `mapstructure:"attributes_encoding"`
+	// ScopeColumns is "alongside" or "instead" and adds scope_name and
+	// scope_version columns holding the name and version of the
+	// instrumentation scope, alongside or instead of the
+	// instrumentation_scope column. They are not written when empty.
+	ScopeColumns string `mapstructure:"scope_columns"`
+	// TraceContextFromAttributes fills the trace_id and span_id columns of
+	// records without a trace ID from their trace_id/span_id or W3C
+	// traceparent attributes.
+	TraceContextFromAttributes bool `mapstructure:"trace_context_from_attributes"`
+	// EntityEvents writes entity state and delete events, which the
+	// collector carries as logs, to dataset.entity_table instead of the logs
+	// table.
+	EntityEvents bool `mapstructure:"entity_events"`
+	// PartitionTimestamp adds a partition_timestamp column the logs table is
+	// partitioned on unless partitioning.field is set. It is "event" for the
+	// time of the record, falling back to the observed time for records
+	// without one, or "observed" for the observed time. Empty omits the
+	// column.
+	PartitionTimestamp string `mapstructure:"partition_timestamp"`
+	// ClusteringFields are the columns the logs table is clustered by when
+	// the exporter creates it, in order of priority. At most four columns.
+	ClusteringFields []string `mapstructure:"clustering_fields"`
+	// Partitioning configures how the logs table is partitioned when the
+	// exporter creates it.
+	Partitioning PartitioningConfig `mapstructure:"partitioning"`
+	// RowRetention is added to the event time of rows for the expires_at
+	// column of the logs table, which scheduled deletions can use for a
+	// retention finer than partition expiration. Zero omits the column.
+	RowRetention time.Duration `mapstructure:"row_retention"`
+	// PolicyTags maps columns of the logs table to the Data Catalog policy
+	// tag applied to them when the exporter creates it, so column-level
+	// security can restrict access to them.
+	PolicyTags map[string]string `mapstructure:"policy_tags"`
+	// Collation maps STRING columns of the logs table to the collation
+	// applied to them when the exporter creates it, e.g. "und:ci" to compare
+	// values case-insensitively.
+	Collation map[string]string `mapstructure:"collation"`
+	// PromotedAttributes are resource or log record attributes copied into
+	// columns of their own.
+	PromotedAttributes []PromotedAttributeConfig `mapstructure:"promoted_attributes"`
+	// ComputedColumns are columns holding the values of OTTL expressions
+	// evaluated on the log records with their resource and scope.
+	ComputedColumns []ComputedColumnConfig `mapstructure:"computed_columns"`
+	// AttributeFilters drop resource, scope or log record attributes before
+	// they are serialized, e.g. large or sensitive ones.
+	AttributeFilters AttributeFiltersConfig `mapstructure:"attribute_filters"`
+	// Severity adds a severity column holding the severity of every log
+	// record normalized to TRACE, DEBUG, INFO, WARN, ERROR or FATAL.
+	Severity SeverityConfig `mapstructure:"severity"`
+	// RawOTLP adds a raw_otlp BYTES column holding every log record as OTLP
+	// protobuf with its resource and scope, so it can be replayed without
+	// reconstructing it from the other columns.
+	RawOTLP bool `mapstructure:"raw_otlp"`
+	// BodyJSON adds a body_json column holding the body of every log record
+	// as a JSON value rather than text, so map and slice bodies can be
+	// queried with JSON functions without parsing the body column.
+	BodyJSON bool `mapstructure:"body_json"`
+	// MaxColumnBytes maps STRING, JSON and RECORD columns of the logs table
+	// to the maximum size of their values in bytes. Longer values are
+	// truncated and a truncated column flags the rows, so pathological log records
+	// cannot exceed the append request limit.
+	MaxColumnBytes map[string]int `mapstructure:"max_column_bytes"`
+	// ColumnNames renames columns of the logs table, e.g. to write to an
+	// existing table with a schema of its own. Other settings refer to the
+	// exporter's column names.
+	ColumnNames map[string]string `mapstructure:"column_names"`
+}
+
+// Policies for rows with an empty REQUIRED string column.
+const (
+	emptyValuesKeep        = "keep"
+	emptyValuesPlaceholder = "placeholder"
+	emptyValuesDrop        = "drop"
+	emptyValuesFail        = "fail"
+)
+
+// EmptyValuesConfig configures rows with an empty value in a REQUIRED string
+// column. BigQuery accepts empty strings, but they are rarely meaningful.
+type EmptyValuesConfig struct {
+	// Policy is keep (write the empty string), placeholder (write
+	// Placeholder instead), drop (skip the row) or fail (reject the whole
+	// batch as a permanent error).
+	Policy string `mapstructure:"policy"`
+	// Placeholder replaces empty values with the placeholder policy.
+	Placeholder string `mapstructure:"placeholder"`
+}
+
+func (cfg EmptyValuesConfig) validate(field string) error {
+	switch cfg.Policy {
+	case emptyValuesKeep, emptyValuesDrop, emptyValuesFail:
+	case emptyValuesPlaceholder:
+		if cfg.Placeholder == "" {
+			return fmt.Errorf("%s.placeholder is required with the placeholder policy", field)
+		}
+	default:
+		return fmt.Errorf("%s.policy %q is not supported, must be one of keep, placeholder, drop, fail", field, cfg.Policy)
+	}
+	return nil
+}
+
+// PromotedAttributeConfig promotes an attribute to a column of its own.
+type PromotedAttributeConfig struct {
+	// Key is the attribute key, e.g. service.name.
+	Key string `mapstructure:"key"`
+	// Source is resource for resource attributes, or span, datapoint or log
+	// for the attributes of the signal's records.
+	Source string `mapstructure:"source"`
+	// Column is the name of the column. It defaults to the key with every
+	// character other than letters, digits and underscores replaced by an
+	// underscore.
+	Column string `mapstructure:"column"`
+	// Type is the type of the column: STRING, INT64, FLOAT64 or BOOL.
+	// Values that cannot be converted are written as NULL. Defaults to
+	// STRING.
+	Type string `mapstructure:"type"`
+	// MaxCardinality is the number of distinct values the column may hold
+	// before the exporter stops writing it until the collector restarts. 0
+	// means no limit.
+	MaxCardinality int `mapstructure:"max_cardinality"`
+}
+
+// ComputedColumnConfig configures a column holding the value of an OTTL
+// expression, e.g. resource.attributes["tenant"] or, as a BOOL,
+// status.code == STATUS_CODE_ERROR.
+type ComputedColumnConfig struct {
+	// Column is the name of the column.
+	Column string `mapstructure:"column"`
+	// Expression is the OTTL value expression, or condition, computing the
+	// value of the column. It may use the standard OTTL converters.
+	Expression string `mapstructure:"expression"`
+	// Type is the type of the column: STRING, INT64, FLOAT64 or BOOL.
+	// Values that cannot be converted, and expressions that fail, are
+	// written as NULL. Defaults to STRING.
+	Type string `mapstructure:"type"`
+}
+
+// AttributeFiltersConfig selects the attributes written to the attribute
+// columns of a signal.
+type AttributeFiltersConfig struct {
+	// Resource filters resource attributes.
+	Resource AttributeFilterConfig `mapstructure:"resource"`
+	// Scope filters instrumentation scope attributes.
+	Scope AttributeFilterConfig `mapstructure:"scope"`
+	// Record filters the attributes of spans, data points or log records.
+	Record AttributeFilterConfig `mapstructure:"record"`
+}
+
+// AttributeFilterConfig selects attributes by key. When any include list is
+// set only matching attributes are written; attributes matching an exclude
+// list are never written.
+type AttributeFilterConfig struct {
+	// Include lists the keys of the attributes to write.
+	Include []string `mapstructure:"include"`
+	// IncludePatterns are regular expressions matching the keys of the
+	// attributes to write.
+	IncludePatterns []string `mapstructure:"include_patterns"`
+	// Exclude lists the keys of the attributes to drop.
+	Exclude []string `mapstructure:"exclude"`
+	// ExcludePatterns are regular expressions matching the keys of the
+	// attributes to drop.
+	ExcludePatterns []string `mapstructure:"exclude_patterns"`
+}
+
+// SeverityConfig configures the normalized severity column of the logs
+// table.
+type SeverityConfig struct {
+	// Enabled adds the severity column.
+	Enabled bool `mapstructure:"enabled"`
+	// TextMapping maps severity texts, compared case-insensitively, to the
+	// level written for them. It takes precedence over the severity number
+	// and the built-in aliases such as "warning" or "crit".
+	TextMapping map[string]string `mapstructure:"text_mapping"`
+}
+
+// UpsertConfig configures writing rows as BigQuery change data capture
+// upserts, which replace the row with the same primary key instead of
+// adding a row.
+type UpsertConfig struct {
+	// Enabled creates the table with a primary key and writes rows with the
+	// _CHANGE_TYPE UPSERT.
+	Enabled bool `mapstructure:"enabled"`
+	// MaxStaleness is the max_staleness option of the table when the
+	// exporter creates it: queries may return results this stale, which
+	// lets BigQuery apply changes in the background instead of at query
+	// time. Zero leaves it unset.
+	MaxStaleness time.Duration `mapstructure:"max_staleness"`
+}
+
+// PartitioningConfig configures the partitioning of a table.
+type PartitioningConfig struct {
+	// Field is the TIMESTAMP or DATE column the table is partitioned on, or
+	// the INT64 column with range partitioning. The table is partitioned by
+	// ingestion time when empty.
+	Field string `mapstructure:"field"`
+	// Granularity is the partition size, one of HOUR, DAY, MONTH or YEAR.
+	Granularity string `mapstructure:"granularity"`
+	// Range partitions the table by ranges of the INT64 column Field
+	// instead of by time when its interval is set.
+	Range RangePartitioningConfig `mapstructure:"range"`
+}
+
+// RangePartitioningConfig configures integer-range partitioning. Values
+// from Start up to but excluding End are split into partitions of Interval
+// values; other values go to the __UNPARTITIONED__ partition.
+type RangePartitioningConfig struct {
+	Start    int64 `mapstructure:"start"`
+	End      int64 `mapstructure:"end"`
+	Interval int64 `mapstructure:"interval"`
+}
+
+// maxRangePartitions is the number of partitions BigQuery allows a table.
+const maxRangePartitions = 10000
+
+func (cfg PartitioningConfig) validate(field string, schema bigquery.Schema) error {
+	if cfg.Range != (RangePartitioningConfig{}) {
+		return cfg.validateRange(field, schema)
+	}
+	switch bigquery.TimePartitioningType(cfg.Granularity) {
+	case bigquery.HourPartitioningType, bigquery.DayPartitioningType, bigquery.MonthPartitioningType, bigquery.YearPartitioningType:
+	default:
+		return fmt.Errorf("%s.granularity %q is not supported, must be one of HOUR, DAY, MONTH, YEAR", field, cfg.Granularity)
+	}
+	if cfg.Field == "" {
+		return nil
+	}
+	idx := slices.IndexFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == cfg.Field })
+	if idx < 0 {
+		return fmt.Errorf("%s.field: %q is not a column of the table", field, cfg.Field)
+	}
+	switch schema[idx].Type {
+	case bigquery.TimestampFieldType:
+	case bigquery.DateFieldType:
+		if cfg.Granularity == string(bigquery.HourPartitioningType) {
+			return fmt.Errorf("%s.granularity: DATE column %q cannot be partitioned by HOUR", field, cfg.Field)
+		}
+	default:
+		return fmt.Errorf("%s.field: column %q of type %s cannot be used for time partitioning", field, cfg.Field, schema[idx].Type)
+	}
+	return nil
+}
+
+func (cfg PartitioningConfig) validateRange(field string, schema bigquery.Schema) error {
+	if cfg.Field == "" {
+		return fmt.Errorf("%s.field is required with %s.range", field, field)
+	}
+	idx := slices.IndexFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == cfg.Field })
+	if idx < 0 {
+		return fmt.Errorf("%s.field: %q is not a column of the table", field, cfg.Field)
+	}
+	if schema[idx].Type != bigquery.IntegerFieldType {
+		return fmt.Errorf("%s.field: column %q of type %s cannot be used for range partitioning", field, cfg.Field, schema[idx].Type)
+	}
+	r := cfg.Range
+	if r.Interval <= 0 {
+		return fmt.Errorf("%s.range.interval must be positive", field)
+	}
+	if r.End <= r.Start {
+		return fmt.Errorf("%s.range.end must be greater than %s.range.start", field, field)
+	}
+	if partitions := (uint64(r.End-r.Start) + uint64(r.Interval) - 1) / uint64(r.Interval); partitions > maxRangePartitions {
+		return fmt.Errorf("%s.range creates %d partitions, more than the %d BigQuery allows", field, partitions, maxRangePartitions)
+	}
+	return nil
+}
+
+// timePartitioning returns the BigQuery time partitioning for cfg, or nil
+// with range partitioning.
+func (cfg PartitioningConfig) timePartitioning() *bigquery.TimePartitioning {
+	if cfg.Range.Interval != 0 {
+		return nil
+	}
+	return &bigquery.TimePartitioning{Type: bigquery.TimePartitioningType(cfg.Granularity), Field: cfg.Field}
+}
+
+// rangePartitioning returns the BigQuery range partitioning for cfg, or nil
+// with time partitioning.
+func (cfg PartitioningConfig) rangePartitioning() *bigquery.RangePartitioning {
+	if cfg.Range.Interval == 0 {
+		return nil
+	}
+	return &bigquery.RangePartitioning{
+		Field: cfg.Field,
+		Range: &bigquery.RangePartitioningRange{Start: cfg.Range.Start, End: cfg.Range.End, Interval: cfg.Range.Interval},
+	}
+}
+
+// WriteConfig configures the Storage Write API stream used for each table.
+type WriteConfig struct {
+	// Mode is either "default", which appends to the table's default stream
+	// and makes rows visible immediately, or "pending", which buffers rows in
+	// a pending stream and commits them atomically.
+	Mode string `mapstructure:"mode"`
+	// CommitInterval is the length of the wall-clock windows on which pending
+	// streams are committed. Windows are aligned to multiples of the interval,
+	// so with 1m every commit happens at the start of a minute. Zero commits
+	// after every append. Only used in the pending mode.
+	CommitInterval time.Duration `mapstructure:"commit_interval"`
+	// WarmUp sends an append without rows on every stream during start, so
+	// the connection is established before the first batch arrives.
+	WarmUp bool `mapstructure:"warm_up"`
+	// Keepalive configures keepalive pings on the Storage Write gRPC channel,
+	// so that idle connections are not silently dropped by NAT gateways or
+	// firewalls.
+	Keepalive KeepaliveConfig `mapstructure:"keepalive"`
+	// MaxSendMessageSizeMiB and MaxRecvMessageSizeMiB limit the size of
+	// messages on the Storage Write gRPC channel. Zero keeps the gRPC defaults.
+	MaxSendMessageSizeMiB int `mapstructure:"max_send_message_size_mib"`
+	MaxRecvMessageSizeMiB int `mapstructure:"max_recv_message_size_mib"`
+	// Compression is the gRPC compression applied to AppendRows requests,
+	// either "none" or "gzip".
+	Compression string `mapstructure:"compression"`
+	// Workers is the number of appends that may run concurrently against
+	// each table. Every table has its own workers, so a slow or failing table
+	// does not hold up appends to the others. Must be 1 in the pending mode,
+	// where appends on a stream are ordered.
+	Workers int `mapstructure:"workers"`
+	// AppendTimeout bounds each append to a single table, including the time
+	// spent waiting for one of its workers. Zero only applies the exporter
+	// timeout.
+	AppendTimeout time.Duration `mapstructure:"append_timeout"`
+	// DebugLogSampleRate is the fraction of append results, between 0 and 1,
+	// logged at debug level with the Storage Write response and the trace
+	// context of the export. Nothing is logged unless the collector logs at
+	// debug level.
+	DebugLogSampleRate float64 `mapstructure:"debug_log_sample_rate"`
+	// TraceLabels identify the pipeline, e.g. its team, in the trace ID of
+	// the Storage Write streams, so write throughput in a shared project can
+	// be attributed to it.
+	TraceLabels map[string]string `mapstructure:"trace_labels"`
+}
+
+// traceID returns the trace ID of the Storage Write streams carrying the
+// trace labels as sorted key=value pairs, or "" without labels.
+func (cfg *WriteConfig) traceID() string {
+	pairs := make([]string, 0, len(cfg.TraceLabels))
+	for _, key := range slices.Sorted(maps.Keys(cfg.TraceLabels)) {
+		pairs = append(pairs, key+"="+cfg.TraceLabels[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// KeepaliveConfig configures gRPC client keepalive pings.
+type KeepaliveConfig struct {
+	// Time is the idle duration after which the client pings the server.
+	// Zero disables keepalive pings.
+	Time time.Duration `mapstructure:"time"`
+	// Timeout is how long the client waits for a ping acknowledgement before
+	// closing the connection. Zero keeps the gRPC default of 20s.
+	Timeout time.Duration `mapstructure:"timeout"`
 }
 
 // DatasetConfig holds BigQuery dataset and table information.
@@ -32,13 +1080,125 @@ type DatasetConfig struct {
 	Project string      `mapstructure:"project"`
 	ID      string      `mapstructure:"id"`
 	Table   TableConfig `mapstructure:",squash"`
+	// Credentials used to write to this destination. Application Default
+	// Credentials are used when empty.
+	Credentials CredentialsConfig `mapstructure:"credentials"`
+	// TableExpiration deletes tables created by the exporter this long after
+	// their creation, independently of partition expiration. Zero keeps
+	// tables indefinitely.
+	TableExpiration time.Duration `mapstructure:"table_expiration"`
+	// UpdateTableExpiration also sets the expiration of existing tables
+	// during start, so every start extends their lifetime by
+	// table_expiration.
+	UpdateTableExpiration bool `mapstructure:"update_table_expiration"`
+	// TableLabels are set on tables created by the exporter, e.g. for cost
+	// attribution.
+	TableLabels map[string]string `mapstructure:"table_labels"`
+	// TableDescription is set on tables created by the exporter.
+	TableDescription string `mapstructure:"table_description"`
+	// UpdateTableMetadata also applies table_labels and table_description to
+	// existing tables during start. Labels that are not configured are kept.
+	UpdateTableMetadata bool `mapstructure:"update_table_metadata"`
+	// KMSKeyName is the Cloud KMS key protecting tables created by the
+	// exporter, e.g.
+	// projects/p/locations/us/keyRings/r/cryptoKeys/k. The dataset default
+	// key, if any, is used when empty.
+	KMSKeyName string `mapstructure:"kms_key_name"`
+	// AutoCreate creates missing datasets during start instead of failing.
+	AutoCreate bool `mapstructure:"auto_create"`
+	// Location is the location of datasets created by the exporter, e.g.
+	// "EU". BigQuery's default location is used when empty.
+	Location string `mapstructure:"location"`
+	// ExpectedLocation fails the start when a dataset the exporter writes
+	// to is in another location, e.g. to catch cross-region egress. The
+	// location is not checked when empty.
+	ExpectedLocation string `mapstructure:"expected_location"`
+	// DefaultTableExpiration is the default expiration of tables in datasets
+	// created by the exporter. Zero keeps tables indefinitely.
+	DefaultTableExpiration time.Duration `mapstructure:"default_table_expiration"`
+	// DefaultPartitionExpiration is the default partition expiration of
+	// partitioned tables in datasets created by the exporter. Zero keeps
+	// partitions indefinitely.
+	DefaultPartitionExpiration time.Duration `mapstructure:"default_partition_expiration"`
+	// ShardWindow bounds the event times rows of time-sharded tables are
+	// routed by.
+	ShardWindow ShardWindowConfig `mapstructure:"shard_window"`
+}
+
+// ShardWindowConfig bounds the event times rows of time-sharded tables are
+// routed by, relative to the time of the export. Rows with event times
+// outside of the window go to the current shard, so skewed clocks do not
+// create shards far in the past or future.
+type ShardWindowConfig struct {
+	// Past is how far event times may lie in the past.
+	Past time.Duration `mapstructure:"past"`
+	// Future is how far event times may lie in the future.
+	Future time.Duration `mapstructure:"future"`
+}
+
+// minDefaultTableExpiration is the shortest default table expiration
+// BigQuery accepts.
+const minDefaultTableExpiration = time.Hour
+
+func (cfg *DatasetConfig) validateAutoCreate() error {
+	if cfg.DefaultTableExpiration < 0 {
+		return errors.New("dataset.default_table_expiration must not be negative")
+	}
+	if cfg.DefaultTableExpiration > 0 && cfg.DefaultTableExpiration < minDefaultTableExpiration {
+		return fmt.Errorf("dataset.default_table_expiration must be at least %s", minDefaultTableExpiration)
+	}
+	if cfg.DefaultPartitionExpiration < 0 {
+		return errors.New("dataset.default_partition_expiration must not be negative")
+	}
+	if cfg.Location != "" && cfg.ExpectedLocation != "" && !strings.EqualFold(cfg.Location, cfg.ExpectedLocation) {
+		return fmt.Errorf("dataset.location %q differs from dataset.expected_location %q", cfg.Location, cfg.ExpectedLocation)
+	}
+	if cfg.AutoCreate {
+		return nil
+	}
+	switch {
+	case cfg.Location != "":
+		return errors.New("dataset.location requires dataset.auto_create")
+	case cfg.DefaultTableExpiration > 0:
+		return errors.New("dataset.default_table_expiration requires dataset.auto_create")
+	case cfg.DefaultPartitionExpiration > 0:
+		return errors.New("dataset.default_partition_expiration requires dataset.auto_create")
+	}
+	return nil
+}
+
+// CredentialsConfig selects the credentials used for a destination, since
+// tenant projects often only accept writes from their own service account.
+type CredentialsConfig struct {
+	// File is the path to a service account key or external account
+	// configuration file.
+	File string `mapstructure:"file"`
+	// Secret is the resource name of a Secret Manager secret version holding
+	// a service account key or external account configuration, e.g.
+	// projects/p/secrets/s/versions/latest. It is read with Application
+	// Default Credentials, so the key never has to be stored on disk.
+	Secret string `mapstructure:"secret"`
+	// ImpersonateServiceAccount is the email of a service account to
+	// impersonate with the base credentials.
+	ImpersonateServiceAccount string `mapstructure:"impersonate_service_account"`
+	// ReloadInterval is how often the credentials file is checked for a
+	// rotated key. Zero disables reloading.
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
 }
 
-// TableConfig holds the table names for each signal.
+// TableConfig holds the table names for each signal. Names other than
+// statistics_table, resource_table and wide_events_table may be time-shard
+// templates with the placeholders %Y, %m, %d and %H, e.g. logs_%Y%m%d.
 type TableConfig struct {
-	Trace  string `mapstructure:"trace_table"`
-	Metric string `mapstructure:"metric_table"`
-	Log    string `mapstructure:"log_table"`
+	Trace      string `mapstructure:"trace_table"`
+	Metric     string `mapstructure:"metric_table"`
+	Log        string `mapstructure:"log_table"`
+	Entity     string `mapstructure:"entity_table"`
+	SpanEvent  string `mapstructure:"trace_event_table"`
+	SpanLink   string `mapstructure:"trace_link_table"`
+	Statistics string `mapstructure:"statistics_table"`
+	Resource   string `mapstructure:"resource_table"`
+	WideEvents string `mapstructure:"wide_events_table"`
 }
 
 // Validate checks if the configuration is valid.
@@ -46,24 +1206,593 @@ func (cfg *Config) Validate() error {
 	if cfg.Dataset.ID == "" {
 		return errors.New("dataset.id is required")
 	}
-	if cfg.Dataset.Project != "" && strings.TrimSpace(cfg.Dataset.Project) != cfg.Dataset.Project {
-		return errors.New("dataset.project must not contain leading or trailing whitespace")
+	if err := validateProject("dataset.project", cfg.Dataset.Project); err != nil {
+		return err
+	}
+	if err := validateProject("traces.project", cfg.Traces.Project); err != nil {
+		return err
+	}
+	if err := validateProject("metrics.project", cfg.Metrics.Project); err != nil {
+		return err
+	}
+	if err := validateProject("logs.project", cfg.Logs.Project); err != nil {
+		return err
+	}
+	if strings.ContainsAny(cfg.UserAgentSuffix, "\r\n") {
+		return errors.New("user_agent_suffix must not contain line breaks")
+	}
+	if _, ok := schemaPresets[cfg.SchemaPreset]; !ok {
+		return fmt.Errorf("schema_preset %q is not supported, must be one of %s", cfg.SchemaPreset, strings.Join(schemaPresetNames(), ", "))
+	}
+	if err := validateJSONColumns("traces.json_columns", cfg.Traces.JSONColumns); err != nil {
+		return err
+	}
+	if err := validateJSONColumns("metrics.json_columns", cfg.Metrics.JSONColumns); err != nil {
+		return err
+	}
+	if err := validateJSONColumns("logs.json_columns", cfg.Logs.JSONColumns); err != nil {
+		return err
+	}
+	if err := validateAttributesEncoding("traces.attributes_encoding", cfg.Traces.AttributesEncoding); err != nil {
+		return err
+	}
+	if err := validateEnumEncoding("traces.enum_encoding", cfg.Traces.EnumEncoding); err != nil {
+		return err
+	}
+	if err := validateAttributesEncoding("metrics.attributes_encoding", cfg.Metrics.AttributesEncoding); err != nil {
+		return err
+	}
+	if err := validateAttributesEncoding("logs.attributes_encoding", cfg.Logs.AttributesEncoding); err != nil {
+		return err
+	}
+	if err := validateScopeColumns("traces.scope_columns", cfg.Traces.ScopeColumns); err != nil {
+		return err
+	}
+	if err := validateScopeColumns("metrics.scope_columns", cfg.Metrics.ScopeColumns); err != nil {
+		return err
+	}
+	if err := validateScopeColumns("logs.scope_columns", cfg.Logs.ScopeColumns); err != nil {
+		return err
+	}
+	switch cfg.IDColumns {
+	case "", idColumnsString, idColumnsBytes:
+	default:
+		return fmt.Errorf("id_columns %q is not supported, must be one of %s, %s", cfg.IDColumns, idColumnsString, idColumnsBytes)
+	}
+	if err := cfg.Traces.AttributeFilters.validate("traces.attribute_filters"); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.AttributeFilters.validate("metrics.attribute_filters"); err != nil {
+		return err
+	}
+	if err := cfg.Logs.AttributeFilters.validate("logs.attribute_filters"); err != nil {
+		return err
+	}
+	if err := cfg.Logs.Severity.validate("logs.severity"); err != nil {
+		return err
+	}
+	if err := validatePromotePresets(cfg.PromotePresets); err != nil {
+		return err
+	}
+	// The preset attributes are validated, and the schemas below computed,
+	// like the promoted attributes the exporter writes.
+	cfg = cfg.withPromotePresets()
+	tracesReserved := slices.Concat(rowconv.TracesSchema, bigquery.Schema{rowconv.StatusClassField}, rowconv.FlagFields, rowconv.CountFields, rowconv.SpanHierarchyFields, optionalColumns)
+	if err := validatePromotedAttributes("traces.promoted_attributes", "span", cfg.Traces.PromotedAttributes, tracesReserved); err != nil {
+		return err
+	}
+	metricsReserved := slices.Concat(rowconv.MetricsSchema, bigquery.Schema{seriesIDField, {Name: rowconv.DataPointsColumn}, rowconv.ExemplarSpansField}, rowconv.ExponentialHistogramFields, rowconv.UnitFields, optionalColumns)
+	if err := validatePromotedAttributes("metrics.promoted_attributes", "datapoint", cfg.Metrics.PromotedAttributes, metricsReserved); err != nil {
+		return err
+	}
+	logsReserved := slices.Concat(rowconv.LogsSchema, bigquery.Schema{rowconv.PartitionTimestampField, rowconv.SeverityField, rowconv.BodyJSONField}, optionalColumns)
+	if err := validatePromotedAttributes("logs.promoted_attributes", "log", cfg.Logs.PromotedAttributes, logsReserved); err != nil {
+		return err
+	}
+	if err := validateComputedColumns("traces.computed_columns", cfg.Traces.ComputedColumns,
+		slices.Concat(tracesReserved, rowconv.PromotedFields(promotedAttributes(cfg.Traces.PromotedAttributes))), parseFunc(newSpanParser)); err != nil {
+		return err
+	}
+	if err := validateComputedColumns("metrics.computed_columns", cfg.Metrics.ComputedColumns,
+		slices.Concat(metricsReserved, rowconv.PromotedFields(promotedAttributes(cfg.Metrics.PromotedAttributes))), parseFunc(newDataPointParser)); err != nil {
+		return err
+	}
+	if err := validateComputedColumns("logs.computed_columns", cfg.Logs.ComputedColumns,
+		slices.Concat(logsReserved, rowconv.PromotedFields(promotedAttributes(cfg.Logs.PromotedAttributes))), parseFunc(newLogParser)); err != nil {
+		return err
+	}
+	tracesSchema := signalSchema(cfg, cfg.SchemaPreset, "traces", cfg.Traces.JSONColumns)
+	metricsSchema := signalSchema(cfg, cfg.SchemaPreset, "metrics", cfg.Metrics.JSONColumns)
+	switch cfg.Logs.PartitionTimestamp {
+	case "", rowconv.PartitionTimestampEvent, rowconv.PartitionTimestampObserved:
+	default:
+		return fmt.Errorf("logs.partition_timestamp %q is not supported, must be one of %s, %s", cfg.Logs.PartitionTimestamp, rowconv.PartitionTimestampEvent, rowconv.PartitionTimestampObserved)
+	}
+	logsSchema := signalSchema(cfg, cfg.SchemaPreset, "logs", cfg.Logs.JSONColumns)
+	if err := validateClusteringFields("traces.clustering_fields", cfg.Traces.ClusteringFields, tracesSchema); err != nil {
+		return err
+	}
+	if err := cfg.Traces.Partitioning.validate("traces.partitioning", tracesSchema); err != nil {
+		return err
+	}
+	if err := validateClusteringFields("metrics.clustering_fields", cfg.Metrics.ClusteringFields, metricsSchema); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.Partitioning.validate("metrics.partitioning", metricsSchema); err != nil {
+		return err
+	}
+	if err := cfg.Traces.EmptyValues.validate("traces.empty_values"); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.EmptyValues.validate("metrics.empty_values"); err != nil {
+		return err
+	}
+	if err := cfg.Traces.Upsert.validate("traces.upsert", cfg.Write); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.Upsert.validate("metrics.upsert", cfg.Write); err != nil {
+		return err
+	}
+	if err := validateClusteringFields("logs.clustering_fields", cfg.Logs.ClusteringFields, logsSchema); err != nil {
+		return err
+	}
+	if err := cfg.Logs.partitioning().validate("logs.partitioning", logsSchema); err != nil {
+		return err
+	}
+	tracesLimitsSchema := tracesSchema
+	if cfg.Traces.ChildTables {
+		// The limits apply to the span event and link tables as well.
+		tracesLimitsSchema = slices.Concat(tracesSchema, rowconv.SpanEventsSchema, rowconv.SpanLinksSchema)
+	}
+	if err := validateMaxColumnBytes("traces.max_column_bytes", cfg.Traces.MaxColumnBytes, tracesLimitsSchema); err != nil {
+		return err
+	}
+	if err := validateMaxColumnBytes("metrics.max_column_bytes", cfg.Metrics.MaxColumnBytes, metricsSchema); err != nil {
+		return err
+	}
+	if err := validateMaxColumnBytes("logs.max_column_bytes", cfg.Logs.MaxColumnBytes, logsSchema); err != nil {
+		return err
+	}
+	if err := validateColumnNames("traces.column_names", cfg.Traces.ColumnNames, tracesSchema); err != nil {
+		return err
+	}
+	if err := validateColumnNames("metrics.column_names", cfg.Metrics.ColumnNames, metricsSchema); err != nil {
+		return err
+	}
+	if err := validateColumnNames("logs.column_names", cfg.Logs.ColumnNames, logsSchema); err != nil {
+		return err
+	}
+	if err := cfg.validateWideEvents(tracesSchema, metricsSchema, logsSchema); err != nil {
+		return err
+	}
+	if err := validatePolicyTags("traces.policy_tags", cfg.Traces.PolicyTags, tracesSchema); err != nil {
+		return err
+	}
+	if err := validatePolicyTags("metrics.policy_tags", cfg.Metrics.PolicyTags, metricsSchema); err != nil {
+		return err
+	}
+	if err := validatePolicyTags("logs.policy_tags", cfg.Logs.PolicyTags, logsSchema); err != nil {
+		return err
+	}
+	if err := validateCollation("traces.collation", cfg.Traces.Collation, tracesSchema); err != nil {
+		return err
+	}
+	if err := validateCollation("metrics.collation", cfg.Metrics.Collation, metricsSchema); err != nil {
+		return err
+	}
+	if err := validateCollation("logs.collation", cfg.Logs.Collation, logsSchema); err != nil {
+		return err
+	}
+	if cfg.MemoryLimitMiB < 0 {
+		return errors.New("memory_limit_mib must not be negative")
+	}
+	if err := cfg.Write.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Dataset.Credentials.validate("dataset.credentials"); err != nil {
+		return err
+	}
+	if err := cfg.Traces.Credentials.validate("traces.credentials"); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.Credentials.validate("metrics.credentials"); err != nil {
+		return err
+	}
+	if err := cfg.Logs.Credentials.validate("logs.credentials"); err != nil {
+		return err
+	}
+	if err := validateScopes(cfg.Scopes); err != nil {
+		return err
+	}
+	if err := validateProxyURL(cfg.ProxyURL); err != nil {
+		return err
+	}
+	if cfg.TLS.HasValue() && cfg.TLS.Get().Insecure {
+		return errors.New("tls.insecure is not supported, BigQuery endpoints require TLS")
 	}
 	if err := validateIdentifier("dataset.id", cfg.Dataset.ID); err != nil {
 		return err
 	}
-	if err := validateIdentifier("dataset.trace_table", cfg.Dataset.Table.Trace); err != nil {
+	if cfg.Traces.Dataset != "" {
+		if err := validateIdentifier("traces.dataset", cfg.Traces.Dataset); err != nil {
+			return err
+		}
+	}
+	if cfg.Metrics.Dataset != "" {
+		if err := validateIdentifier("metrics.dataset", cfg.Metrics.Dataset); err != nil {
+			return err
+		}
+	}
+	if cfg.Logs.Dataset != "" {
+		if err := validateIdentifier("logs.dataset", cfg.Logs.Dataset); err != nil {
+			return err
+		}
+	}
+	if err := validateTableName("dataset.trace_table", cfg.Dataset.Table.Trace); err != nil {
+		return err
+	}
+	if err := validateTableName("dataset.metric_table", cfg.Dataset.Table.Metric); err != nil {
+		return err
+	}
+	if err := validateTableName("dataset.log_table", cfg.Dataset.Table.Log); err != nil {
+		return err
+	}
+	if err := validateTableName("dataset.entity_table", cfg.Dataset.Table.Entity); err != nil {
+		return err
+	}
+	if err := validateTableName("dataset.trace_event_table", cfg.Dataset.Table.SpanEvent); err != nil {
+		return err
+	}
+	if err := validateTableName("dataset.trace_link_table", cfg.Dataset.Table.SpanLink); err != nil {
+		return err
+	}
+	if err := validateIdentifier("dataset.statistics_table", cfg.Dataset.Table.Statistics); err != nil {
 		return err
 	}
-	if err := validateIdentifier("dataset.metric_table", cfg.Dataset.Table.Metric); err != nil {
+	if err := validateIdentifier("dataset.resource_table", cfg.Dataset.Table.Resource); err != nil {
 		return err
 	}
-	if err := validateIdentifier("dataset.log_table", cfg.Dataset.Table.Log); err != nil {
+	if err := validateIdentifier("dataset.wide_events_table", cfg.Dataset.Table.WideEvents); err != nil {
 		return err
 	}
+	if cfg.Dataset.TableExpiration < 0 {
+		return errors.New("dataset.table_expiration must not be negative")
+	}
+	if cfg.Dataset.UpdateTableExpiration && cfg.Dataset.TableExpiration == 0 {
+		return errors.New("dataset.update_table_expiration requires dataset.table_expiration")
+	}
+	if err := validateLabels("dataset.table_labels", cfg.Dataset.TableLabels); err != nil {
+		return err
+	}
+	if _, ok := cfg.Dataset.TableLabels[schemaVersionLabel]; ok {
+		return fmt.Errorf("dataset.table_labels must not set %q, which the exporter sets on the tables it creates", schemaVersionLabel)
+	}
+	if len(cfg.Dataset.TableLabels) == maxTableLabels {
+		return fmt.Errorf("dataset.table_labels must not have more than %d labels, since the exporter adds %q", maxTableLabels-1, schemaVersionLabel)
+	}
+	if err := cfg.Dataset.validateAutoCreate(); err != nil {
+		return err
+	}
+	if cfg.Dataset.ShardWindow.Past < 0 {
+		return errors.New("dataset.shard_window.past must not be negative")
+	}
+	if cfg.Dataset.ShardWindow.Future < 0 {
+		return errors.New("dataset.shard_window.future must not be negative")
+	}
+	if !cfg.AutoCreateTables && cfg.Dataset.AutoCreate {
+		return errors.New("dataset.auto_create cannot be used with auto_create_tables: false")
+	}
+	if !cfg.AutoCreateTables && cfg.Dataset.UpdateTableExpiration {
+		return errors.New("dataset.update_table_expiration cannot be used with auto_create_tables: false")
+	}
+	if !cfg.AutoCreateTables && cfg.Dataset.UpdateTableMetadata {
+		return errors.New("dataset.update_table_metadata cannot be used with auto_create_tables: false")
+	}
+	if !cfg.AutoCreateTables && cfg.AllowSchemaUpdate {
+		return errors.New("allow_schema_update cannot be used with auto_create_tables: false")
+	}
+	if err := cfg.SchemaSnapshot.validate(cfg.AllowSchemaUpdate); err != nil {
+		return err
+	}
+	if !cfg.AutoCreateTables && cfg.CreateViews {
+		return errors.New("create_views cannot be used with auto_create_tables: false")
+	}
+	if !cfg.AutoCreateTables && cfg.CreateUDFs {
+		return errors.New("create_udfs cannot be used with auto_create_tables: false")
+	}
+	if cfg.CreateViews && (len(cfg.Traces.ColumnNames) > 0 || len(cfg.Metrics.ColumnNames) > 0 || len(cfg.Logs.ColumnNames) > 0) {
+		return errors.New("create_views cannot be used with column_names, since the views query the exporter's column names")
+	}
+	if cfg.CreateViews && slices.Contains([]string{cfg.Traces.AttributesEncoding, cfg.Metrics.AttributesEncoding, cfg.Logs.AttributesEncoding}, attributesEncodingKeyValue) {
+		return errors.New("create_views cannot be used with attributes_encoding: key_value, since the views query JSON attribute columns")
+	}
+	if cfg.CreateViews && cfg.Traces.EnumEncoding == enumEncodingInt64 {
+		return errors.New("create_views cannot be used with traces.enum_encoding: int64, since the traces view compares status_code with ERROR")
+	}
+	if cfg.CreateViews && cfg.NormalizeResources.Enabled {
+		return errors.New("create_views cannot be used with normalize_resources, since the views query resource_attributes")
+	}
+	if cfg.CreateViews {
+		for _, name := range []string{cfg.Dataset.Table.Trace, cfg.Dataset.Table.Metric, cfg.Dataset.Table.Log} {
+			if isTableTemplate(name) {
+				return fmt.Errorf("create_views cannot be used with the time-sharded table %q", name)
+			}
+		}
+	}
+	if err := cfg.validateDualWrite(); err != nil {
+		return err
+	}
+	if err := cfg.EventDate.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.Rollup.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Metrics.MetricNames.validate(); err != nil {
+		return err
+	}
+	if cfg.Metrics.GroupDataPoints && cfg.Metrics.Rollup.Enabled {
+		return errors.New("metrics.rollup cannot be used with metrics.group_datapoints, since the rollup aggregates data point rows")
+	}
+	if cfg.Metrics.GroupDataPoints && cfg.Metrics.Upsert.Enabled {
+		return errors.New("metrics.upsert cannot be used with metrics.group_datapoints, since rows hold several time series")
+	}
+	if cfg.Metrics.GroupDataPoints && cfg.CreateViews {
+		return errors.New("create_views cannot be used with metrics.group_datapoints, since the metrics view queries data point rows")
+	}
+	if cfg.Metrics.Rollup.Enabled && cfg.NormalizeResources.Enabled {
+		return errors.New("metrics.rollup cannot be used with normalize_resources, since the rollup hashes resource_attributes")
+	}
+	if err := cfg.AnonymizeAttributes.validate(); err != nil {
+		return err
+	}
+	if err := cfg.NormalizeResources.validate(); err != nil {
+		return err
+	}
+	if cfg.NormalizeResources.Enabled && slices.Contains([]string{cfg.Traces.AttributesEncoding, cfg.Metrics.AttributesEncoding, cfg.Logs.AttributesEncoding}, attributesEncodingKeyValue) {
+		return errors.New("normalize_resources cannot be used with attributes_encoding: key_value")
+	}
+	if cfg.ResourceHash && slices.Contains([]string{cfg.Traces.AttributesEncoding, cfg.Metrics.AttributesEncoding, cfg.Logs.AttributesEncoding}, attributesEncodingKeyValue) {
+		return errors.New("resource_hash cannot be used with attributes_encoding: key_value, whose records keep the order of the attributes")
+	}
+	if !cfg.AutoCreateTables && cfg.Metrics.Rollup.Enabled {
+		return errors.New("metrics.rollup cannot be used with auto_create_tables: false")
+	}
+	if cfg.Metrics.Rollup.Enabled && len(cfg.Metrics.ColumnNames) > 0 {
+		return errors.New("metrics.rollup cannot be used with metrics.column_names")
+	}
+	if cfg.Metrics.Rollup.Enabled && isTableTemplate(cfg.Dataset.Table.Metric) {
+		return fmt.Errorf("metrics.rollup cannot be used with the time-sharded table %q", cfg.Dataset.Table.Metric)
+	}
+	if cfg.Dataset.KMSKeyName != "" && !kmsKeyNamePattern.MatchString(cfg.Dataset.KMSKeyName) {
+		return fmt.Errorf("dataset.kms_key_name %q must be of the form projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>", cfg.Dataset.KMSKeyName)
+	}
+	if cfg.Dataset.UpdateTableMetadata && len(cfg.Dataset.TableLabels) == 0 && cfg.Dataset.TableDescription == "" {
+		return errors.New("dataset.update_table_metadata requires dataset.table_labels or dataset.table_description")
+	}
+	if cfg.Traces.RowRetention < 0 {
+		return errors.New("traces.row_retention must not be negative")
+	}
+	if cfg.Metrics.RowRetention < 0 {
+		return errors.New("metrics.row_retention must not be negative")
+	}
+	if cfg.Logs.RowRetention < 0 {
+		return errors.New("logs.row_retention must not be negative")
+	}
+	if cfg.TableCreateJitter < 0 {
+		return errors.New("table_create_jitter must not be negative")
+	}
+	if cfg.AdaptiveSlim.Enabled && cfg.AdaptiveSlim.Threshold < 1 {
+		return errors.New("adaptive_slim.threshold must be at least 1")
+	}
+	if cfg.AdaptiveSlim.Enabled && cfg.AdaptiveSlim.Cooldown <= 0 {
+		return errors.New("adaptive_slim.cooldown must be positive")
+	}
+	if cfg.Statistics.Enabled && cfg.Statistics.FlushInterval <= 0 {
+		return errors.New("statistics.flush_interval must be positive")
+	}
+	return nil
+}
+
+func validateLabels(field string, labels map[string]string) error {
+	if len(labels) > maxTableLabels {
+		return fmt.Errorf("%s must not have more than %d labels", field, maxTableLabels)
+	}
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("%s key %q must start with a lowercase letter and contain at most 63 lowercase letters, digits, underscores or dashes", field, key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("%s value %q of %q must contain at most 63 lowercase letters, digits, underscores or dashes", field, value, key)
+		}
+	}
+	return nil
+}
+
+func (cfg *WriteConfig) validate() error {
+	switch cfg.Mode {
+	case writeModeDefault:
+		if cfg.CommitInterval != 0 {
+			return errors.New("write.commit_interval is only supported with write.mode pending")
+		}
+	case writeModePending:
+		if cfg.CommitInterval < 0 {
+			return errors.New("write.commit_interval must not be negative")
+		}
+	default:
+		return fmt.Errorf("write.mode %q is not supported, must be one of %s, %s", cfg.Mode, writeModeDefault, writeModePending)
+	}
+	if cfg.Keepalive.Time != 0 && cfg.Keepalive.Time < minKeepaliveTime {
+		return fmt.Errorf("write.keepalive.time must be zero or at least %s", minKeepaliveTime)
+	}
+	if cfg.Keepalive.Timeout < 0 {
+		return errors.New("write.keepalive.timeout must not be negative")
+	}
+	if cfg.Keepalive.Timeout != 0 && cfg.Keepalive.Time == 0 {
+		return errors.New("write.keepalive.timeout requires write.keepalive.time")
+	}
+	switch cfg.Compression {
+	case "", writeCompressionNone, gzip.Name:
+	default:
+		return fmt.Errorf("write.compression %q is not supported, must be one of %s, %s", cfg.Compression, writeCompressionNone, gzip.Name)
+	}
+	if cfg.MaxSendMessageSizeMiB < 0 {
+		return errors.New("write.max_send_message_size_mib must not be negative")
+	}
+	if cfg.MaxRecvMessageSizeMiB < 0 {
+		return errors.New("write.max_recv_message_size_mib must not be negative")
+	}
+	if cfg.Workers < 1 {
+		return errors.New("write.workers must be at least 1")
+	}
+	if cfg.Workers > 1 && cfg.Mode == writeModePending {
+		return errors.New("write.workers must be 1 with write.mode pending")
+	}
+	if cfg.AppendTimeout < 0 {
+		return errors.New("write.append_timeout must not be negative")
+	}
+	if cfg.DebugLogSampleRate < 0 || cfg.DebugLogSampleRate > 1 {
+		return errors.New("write.debug_log_sample_rate must be between 0 and 1")
+	}
+	return validateLabels("write.trace_labels", cfg.TraceLabels)
+}
+
+func (cfg CredentialsConfig) validate(field string) error {
+	if sa := cfg.ImpersonateServiceAccount; sa != "" && !strings.Contains(sa, "@") {
+		return fmt.Errorf("%s.impersonate_service_account must be a service account email, got %q", field, sa)
+	}
+	if cfg.File != "" && cfg.Secret != "" {
+		return fmt.Errorf("%s.file and %s.secret are mutually exclusive", field, field)
+	}
+	if cfg.Secret != "" && !secretVersionPattern.MatchString(cfg.Secret) {
+		return fmt.Errorf("%s.secret must match %s, got %q", field, secretVersionPattern.String(), cfg.Secret)
+	}
+	if cfg.ReloadInterval < 0 {
+		return fmt.Errorf("%s.reload_interval must not be negative", field)
+	}
+	if cfg.ReloadInterval > 0 && cfg.File == "" {
+		return fmt.Errorf("%s.reload_interval requires %s.file", field, field)
+	}
+	return nil
+}
+
+func validateProject(field, value string) error {
+	if strings.TrimSpace(value) != value {
+		return fmt.Errorf("%s must not contain leading or trailing whitespace", field)
+	}
+	return nil
+}
+
+func validateJSONColumns(field, value string) error {
+	switch value {
+	case "", jsonColumnsJSON, jsonColumnsString:
+		return nil
+	default:
+		return fmt.Errorf("%s %q is not supported, must be one of %s, %s", field, value, jsonColumnsJSON, jsonColumnsString)
+	}
+}
+
+// maxClusteringFields is the number of clustering columns BigQuery allows.
+const maxClusteringFields = 4
+
+// validateClusteringFields checks that fields are distinct columns of schema
+// with a type BigQuery can cluster by.
+func validateClusteringFields(field string, fields []string, schema bigquery.Schema) error {
+	if len(fields) > maxClusteringFields {
+		return fmt.Errorf("%s must not have more than %d columns", field, maxClusteringFields)
+	}
+	for i, name := range fields {
+		if slices.Contains(fields[:i], name) {
+			return fmt.Errorf("%s contains %q more than once", field, name)
+		}
+		idx := slices.IndexFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == name })
+		if idx < 0 {
+			return fmt.Errorf("%s: %q is not a column of the table", field, name)
+		}
+		switch schema[idx].Type {
+		case bigquery.FloatFieldType, bigquery.JSONFieldType, bigquery.BytesFieldType, bigquery.RecordFieldType:
+			return fmt.Errorf("%s: column %q of type %s cannot be used for clustering", field, name, schema[idx].Type)
+		}
+	}
+	return nil
+}
+
+// validatePolicyTags checks that tags map columns of schema to policy tag
+// resource names.
+func validatePolicyTags(field string, tags map[string]string, schema bigquery.Schema) error {
+	for _, column := range slices.Sorted(maps.Keys(tags)) {
+		if !slices.ContainsFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == column }) {
+			return fmt.Errorf("%s: %q is not a column of the table", field, column)
+		}
+		if !policyTagPattern.MatchString(tags[column]) {
+			return fmt.Errorf("%s: policy tag %q of column %q must be of the form projects/<project>/locations/<location>/taxonomies/<taxonomy>/policyTags/<tag>", field, tags[column], column)
+		}
+	}
+	return nil
+}
+
+// validateCollation checks that collation maps STRING columns of schema to
+// collation specifications.
+func validateCollation(field string, collation map[string]string, schema bigquery.Schema) error {
+	for _, column := range slices.Sorted(maps.Keys(collation)) {
+		idx := slices.IndexFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == column })
+		if idx < 0 {
+			return fmt.Errorf("%s: %q is not a column of the table", field, column)
+		}
+		if schema[idx].Type != bigquery.StringFieldType {
+			return fmt.Errorf("%s: column %q of type %s cannot have a collation, only STRING columns can", field, column, schema[idx].Type)
+		}
+		if collation[column] == "" {
+			return fmt.Errorf("%s: collation of column %q must not be empty", field, column)
+		}
+	}
+	return nil
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("scopes must not be empty")
+	}
+	for _, scope := range scopes {
+		if !strings.HasPrefix(scope, "https://www.googleapis.com/auth/") {
+			return fmt.Errorf("scope %q must be a https://www.googleapis.com/auth/ OAuth scope", scope)
+		}
+	}
+	return nil
+}
+
+func validateProxyURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("proxy_url is invalid: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("proxy_url scheme must be one of http, https or socks5, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("proxy_url must include a host")
+	}
 	return nil
 }
 
+// validateTableName validates a table name, which may be a time-shard
+// template such as logs_%Y%m%d.
+func validateTableName(field, value string) error {
+	if !isTableTemplate(value) {
+		return validateIdentifier(field, value)
+	}
+	expanded, err := expandTableTemplate(value, time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC))
+	if err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	return validateIdentifier(field, expanded)
+}
+
 func validateIdentifier(field, value string) error {
 	if value == "" {
 		return fmt.Errorf("%s is required", field)
@@ -79,14 +1808,60 @@ func validateIdentifier(field, value string) error {
 
 func createDefaultConfig() *Config {
 	return &Config{
-		BackOffConfig: configretry.NewDefaultBackOffConfig(),
-		QueueConfig:   configoptional.None[exporterhelper.QueueBatchConfig](),
+		BackOffConfig:    configretry.NewDefaultBackOffConfig(),
+		QueueConfig:      configoptional.None[exporterhelper.QueueBatchConfig](),
+		StartupRetry:     newDefaultStartupRetryConfig(),
+		TLS:              configoptional.None[configtls.ClientConfig](),
+		Scopes:           []string{bigquery.Scope},
+		SchemaPreset:     defaultSchemaPreset,
+		AutoCreateTables: true,
+		Write: WriteConfig{
+			Mode:    writeModeDefault,
+			Workers: 1,
+		},
 		Dataset: DatasetConfig{
 			Table: TableConfig{
-				Trace:  "trace",
-				Metric: "metric",
-				Log:    "log",
+				Trace:      "trace",
+				Metric:     "metric",
+				Log:        "log",
+				Entity:     "entity",
+				SpanEvent:  "trace_event",
+				SpanLink:   "trace_link",
+				Statistics: "append_statistics",
+				Resource:   "resource",
+				WideEvents: "wide_events",
 			},
+			ShardWindow: ShardWindowConfig{Past: 7 * 24 * time.Hour, Future: time.Hour},
+		},
+		Traces: TracesConfig{
+			Partitioning: PartitioningConfig{Granularity: string(bigquery.DayPartitioningType)},
+			EmptyValues:  EmptyValuesConfig{Policy: emptyValuesKeep, Placeholder: "unknown"},
+		},
+		Metrics: MetricsConfig{
+			Partitioning: PartitioningConfig{Granularity: string(bigquery.DayPartitioningType)},
+			EmptyValues:  EmptyValuesConfig{Policy: emptyValuesKeep, Placeholder: "unknown"},
+			Rollup:       RollupConfig{Granularity: "MINUTE", RefreshInterval: 30 * time.Minute},
+		},
+		Logs: LogsConfig{
+			Partitioning: PartitioningConfig{Granularity: string(bigquery.DayPartitioningType)},
+		},
+		EventDate: EventDateConfig{TimeZone: "UTC"},
+		Statistics: StatisticsConfig{
+			FlushInterval: time.Minute,
+		},
+		NormalizeResources: NormalizeResourcesConfig{
+			CacheSize: 100000,
+		},
+		WideEvents: WideEventsConfig{
+			ClusteringFields: []string{signalTypeColumn},
+		},
+		SchemaSnapshot: SchemaSnapshotConfig{
+			Suffix:    "_snapshot_%Y%m%d%H",
+			Retention: 7 * 24 * time.Hour,
+		},
+		AdaptiveSlim: AdaptiveSlimConfig{
+			Threshold: 3,
+			Cooldown:  5 * time.Minute,
 		},
 		TimeoutConfig: exporterhelper.TimeoutConfig{
 			Timeout: 30 * time.Second,
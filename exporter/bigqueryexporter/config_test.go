@@ -4,12 +4,17 @@
 package bigqueryexporter
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/config/configtls"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
 )
 
@@ -31,6 +36,18 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, "log", cfg.Dataset.Table.Log)
 		assert.Equal(t, 30*time.Second, cfg.TimeoutConfig.Timeout)
 		assert.False(t, cfg.QueueConfig.HasValue())
+		assert.Equal(t, defaultSchemaPreset, cfg.SchemaPreset)
+		assert.Equal(t, writeModeDefault, cfg.Write.Mode)
+		assert.Equal(t, 1, cfg.Write.Workers)
+		assert.Equal(t, PartitioningConfig{Granularity: "DAY"}, cfg.Metrics.Partitioning)
+		assert.False(t, cfg.Statistics.Enabled)
+		assert.Equal(t, "append_statistics", cfg.Dataset.Table.Statistics)
+		assert.Equal(t, NormalizeResourcesConfig{CacheSize: 100000}, cfg.NormalizeResources)
+		assert.Equal(t, "resource", cfg.Dataset.Table.Resource)
+		assert.Equal(t, WideEventsConfig{ClusteringFields: []string{"signal_type"}}, cfg.WideEvents)
+		assert.Equal(t, "wide_events", cfg.Dataset.Table.WideEvents)
+		assert.False(t, cfg.TLS.HasValue())
+		assert.Equal(t, []string{"https://www.googleapis.com/auth/bigquery"}, cfg.Scopes)
 	})
 	t.Run("no_project", func(t *testing.T) {
 		sub, subErr := cm.Sub("bigquery/no_project")
@@ -57,16 +74,132 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, "custom_traces", cfg.Dataset.Table.Trace)
 		assert.Equal(t, "custom_metrics", cfg.Dataset.Table.Metric)
 		assert.Equal(t, "custom_logs", cfg.Dataset.Table.Log)
+		assert.Equal(t, "/etc/bigquery/key.json", cfg.Dataset.Credentials.File)
+		assert.Equal(t, "writer@my-project.iam.gserviceaccount.com", cfg.Dataset.Credentials.ImpersonateServiceAccount)
+		assert.Equal(t, time.Minute, cfg.Dataset.Credentials.ReloadInterval)
+		assert.Equal(t, ShardWindowConfig{Past: 24 * time.Hour, Future: 10 * time.Minute}, cfg.Dataset.ShardWindow)
 		assert.Equal(t, 30*time.Second, cfg.TimeoutConfig.Timeout)
 		assert.True(t, cfg.BackOffConfig.Enabled)
 		assert.Equal(t, 5*time.Second, cfg.BackOffConfig.InitialInterval)
 		assert.Equal(t, 30*time.Second, cfg.BackOffConfig.MaxInterval)
 		assert.Equal(t, 300*time.Second, cfg.BackOffConfig.MaxElapsedTime)
+		assert.Equal(t, 2*time.Second, cfg.StartupRetry.InitialInterval)
+		assert.Equal(t, 20*time.Second, cfg.StartupRetry.MaxInterval)
+		assert.Equal(t, 5*time.Minute, cfg.StartupRetry.MaxElapsedTime)
 
 		require.True(t, cfg.QueueConfig.HasValue())
 		qcfg := cfg.QueueConfig.Get()
 		assert.Equal(t, 10, qcfg.NumConsumers)
 		assert.Equal(t, int64(1000), qcfg.QueueSize)
+		assert.Equal(t, "fleet-a", cfg.UserAgentSuffix)
+		assert.Equal(t, "http://proxy.internal:3128", cfg.ProxyURL)
+		assert.Equal(t, "slim", cfg.SchemaPreset)
+		assert.Equal(t, writeModePending, cfg.Write.Mode)
+		assert.Equal(t, time.Minute, cfg.Write.CommitInterval)
+		require.True(t, cfg.TLS.HasValue())
+		assert.Equal(t, "/etc/ssl/gateway-ca.pem", cfg.TLS.Get().CAFile)
+		assert.Equal(t, "/etc/ssl/client.pem", cfg.TLS.Get().CertFile)
+		assert.Equal(t, "/etc/ssl/client-key.pem", cfg.TLS.Get().KeyFile)
+		assert.Equal(t, []string{"https://www.googleapis.com/auth/bigquery.insertdata"}, cfg.Scopes)
+		assert.True(t, cfg.Logs.TraceContextFromAttributes)
+		assert.True(t, cfg.Logs.EntityEvents)
+		assert.Equal(t, "entity", cfg.Dataset.Table.Entity)
+		assert.True(t, cfg.PreflightAuthCheck)
+		assert.Equal(t, []string{"exception", "message"}, cfg.Traces.IncludeEventNames)
+		assert.True(t, cfg.Write.WarmUp)
+		assert.Equal(t, KeepaliveConfig{Time: time.Minute, Timeout: 10 * time.Second}, cfg.Write.Keepalive)
+		assert.Equal(t, 16, cfg.Write.MaxSendMessageSizeMiB)
+		assert.Equal(t, "gzip", cfg.Write.Compression)
+		assert.Equal(t, 1, cfg.Write.Workers)
+		assert.Equal(t, 15*time.Second, cfg.Write.AppendTimeout)
+		assert.Equal(t, map[string]string{"team": "observability", "pipeline": "edge-logs"}, cfg.Write.TraceLabels)
+		assert.Equal(t, 0.01, cfg.Write.DebugLogSampleRate)
+		assert.Equal(t, "analytics-project", cfg.Traces.Project)
+		assert.Empty(t, cfg.Metrics.Project)
+		assert.Equal(t, jsonColumnsString, cfg.Metrics.JSONColumns)
+		assert.Equal(t, "security-project", cfg.Logs.Project)
+		assert.Equal(t, "audit-writer@security-project.iam.gserviceaccount.com", cfg.Logs.Credentials.ImpersonateServiceAccount)
+		assert.Equal(t, "custom_statistics", cfg.Dataset.Table.Statistics)
+		assert.Equal(t, 168*time.Hour, cfg.Dataset.TableExpiration)
+		assert.Equal(t, 64, cfg.MemoryLimitMiB)
+		assert.Equal(t, []string{"trace_id"}, cfg.Traces.ClusteringFields)
+		assert.True(t, cfg.Traces.StatusClass)
+		assert.True(t, cfg.Traces.SpanHierarchy)
+		assert.True(t, cfg.Traces.FlagColumns)
+		assert.True(t, cfg.Traces.CountColumns)
+		assert.True(t, cfg.Traces.ChildTables)
+		assert.Equal(t, "custom_trace_events", cfg.Dataset.Table.SpanEvent)
+		assert.Equal(t, "trace_link", cfg.Dataset.Table.SpanLink)
+		assert.Equal(t, []string{"severity_text", "trace_id"}, cfg.Logs.ClusteringFields)
+		assert.Equal(t, map[string]string{"body": "projects/my-project/locations/us/taxonomies/123/policyTags/456"}, cfg.Logs.PolicyTags)
+		assert.Empty(t, cfg.Traces.PolicyTags)
+		assert.Equal(t, map[string]string{"severity_text": "und:ci"}, cfg.Logs.Collation)
+		assert.Equal(t, "event", cfg.Logs.PartitionTimestamp)
+		assert.Empty(t, cfg.Metrics.ClusteringFields)
+		assert.Equal(t, PartitioningConfig{Field: "start_time", Granularity: "HOUR"}, cfg.Traces.Partitioning)
+		assert.Equal(t, 720*time.Hour, cfg.Traces.RowRetention)
+		assert.True(t, cfg.Dataset.AutoCreate)
+		assert.Equal(t, "US", cfg.Dataset.Location)
+		assert.Equal(t, "us", cfg.Dataset.ExpectedLocation)
+		assert.Equal(t, 720*time.Hour, cfg.Dataset.DefaultTableExpiration)
+		assert.Equal(t, 168*time.Hour, cfg.Dataset.DefaultPartitionExpiration)
+		assert.Equal(t, PartitioningConfig{Granularity: "DAY"}, cfg.Logs.Partitioning)
+		assert.Equal(t, PartitioningConfig{Field: "count", Granularity: "DAY", Range: RangePartitioningConfig{Start: 0, End: 100000, Interval: 1000}}, cfg.Metrics.Partitioning)
+		assert.True(t, cfg.Dataset.UpdateTableExpiration)
+		assert.Equal(t, map[string]string{"team": "observability", "cost-center": "cc-1234"}, cfg.Dataset.TableLabels)
+		assert.Equal(t, "OpenTelemetry data exported by the collector", cfg.Dataset.TableDescription)
+		assert.True(t, cfg.Dataset.UpdateTableMetadata)
+		assert.True(t, cfg.AutoCreateTables)
+		assert.True(t, cfg.AllowSchemaUpdate)
+		assert.Equal(t, SchemaSnapshotConfig{Enabled: true, Suffix: "_pre_upgrade_%Y%m%d", Retention: 720 * time.Hour}, cfg.SchemaSnapshot)
+		assert.True(t, cfg.Watermark)
+		assert.True(t, cfg.CollectorColumns)
+		assert.Equal(t, []string{"kubernetes"}, cfg.PromotePresets)
+		assert.True(t, cfg.ProbeCapabilities)
+		assert.True(t, cfg.CreateViews)
+		assert.True(t, cfg.CreateUDFs)
+		assert.Equal(t, 5*time.Second, cfg.TableCreateJitter)
+		assert.Equal(t, DualWriteConfig{Enabled: true, TableSuffix: "_legacy", SchemaPreset: "compat"}, cfg.DualWrite)
+		assert.Equal(t, EventDateConfig{Enabled: true, TimeZone: "Asia/Tokyo"}, cfg.EventDate)
+		assert.Equal(t, AdaptiveSlimConfig{Enabled: true, Threshold: 5, Cooldown: 10 * time.Minute}, cfg.AdaptiveSlim)
+		assert.Equal(t, "my_traces", cfg.Traces.Dataset)
+		assert.Empty(t, cfg.Metrics.Dataset)
+		assert.Equal(t, "my_audit_logs", cfg.Logs.Dataset)
+		assert.Equal(t, EmptyValuesConfig{Policy: "placeholder", Placeholder: "<unnamed>"}, cfg.Traces.EmptyValues)
+		assert.Equal(t, []PromotedAttributeConfig{
+			{Key: "service.name", Source: "resource"},
+			{Key: "http.response.status_code", Source: "span", Column: "http_status", Type: "INT64"},
+			{Key: "enduser.id", Source: "span", MaxCardinality: 10000},
+		}, cfg.Traces.PromotedAttributes)
+		assert.Equal(t, []ComputedColumnConfig{
+			{Column: "tenant", Expression: `resource.attributes["X-Tenant"]`},
+			{Column: "is_error", Expression: "status.code == STATUS_CODE_ERROR", Type: "BOOL"},
+		}, cfg.Traces.ComputedColumns)
+		assert.Equal(t, AttributeFiltersConfig{
+			Resource: AttributeFilterConfig{Exclude: []string{"process.command_line"}},
+			Record:   AttributeFilterConfig{ExcludePatterns: []string{`^http\.request\.header\.`}},
+		}, cfg.Traces.AttributeFilters)
+		assert.True(t, cfg.Logs.RawOTLP)
+		assert.True(t, cfg.Logs.BodyJSON)
+		assert.Equal(t, map[string]int{"body": 65536, "log_attributes": 32768}, cfg.Logs.MaxColumnBytes)
+		assert.Equal(t, []string{"enduser.id"}, cfg.AnonymizeAttributes.Hash)
+		assert.Equal(t, []string{"client.address"}, cfg.AnonymizeAttributes.Redact)
+		assert.Equal(t, configopaque.String("pepper"), cfg.AnonymizeAttributes.Salt)
+		assert.Equal(t, scopeColumnsInstead, cfg.Logs.ScopeColumns)
+		assert.Equal(t, SeverityConfig{Enabled: true, TextMapping: map[string]string{"sev-3": "ERROR"}}, cfg.Logs.Severity)
+		assert.True(t, cfg.Metrics.ExponentialHistogramColumns)
+		assert.True(t, cfg.Metrics.ExemplarSpans)
+		assert.True(t, cfg.Metrics.NormalizeUnits)
+		assert.Equal(t, MetricNamesConfig{Normalization: "prometheus", Prefix: "otel_"}, cfg.Metrics.MetricNames)
+		assert.Equal(t, EmptyValuesConfig{Policy: "drop", Placeholder: "unknown"}, cfg.Metrics.EmptyValues)
+		assert.Equal(t, RollupConfig{Enabled: true, Granularity: "HOUR", RefreshInterval: 30 * time.Minute}, cfg.Metrics.Rollup)
+		assert.Equal(t, "projects/my-project/locations/us/keyRings/otel/cryptoKeys/bigquery", cfg.Dataset.KMSKeyName)
+		assert.Equal(t, StatisticsConfig{Enabled: true, FlushInterval: 5 * time.Minute}, cfg.Statistics)
+		assert.Equal(t, NormalizeResourcesConfig{CacheSize: 5000}, cfg.NormalizeResources)
+		assert.True(t, cfg.ResourceHash)
+		assert.Equal(t, "custom_resource", cfg.Dataset.Table.Resource)
+		assert.Equal(t, WideEventsConfig{ClusteringFields: []string{"signal_type", "service_name"}}, cfg.WideEvents)
+		assert.Equal(t, "custom_wide_events", cfg.Dataset.Table.WideEvents)
 	})
 }
 
@@ -105,17 +238,1710 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "signal project override",
+			mutate: func(c *Config) {
+				c.Logs.Project = "security-project"
+			},
+		},
+		{
+			name: "signal project has surrounding whitespace",
+			mutate: func(c *Config) {
+				c.Metrics.Project = "metrics-project\n"
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid dataset identifier",
 			mutate: func(c *Config) {
-				c.Dataset.ID = "otel-dataset"
+				c.Dataset.ID = "otel-dataset"
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid traces table identifier",
+			mutate: func(c *Config) {
+				c.Dataset.Table.Trace = "trace-events"
+			},
+			wantErr: true,
+		},
+		{
+			name: "logs partition timestamp",
+			mutate: func(c *Config) {
+				c.Logs.PartitionTimestamp = "observed"
+				c.Logs.ClusteringFields = []string{"partition_timestamp"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported logs partition timestamp",
+			mutate: func(c *Config) {
+				c.Logs.PartitionTimestamp = "ingestion"
+			},
+			wantErr: true,
+		},
+		{
+			name: "logs partitioned on partition timestamp without it",
+			mutate: func(c *Config) {
+				c.Logs.Partitioning.Field = "partition_timestamp"
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trace event table identifier",
+			mutate: func(c *Config) {
+				c.Dataset.Table.SpanEvent = "trace-event"
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trace link table identifier",
+			mutate: func(c *Config) {
+				c.Dataset.Table.SpanLink = "trace link"
+			},
+			wantErr: true,
+		},
+		{
+			name: "child tables with policy tag on events",
+			mutate: func(c *Config) {
+				c.Traces.ChildTables = true
+				c.Traces.PolicyTags = map[string]string{"events": "projects/p/locations/us/taxonomies/1/policyTags/2"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "user agent suffix with line break",
+			mutate: func(c *Config) {
+				c.UserAgentSuffix = "fleet\r\nX-Injected: 1"
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid proxy url",
+			mutate: func(c *Config) {
+				c.ProxyURL = "http://proxy.internal:3128"
+			},
+			wantErr: false,
+		},
+		{
+			name: "proxy url with unsupported scheme",
+			mutate: func(c *Config) {
+				c.ProxyURL = "ftp://proxy.internal"
+			},
+			wantErr: true,
+		},
+		{
+			name: "proxy url without host",
+			mutate: func(c *Config) {
+				c.ProxyURL = "http://"
+			},
+			wantErr: true,
+		},
+		{
+			name: "compat schema preset",
+			mutate: func(c *Config) {
+				c.SchemaPreset = "compat"
+			},
+			wantErr: false,
+		},
+		{
+			name: "structured schema preset",
+			mutate: func(c *Config) {
+				c.SchemaPreset = "structured"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown schema preset",
+			mutate: func(c *Config) {
+				c.SchemaPreset = "wide"
+			},
+			wantErr: true,
+		},
+		{
+			name: "pending write mode with commit windows",
+			mutate: func(c *Config) {
+				c.Write.Mode = writeModePending
+				c.Write.CommitInterval = time.Minute
+			},
+			wantErr: false,
+		},
+		{
+			name: "commit interval without pending mode",
+			mutate: func(c *Config) {
+				c.Write.CommitInterval = time.Minute
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative commit interval",
+			mutate: func(c *Config) {
+				c.Write.Mode = writeModePending
+				c.Write.CommitInterval = -time.Second
+			},
+			wantErr: true,
+		},
+		{
+			name: "string json columns",
+			mutate: func(c *Config) {
+				c.Metrics.JSONColumns = jsonColumnsString
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown json columns type",
+			mutate: func(c *Config) {
+				c.Traces.JSONColumns = "text"
+			},
+			wantErr: true,
+		},
+		{
+			name: "keepalive below gRPC minimum",
+			mutate: func(c *Config) {
+				c.Write.Keepalive.Time = time.Second
+			},
+			wantErr: true,
+		},
+		{
+			name: "keepalive timeout without time",
+			mutate: func(c *Config) {
+				c.Write.Keepalive.Timeout = 5 * time.Second
+			},
+			wantErr: true,
+		},
+		{
+			name: "gzip compression",
+			mutate: func(c *Config) {
+				c.Write.Compression = "gzip"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported compression",
+			mutate: func(c *Config) {
+				c.Write.Compression = "snappy"
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max message size",
+			mutate: func(c *Config) {
+				c.Write.MaxRecvMessageSizeMiB = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "concurrent appends per table",
+			mutate: func(c *Config) {
+				c.Write.Workers = 4
+				c.Write.AppendTimeout = 10 * time.Second
+			},
+			wantErr: false,
+		},
+		{
+			name: "no workers",
+			mutate: func(c *Config) {
+				c.Write.Workers = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "concurrent appends in pending mode",
+			mutate: func(c *Config) {
+				c.Write.Mode = writeModePending
+				c.Write.Workers = 2
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative append timeout",
+			mutate: func(c *Config) {
+				c.Write.AppendTimeout = -time.Second
+			},
+			wantErr: true,
+		},
+		{
+			name: "statistics table",
+			mutate: func(c *Config) {
+				c.Statistics.Enabled = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "statistics without flush interval",
+			mutate: func(c *Config) {
+				c.Statistics = StatisticsConfig{Enabled: true}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid statistics table",
+			mutate: func(c *Config) {
+				c.Dataset.Table.Statistics = "append-statistics"
+			},
+			wantErr: true,
+		},
+		{
+			name: "table expiration",
+			mutate: func(c *Config) {
+				c.Dataset.TableExpiration = 24 * time.Hour
+				c.Dataset.UpdateTableExpiration = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative table expiration",
+			mutate: func(c *Config) {
+				c.Dataset.TableExpiration = -time.Hour
+			},
+			wantErr: true,
+		},
+		{
+			name: "update table expiration without expiration",
+			mutate: func(c *Config) {
+				c.Dataset.UpdateTableExpiration = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "table labels and description",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{"team": "observability", "cost-center": "", "env_2": "prod-eu"}
+				c.Dataset.TableDescription = "OpenTelemetry data"
+				c.Dataset.UpdateTableMetadata = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "table label key with uppercase letters",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{"Team": "observability"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "table label key starting with a digit",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{"1team": "observability"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "table label value too long",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{"team": strings.Repeat("a", 64)}
+			},
+			wantErr: true,
+		},
+		{
+			name: "table label value with invalid characters",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{"team": "a.b"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many table labels",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{}
+				for i := range maxTableLabels + 1 {
+					c.Dataset.TableLabels[fmt.Sprintf("label_%d", i)] = ""
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "no room for the schema version label",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{}
+				for i := range maxTableLabels {
+					c.Dataset.TableLabels[fmt.Sprintf("label_%d", i)] = ""
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "update table metadata without labels or description",
+			mutate: func(c *Config) {
+				c.Dataset.UpdateTableMetadata = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "time-sharded table names",
+			mutate: func(c *Config) {
+				c.Dataset.Table.Trace = "trace_%Y%m%d%H"
+				c.Dataset.Table.Log = "log_%Y%m"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported table name placeholder",
+			mutate: func(c *Config) {
+				c.Dataset.Table.Log = "log_%j"
+			},
+			wantErr: true,
+		},
+		{
+			name: "time-sharded statistics table",
+			mutate: func(c *Config) {
+				c.Dataset.Table.Statistics = "append_statistics_%Y"
+			},
+			wantErr: true,
+		},
+		{
+			name: "per-signal datasets",
+			mutate: func(c *Config) {
+				c.Traces.Dataset = "otel_traces"
+				c.Metrics.Dataset = "otel_metrics"
+				c.Logs.Dataset = "otel_logs"
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid per-signal dataset",
+			mutate: func(c *Config) {
+				c.Logs.Dataset = "otel-logs"
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty values policies",
+			mutate: func(c *Config) {
+				c.Traces.EmptyValues = EmptyValuesConfig{Policy: "placeholder", Placeholder: "<unnamed>"}
+				c.Metrics.EmptyValues.Policy = "fail"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported empty values policy",
+			mutate: func(c *Config) {
+				c.Traces.EmptyValues.Policy = "ignore"
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty values placeholder policy without placeholder",
+			mutate: func(c *Config) {
+				c.Metrics.EmptyValues = EmptyValuesConfig{Policy: "placeholder"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "upsert",
+			mutate: func(c *Config) {
+				c.Traces.Upsert = UpsertConfig{Enabled: true, MaxStaleness: 15 * time.Minute}
+				c.Metrics.Upsert.Enabled = true
+				c.Metrics.ClusteringFields = []string{"series_id"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "upsert with pending write mode",
+			mutate: func(c *Config) {
+				c.Metrics.Upsert.Enabled = true
+				c.Write.Mode = writeModePending
+			},
+			wantErr: true,
+		},
+		{
+			name: "upsert max staleness without upsert",
+			mutate: func(c *Config) {
+				c.Traces.Upsert.MaxStaleness = time.Minute
+			},
+			wantErr: true,
+		},
+		{
+			name: "series id clustering without upsert",
+			mutate: func(c *Config) {
+				c.Metrics.ClusteringFields = []string{"series_id"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attributes",
+			mutate: func(c *Config) {
+				c.Traces.PromotedAttributes = []PromotedAttributeConfig{{Source: "resource", Key: "service.name"}}
+				c.Traces.ClusteringFields = []string{"service_name"}
+				c.Logs.PromotedAttributes = []PromotedAttributeConfig{{Source: "log", Key: "http.response.status_code", Column: "http_status", Type: "INT64"}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "promoted attribute with source of another signal",
+			mutate: func(c *Config) {
+				c.Metrics.PromotedAttributes = []PromotedAttributeConfig{{Source: "span", Key: "a"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with a built-in column",
+			mutate: func(c *Config) {
+				c.Logs.PromotedAttributes = []PromotedAttributeConfig{{Source: "log", Key: "severity.text", Column: "severity_text"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with a disabled optional column",
+			mutate: func(c *Config) {
+				c.Metrics.PromotedAttributes = []PromotedAttributeConfig{{Source: "resource", Key: "series.id"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "attribute filters",
+			mutate: func(c *Config) {
+				c.Metrics.AttributeFilters.Resource = AttributeFilterConfig{Exclude: []string{"host.id"}, ExcludePatterns: []string{`^process\.`}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "attribute filter with invalid pattern",
+			mutate: func(c *Config) {
+				c.Logs.AttributeFilters.Record.IncludePatterns = []string{"["}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with raw_otlp",
+			mutate: func(c *Config) {
+				c.Logs.PromotedAttributes = []PromotedAttributeConfig{{Source: "log", Key: "raw.otlp"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "bytes id columns",
+			mutate: func(c *Config) {
+				c.IDColumns = "bytes"
+			},
+		},
+		{
+			name: "unsupported id columns",
+			mutate: func(c *Config) {
+				c.IDColumns = "base64"
+			},
+			wantErr: true,
+		},
+		{
+			name: "bytes id columns clustering by trace_id",
+			mutate: func(c *Config) {
+				c.IDColumns = "bytes"
+				c.Logs.ClusteringFields = []string{"trace_id"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with exponential histogram columns",
+			mutate: func(c *Config) {
+				c.Metrics.PromotedAttributes = []PromotedAttributeConfig{{Source: "datapoint", Key: "scale"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with flag columns",
+			mutate: func(c *Config) {
+				c.Traces.PromotedAttributes = []PromotedAttributeConfig{{Source: "span", Key: "sampled"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported severity level",
+			mutate: func(c *Config) {
+				c.Logs.Severity = SeverityConfig{Enabled: true, TextMapping: map[string]string{"sev-3": "CRITICAL"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with severity",
+			mutate: func(c *Config) {
+				c.Logs.PromotedAttributes = []PromotedAttributeConfig{{Source: "log", Key: "severity"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "column names",
+			mutate: func(c *Config) {
+				c.Logs.ColumnNames = map[string]string{"log_timestamp": "timestamp", "body": "message"}
+			},
+		},
+		{
+			name: "column name of another column",
+			mutate: func(c *Config) {
+				c.Logs.ColumnNames = map[string]string{"body": "severity_text"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "column names with views",
+			mutate: func(c *Config) {
+				c.CreateViews = true
+				c.Traces.ColumnNames = map[string]string{"name": "span_name"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "column names with rollup",
+			mutate: func(c *Config) {
+				c.Metrics.Rollup.Enabled = true
+				c.Metrics.ColumnNames = map[string]string{"metric_name": "name"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "key value attributes",
+			mutate: func(c *Config) {
+				c.Traces.AttributesEncoding = attributesEncodingKeyValue
+				c.Metrics.AttributesEncoding = attributesEncodingKeyValue
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown attributes encoding",
+			mutate: func(c *Config) {
+				c.Logs.AttributesEncoding = "map"
+			},
+			wantErr: true,
+		},
+		{
+			name: "key value attributes with views",
+			mutate: func(c *Config) {
+				c.CreateViews = true
+				c.Logs.AttributesEncoding = attributesEncodingKeyValue
+			},
+			wantErr: true,
+		},
+		{
+			name: "normalize resources",
+			mutate: func(c *Config) {
+				c.NormalizeResources.Enabled = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "normalize resources without cache",
+			mutate: func(c *Config) {
+				c.NormalizeResources = NormalizeResourcesConfig{Enabled: true}
+			},
+			wantErr: true,
+		},
+		{
+			name: "normalize resources with views",
+			mutate: func(c *Config) {
+				c.CreateViews = true
+				c.NormalizeResources.Enabled = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "normalize resources with rollup",
+			mutate: func(c *Config) {
+				c.Metrics.Rollup.Enabled = true
+				c.NormalizeResources.Enabled = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "normalize resources with key value attributes",
+			mutate: func(c *Config) {
+				c.NormalizeResources.Enabled = true
+				c.Logs.AttributesEncoding = attributesEncodingKeyValue
+			},
+			wantErr: true,
+		},
+		{
+			name: "clustering by normalized resource column",
+			mutate: func(c *Config) {
+				c.NormalizeResources.Enabled = true
+				c.Metrics.ClusteringFields = []string{"resource_schema_url"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid resource table",
+			mutate: func(c *Config) {
+				c.Dataset.Table.Resource = "resource-table"
+			},
+			wantErr: true,
+		},
+		{
+			name: "scope columns",
+			mutate: func(c *Config) {
+				c.Traces.ScopeColumns = scopeColumnsAlongside
+				c.Metrics.ScopeColumns = scopeColumnsInstead
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown scope columns",
+			mutate: func(c *Config) {
+				c.Logs.ScopeColumns = "both"
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with scope column",
+			mutate: func(c *Config) {
+				c.Logs.PromotedAttributes = []PromotedAttributeConfig{{Key: "scope_name"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "group datapoints",
+			mutate: func(c *Config) {
+				c.Metrics.GroupDataPoints = true
+				c.Metrics.RawOTLP = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "group datapoints with upsert",
+			mutate: func(c *Config) {
+				c.Metrics.GroupDataPoints = true
+				c.Metrics.Upsert.Enabled = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "group datapoints with create views",
+			mutate: func(c *Config) {
+				c.Metrics.GroupDataPoints = true
+				c.CreateViews = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "clustering by grouped datapoint column",
+			mutate: func(c *Config) {
+				c.Metrics.GroupDataPoints = true
+				c.Metrics.ClusteringFields = []string{"value_int"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with body_json",
+			mutate: func(c *Config) {
+				c.Logs.BodyJSON = true
+				c.Logs.PromotedAttributes = []PromotedAttributeConfig{{Key: "body.json", Source: "log"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "anonymize attributes",
+			mutate: func(c *Config) {
+				c.AnonymizeAttributes = AnonymizeAttributesConfig{Hash: []string{"enduser.id"}, Redact: []string{"client.address"}, Salt: "pepper"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty anonymized attribute",
+			mutate: func(c *Config) {
+				c.AnonymizeAttributes.Redact = []string{""}
+			},
+			wantErr: true,
+		},
+		{
+			name: "attribute both hashed and redacted",
+			mutate: func(c *Config) {
+				c.AnonymizeAttributes = AnonymizeAttributesConfig{Hash: []string{"enduser.id"}, Redact: []string{"enduser.id"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "max column bytes",
+			mutate: func(c *Config) {
+				c.Traces.MaxColumnBytes = map[string]int{"events": 100000, "span_attributes": 32768}
+				c.Logs.MaxColumnBytes = map[string]int{"body": 65536}
+			},
+			wantErr: false,
+		},
+		{
+			name: "max column bytes of child table columns",
+			mutate: func(c *Config) {
+				c.Traces.ChildTables = true
+				c.Traces.MaxColumnBytes = map[string]int{"event_attributes": 4096, "link_attributes": 4096}
+			},
+			wantErr: false,
+		},
+		{
+			name: "max column bytes of child table column without child tables",
+			mutate: func(c *Config) {
+				c.Traces.MaxColumnBytes = map[string]int{"event_attributes": 4096}
+			},
+			wantErr: true,
+		},
+		{
+			name: "max column bytes of unknown column",
+			mutate: func(c *Config) {
+				c.Logs.MaxColumnBytes = map[string]int{"message": 65536}
+			},
+			wantErr: true,
+		},
+		{
+			name: "max column bytes of integer column",
+			mutate: func(c *Config) {
+				c.Metrics.MaxColumnBytes = map[string]int{"value_int": 8}
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-positive max column bytes",
+			mutate: func(c *Config) {
+				c.Logs.MaxColumnBytes = map[string]int{"body": 0}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with collector column",
+			mutate: func(c *Config) {
+				c.CollectorColumns = true
+				c.Traces.PromotedAttributes = []PromotedAttributeConfig{{Key: "collector.version", Source: "resource"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "kubernetes promote preset",
+			mutate: func(c *Config) {
+				c.PromotePresets = []string{"kubernetes"}
+				c.Traces.PromotedAttributes = []PromotedAttributeConfig{{Key: "k8s.pod.name", Source: "resource", Type: "STRING"}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "http promote preset with status class",
+			mutate: func(c *Config) {
+				c.PromotePresets = []string{"kubernetes", "http"}
+				c.Traces.StatusClass = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown promote preset",
+			mutate: func(c *Config) {
+				c.PromotePresets = []string{"nomad"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate promote preset",
+			mutate: func(c *Config) {
+				c.PromotePresets = []string{"kubernetes", "kubernetes"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promote preset column renamed to an exporter column",
+			mutate: func(c *Config) {
+				c.PromotePresets = []string{"kubernetes"}
+				c.Logs.ColumnNames = map[string]string{"body": "k8s_node_name"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "exemplar spans with bytes id columns",
+			mutate: func(c *Config) {
+				c.Metrics.ExemplarSpans = true
+				c.Metrics.GroupDataPoints = true
+				c.IDColumns = "bytes"
+			},
+			wantErr: false,
+		},
+		{
+			name: "promoted attribute colliding with exemplar_spans",
+			mutate: func(c *Config) {
+				c.Metrics.ExemplarSpans = true
+				c.Metrics.PromotedAttributes = []PromotedAttributeConfig{{Source: "datapoint", Key: "exemplar.spans"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "resource hash",
+			mutate: func(c *Config) {
+				c.ResourceHash = true
+				c.Metrics.ClusteringFields = []string{resourceHashColumn}
+			},
+			wantErr: false,
+		},
+		{
+			name: "resource hash with key value attributes",
+			mutate: func(c *Config) {
+				c.ResourceHash = true
+				c.Traces.AttributesEncoding = attributesEncodingKeyValue
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with resource_hash",
+			mutate: func(c *Config) {
+				c.ResourceHash = true
+				c.Traces.PromotedAttributes = []PromotedAttributeConfig{{Key: "resource.hash"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "computed columns",
+			mutate: func(c *Config) {
+				c.Traces.ComputedColumns = []ComputedColumnConfig{{Column: "is_error", Expression: "status.code == STATUS_CODE_ERROR", Type: "BOOL"}}
+				c.Metrics.ComputedColumns = []ComputedColumnConfig{{Column: "host", Expression: `attributes["host"]`}}
+				c.Logs.ComputedColumns = []ComputedColumnConfig{{Column: "tenant", Expression: `resource.attributes["X-Tenant"]`}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "computed column with invalid expression",
+			mutate: func(c *Config) {
+				c.Traces.ComputedColumns = []ComputedColumnConfig{{Column: "tenant", Expression: "Unknown(name)"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "computed column without expression",
+			mutate: func(c *Config) {
+				c.Logs.ComputedColumns = []ComputedColumnConfig{{Column: "tenant"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "computed column with invalid type",
+			mutate: func(c *Config) {
+				c.Metrics.ComputedColumns = []ComputedColumnConfig{{Column: "host", Expression: `attributes["host"]`, Type: "DATE"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "computed column colliding with promoted attribute",
+			mutate: func(c *Config) {
+				c.Traces.PromotedAttributes = []PromotedAttributeConfig{{Key: "tenant"}}
+				c.Traces.ComputedColumns = []ComputedColumnConfig{{Column: "Tenant", Expression: `resource.attributes["tenant"]`}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "computed column colliding with exporter column",
+			mutate: func(c *Config) {
+				c.Logs.ComputedColumns = []ComputedColumnConfig{{Column: "body", Expression: "body"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate computed column",
+			mutate: func(c *Config) {
+				c.Logs.ComputedColumns = []ComputedColumnConfig{{Column: "a", Expression: "body"}, {Column: "a", Expression: "body"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with normalized_unit",
+			mutate: func(c *Config) {
+				c.Metrics.PromotedAttributes = []PromotedAttributeConfig{{Source: "datapoint", Key: "normalized.unit"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported metric name normalization",
+			mutate: func(c *Config) {
+				c.Metrics.MetricNames.Normalization = "snake_case"
+			},
+			wantErr: true,
+		},
+		{
+			name: "wide events",
+			mutate: func(c *Config) {
+				c.WideEvents.Enabled = true
+				c.Watermark = true
+				c.Traces.ComputedColumns = []ComputedColumnConfig{{Column: "tenant", Expression: `resource.attributes["tenant"]`}}
+				c.Logs.ComputedColumns = []ComputedColumnConfig{{Column: "tenant", Expression: `resource.attributes["tenant"]`}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "wide events with dual write",
+			mutate: func(c *Config) {
+				c.WideEvents.Enabled = true
+				c.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "_legacy", SchemaPreset: "compat"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "wide events with signal dataset",
+			mutate: func(c *Config) {
+				c.WideEvents.Enabled = true
+				c.Logs.Dataset = "audit"
+			},
+			wantErr: true,
+		},
+		{
+			name: "wide events with signal partitioning",
+			mutate: func(c *Config) {
+				c.WideEvents.Enabled = true
+				c.Logs.PartitionTimestamp = "event"
+			},
+			wantErr: true,
+		},
+		{
+			name: "wide events with conflicting column types",
+			mutate: func(c *Config) {
+				c.WideEvents.Enabled = true
+				c.Traces.ComputedColumns = []ComputedColumnConfig{{Column: "tenant", Expression: `resource.attributes["tenant"]`}}
+				c.Logs.ComputedColumns = []ComputedColumnConfig{{Column: "tenant", Expression: "severity_number", Type: "INT64"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "wide events clustering field missing",
+			mutate: func(c *Config) {
+				c.WideEvents.Enabled = true
+				c.WideEvents.ClusteringFields = []string{"signal_type", "missing"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid wide events table",
+			mutate: func(c *Config) {
+				c.Dataset.Table.WideEvents = "wide_%Y%m%d"
+			},
+			wantErr: true,
+		},
+		{
+			name: "int64 enum encoding",
+			mutate: func(c *Config) {
+				c.Traces.EnumEncoding = "int64"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported enum encoding",
+			mutate: func(c *Config) {
+				c.Traces.EnumEncoding = "int32"
+			},
+			wantErr: true,
+		},
+		{
+			name: "int64 enum encoding with create views",
+			mutate: func(c *Config) {
+				c.Traces.EnumEncoding = "int64"
+				c.CreateViews = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "promoted attribute colliding with events_count",
+			mutate: func(c *Config) {
+				c.Traces.PromotedAttributes = []PromotedAttributeConfig{{Source: "span", Key: "events.count"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative shard window past",
+			mutate: func(c *Config) {
+				c.Dataset.ShardWindow.Past = -time.Hour
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative shard window future",
+			mutate: func(c *Config) {
+				c.Dataset.ShardWindow.Future = -time.Hour
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero shard window",
+			mutate: func(c *Config) {
+				c.Dataset.ShardWindow = ShardWindowConfig{}
+			},
+			wantErr: false,
+		},
+		{
+			name: "no auto create tables",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+			},
+			wantErr: false,
+		},
+		{
+			name: "update table expiration without auto create tables",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+				c.Dataset.TableExpiration = time.Hour
+				c.Dataset.UpdateTableExpiration = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "update table metadata without auto create tables",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+				c.Dataset.TableDescription = "OpenTelemetry data"
+				c.Dataset.UpdateTableMetadata = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "create views without auto create tables",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+				c.CreateViews = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "create views with time-sharded table",
+			mutate: func(c *Config) {
+				c.CreateViews = true
+				c.Dataset.Table.Log = "log_%Y%m%d"
+			},
+			wantErr: true,
+		},
+		{
+			name: "create views with time-sharded entity table",
+			mutate: func(c *Config) {
+				c.CreateViews = true
+				c.Dataset.Table.Entity = "entity_%Y%m%d"
+			},
+			wantErr: false,
+		},
+		{
+			name: "reserved table label",
+			mutate: func(c *Config) {
+				c.Dataset.TableLabels = map[string]string{"otel_schema_version": "3"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "create udfs",
+			mutate: func(c *Config) {
+				c.CreateUDFs = true
+				c.Dataset.Table.Log = "log_%Y%m%d"
+			},
+			wantErr: false,
+		},
+		{
+			name: "create udfs without auto create tables",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+				c.CreateUDFs = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "policy tags",
+			mutate: func(c *Config) {
+				c.Traces.PolicyTags = map[string]string{"span_attributes": "projects/p/locations/us/taxonomies/1/policyTags/2"}
+				c.Logs.PolicyTags = map[string]string{"body": "projects/p/locations/us/taxonomies/1/policyTags/3"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "policy tag on unknown column",
+			mutate: func(c *Config) {
+				c.Metrics.PolicyTags = map[string]string{"body": "projects/p/locations/us/taxonomies/1/policyTags/2"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "policy tag on column omitted by preset",
+			mutate: func(c *Config) {
+				c.SchemaPreset = "slim"
+				c.Traces.PolicyTags = map[string]string{"events": "projects/p/locations/us/taxonomies/1/policyTags/2"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid policy tag",
+			mutate: func(c *Config) {
+				c.Logs.PolicyTags = map[string]string{"body": "taxonomies/1/policyTags/2"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "dataset auto create",
+			mutate: func(c *Config) {
+				c.Dataset.AutoCreate = true
+				c.Dataset.Location = "EU"
+				c.Dataset.DefaultTableExpiration = 720 * time.Hour
+				c.Dataset.DefaultPartitionExpiration = 168 * time.Hour
+			},
+			wantErr: false,
+		},
+		{
+			name: "dataset expected location",
+			mutate: func(c *Config) {
+				c.Dataset.ExpectedLocation = "europe-west1"
+			},
+			wantErr: false,
+		},
+		{
+			name: "dataset location differs from expected location",
+			mutate: func(c *Config) {
+				c.Dataset.AutoCreate = true
+				c.Dataset.Location = "EU"
+				c.Dataset.ExpectedLocation = "US"
+			},
+			wantErr: true,
+		},
+		{
+			name: "dataset default expiration without auto create",
+			mutate: func(c *Config) {
+				c.Dataset.DefaultPartitionExpiration = 168 * time.Hour
+			},
+			wantErr: true,
+		},
+		{
+			name: "dataset default table expiration too short",
+			mutate: func(c *Config) {
+				c.Dataset.AutoCreate = true
+				c.Dataset.DefaultTableExpiration = time.Minute
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative dataset default partition expiration",
+			mutate: func(c *Config) {
+				c.Dataset.AutoCreate = true
+				c.Dataset.DefaultPartitionExpiration = -time.Hour
+			},
+			wantErr: true,
+		},
+		{
+			name: "dataset auto create without table auto create",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+				c.Dataset.AutoCreate = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "collation",
+			mutate: func(c *Config) {
+				c.Traces.Collation = map[string]string{"name": "und:ci"}
+				c.Logs.Collation = map[string]string{"severity_text": "und:ci"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "collation on unknown column",
+			mutate: func(c *Config) {
+				c.Metrics.Collation = map[string]string{"severity_text": "und:ci"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "collation on JSON column",
+			mutate: func(c *Config) {
+				c.Logs.Collation = map[string]string{"log_attributes": "und:ci"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "collation on JSON column stored as STRING",
+			mutate: func(c *Config) {
+				c.Logs.JSONColumns = jsonColumnsString
+				c.Logs.Collation = map[string]string{"log_attributes": "und:ci"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty collation",
+			mutate: func(c *Config) {
+				c.Metrics.Collation = map[string]string{"metric_name": ""}
+			},
+			wantErr: true,
+		},
+		{
+			name: "adaptive slim",
+			mutate: func(c *Config) {
+				c.AdaptiveSlim.Enabled = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "adaptive slim zero threshold",
+			mutate: func(c *Config) {
+				c.AdaptiveSlim = AdaptiveSlimConfig{Enabled: true, Cooldown: time.Minute}
+			},
+			wantErr: true,
+		},
+		{
+			name: "adaptive slim zero cooldown",
+			mutate: func(c *Config) {
+				c.AdaptiveSlim = AdaptiveSlimConfig{Enabled: true, Threshold: 3}
+			},
+			wantErr: true,
+		},
+		{
+			name: "disabled adaptive slim is not validated",
+			mutate: func(c *Config) {
+				c.AdaptiveSlim = AdaptiveSlimConfig{}
+			},
+			wantErr: false,
+		},
+		{
+			name: "write trace labels",
+			mutate: func(c *Config) {
+				c.Write.TraceLabels = map[string]string{"team": "observability", "pipeline": ""}
+			},
+			wantErr: false,
+		},
+		{
+			name: "write trace labels with invalid key",
+			mutate: func(c *Config) {
+				c.Write.TraceLabels = map[string]string{"Team": "observability"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "write trace labels with invalid value",
+			mutate: func(c *Config) {
+				c.Write.TraceLabels = map[string]string{"team": "a,b"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "row retention",
+			mutate: func(c *Config) {
+				c.Logs.RowRetention = 90 * 24 * time.Hour
+				c.Logs.ClusteringFields = []string{"expires_at"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative row retention",
+			mutate: func(c *Config) {
+				c.Metrics.RowRetention = -time.Hour
+			},
+			wantErr: true,
+		},
+		{
+			name: "expires at column without row retention",
+			mutate: func(c *Config) {
+				c.Traces.ClusteringFields = []string{"expires_at"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative table create jitter",
+			mutate: func(c *Config) {
+				c.TableCreateJitter = -time.Second
+			},
+			wantErr: true,
+		},
+		{
+			name: "dual write",
+			mutate: func(c *Config) {
+				c.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "_v2", SchemaPreset: "slim", JSONColumns: "json"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "dual write without table suffix",
+			mutate: func(c *Config) {
+				c.DualWrite = DualWriteConfig{Enabled: true, SchemaPreset: "slim"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "dual write with the primary schema",
+			mutate: func(c *Config) {
+				c.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "_v2"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "dual write with unknown schema preset",
+			mutate: func(c *Config) {
+				c.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "_v2", SchemaPreset: "wide"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "dual write with invalid table suffix",
+			mutate: func(c *Config) {
+				c.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "-v2", JSONColumns: "string"}
 			},
 			wantErr: true,
 		},
 		{
-			name: "invalid traces table identifier",
+			name: "dual write without a clustering column",
 			mutate: func(c *Config) {
-				c.Dataset.Table.Trace = "trace-events"
+				c.Metrics.ClusteringFields = []string{"exemplars"}
+				c.Metrics.JSONColumns = "string"
+				c.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "_v2", SchemaPreset: "slim"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "event date",
+			mutate: func(c *Config) {
+				c.EventDate = EventDateConfig{Enabled: true, TimeZone: "Europe/Berlin"}
+				c.Logs.Partitioning = PartitioningConfig{Field: "event_date", Granularity: "DAY"}
+				c.Logs.ClusteringFields = []string{"event_date"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "event date with unknown time zone",
+			mutate: func(c *Config) {
+				c.EventDate = EventDateConfig{Enabled: true, TimeZone: "Mars/Olympus_Mons"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "event date with empty time zone",
+			mutate: func(c *Config) {
+				c.EventDate = EventDateConfig{Enabled: true}
+			},
+			wantErr: true,
+		},
+		{
+			name: "event date partitioned by hour",
+			mutate: func(c *Config) {
+				c.EventDate.Enabled = true
+				c.Traces.Partitioning = PartitioningConfig{Field: "event_date", Granularity: "HOUR"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "event date column without event date",
+			mutate: func(c *Config) {
+				c.Metrics.Partitioning = PartitioningConfig{Field: "event_date", Granularity: "DAY"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "metric rollup",
+			mutate: func(c *Config) {
+				c.Metrics.Rollup.Enabled = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "metric rollup with unsupported granularity",
+			mutate: func(c *Config) {
+				c.Metrics.Rollup.Enabled = true
+				c.Metrics.Rollup.Granularity = "SECOND"
+			},
+			wantErr: true,
+		},
+		{
+			name: "metric rollup with too short refresh interval",
+			mutate: func(c *Config) {
+				c.Metrics.Rollup.Enabled = true
+				c.Metrics.Rollup.RefreshInterval = 30 * time.Second
+			},
+			wantErr: true,
+		},
+		{
+			name: "metric rollup without auto create tables",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+				c.Metrics.Rollup.Enabled = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "metric rollup with time-sharded metric table",
+			mutate: func(c *Config) {
+				c.Metrics.Rollup.Enabled = true
+				c.Dataset.Table.Metric = "metric_%Y%m%d"
+			},
+			wantErr: true,
+		},
+		{
+			name: "allow schema update without auto create tables",
+			mutate: func(c *Config) {
+				c.AutoCreateTables = false
+				c.AllowSchemaUpdate = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "schema snapshot",
+			mutate: func(c *Config) {
+				c.AllowSchemaUpdate = true
+				c.SchemaSnapshot = SchemaSnapshotConfig{Enabled: true, Suffix: "_pre_%Y%m%d", Retention: 0}
+			},
+			wantErr: false,
+		},
+		{
+			name: "schema snapshot without allow schema update",
+			mutate: func(c *Config) {
+				c.SchemaSnapshot.Enabled = true
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid schema snapshot suffix",
+			mutate: func(c *Config) {
+				c.AllowSchemaUpdate = true
+				c.SchemaSnapshot = SchemaSnapshotConfig{Enabled: true, Suffix: "-%M"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative schema snapshot retention",
+			mutate: func(c *Config) {
+				c.AllowSchemaUpdate = true
+				c.SchemaSnapshot = SchemaSnapshotConfig{Enabled: true, Suffix: "_snapshot", Retention: -time.Hour}
+			},
+			wantErr: true,
+		},
+		{
+			name: "kms key name",
+			mutate: func(c *Config) {
+				c.Dataset.KMSKeyName = "projects/p/locations/us/keyRings/r/cryptoKeys/k"
+			},
+			wantErr: false,
+		},
+		{
+			name: "kms key version",
+			mutate: func(c *Config) {
+				c.Dataset.KMSKeyName = "projects/p/locations/us/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+			},
+			wantErr: true,
+		},
+		{
+			name: "kms key without key ring",
+			mutate: func(c *Config) {
+				c.Dataset.KMSKeyName = "projects/p/locations/us/cryptoKeys/k"
+			},
+			wantErr: true,
+		},
+		{
+			name: "clustering fields",
+			mutate: func(c *Config) {
+				c.Traces.ClusteringFields = []string{"trace_id", "name"}
+				c.Metrics.ClusteringFields = []string{"metric_name"}
+				c.Logs.ClusteringFields = []string{"severity_text"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown clustering field",
+			mutate: func(c *Config) {
+				c.Logs.ClusteringFields = []string{"severity"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "json clustering field",
+			mutate: func(c *Config) {
+				c.Traces.ClusteringFields = []string{"span_attributes"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "float clustering field",
+			mutate: func(c *Config) {
+				c.Metrics.ClusteringFields = []string{"value_double"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate clustering field",
+			mutate: func(c *Config) {
+				c.Traces.ClusteringFields = []string{"trace_id", "trace_id"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many clustering fields",
+			mutate: func(c *Config) {
+				c.Traces.ClusteringFields = []string{"trace_id", "span_id", "name", "kind", "status_code"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative memory limit",
+			mutate: func(c *Config) {
+				c.MemoryLimitMiB = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "partitioning by column",
+			mutate: func(c *Config) {
+				c.Traces.Partitioning = PartitioningConfig{Field: "start_time", Granularity: "HOUR"}
+				c.Metrics.Partitioning = PartitioningConfig{Field: "datapoint_timestamp", Granularity: "MONTH"}
+				c.Logs.Partitioning = PartitioningConfig{Field: "log_timestamp", Granularity: "YEAR"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown partitioning granularity",
+			mutate: func(c *Config) {
+				c.Logs.Partitioning.Granularity = "WEEK"
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown partitioning field",
+			mutate: func(c *Config) {
+				c.Traces.Partitioning.Field = "timestamp"
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-timestamp partitioning field",
+			mutate: func(c *Config) {
+				c.Metrics.Partitioning.Field = "metric_name"
+			},
+			wantErr: true,
+		},
+		{
+			name: "range partitioning",
+			mutate: func(c *Config) {
+				c.Logs.Partitioning.Field = "severity_number"
+				c.Logs.Partitioning.Range = RangePartitioningConfig{Start: 0, End: 25, Interval: 4}
+			},
+			wantErr: false,
+		},
+		{
+			name: "range partitioning without field",
+			mutate: func(c *Config) {
+				c.Logs.Partitioning.Range = RangePartitioningConfig{End: 25, Interval: 4}
+			},
+			wantErr: true,
+		},
+		{
+			name: "range partitioning on timestamp column",
+			mutate: func(c *Config) {
+				c.Logs.Partitioning.Field = "log_timestamp"
+				c.Logs.Partitioning.Range = RangePartitioningConfig{End: 25, Interval: 4}
+			},
+			wantErr: true,
+		},
+		{
+			name: "range partitioning without interval",
+			mutate: func(c *Config) {
+				c.Logs.Partitioning.Field = "severity_number"
+				c.Logs.Partitioning.Range = RangePartitioningConfig{End: 25}
+			},
+			wantErr: true,
+		},
+		{
+			name: "range partitioning with empty range",
+			mutate: func(c *Config) {
+				c.Logs.Partitioning.Field = "severity_number"
+				c.Logs.Partitioning.Range = RangePartitioningConfig{Start: 25, End: 25, Interval: 1}
+			},
+			wantErr: true,
+		},
+		{
+			name: "range partitioning with too many partitions",
+			mutate: func(c *Config) {
+				c.Metrics.Partitioning.Field = "value_int"
+				c.Metrics.Partitioning.Range = RangePartitioningConfig{Start: -1 << 62, End: 1 << 62, Interval: 1000}
+			},
+			wantErr: true,
+		},
+		{
+			name: "clustering by status class",
+			mutate: func(c *Config) {
+				c.Traces.StatusClass = true
+				c.Traces.ClusteringFields = []string{"status_class"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "clustering by disabled status class",
+			mutate: func(c *Config) {
+				c.Traces.ClusteringFields = []string{"status_class"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "debug log sample rate above one",
+			mutate: func(c *Config) {
+				c.Write.DebugLogSampleRate = 1.5
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown write mode",
+			mutate: func(c *Config) {
+				c.Write.Mode = "buffered"
+			},
+			wantErr: true,
+		},
+		{
+			name: "insecure tls",
+			mutate: func(c *Config) {
+				tlsCfg := configtls.NewDefaultClientConfig()
+				tlsCfg.Insecure = true
+				c.TLS = configoptional.Some(tlsCfg)
+			},
+			wantErr: true,
+		},
+		{
+			name: "insertdata scope",
+			mutate: func(c *Config) {
+				c.Scopes = []string{insertDataScope}
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty scopes",
+			mutate: func(c *Config) {
+				c.Scopes = nil
+			},
+			wantErr: true,
+		},
+		{
+			name: "scope without prefix",
+			mutate: func(c *Config) {
+				c.Scopes = []string{"bigquery"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "impersonation target is not an email",
+			mutate: func(c *Config) {
+				c.Dataset.Credentials.ImpersonateServiceAccount = "writer"
+			},
+			wantErr: true,
+		},
+		{
+			name: "credentials secret",
+			mutate: func(c *Config) {
+				c.Dataset.Credentials.Secret = "projects/p/secrets/bq-writer/versions/latest"
+			},
+			wantErr: false,
+		},
+		{
+			name: "credentials secret is not a version name",
+			mutate: func(c *Config) {
+				c.Dataset.Credentials.Secret = "projects/p/secrets/bq-writer"
+			},
+			wantErr: true,
+		},
+		{
+			name: "credentials file and secret",
+			mutate: func(c *Config) {
+				c.Dataset.Credentials.File = "/etc/bigquery/key.json"
+				c.Dataset.Credentials.Secret = "projects/p/secrets/bq-writer/versions/latest"
+			},
+			wantErr: true,
+		},
+		{
+			name: "credentials reload without file",
+			mutate: func(c *Config) {
+				c.Dataset.Credentials.ReloadInterval = time.Minute
+			},
+			wantErr: true,
+		},
+		{
+			name: "signal credentials file and secret",
+			mutate: func(c *Config) {
+				c.Logs.Credentials.File = "/etc/bigquery/key.json"
+				c.Logs.Credentials.Secret = "projects/p/secrets/bq-writer/versions/latest"
+			},
+			wantErr: true,
+		},
+		{
+			name: "signal credentials with wide events",
+			mutate: func(c *Config) {
+				c.WideEvents.Enabled = true
+				c.Traces.Credentials.File = "/etc/bigquery/key.json"
 			},
 			wantErr: true,
 		},
@@ -141,3 +1967,10 @@ func TestConfigValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteConfigTraceID(t *testing.T) {
+	cfg := WriteConfig{}
+	assert.Empty(t, cfg.traceID())
+	cfg.TraceLabels = map[string]string{"team": "observability", "pipeline": "edge-logs"}
+	assert.Equal(t, "pipeline=edge-logs,team=observability", cfg.traceID())
+}
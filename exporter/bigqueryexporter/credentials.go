@@ -0,0 +1,263 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// findCredentials loads the credentials file or Secret Manager secret of
+// creds, or Application Default Credentials when neither is configured.
+func findCredentials(ctx context.Context, creds CredentialsConfig, scopes []string) (*google.Credentials, error) {
+	var (
+		data   []byte
+		source string
+		err    error
+	)
+	switch {
+	case creds.File != "":
+		source = "file " + creds.File
+		data, err = os.ReadFile(creds.File)
+		if err != nil {
+			return nil, fmt.Errorf("read credentials file: %w", err)
+		}
+	case creds.Secret != "":
+		source = "secret " + creds.Secret
+		data, err = accessSecretVersion(ctx, creds.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("access credentials secret %s: %w", creds.Secret, err)
+		}
+	default:
+		found, err := google.FindDefaultCredentials(ctx, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("find default credentials: %w", err)
+		}
+		return found, nil
+	}
+	found, err := google.CredentialsFromJSON(ctx, data, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("load credentials %s: %w", source, err)
+	}
+	return found, nil
+}
+
+// accessSecretVersion returns the payload of a Secret Manager secret version,
+// read with Application Default Credentials. It is a variable so tests can
+// replace it.
+var accessSecretVersion = func(ctx context.Context, name string) ([]byte, error) {
+	found, err := google.FindDefaultCredentials(ctx, cloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("find default credentials: %w", err)
+	}
+	svc, err := secretmanager.NewService(ctx, authenticatedOptions(ctx, found.TokenSource)...)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Payload.Data)
+}
+
+// newTokenSource returns the token source for creds, impersonating the
+// configured service account if any.
+func newTokenSource(ctx context.Context, creds CredentialsConfig, scopes []string) (oauth2.TokenSource, error) {
+	found, err := findCredentials(ctx, creds, scopes)
+	if err != nil {
+		return nil, err
+	}
+	if creds.ImpersonateServiceAccount == "" {
+		return found.TokenSource, nil
+	}
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: creds.ImpersonateServiceAccount,
+		Scopes:          scopes,
+	}, authenticatedOptions(ctx, found.TokenSource)...)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate %s: %w", creds.ImpersonateServiceAccount, err)
+	}
+	return ts, nil
+}
+
+// authenticatedOptions returns the client options authenticating API calls
+// with ts. When ctx carries the HTTP client of proxyContext, the calls are
+// sent through it.
+func authenticatedOptions(ctx context.Context, ts oauth2.TokenSource) []option.ClientOption {
+	client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	if !ok {
+		return []option.ClientOption{option.WithTokenSource(ts)}
+	}
+	return []option.ClientOption{option.WithHTTPClient(&http.Client{
+		Transport: &oauth2.Transport{Source: ts, Base: client.Transport},
+	})}
+}
+
+// credentialOptions returns the client options that authenticate as creds,
+// and the token source watching the credentials file when it is reloaded.
+// No options are returned for default credentials so that the client
+// libraries keep their own Application Default Credentials handling.
+func credentialOptions(ctx context.Context, logger *zap.Logger, creds CredentialsConfig, scopes []string) ([]option.ClientOption, *reloadingTokenSource, error) {
+	if creds == (CredentialsConfig{}) {
+		return nil, nil, nil
+	}
+	ts, err := newTokenSource(ctx, creds, scopes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if creds.File == "" || creds.ReloadInterval <= 0 {
+		return []option.ClientOption{option.WithTokenSource(ts)}, nil, nil
+	}
+	reloading, err := newReloadingTokenSource(logger, creds.File, creds.ReloadInterval, ts, func() (oauth2.TokenSource, error) {
+		// The start context is done by the time a reload happens, but its
+		// proxied HTTP client is kept.
+		return newTokenSource(context.WithoutCancel(ctx), creds, scopes)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return []option.ClientOption{option.WithTokenSource(reloading)}, reloading, nil
+}
+
+// destinationCredentials are the client options authenticating as one
+// configured credentials, and the token source watching its file when it is
+// reloaded.
+type destinationCredentials struct {
+	opts     []option.ClientOption
+	reloader *reloadingTokenSource
+}
+
+// destinationCredentials returns the client options for creds, loading the
+// credentials once for every destination written with them.
+func (e *bigQueryExporter) destinationCredentials(ctx context.Context, creds CredentialsConfig) (*destinationCredentials, error) {
+	if dc, ok := e.credentials[creds]; ok {
+		return dc, nil
+	}
+	opts, reloader, err := credentialOptions(ctx, e.logger, creds, e.cfg.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	dc := &destinationCredentials{opts: opts, reloader: reloader}
+	e.credentials[creds] = dc
+	return dc, nil
+}
+
+// reloadingTokenSource rebuilds its token source when the content of the
+// credentials file changes, so rotated keys are picked up without a restart.
+// The file is checked every interval once watch is called.
+type reloadingTokenSource struct {
+	logger   *zap.Logger
+	path     string
+	interval time.Duration
+	build    func() (oauth2.TokenSource, error)
+
+	mu     sync.Mutex
+	ts     oauth2.TokenSource
+	digest [sha256.Size]byte
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newReloadingTokenSource(
+	logger *zap.Logger,
+	path string,
+	interval time.Duration,
+	ts oauth2.TokenSource,
+	build func() (oauth2.TokenSource, error),
+) (*reloadingTokenSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read credentials file: %w", err)
+	}
+	return &reloadingTokenSource{
+		logger:   logger,
+		path:     path,
+		interval: interval,
+		build:    build,
+		ts:       ts,
+		digest:   sha256.Sum256(data),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func (r *reloadingTokenSource) Token() (*oauth2.Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ts.Token()
+}
+
+// watch checks the credentials file every interval until stop is called,
+// and calls checked after every check with whether the token source was
+// rebuilt, so connections authenticated with the previous credentials can
+// be replaced and replacements that failed retried.
+func (r *reloadingTokenSource) watch(checked func(reloaded bool)) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+			}
+			checked(r.reload())
+		}
+	}()
+}
+
+// stop stops watching the credentials file. It is a no-op on a nil
+// receiver.
+func (r *reloadingTokenSource) stop() {
+	if r == nil {
+		return
+	}
+	close(r.done)
+	r.wg.Wait()
+}
+
+// reload swaps the token source if the file changed, and reports whether
+// it did. Failures keep the current token source, since a rotation may be
+// observed half-written.
+func (r *reloadingTokenSource) reload() bool {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		r.logger.Warn("Failed to read credentials file, keeping current credentials", zap.String("path", r.path), zap.Error(err))
+		return false
+	}
+	digest := sha256.Sum256(data)
+	r.mu.Lock()
+	unchanged := digest == r.digest
+	r.mu.Unlock()
+	if unchanged {
+		return false
+	}
+	ts, err := r.build()
+	if err != nil {
+		r.logger.Warn("Failed to load rotated credentials file, keeping current credentials", zap.String("path", r.path), zap.Error(err))
+		return false
+	}
+	r.mu.Lock()
+	r.ts = ts
+	r.digest = digest
+	r.mu.Unlock()
+	r.logger.Info("Reloaded rotated credentials file", zap.String("path", r.path))
+	return true
+}
@@ -0,0 +1,109 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// slimDegradation switches a table to the columns of the slim preset while
+// appends to it keep failing with quota errors, and back once none occurred
+// for the cooldown. It is nil when adaptive_slim is disabled.
+type slimDegradation struct {
+	logger    *zap.Logger
+	table     string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu sync.Mutex
+	// failures counts the consecutive appends that failed with a quota
+	// error.
+	failures int
+	// until is when the table returns to the full columns, or the zero time
+	// when it is not degraded.
+	until time.Time
+}
+
+func newSlimDegradation(logger *zap.Logger, table string, cfg AdaptiveSlimConfig) *slimDegradation {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &slimDegradation{logger: logger, table: table, threshold: cfg.Threshold, cooldown: cfg.Cooldown, now: time.Now}
+}
+
+// active reports whether rows are currently written without the columns
+// the slim preset omits.
+func (d *slimDegradation) active() bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.until.IsZero() {
+		return false
+	}
+	if d.now().Before(d.until) {
+		return true
+	}
+	d.until = time.Time{}
+	d.failures = 0
+	d.logger.Info("Quota pressure subsided; writing all columns again", zap.String("table", d.table))
+	return false
+}
+
+// record updates the state with the result of an append.
+func (d *slimDegradation) record(err error) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !isQuotaError(err) {
+		if err == nil {
+			d.failures = 0
+		}
+		return
+	}
+	d.failures++
+	if d.failures < d.threshold {
+		return
+	}
+	if d.until.IsZero() {
+		d.logger.Warn("Appends keep failing with quota errors; writing rows without the columns omitted by the slim preset",
+			zap.String("table", d.table), zap.Int("failures", d.failures), zap.Strings("columns", schemaPresets["slim"].omit), zap.Duration("cooldown", d.cooldown))
+	}
+	d.until = d.now().Add(d.cooldown)
+}
+
+// isQuotaError reports whether an append failed because a quota or the
+// throughput limit was exceeded.
+func isQuotaError(err error) bool {
+	var ae *appendError
+	return errors.As(err, &ae) && ae.code == codes.ResourceExhausted
+}
+
+// slimRows removes the columns omitted by the slim preset from rows and
+// returns how many rows had any.
+func slimRows(rows []row) int {
+	slimmed := 0
+	for _, r := range rows {
+		found := false
+		for _, column := range schemaPresets["slim"].omit {
+			if _, ok := r[column]; ok {
+				delete(r, column)
+				found = true
+			}
+		}
+		if found {
+			slimmed++
+		}
+	}
+	return slimmed
+}
@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSlimDegradation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	d := newSlimDegradation(zap.NewNop(), "t", AdaptiveSlimConfig{Enabled: true, Threshold: 2, Cooldown: time.Minute})
+	d.now = func() time.Time { return now }
+	quota := newAppendError(status.Error(codes.ResourceExhausted, "quota exceeded"), 1, nil)
+
+	d.record(quota)
+	assert.False(t, d.active())
+	// A success resets the consecutive failures.
+	d.record(nil)
+	d.record(quota)
+	assert.False(t, d.active())
+	// Other errors neither count nor reset.
+	d.record(newAppendError(status.Error(codes.InvalidArgument, "bad row"), 1, nil))
+	d.record(quota)
+	assert.True(t, d.active())
+
+	// Quota errors while degraded extend the cooldown.
+	now = now.Add(50 * time.Second)
+	d.record(quota)
+	now = now.Add(50 * time.Second)
+	assert.True(t, d.active())
+	now = now.Add(11 * time.Second)
+	assert.False(t, d.active())
+
+	// Recovery starts counting again.
+	d.record(quota)
+	assert.False(t, d.active())
+}
+
+func TestSlimDegradationDisabled(t *testing.T) {
+	d := newSlimDegradation(zap.NewNop(), "t", AdaptiveSlimConfig{Threshold: 1, Cooldown: time.Minute})
+	assert.Nil(t, d)
+	d.record(newAppendError(status.Error(codes.ResourceExhausted, "quota exceeded"), 1, nil))
+	assert.False(t, d.active())
+}
+
+func TestIsQuotaError(t *testing.T) {
+	assert.True(t, isQuotaError(newAppendError(status.Error(codes.ResourceExhausted, "quota exceeded"), 1, nil)))
+	assert.False(t, isQuotaError(newAppendError(status.Error(codes.Unavailable, "unavailable"), 1, nil)))
+	assert.False(t, isQuotaError(errors.New("quota")))
+	assert.False(t, isQuotaError(nil))
+}
+
+func TestSlimRows(t *testing.T) {
+	rows := []row{
+		{"name": "a", "events": "[]", "links": "[]"},
+		{"metric_name": "b", "exemplars": "[]"},
+		{"body": "c"},
+	}
+	assert.Equal(t, 2, slimRows(rows))
+	assert.Equal(t, []row{{"name": "a"}, {"metric_name": "b"}, {"body": "c"}}, rows)
+}
@@ -0,0 +1,102 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"maps"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+// dualWriteSuffix is appended to the name of a signal to name the target of
+// its dual-write table.
+const dualWriteSuffix = "_dual_write"
+
+// dualWriteTable holds the appender and, for time-sharded tables, the shards
+// of a dual-write table.
+type dualWriteTable struct {
+	appender *storageAppender
+	shards   *tableShards
+}
+
+// dualWriteTargets returns the targets of the dual-write tables of the
+// traces, metrics and logs targets. They share everything with their
+// primary target but the table and schema.
+func (e *bigQueryExporter) dualWriteTargets(targets []signalTarget, tableNames map[string]string) []signalTarget {
+	cfg := e.cfg.DualWrite
+	if !cfg.Enabled {
+		return nil
+	}
+	preset := cfg.preset(e.cfg.SchemaPreset)
+	var dual []signalTarget
+	for _, target := range targets {
+		var table *dualWriteTable
+		switch target.name {
+		case "traces":
+			table = &e.tracesDualWrite
+		case "metrics":
+			table = &e.metricsDualWrite
+		case "logs":
+			table = &e.logsDualWrite
+		default:
+			continue
+		}
+		jsonColumns := e.jsonColumns(target.project, target.dataset, cfg.JSONColumns)
+		name := target.name + dualWriteSuffix
+		tableNames[name] = tableNames[target.name] + cfg.TableSuffix
+		target.schema = signalSchema(e.cfg, preset, target.name, jsonColumns)
+		target.name = name
+		target.appender = &table.appender
+		target.shards = &table.shards
+		dual = append(dual, target)
+	}
+	return dual
+}
+
+// appendSignalRows appends rows to the table of a signal and, with
+// dual_write, concurrently to its dual-write table. The dual-write table
+// gets copies of the rows, since appending sets and removes columns. The
+// collector columns are set first. A failed append to the dual-write table
+// is logged and counted but does not fail the export, since retrying the
+// batch would write its rows to the primary table again.
+func (e *bigQueryExporter) appendSignalRows(ctx context.Context, appender *storageAppender, shards *tableShards, dual *dualWriteTable, rows []row, columns ...string) error {
+	setCollectorColumns(rows, e.collector)
+	if e.cfg.ResourceHash && !e.cfg.NormalizeResources.Enabled {
+		// normalize_resources already replaced the resource columns by
+		// their hash.
+		setResourceHashes(rows)
+	}
+	if !e.cfg.DualWrite.Enabled {
+		return appendTableRows(ctx, appender, shards, rows, columns...)
+	}
+	dualRows := make([]row, len(rows))
+	for i, r := range rows {
+		dualRows[i] = maps.Clone(r)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := appendTableRows(ctx, dual.appender, dual.shards, dualRows, columns...); err != nil {
+			e.logger.Warn("Dual write failed; the rows are missing from the dual-write table",
+				zap.String("table", dual.appender.tableRef), zap.Int("rows", len(dualRows)), zap.Error(err))
+			e.telemetry.ExporterBigqueryDualWriteFailedRows.Add(ctx, int64(len(dualRows)), metric.WithAttributes(attribute.String("table", dual.appender.tableRef)))
+		}
+	}()
+	err := appendTableRows(ctx, appender, shards, rows, columns...)
+	<-done
+	return err
+}
+
+// retrySignalRows returns a function appending rows again to the table of a
+// signal after appendSignalRows failed for it. The dual-write table, whose
+// failures do not fail the append, is not appended to again.
+func retrySignalRows(appender *storageAppender, shards *tableShards, rows []row, columns ...string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		restoreColumnNames(rows, appender.columnNames)
+		return appendTableRows(ctx, appender, shards, rows, columns...)
+	}
+}
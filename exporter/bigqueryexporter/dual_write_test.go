@@ -0,0 +1,115 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadatatest"
+)
+
+func TestDualWriteTargets(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.Table.Log = "log_%Y%m%d"
+	cfg.Watermark = true
+	cfg.Logs.EntityEvents = true
+	cfg.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "_legacy", SchemaPreset: "compat"}
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.project = "p"
+
+	targets := map[string]signalTarget{}
+	for _, target := range exp.signalTargets() {
+		targets[target.name] = target
+	}
+	require.Len(t, targets, 7)
+	assert.NotContains(t, targets, "entities"+dualWriteSuffix)
+
+	traces := targets["traces"+dualWriteSuffix]
+	assert.Equal(t, "trace_legacy", traces.tableID)
+	assert.Empty(t, traces.template)
+	assert.Same(t, &exp.tracesDualWrite.appender, traces.appender)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(traces.schema, "span_attributes").Type)
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(targets["traces"].schema, "span_attributes").Type)
+	assert.NotNil(t, schemaField(traces.schema, watermarkColumn))
+
+	logs := targets["logs"+dualWriteSuffix]
+	assert.Equal(t, "log_%Y%m%d_legacy", logs.template)
+	assert.Equal(t, targets["logs"].tableID+"_legacy", logs.tableID)
+	assert.Same(t, &exp.logsDualWrite.shards, logs.shards)
+}
+
+func TestDualWriteTargetsDisabled(t *testing.T) {
+	exp := newBigQueryExporter(t.Context(), createDefaultConfig(), exportertest.NewNopSettings(metadata.Type))
+	assert.Len(t, exp.signalTargets(), 3)
+}
+
+func TestAppendSignalRowsDualWrite(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+	core, logs := observer.New(zapcore.WarnLevel)
+	set := exportertest.NewNopSettings(metadata.Type)
+	set.Logger = zap.New(core)
+	cfg := createDefaultConfig()
+	cfg.DualWrite = DualWriteConfig{Enabled: true, TableSuffix: "_legacy", SchemaPreset: "compat"}
+	exp := newBigQueryExporter(t.Context(), cfg, set)
+	var err error
+	exp.telemetry, err = metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	t.Cleanup(exp.telemetry.Shutdown)
+
+	var failDual atomic.Bool
+	srv := &fakeWriteServer{appendResponse: func(stream string, _ [][]byte) *storagepb.AppendRowsResponse {
+		if failDual.Load() && strings.Contains(stream, "/tables/log_legacy/") {
+			return &storagepb.AppendRowsResponse{Response: &storagepb.AppendRowsResponse_Error{
+				Error: status.New(codes.InvalidArgument, "invalid").Proto(),
+			}}
+		}
+		return nil
+	}}
+	client := newFakeWriteClient(t, srv)
+	newAppender := func(table string) *storageAppender {
+		a, err := newStorageAppender(t.Context(), client, zap.NewNop(), exp.telemetry, "p", "d", table,
+			bigquery.Schema{{Name: "name", Type: bigquery.StringFieldType}},
+			WriteConfig{Mode: writeModeDefault, Workers: 1})
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, a.close(context.Background())) })
+		return a
+	}
+	primary := newAppender("log")
+	dual := &dualWriteTable{appender: newAppender("log_legacy")}
+	rows := func() []row { return []row{{"name": "a"}, {"name": "b"}} }
+
+	require.NoError(t, exp.appendSignalRows(t.Context(), primary, nil, dual, rows()))
+	assert.Len(t, srv.committedRows(), 4)
+
+	// A failing dual-write table does not fail the batch, whose retry
+	// would write the rows to the primary table again.
+	failDual.Store(true)
+	require.NoError(t, exp.appendSignalRows(t.Context(), primary, nil, dual, rows()))
+	assert.Len(t, srv.committedRows(), 6)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "Dual write failed; the rows are missing from the dual-write table", logs.All()[0].Message)
+	metadatatest.AssertEqualExporterBigqueryDualWriteFailedRows(t, tel, []metricdata.DataPoint[int64]{
+		{Value: 2, Attributes: attribute.NewSet(attribute.String("table", dual.appender.tableRef))},
+	}, metricdatatest.IgnoreTimestamp())
+}
@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// requiredStringColumns returns the REQUIRED STRING columns of schema, which
+// are subject to the empty_values policy.
+func requiredStringColumns(schema bigquery.Schema) []string {
+	var columns []string
+	for _, field := range schema {
+		if field.Required && field.Type == bigquery.StringFieldType {
+			columns = append(columns, field.Name)
+		}
+	}
+	return columns
+}
+
+var (
+	tracesRequiredColumns  = requiredStringColumns(rowconv.TracesSchema)
+	metricsRequiredColumns = requiredStringColumns(rowconv.MetricsSchema)
+)
+
+// applyEmptyValues applies cfg to rows with an empty value in one of columns
+// and returns the rows to append. Every affected row is counted per column.
+func applyEmptyValues(ctx context.Context, appender *storageAppender, rows []row, columns []string, cfg EmptyValuesConfig) ([]row, error) {
+	counts := make(map[string]int64)
+	kept := rows[:0]
+	for _, r := range rows {
+		empty := false
+		for _, column := range columns {
+			if v, ok := r[column].(string); !ok || v != "" {
+				continue
+			}
+			empty = true
+			counts[column]++
+			if cfg.Policy == emptyValuesPlaceholder {
+				r[column] = cfg.Placeholder
+			}
+		}
+		if empty && cfg.Policy == emptyValuesDrop {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if len(counts) == 0 {
+		return rows, nil
+	}
+
+	empty := make([]string, 0, len(counts))
+	for _, column := range columns {
+		n, ok := counts[column]
+		if !ok {
+			continue
+		}
+		empty = append(empty, column)
+		appender.telemetry.ExporterBigqueryEmptyRequiredValues.Add(ctx, n, metric.WithAttributes(
+			attribute.String("table", appender.tableRef),
+			attribute.String("column", column),
+			attribute.String("policy", cfg.Policy),
+		))
+	}
+	switch cfg.Policy {
+	case emptyValuesDrop:
+		appender.logger.Debug("Dropping rows with empty required values",
+			zap.String("table", appender.tableRef), zap.Strings("columns", empty), zap.Int("rows", len(rows)-len(kept)))
+		appender.statistics.record(statisticsCounts{droppedRows: int64(len(rows) - len(kept))})
+	case emptyValuesFail:
+		appender.statistics.record(statisticsCounts{failedAppends: 1, failedRows: int64(len(rows))})
+		return nil, consumererror.NewPermanent(fmt.Errorf("rows have empty values in required columns %s", strings.Join(empty, ", ")))
+	}
+	return kept, nil
+}
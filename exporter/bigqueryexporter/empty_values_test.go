@@ -0,0 +1,107 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadatatest"
+)
+
+func TestRequiredStringColumns(t *testing.T) {
+	assert.Equal(t, []string{"trace_id", "span_id", "name"}, tracesRequiredColumns)
+	assert.Equal(t, []string{"metric_name", "metric_type"}, metricsRequiredColumns)
+}
+
+func TestApplyEmptyValues(t *testing.T) {
+	newRows := func() []row {
+		return []row{
+			{"trace_id": "a", "span_id": "b", "name": "GET /"},
+			{"trace_id": "a", "span_id": "c", "name": ""},
+			{"trace_id": "", "span_id": "d", "name": ""},
+		}
+	}
+	tests := []struct {
+		policy  string
+		want    []row
+		wantErr bool
+		dropped int64
+		failed  int64
+	}{
+		{
+			policy: emptyValuesKeep,
+			want:   newRows(),
+		},
+		{
+			policy: emptyValuesPlaceholder,
+			want: []row{
+				{"trace_id": "a", "span_id": "b", "name": "GET /"},
+				{"trace_id": "a", "span_id": "c", "name": "unknown"},
+				{"trace_id": "unknown", "span_id": "d", "name": "unknown"},
+			},
+		},
+		{
+			policy:  emptyValuesDrop,
+			want:    []row{{"trace_id": "a", "span_id": "b", "name": "GET /"}},
+			dropped: 2,
+		},
+		{
+			policy:  emptyValuesFail,
+			wantErr: true,
+			failed:  3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			tel := componenttest.NewTelemetry()
+			t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+			tb, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+			require.NoError(t, err)
+			t.Cleanup(tb.Shutdown)
+			stats := newAppendStatistics()
+			a := &storageAppender{logger: zap.NewNop(), telemetry: tb, tableRef: "projects/p/datasets/d/tables/trace"}
+			a.statistics = stats.table("traces", "p.d.trace")
+
+			rows, err := applyEmptyValues(t.Context(), a, newRows(), tracesRequiredColumns, EmptyValuesConfig{Policy: tt.policy, Placeholder: "unknown"})
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, consumererror.IsPermanent(err))
+				assert.ErrorContains(t, err, "trace_id, name")
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, rows)
+			}
+
+			var counts statisticsCounts
+			for _, c := range stats.drain() {
+				counts = *c
+			}
+			assert.Equal(t, tt.dropped, counts.droppedRows)
+			assert.Equal(t, tt.failed, counts.failedRows)
+
+			metadatatest.AssertEqualExporterBigqueryEmptyRequiredValues(t, tel, []metricdata.DataPoint[int64]{
+				{Value: 1, Attributes: attribute.NewSet(attribute.String("table", a.tableRef), attribute.String("column", "trace_id"), attribute.String("policy", tt.policy))},
+				{Value: 2, Attributes: attribute.NewSet(attribute.String("table", a.tableRef), attribute.String("column", "name"), attribute.String("policy", tt.policy))},
+			}, metricdatatest.IgnoreTimestamp())
+		})
+	}
+}
+
+func TestApplyEmptyValuesNoEmptyValues(t *testing.T) {
+	rows := []row{{"metric_name": "cpu", "metric_type": "gauge"}}
+	got, err := applyEmptyValues(t.Context(), &storageAppender{}, rows, metricsRequiredColumns, EmptyValuesConfig{Policy: emptyValuesFail})
+	require.NoError(t, err)
+	assert.Equal(t, rows, got)
+}
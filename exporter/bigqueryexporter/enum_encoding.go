@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// Values of the traces.enum_encoding setting.
+const (
+	enumEncodingString = "string"
+	enumEncodingInt64  = "int64"
+)
+
+// withIntegerEnums returns schema with the rowconv.EnumColumns as INTEGER
+// columns when encoding is int64. schema is not modified.
+func withIntegerEnums(schema bigquery.Schema, encoding string) bigquery.Schema {
+	if encoding != enumEncodingInt64 {
+		return schema
+	}
+	out := make(bigquery.Schema, 0, len(schema))
+	for _, field := range schema {
+		if slices.Contains(rowconv.EnumColumns, field.Name) {
+			column := *field
+			column.Type = bigquery.IntegerFieldType
+			field = &column
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+func validateEnumEncoding(field, value string) error {
+	switch value {
+	case "", enumEncodingString, enumEncodingInt64:
+		return nil
+	default:
+		return fmt.Errorf("%s %q is not supported, must be one of %s, %s", field, value, enumEncodingString, enumEncodingInt64)
+	}
+}
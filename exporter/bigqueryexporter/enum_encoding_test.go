@@ -0,0 +1,54 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWithIntegerEnums(t *testing.T) {
+	assert.Equal(t, rowconv.TracesSchema, withIntegerEnums(rowconv.TracesSchema, enumEncodingString))
+
+	schema := tracesTableSchema(defaultSchemaPreset, TracesConfig{EnumEncoding: enumEncodingInt64})
+	for _, name := range []string{"kind", "status_code"} {
+		assert.Equal(t, bigquery.IntegerFieldType, schemaField(schema, name).Type, name)
+	}
+	assert.Equal(t, bigquery.StringFieldType, schemaField(rowconv.TracesSchema, "kind").Type, "input schema is not modified")
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "status_message").Type)
+}
+
+func TestEnumEncodingRowOptions(t *testing.T) {
+	assert.False(t, TracesConfig{}.rowOptions().IntegerEnums)
+	assert.False(t, TracesConfig{EnumEncoding: enumEncodingString}.rowOptions().IntegerEnums)
+	assert.True(t, TracesConfig{EnumEncoding: enumEncodingInt64}.rowOptions().IntegerEnums)
+}
+
+func TestEncodeRowIntegerEnums(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetKind(ptrace.SpanKindServer)
+	span.Status().SetCode(ptrace.StatusCodeOk)
+
+	cfg := TracesConfig{EnumEncoding: enumEncodingInt64}
+	desc, _, err := schemaDescriptor(tracesTableSchema(defaultSchemaPreset, cfg))
+	require.NoError(t, err)
+	rows := rowconv.Traces(td, cfg.rowOptions())
+	require.Len(t, rows, 1)
+	b, err := encodeRow(desc, rows[0])
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(b, msg))
+
+	assert.Equal(t, int64(2), nestedField(msg, "kind").Int())
+	assert.Equal(t, int64(1), nestedField(msg, "status_code").Int())
+}
@@ -0,0 +1,102 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// appendError describes a failed AppendRows call. Its message carries the
+// gRPC code, the number of rejected rows and whether the failure is retried,
+// so exporterhelper logs and failed-send telemetry show an actionable reason.
+type appendError struct {
+	code      codes.Code
+	rows      int
+	rowErrors []*storagepb.RowError
+	retryable bool
+	err       error
+}
+
+func (e *appendError) Error() string {
+	msg := fmt.Sprintf("code=%s rows=%d row_errors=%d retryable=%t", e.code, e.rows, len(e.rowErrors), e.retryable)
+	if len(e.rowErrors) > 0 {
+		first := e.rowErrors[0]
+		msg += fmt.Sprintf(" first_row_error=[index=%d code=%s: %s]", first.GetIndex(), first.GetCode(), first.GetMessage())
+	}
+	return msg + ": " + e.err.Error()
+}
+
+func (e *appendError) Unwrap() error {
+	return e.err
+}
+
+// newAppendError classifies err and wraps it in an appendError. Errors that
+// cannot succeed on retry are marked permanent so exporterhelper drops the
+// batch immediately instead of retrying it until max_elapsed_time.
+func newAppendError(err error, rows int, resp *storagepb.AppendRowsResponse) error {
+	ae := &appendError{
+		code: errorCode(err),
+		rows: rows,
+		err:  err,
+	}
+	if resp != nil {
+		ae.rowErrors = resp.GetRowErrors()
+	}
+	ae.retryable = len(ae.rowErrors) == 0 && isRetryableCode(ae.code)
+	if !ae.retryable {
+		return consumererror.NewPermanent(ae)
+	}
+	return ae
+}
+
+func errorCode(err error) codes.Code {
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+	return status.FromContextError(err).Code()
+}
+
+// isRetryableCode reports whether a failure with code may succeed when it is
+// retried. Errors without a gRPC status, such as a connection closed with
+// io.EOF or reset by the network, have code Unknown and are retried.
+func isRetryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Aborted,
+		codes.Canceled,
+		codes.DeadlineExceeded,
+		codes.Internal,
+		codes.ResourceExhausted,
+		codes.Unavailable,
+		codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// storageErrorCode returns the Storage Write API error code carried in the
+// status details of err, if any.
+func storageErrorCode(err error) storagepb.StorageError_StorageErrorCode {
+	s, ok := status.FromError(err)
+	if !ok {
+		return storagepb.StorageError_STORAGE_ERROR_CODE_UNSPECIFIED
+	}
+	for _, detail := range s.Details() {
+		if storageErr, ok := detail.(*storagepb.StorageError); ok {
+			return storageErr.GetCode()
+		}
+	}
+	return storagepb.StorageError_STORAGE_ERROR_CODE_UNSPECIFIED
+}
+
+// isTableNotFound reports whether an append failed because the table does
+// not exist, e.g. because it was deleted after the exporter started.
+func isTableNotFound(err error) bool {
+	return errorCode(err) == codes.NotFound || storageErrorCode(err) == storagepb.StorageError_TABLE_NOT_FOUND
+}
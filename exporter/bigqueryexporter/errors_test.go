@@ -0,0 +1,140 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewAppendError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		resp          *storagepb.AppendRowsResponse
+		wantCode      codes.Code
+		wantRetryable bool
+		wantContains  string
+	}{
+		{
+			name:          "unavailable is retried",
+			err:           status.Error(codes.Unavailable, "backend unavailable"),
+			wantCode:      codes.Unavailable,
+			wantRetryable: true,
+			wantContains:  "code=Unavailable rows=3 row_errors=0 retryable=true",
+		},
+		{
+			name:          "deadline exceeded context error is retried",
+			err:           context.DeadlineExceeded,
+			wantCode:      codes.DeadlineExceeded,
+			wantRetryable: true,
+		},
+		{
+			name:          "connection closed is retried",
+			err:           fmt.Errorf("send: %w", io.EOF),
+			wantCode:      codes.Unknown,
+			wantRetryable: true,
+		},
+		{
+			name:          "transport error without a status is retried",
+			err:           errors.New("read tcp: connection reset by peer"),
+			wantCode:      codes.Unknown,
+			wantRetryable: true,
+		},
+		{
+			name:          "unknown status is retried",
+			err:           status.Error(codes.Unknown, "stream terminated"),
+			wantCode:      codes.Unknown,
+			wantRetryable: true,
+		},
+		{
+			name:          "schema mismatch is permanent",
+			err:           schemaMismatch(t),
+			wantCode:      codes.InvalidArgument,
+			wantRetryable: false,
+		},
+		{
+			name:          "invalid argument is permanent",
+			err:           status.Error(codes.InvalidArgument, "bad schema"),
+			wantCode:      codes.InvalidArgument,
+			wantRetryable: false,
+		},
+		{
+			name: "row errors are permanent",
+			err:  status.Error(codes.InvalidArgument, "rows rejected"),
+			resp: &storagepb.AppendRowsResponse{RowErrors: []*storagepb.RowError{
+				{Index: 2, Code: storagepb.RowError_FIELDS_ERROR, Message: "name is required"},
+			}},
+			wantCode:      codes.InvalidArgument,
+			wantRetryable: false,
+			wantContains:  "row_errors=1 retryable=false first_row_error=[index=2 code=FIELDS_ERROR: name is required]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newAppendError(tt.err, 3, tt.resp)
+
+			var ae *appendError
+			require.ErrorAs(t, err, &ae)
+			assert.Equal(t, tt.wantCode, ae.code)
+			assert.Equal(t, tt.wantRetryable, ae.retryable)
+			assert.Equal(t, !tt.wantRetryable, consumererror.IsPermanent(err))
+			assert.ErrorIs(t, err, tt.err)
+			if tt.wantContains != "" {
+				assert.Contains(t, err.Error(), tt.wantContains)
+			}
+		})
+	}
+}
+
+func TestAppendErrorSurvivesWrapping(t *testing.T) {
+	err := fmt.Errorf("append traces rows: %w", newAppendError(status.Error(codes.InvalidArgument, "bad request"), 1, nil))
+	assert.True(t, consumererror.IsPermanent(err))
+
+	err = fmt.Errorf("append traces rows: %w", newAppendError(errors.New("boom"), 1, nil))
+	assert.False(t, consumererror.IsPermanent(err))
+	var ae *appendError
+	require.ErrorAs(t, err, &ae)
+	assert.True(t, ae.retryable)
+}
+
+func schemaMismatch(t *testing.T) error {
+	st, err := status.New(codes.InvalidArgument, "Input schema has more fields than BigQuery schema").
+		WithDetails(&storagepb.StorageError{Code: storagepb.StorageError_SCHEMA_MISMATCH_EXTRA_FIELDS})
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestStorageErrorCode(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "Input schema has more fields than BigQuery schema").
+		WithDetails(&storagepb.StorageError{Code: storagepb.StorageError_SCHEMA_MISMATCH_EXTRA_FIELDS})
+	require.NoError(t, err)
+
+	assert.Equal(t, storagepb.StorageError_SCHEMA_MISMATCH_EXTRA_FIELDS, storageErrorCode(st.Err()))
+	assert.Equal(t, storagepb.StorageError_SCHEMA_MISMATCH_EXTRA_FIELDS, storageErrorCode(fmt.Errorf("append: %w", st.Err())))
+	assert.Equal(t, storagepb.StorageError_STORAGE_ERROR_CODE_UNSPECIFIED, storageErrorCode(status.Error(codes.InvalidArgument, "bad request")))
+	assert.Equal(t, storagepb.StorageError_STORAGE_ERROR_CODE_UNSPECIFIED, storageErrorCode(io.EOF))
+}
+
+func TestIsTableNotFound(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "Table is deleted").
+		WithDetails(&storagepb.StorageError{Code: storagepb.StorageError_TABLE_NOT_FOUND})
+	require.NoError(t, err)
+
+	assert.True(t, isTableNotFound(status.Error(codes.NotFound, "Requested entity was not found")))
+	assert.True(t, isTableNotFound(fmt.Errorf("append: %w", st.Err())))
+	assert.False(t, isTableNotFound(status.Error(codes.PermissionDenied, "denied")))
+	assert.False(t, isTableNotFound(io.EOF))
+}
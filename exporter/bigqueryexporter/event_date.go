@@ -0,0 +1,45 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"slices"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+const eventDateColumn = "event_date"
+
+var eventDateField = &bigquery.FieldSchema{Name: eventDateColumn, Type: bigquery.DateFieldType, Required: false}
+
+// withEventDate returns schema with the event_date column appended when
+// enabled.
+func withEventDate(schema bigquery.Schema, enabled bool) bigquery.Schema {
+	if !enabled {
+		return schema
+	}
+	return append(slices.Clip(schema), eventDateField)
+}
+
+// setEventDates sets the event_date column of rows to the date of their
+// event timestamp in loc. The first of columns holding a timestamp after
+// the Unix epoch is used; rows without one get a NULL date. It is a no-op
+// when loc is nil.
+func setEventDates(rows []row, loc *time.Location, columns []string) {
+	if loc == nil {
+		return
+	}
+	for _, r := range rows {
+		var date bigquery.Value
+		for _, column := range columns {
+			if ts, ok := r[column].(time.Time); ok && ts.UnixNano() > 0 {
+				date = civil.DateOf(ts.In(loc))
+				break
+			}
+		}
+		r[eventDateColumn] = date
+	}
+}
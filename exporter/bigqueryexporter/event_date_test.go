@@ -0,0 +1,64 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestSetEventDates(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// 02:30 UTC is still the previous day in New York.
+	ts := time.Date(2024, 5, 2, 2, 30, 0, 0, time.UTC)
+	rows := []row{
+		{"log_timestamp": ts},
+		{"log_timestamp": time.Unix(0, 0), "observed_timestamp": ts.Add(24 * time.Hour)},
+		{"log_timestamp": time.Unix(0, 0)},
+	}
+
+	setEventDates(rows, loc, []string{"log_timestamp", "observed_timestamp"})
+	assert.Equal(t, civil.Date{Year: 2024, Month: time.May, Day: 1}, rows[0][eventDateColumn])
+	assert.Equal(t, civil.Date{Year: 2024, Month: time.May, Day: 2}, rows[1][eventDateColumn])
+	assert.Contains(t, rows[2], eventDateColumn)
+	assert.Nil(t, rows[2][eventDateColumn])
+
+	setEventDates(rows, time.UTC, []string{"log_timestamp"})
+	assert.Equal(t, civil.Date{Year: 2024, Month: time.May, Day: 2}, rows[0][eventDateColumn])
+}
+
+func TestSetEventDatesDisabled(t *testing.T) {
+	rows := []row{{"start_time": time.Now()}}
+	setEventDates(rows, nil, []string{"start_time"})
+	assert.NotContains(t, rows[0], eventDateColumn)
+}
+
+func TestWithEventDate(t *testing.T) {
+	assert.Equal(t, rowconv.MetricsSchema, withEventDate(rowconv.MetricsSchema, false))
+
+	schema := withEventDate(rowconv.MetricsSchema, true)
+	assert.Len(t, schema, len(rowconv.MetricsSchema)+1)
+	assert.Equal(t, bigquery.DateFieldType, schemaField(schema, eventDateColumn).Type)
+	assert.Nil(t, schemaField(rowconv.MetricsSchema, eventDateColumn))
+}
+
+func TestEncodeEventDate(t *testing.T) {
+	desc, _, err := schemaDescriptor(withEventDate(bigquery.Schema{}, true))
+	require.NoError(t, err)
+	b, err := encodeRow(desc, row{eventDateColumn: civil.Date{Year: 1970, Month: time.January, Day: 3}})
+	require.NoError(t, err)
+	// Field 1, varint 2: the number of days since the Unix epoch.
+	assert.Equal(t, []byte{0x08, 0x02}, b)
+
+	_, err = encodeRow(desc, row{eventDateColumn: "1970-01-03"})
+	assert.ErrorContains(t, err, "expected civil.Date")
+}
@@ -0,0 +1,44 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"slices"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+const expiresAtColumn = "expires_at"
+
+var expiresAtField = &bigquery.FieldSchema{Name: expiresAtColumn, Type: bigquery.TimestampFieldType, Required: false}
+
+// withExpiresAt returns schema with the expires_at column appended when
+// retention is positive.
+func withExpiresAt(schema bigquery.Schema, retention time.Duration) bigquery.Schema {
+	if retention <= 0 {
+		return schema
+	}
+	return append(slices.Clip(schema), expiresAtField)
+}
+
+// setExpiresAt sets the expires_at column of rows to their event timestamp
+// plus retention. The first of columns holding a timestamp after the Unix
+// epoch is used; rows without one expire retention after now. It is a
+// no-op when retention is not positive.
+func setExpiresAt(rows []row, retention time.Duration, now time.Time, columns []string) {
+	if retention <= 0 {
+		return
+	}
+	for _, r := range rows {
+		eventTime := now
+		for _, column := range columns {
+			if ts, ok := r[column].(time.Time); ok && ts.UnixNano() > 0 {
+				eventTime = ts
+				break
+			}
+		}
+		r[expiresAtColumn] = eventTime.Add(retention)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestSetExpiresAt(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	rows := []row{
+		{"log_timestamp": ts},
+		{"log_timestamp": time.Unix(0, 0), "observed_timestamp": ts.Add(time.Hour)},
+		{},
+	}
+
+	setExpiresAt(rows, 72*time.Hour, now, []string{"log_timestamp", "observed_timestamp"})
+	assert.Equal(t, ts.Add(72*time.Hour), rows[0][expiresAtColumn])
+	assert.Equal(t, ts.Add(73*time.Hour), rows[1][expiresAtColumn])
+	// Rows without an event time expire relative to now.
+	assert.Equal(t, now.Add(72*time.Hour), rows[2][expiresAtColumn])
+}
+
+func TestSetExpiresAtDisabled(t *testing.T) {
+	rows := []row{{"start_time": time.Now()}}
+	setExpiresAt(rows, 0, time.Now(), []string{"start_time"})
+	assert.NotContains(t, rows[0], expiresAtColumn)
+}
+
+func TestWithExpiresAt(t *testing.T) {
+	assert.Equal(t, rowconv.TracesSchema, withExpiresAt(rowconv.TracesSchema, 0))
+
+	schema := withExpiresAt(rowconv.TracesSchema, time.Hour)
+	assert.Len(t, schema, len(rowconv.TracesSchema)+1)
+	assert.Equal(t, bigquery.TimestampFieldType, schemaField(schema, expiresAtColumn).Type)
+	assert.Nil(t, schemaField(rowconv.TracesSchema, expiresAtColumn))
+}
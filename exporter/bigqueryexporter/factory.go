@@ -5,6 +5,7 @@ package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-coll
 
 import (
 	"context"
+	"sync/atomic"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/exporter"
@@ -12,6 +13,7 @@ import (
 	"go.opentelemetry.io/collector/exporter/xexporter"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/sharedcomponent"
 )
 
 func NewFactory() exporter.Factory {
@@ -26,10 +28,10 @@ func NewFactory() exporter.Factory {
 
 func createTracesExporter(ctx context.Context, set exporter.Settings, config component.Config) (exporter.Traces, error) {
 	cfg := config.(*Config)
-	exp := newBigQueryExporter(ctx, cfg, set.Logger)
+	shared, exp := getOrCreateExporter(ctx, set, cfg)
 	return exporterhelper.NewTraces(ctx, set, config, exp.pushTraces,
-		exporterhelper.WithStart(exp.start),
-		exporterhelper.WithShutdown(exp.shutdown),
+		exporterhelper.WithStart(shared.Start),
+		exporterhelper.WithShutdown(exp.release(shared)),
 		exporterhelper.WithTimeout(cfg.TimeoutConfig),
 		exporterhelper.WithQueue(cfg.QueueConfig),
 		exporterhelper.WithRetry(cfg.BackOffConfig),
@@ -38,10 +40,10 @@ func createTracesExporter(ctx context.Context, set exporter.Settings, config com
 
 func createMetricsExporter(ctx context.Context, set exporter.Settings, config component.Config) (exporter.Metrics, error) {
 	cfg := config.(*Config)
-	exp := newBigQueryExporter(ctx, cfg, set.Logger)
+	shared, exp := getOrCreateExporter(ctx, set, cfg)
 	return exporterhelper.NewMetrics(ctx, set, config, exp.pushMetrics,
-		exporterhelper.WithStart(exp.start),
-		exporterhelper.WithShutdown(exp.shutdown),
+		exporterhelper.WithStart(shared.Start),
+		exporterhelper.WithShutdown(exp.release(shared)),
 		exporterhelper.WithTimeout(cfg.TimeoutConfig),
 		exporterhelper.WithQueue(cfg.QueueConfig),
 		exporterhelper.WithRetry(cfg.BackOffConfig),
@@ -50,12 +52,39 @@ func createMetricsExporter(ctx context.Context, set exporter.Settings, config co
 
 func createLogsExporter(ctx context.Context, set exporter.Settings, config component.Config) (exporter.Logs, error) {
 	cfg := config.(*Config)
-	exp := newBigQueryExporter(ctx, cfg, set.Logger)
+	shared, exp := getOrCreateExporter(ctx, set, cfg)
 	return exporterhelper.NewLogs(ctx, set, config, exp.pushLogs,
-		exporterhelper.WithStart(exp.start),
-		exporterhelper.WithShutdown(exp.shutdown),
+		exporterhelper.WithStart(shared.Start),
+		exporterhelper.WithShutdown(exp.release(shared)),
 		exporterhelper.WithTimeout(cfg.TimeoutConfig),
 		exporterhelper.WithQueue(cfg.QueueConfig),
 		exporterhelper.WithRetry(cfg.BackOffConfig),
 	)
 }
+
+// exporters shares one bigQueryExporter, and thus one set of clients and
+// streams, between the traces, metrics and logs exporters created from the
+// same configuration.
+var exporters = sharedcomponent.NewSharedComponents()
+
+func getOrCreateExporter(ctx context.Context, set exporter.Settings, cfg *Config) (*sharedcomponent.SharedComponent, *bigQueryExporter) {
+	shared := exporters.GetOrAdd(cfg, func() component.Component {
+		return newBigQueryExporter(ctx, cfg, set)
+	})
+	exp := shared.Unwrap().(*bigQueryExporter)
+	exp.users.Add(1)
+	return shared, exp
+}
+
+// release returns the shutdown function of one signal exporter. The shared
+// exporter is only shut down with the last signal, so that signals shutting
+// down later can still drain their sending queues.
+func (e *bigQueryExporter) release(shared *sharedcomponent.SharedComponent) component.ShutdownFunc {
+	var released atomic.Bool
+	return func(ctx context.Context) error {
+		if released.Swap(true) || e.users.Add(-1) > 0 {
+			return nil
+		}
+		return shared.Shutdown(ctx)
+	}
+}
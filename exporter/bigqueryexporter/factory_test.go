@@ -0,0 +1,41 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestExporterSharedAcrossSignals(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel_dataset"
+	set := exportertest.NewNopSettings(metadata.Type)
+
+	tracesShared, tracesExp := getOrCreateExporter(t.Context(), set, cfg)
+	logsShared, logsExp := getOrCreateExporter(t.Context(), set, cfg)
+	assert.Same(t, tracesShared, logsShared)
+	assert.Same(t, tracesExp, logsExp)
+	assert.EqualValues(t, 2, tracesExp.users.Load())
+
+	_, otherExp := getOrCreateExporter(t.Context(), set, createDefaultConfig())
+	assert.NotSame(t, tracesExp, otherExp)
+
+	releaseTraces := tracesExp.release(tracesShared)
+	require.NoError(t, releaseTraces(t.Context()))
+	require.NoError(t, releaseTraces(t.Context()))
+	assert.EqualValues(t, 1, tracesExp.users.Load())
+	_, exp := getOrCreateExporter(t.Context(), set, cfg)
+	assert.Same(t, tracesExp, exp, "exporter must stay shared until the last signal shuts down")
+
+	require.NoError(t, tracesExp.release(logsShared)(t.Context()))
+	require.NoError(t, tracesExp.release(logsShared)(t.Context()))
+	_, exp = getOrCreateExporter(t.Context(), set, cfg)
+	assert.NotSame(t, tracesExp, exp)
+}
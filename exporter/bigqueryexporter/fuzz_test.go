@@ -0,0 +1,220 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// FuzzSignalsToRows checks that rows converted from arbitrary pdata are
+// always accepted by the encoder and only carry valid UTF-8 strings and
+// valid JSON, for every schema preset.
+func FuzzSignalsToRows(f *testing.F) {
+	f.Add("GET /users", "http.method", "GET", []byte{1, 2, 3}, 1.5, uint64(1700000000000000000), int64(3))
+	f.Add("\xff\xfe", "", "\x80abc", []byte{}, math.NaN(), uint64(math.MaxUint64), int64(math.MinInt64))
+	f.Add("", "k", "", make([]byte, 32), math.Inf(-1), uint64(0), int64(999))
+	f.Fuzz(func(t *testing.T, name, key, value string, id []byte, number float64, ts uint64, n int64) {
+		in := fuzzInput{name: name, key: key, value: value, id: id, number: number, ts: pcommon.Timestamp(ts), n: n}
+		for _, preset := range schemaPresetNames() {
+			for _, jsonColumns := range []string{"", jsonColumnsString} {
+				tracesCfg := TracesConfig{JSONColumns: jsonColumns, StatusClass: true}
+				requireEncodableRows(t, tracesTableSchema(preset, tracesCfg), rowconv.Traces(in.traces(), tracesCfg.rowOptions()))
+				requireEncodableRows(t, tableSchema(rowconv.MetricsSchema, preset, jsonColumns), rowconv.Metrics(in.metrics(), rowconv.MetricsOptions{}))
+				requireEncodableRows(t, tableSchema(rowconv.LogsSchema, preset, jsonColumns), rowconv.Logs(in.logs(), rowconv.LogsOptions{TraceContextFromAttributes: true}))
+			}
+		}
+	})
+}
+
+type fuzzInput struct {
+	name, key, value string
+	id               []byte
+	number           float64
+	ts               pcommon.Timestamp
+	n                int64
+}
+
+func (in fuzzInput) fillAttributes(attrs pcommon.Map) {
+	attrs.PutStr(in.key, in.value)
+	attrs.PutDouble(in.key+".double", in.number)
+	attrs.PutInt(in.key+".int", in.n)
+	attrs.PutEmptyBytes(in.key + ".bytes").FromRaw(in.id)
+	nested := attrs.PutEmptyMap(in.key + ".map")
+	nested.PutStr(in.value, in.name)
+	nested.PutEmptySlice("values").AppendEmpty().SetDouble(in.number)
+	attrs.PutStr("trace_id", in.value)
+	attrs.PutStr("traceparent", in.name)
+	attrs.PutStr("http.response.status_code", in.value)
+	attrs.PutInt("http.status_code", in.n)
+	// Large attribute maps.
+	for i := range int(uint64(in.n) % 512) {
+		attrs.PutStr(in.key+strconv.Itoa(i), in.value)
+	}
+}
+
+func (in fuzzInput) traceID() pcommon.TraceID {
+	var tid pcommon.TraceID
+	copy(tid[:], in.id)
+	return tid
+}
+
+func (in fuzzInput) spanID() pcommon.SpanID {
+	var sid pcommon.SpanID
+	copy(sid[:], in.id)
+	return sid
+}
+
+func (in fuzzInput) traces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	in.fillAttributes(rs.Resource().Attributes())
+	rs.SetSchemaUrl(in.value)
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName(in.name)
+	span := ss.Spans().AppendEmpty()
+	span.SetName(in.name)
+	span.SetTraceID(in.traceID())
+	span.SetSpanID(in.spanID())
+	span.TraceState().FromRaw(in.value)
+	span.SetStartTimestamp(in.ts)
+	span.SetEndTimestamp(in.ts)
+	span.SetKind(ptrace.SpanKind(in.n))
+	span.Status().SetCode(ptrace.StatusCode(in.n))
+	span.Status().SetMessage(in.value)
+	in.fillAttributes(span.Attributes())
+	event := span.Events().AppendEmpty()
+	event.SetName(in.name)
+	event.SetTimestamp(in.ts)
+	in.fillAttributes(event.Attributes())
+	link := span.Links().AppendEmpty()
+	link.SetTraceID(in.traceID())
+	link.TraceState().FromRaw(in.value)
+	in.fillAttributes(link.Attributes())
+	return td
+}
+
+func (in fuzzInput) metrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	in.fillAttributes(rm.Resource().Attributes())
+	sm := rm.ScopeMetrics().AppendEmpty()
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName(in.name)
+	gauge.SetUnit(in.value)
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetDoubleValue(in.number)
+	dp.SetTimestamp(in.ts)
+	in.fillAttributes(dp.Attributes())
+	ex := dp.Exemplars().AppendEmpty()
+	ex.SetDoubleValue(in.number)
+	ex.SetTraceID(in.traceID())
+	in.fillAttributes(ex.FilteredAttributes())
+
+	hist := sm.Metrics().AppendEmpty()
+	hist.SetName(in.name)
+	hdp := hist.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.SetCount(uint64(in.n))
+	hdp.SetSum(in.number)
+	hdp.SetMin(in.number)
+	hdp.SetMax(in.number)
+	hdp.ExplicitBounds().FromRaw([]float64{in.number})
+	hdp.BucketCounts().FromRaw([]uint64{uint64(in.n), 0})
+
+	summary := sm.Metrics().AppendEmpty()
+	summary.SetName(in.name)
+	sdp := summary.SetEmptySummary().DataPoints().AppendEmpty()
+	sdp.SetSum(in.number)
+	q := sdp.QuantileValues().AppendEmpty()
+	q.SetQuantile(in.number)
+	q.SetValue(in.number)
+
+	exp := sm.Metrics().AppendEmpty()
+	exp.SetName(in.name)
+	edp := exp.SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+	edp.SetScale(int32(in.n))
+	edp.SetZeroThreshold(in.number)
+	edp.Positive().SetOffset(int32(in.n))
+	return md
+}
+
+func (in fuzzInput) logs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	in.fillAttributes(rl.Resource().Attributes())
+	sl := rl.ScopeLogs().AppendEmpty()
+	lr := sl.LogRecords().AppendEmpty()
+	lr.Body().SetStr(in.value)
+	lr.SetSeverityText(in.name)
+	lr.SetSeverityNumber(plog.SeverityNumber(in.n))
+	lr.SetTimestamp(in.ts)
+	lr.SetObservedTimestamp(in.ts)
+	in.fillAttributes(lr.Attributes())
+
+	mapBody := sl.LogRecords().AppendEmpty()
+	in.fillAttributes(mapBody.Body().SetEmptyMap())
+	mapBody.Body().Map().PutDouble("number", in.number)
+
+	bytesBody := sl.LogRecords().AppendEmpty()
+	bytesBody.Body().SetEmptyBytes().FromRaw(in.id)
+	return ld
+}
+
+func requireEncodableRows(t *testing.T, schema bigquery.Schema, rows []rowconv.Row) {
+	t.Helper()
+	desc, _, err := schemaDescriptor(schema)
+	require.NoError(t, err)
+	jsonColumns := map[protoreflect.Name]bool{}
+	for _, field := range schema {
+		if field.Type == bigquery.JSONFieldType {
+			jsonColumns[protoreflect.Name(field.Name)] = true
+		}
+	}
+
+	for _, r := range rows {
+		b, err := encodeRow(desc, r)
+		require.NoError(t, err)
+
+		msg := dynamicpb.NewMessage(desc)
+		require.NoError(t, proto.Unmarshal(b, msg))
+		msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			if fd.Kind() != protoreflect.StringKind {
+				return true
+			}
+			s := v.String()
+			assert.True(t, utf8.ValidString(s), "column %s is not valid UTF-8", fd.Name())
+			if jsonColumns[fd.Name()] {
+				assert.True(t, json.Valid([]byte(s)), "column %s is not valid JSON: %q", fd.Name(), s)
+			}
+			return true
+		})
+	}
+}
+
+func TestChunkRows(t *testing.T) {
+	rows := [][]byte{make([]byte, 4), make([]byte, 4), make([]byte, 4), make([]byte, 12)}
+	chunks := chunkRows(rows, 8)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 2)
+	assert.Len(t, chunks[1], 1)
+	assert.Len(t, chunks[2], 1)
+	assert.Empty(t, chunkRows(nil, 8))
+}
@@ -0,0 +1,67 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"encoding/hex"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// Values of the id_columns setting.
+const (
+	idColumnsString = "string"
+	idColumnsBytes  = "bytes"
+)
+
+// idColumns are the trace and span ID columns of the traces, logs, span
+// events and span links tables, and of the exemplar_spans records of the
+// metrics table.
+var idColumns = []string{"trace_id", "span_id", "parent_span_id", "linked_trace_id", "linked_span_id"}
+
+// withIDColumns returns schema with the ID columns typed as BYTES when
+// idColumnsSetting is bytes, including those of the exemplar_spans records,
+// grouped into datapoints or not. schema is not modified.
+func withIDColumns(schema bigquery.Schema, idColumnsSetting string) bigquery.Schema {
+	if idColumnsSetting != idColumnsBytes {
+		return schema
+	}
+	out := make(bigquery.Schema, 0, len(schema))
+	for _, field := range schema {
+		switch {
+		case slices.Contains(idColumns, field.Name):
+			bytesField := *field
+			bytesField.Type = bigquery.BytesFieldType
+			field = &bytesField
+		case field.Name == rowconv.ExemplarSpansColumn || field.Name == rowconv.DataPointsColumn:
+			record := *field
+			record.Schema = withIDColumns(field.Schema, idColumnsSetting)
+			field = &record
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+// setBytesIDs replaces the hex encoded IDs of rows by their 16 or 8 bytes.
+// Empty IDs become empty bytes, and values that are not hex encoded, such
+// as empty_values placeholders, their UTF-8 bytes.
+func setBytesIDs(rows []row) {
+	for _, r := range rows {
+		for _, column := range idColumns {
+			s, ok := r[column].(string)
+			if !ok {
+				continue
+			}
+			id, err := hex.DecodeString(s)
+			if err != nil {
+				id = []byte(s)
+			}
+			r[column] = id
+		}
+	}
+}
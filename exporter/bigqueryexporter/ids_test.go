@@ -0,0 +1,54 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWithIDColumns(t *testing.T) {
+	assert.Equal(t, rowconv.TracesSchema, withIDColumns(rowconv.TracesSchema, ""))
+	assert.Equal(t, rowconv.TracesSchema, withIDColumns(rowconv.TracesSchema, idColumnsString))
+
+	schema := withIDColumns(rowconv.TracesSchema, idColumnsBytes)
+	for _, name := range []string{"trace_id", "span_id", "parent_span_id"} {
+		assert.Equal(t, bigquery.BytesFieldType, schemaField(schema, name).Type, name)
+	}
+	assert.True(t, schemaField(schema, "trace_id").Required)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "name").Type)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(rowconv.TracesSchema, "trace_id").Type, "schema must not be modified")
+
+	links := withIDColumns(rowconv.SpanLinksSchema, idColumnsBytes)
+	assert.Equal(t, bigquery.BytesFieldType, schemaField(links, "linked_trace_id").Type)
+	assert.Equal(t, bigquery.BytesFieldType, schemaField(links, "linked_span_id").Type)
+
+	exemplarSpans := schemaField(withIDColumns(bigquery.Schema{rowconv.ExemplarSpansField}, idColumnsBytes), rowconv.ExemplarSpansColumn)
+	assert.Equal(t, bigquery.BytesFieldType, schemaField(exemplarSpans.Schema, "trace_id").Type)
+	assert.Equal(t, bigquery.BytesFieldType, schemaField(exemplarSpans.Schema, "span_id").Type)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(rowconv.ExemplarSpansField.Schema, "trace_id").Type, "schema must not be modified")
+}
+
+func TestSetBytesIDs(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	span.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+
+	rows := rowconv.Traces(td, rowconv.TracesOptions{})
+	rows = append(rows, row{"trace_id": "unknown"})
+	setBytesIDs(rows)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, rows[0]["trace_id"])
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, rows[0]["span_id"])
+	assert.Equal(t, []byte{}, rows[0]["parent_span_id"])
+	assert.Equal(t, span.Name(), rows[0]["name"])
+	assert.Equal(t, []byte("unknown"), rows[1]["trace_id"])
+	assert.NotContains(t, rows[1], "span_id")
+}
@@ -14,7 +14,11 @@ import (
 	"time"
 
 	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
 	"golang.org/x/oauth2/google"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconvtest"
 )
 
 const runIntegrationEnv = "RUN_BIGQUERY_INTEGRATION"
@@ -122,6 +126,25 @@ func (f *integrationFixture) rowCount(table string) (int64, error) {
 	return v, nil
 }
 
+// requireRoundTrip checks that table stores exactly want, normalized with
+// schema, and that the data reconstructed from the stored rows converts
+// back to the same rows.
+func requireRoundTrip[T any](t *testing.T, f *integrationFixture, table string, schema bigquery.Schema, want []rowconv.Row, reconstruct func([]rowconv.Row) (T, error), convert func(T) []rowconv.Row) {
+	t.Helper()
+	rows, err := rowconvtest.ReadRows(f.ctx, f.client.Dataset(f.datasetID).Table(table))
+	if err != nil {
+		t.Fatalf("read rows of %s: %v", table, err)
+	}
+	got := rowconvtest.Normalize(schema, rows)
+	assert.ElementsMatch(t, rowconvtest.Normalize(schema, want), got, "rows of %s", table)
+
+	data, err := reconstruct(got)
+	if err != nil {
+		t.Fatalf("reconstruct rows of %s: %v", table, err)
+	}
+	assert.ElementsMatch(t, got, rowconvtest.Normalize(schema, convert(data)), "reconstructed rows of %s", table)
+}
+
 func adcProjectID(ctx context.Context) (string, error) {
 	for _, k := range []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT", "GCP_PROJECT"} {
 		if v := strings.TrimSpace(os.Getenv(k)); v != "" {
@@ -6,9 +6,16 @@ package bigqueryexporter
 import (
 	"strings"
 	"testing"
+	"time"
 
-	"go.uber.org/zap"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconvtest"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
 )
 
@@ -21,9 +28,9 @@ func TestIntegration_ExporterLifecycleAndWrites(t *testing.T) {
 		cfg.Dataset.Project = fx.projectID
 		cfg.Dataset.ID = temporaryDatasetID()
 
-		exp := newBigQueryExporter(t.Context(), cfg, zap.NewNop())
+		exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
 
-		err := exp.start(t.Context(), nil)
+		err := exp.Start(t.Context(), nil)
 		if err == nil {
 			t.Fatal("start expected error, got nil")
 		}
@@ -36,13 +43,15 @@ func TestIntegration_ExporterLifecycleAndWrites(t *testing.T) {
 		cfg := createDefaultConfig()
 		cfg.Dataset.Project = fx.projectID
 		cfg.Dataset.ID = fx.datasetID
+		cfg.PreflightAuthCheck = true
+		cfg.Write.WarmUp = true
 
-		exp := newBigQueryExporter(t.Context(), cfg, zap.NewNop())
-		if err := exp.start(t.Context(), nil); err != nil {
+		exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+		if err := exp.Start(t.Context(), nil); err != nil {
 			t.Fatalf("start exporter: %v", err)
 		}
 		defer func() {
-			if err := exp.shutdown(t.Context()); err != nil {
+			if err := exp.Shutdown(t.Context()); err != nil {
 				t.Fatalf("shutdown exporter: %v", err)
 			}
 		}()
@@ -80,12 +89,12 @@ func TestIntegration_ExporterLifecycleAndWrites(t *testing.T) {
 		cfg.Dataset.Table.Metric = "metric_custom"
 		cfg.Dataset.Table.Log = "log_custom"
 
-		exp := newBigQueryExporter(t.Context(), cfg, zap.NewNop())
-		if err := exp.start(t.Context(), nil); err != nil {
+		exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+		if err := exp.Start(t.Context(), nil); err != nil {
 			t.Fatalf("start exporter: %v", err)
 		}
 		defer func() {
-			if err := exp.shutdown(t.Context()); err != nil {
+			if err := exp.Shutdown(t.Context()); err != nil {
 				t.Fatalf("shutdown exporter: %v", err)
 			}
 		}()
@@ -116,4 +125,78 @@ func TestIntegration_ExporterLifecycleAndWrites(t *testing.T) {
 		fx.waitForRows(t, cfg.Dataset.Table.Metric, 12)
 		fx.waitForRows(t, cfg.Dataset.Table.Log, 6)
 	})
+	t.Run("pending write mode commits on shutdown", func(t *testing.T) {
+		cfg := createDefaultConfig()
+		cfg.Dataset.Project = fx.projectID
+		cfg.Dataset.ID = fx.datasetID
+		cfg.Dataset.Table.Trace = "trace_pending"
+		cfg.Dataset.Table.Metric = "metric_pending"
+		cfg.Dataset.Table.Log = "log_pending"
+		cfg.Write.Mode = writeModePending
+		cfg.Write.CommitInterval = time.Hour
+
+		exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+		if err := exp.Start(t.Context(), nil); err != nil {
+			t.Fatalf("start exporter: %v", err)
+		}
+		if err := exp.pushLogs(t.Context(), testdata.GenerateLogsManyLogRecordsSameResource(4)); err != nil {
+			t.Fatalf("push logs: %v", err)
+		}
+		if err := exp.Shutdown(t.Context()); err != nil {
+			t.Fatalf("shutdown exporter: %v", err)
+		}
+
+		fx.waitForRows(t, cfg.Dataset.Table.Log, 4)
+	})
+
+	for _, preset := range schemaPresetNames() {
+		t.Run("round trips rows with schema preset "+preset, func(t *testing.T) {
+			cfg := createDefaultConfig()
+			cfg.Dataset.Project = fx.projectID
+			cfg.Dataset.ID = fx.datasetID
+			cfg.Dataset.Table.Trace = "trace_" + preset
+			cfg.Dataset.Table.Metric = "metric_" + preset
+			cfg.Dataset.Table.Log = "log_" + preset
+			cfg.SchemaPreset = preset
+
+			exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+			if err := exp.Start(t.Context(), nil); err != nil {
+				t.Fatalf("start exporter: %v", err)
+			}
+			defer func() {
+				if err := exp.Shutdown(t.Context()); err != nil {
+					t.Fatalf("shutdown exporter: %v", err)
+				}
+			}()
+
+			td := testdata.GenerateTracesTwoSpansSameResourceOneDifferent()
+			md := testdata.GeneratMetricsAllTypesWithSampleDatapoints()
+			ld := testdata.GenerateLogsTwoLogRecordsSameResource()
+			if err := exp.pushTraces(t.Context(), td); err != nil {
+				t.Fatalf("push traces: %v", err)
+			}
+			if err := exp.pushMetrics(t.Context(), md); err != nil {
+				t.Fatalf("push metrics: %v", err)
+			}
+			if err := exp.pushLogs(t.Context(), ld); err != nil {
+				t.Fatalf("push logs: %v", err)
+			}
+			fx.waitForRows(t, cfg.Dataset.Table.Trace, int64(td.SpanCount()))
+			fx.waitForRows(t, cfg.Dataset.Table.Metric, int64(md.DataPointCount()))
+			fx.waitForRows(t, cfg.Dataset.Table.Log, int64(ld.LogRecordCount()))
+
+			requireRoundTrip(t, fx, cfg.Dataset.Table.Trace, tracesTableSchema(preset, TracesConfig{JSONColumns: jsonColumnsJSON}),
+				rowconv.Traces(td, cfg.Traces.rowOptions()), rowconvtest.Traces, func(td ptrace.Traces) []rowconv.Row {
+					return rowconv.Traces(td, cfg.Traces.rowOptions())
+				})
+			requireRoundTrip(t, fx, cfg.Dataset.Table.Metric, tableSchema(rowconv.MetricsSchema, preset, jsonColumnsJSON),
+				rowconv.Metrics(md, cfg.Metrics.rowOptions()), rowconvtest.Metrics, func(md pmetric.Metrics) []rowconv.Row {
+					return rowconv.Metrics(md, cfg.Metrics.rowOptions())
+				})
+			requireRoundTrip(t, fx, cfg.Dataset.Table.Log, tableSchema(rowconv.LogsSchema, preset, jsonColumnsJSON),
+				rowconv.Logs(ld, cfg.Logs.rowOptions()), rowconvtest.Logs, func(ld plog.Logs) []rowconv.Row {
+					return rowconv.Logs(ld, cfg.Logs.rowOptions())
+				})
+		})
+	}
 }
@@ -4,63 +4,72 @@
 package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
 
 import (
+	"slices"
+
 	"cloud.google.com/go/bigquery"
-	"go.opentelemetry.io/collector/pdata/pcommon"
-	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
 )
 
-var logsSchema = bigquery.Schema{
-	{Name: "observed_timestamp", Type: bigquery.TimestampFieldType, Required: false},
-	{Name: "log_timestamp", Type: bigquery.TimestampFieldType, Required: false},
-	{Name: "trace_id", Type: bigquery.StringFieldType, Required: false},
-	{Name: "span_id", Type: bigquery.StringFieldType, Required: false},
-	{Name: "severity_number", Type: bigquery.IntegerFieldType, Required: false},
-	{Name: "severity_text", Type: bigquery.StringFieldType, Required: false},
-	{Name: "body", Type: bigquery.StringFieldType, Required: false},
-	{Name: "flags", Type: bigquery.IntegerFieldType, Required: false},
-	{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType, Required: false},
-	{Name: "resource_attributes", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "resource_schema_url", Type: bigquery.StringFieldType, Required: false},
-	{Name: "log_attributes", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "instrumentation_scope", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "scope_schema_url", Type: bigquery.StringFieldType, Required: false},
+// logsTableSchema returns the logs table schema with the preset applied and
+// the optional columns enabled in cfg.
+func logsTableSchema(preset string, cfg LogsConfig) bigquery.Schema {
+	schema := withKeyValueAttributes(rowconv.LogsSchema, cfg.AttributesEncoding)
+	if cfg.BodyJSON {
+		// Appended before the preset, so it is a STRING column wherever the
+		// other JSON columns are.
+		schema = slices.Concat(schema, bigquery.Schema{rowconv.BodyJSONField})
+	}
+	schema = tableSchema(schema, preset, cfg.JSONColumns)
+	if cfg.PartitionTimestamp != "" {
+		schema = append(schema, rowconv.PartitionTimestampField)
+	}
+	if cfg.Severity.Enabled {
+		schema = append(schema, rowconv.SeverityField)
+	}
+	schema = withScopeColumns(schema, cfg.ScopeColumns)
+	if cfg.RawOTLP {
+		schema = append(schema, rowconv.RawOTLPField)
+	}
+	schema = append(schema, rowconv.PromotedFields(promotedAttributes(cfg.PromotedAttributes))...)
+	schema = append(schema, rowconv.ComputedFields(computedColumns(cfg.ComputedColumns))...)
+	return withTruncated(schema, cfg.MaxColumnBytes)
 }
 
-func logsToRows(ld plog.Logs) []row {
-	var rows []row
-	for _, rl := range ld.ResourceLogs().All() {
-		for _, sl := range rl.ScopeLogs().All() {
-			for _, lr := range sl.LogRecords().All() {
-				rows = append(rows, row{
-					"observed_timestamp":       lr.ObservedTimestamp().AsTime(),
-					"log_timestamp":            lr.Timestamp().AsTime(),
-					"trace_id":                 traceIDToHex(lr.TraceID()),
-					"span_id":                  spanIDToHex(lr.SpanID()),
-					"severity_number":          int64(lr.SeverityNumber()),
-					"severity_text":            lr.SeverityText(),
-					"body":                     bodyToString(lr.Body()),
-					"flags":                    int64(uint32(lr.Flags())),
-					"dropped_attributes_count": int64(lr.DroppedAttributesCount()),
-					"resource_attributes":      attributesToJSON(rl.Resource().Attributes()),
-					"resource_schema_url":      rl.SchemaUrl(),
-					"log_attributes":           attributesToJSON(lr.Attributes()),
-					"instrumentation_scope":    scopeToJSON(sl.Scope()),
-					"scope_schema_url":         sl.SchemaUrl(),
-				})
-			}
-		}
+// partitioning returns the partitioning of the logs table. Tables are
+// partitioned on partition_timestamp when it is enabled and no other column
+// is configured.
+func (cfg LogsConfig) partitioning() PartitioningConfig {
+	partitioning := cfg.Partitioning
+	if cfg.PartitionTimestamp != "" && partitioning.Field == "" {
+		partitioning.Field = rowconv.PartitionTimestampField.Name
 	}
+	return partitioning
+}
 
-	return rows
+// timestampColumns returns the columns holding the event time of log rows
+// in order of preference, as used for time-sharded tables, event_date,
+// expires_at and the watermark.
+func (cfg LogsConfig) timestampColumns() []string {
+	if cfg.PartitionTimestamp == rowconv.PartitionTimestampObserved {
+		return []string{"observed_timestamp"}
+	}
+	return []string{"log_timestamp", "observed_timestamp"}
 }
 
-func bodyToString(body pcommon.Value) string {
-	switch body.Type() {
-	case pcommon.ValueTypeMap, pcommon.ValueTypeSlice:
-		return marshalJSON(body.AsRaw())
-	case pcommon.ValueTypeEmpty:
-		return ""
-	default:
-		return body.AsString()
+// rowOptions returns the conversion options of cfg.
+func (cfg LogsConfig) rowOptions() rowconv.LogsOptions {
+	return rowconv.LogsOptions{
+		TraceContextFromAttributes: cfg.TraceContextFromAttributes,
+		EntityEvents:               cfg.EntityEvents,
+		PartitionTimestamp:         cfg.PartitionTimestamp,
+		PromotedAttributes:         promotedAttributes(cfg.PromotedAttributes),
+		AttributeFilters:           cfg.AttributeFilters.filters(),
+		KeyValueAttributes:         cfg.AttributesEncoding == attributesEncodingKeyValue,
+		ScopeColumns:               cfg.ScopeColumns != "",
+		Severity:                   cfg.Severity.options(),
+		RawOTLP:                    cfg.RawOTLP,
+		BodyJSON:                   cfg.BodyJSON,
+		ComputedColumns:            computedColumns(cfg.ComputedColumns),
 	}
 }
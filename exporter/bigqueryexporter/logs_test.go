@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsTableSchemaPartitionTimestamp(t *testing.T) {
+	assert.Nil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{}), "partition_timestamp"))
+	field := schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{PartitionTimestamp: "event"}), "partition_timestamp")
+	if assert.NotNil(t, field) {
+		assert.Equal(t, bigquery.TimestampFieldType, field.Type)
+	}
+}
+
+func TestLogsPartitioning(t *testing.T) {
+	day := string(bigquery.DayPartitioningType)
+	tests := []struct {
+		name string
+		cfg  LogsConfig
+		want PartitioningConfig
+	}{
+		{
+			name: "ingestion time",
+			cfg:  LogsConfig{Partitioning: PartitioningConfig{Granularity: day}},
+			want: PartitioningConfig{Granularity: day},
+		},
+		{
+			name: "partition timestamp",
+			cfg:  LogsConfig{PartitionTimestamp: "observed", Partitioning: PartitioningConfig{Granularity: day}},
+			want: PartitioningConfig{Field: "partition_timestamp", Granularity: day},
+		},
+		{
+			name: "configured field",
+			cfg:  LogsConfig{PartitionTimestamp: "event", Partitioning: PartitioningConfig{Field: "log_timestamp", Granularity: day}},
+			want: PartitioningConfig{Field: "log_timestamp", Granularity: day},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.partitioning())
+		})
+	}
+}
+
+func TestLogsTableSchemaSeverity(t *testing.T) {
+	assert.Nil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{}), "severity"))
+	assert.NotNil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{Severity: SeverityConfig{Enabled: true}}), "severity"))
+	assert.Nil(t, LogsConfig{}.rowOptions().Severity)
+	assert.NotNil(t, LogsConfig{Severity: SeverityConfig{Enabled: true}}.rowOptions().Severity)
+}
+
+func TestLogsTableSchemaRawOTLP(t *testing.T) {
+	assert.Nil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{}), "raw_otlp"))
+	assert.NotNil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{RawOTLP: true}), "raw_otlp"))
+}
+
+func TestLogsTimestampColumns(t *testing.T) {
+	assert.Equal(t, []string{"log_timestamp", "observed_timestamp"}, LogsConfig{}.timestampColumns())
+	assert.Equal(t, []string{"log_timestamp", "observed_timestamp"}, LogsConfig{PartitionTimestamp: "event"}.timestampColumns())
+	assert.Equal(t, []string{"observed_timestamp"}, LogsConfig{PartitionTimestamp: "observed"}.timestampColumns())
+}
+
+func TestLogsRowOptions(t *testing.T) {
+	cfg := LogsConfig{TraceContextFromAttributes: true, EntityEvents: true, PartitionTimestamp: "event", RawOTLP: true}
+	opts := cfg.rowOptions()
+	assert.True(t, opts.TraceContextFromAttributes)
+	assert.True(t, opts.EntityEvents)
+	assert.Equal(t, "event", opts.PartitionTimestamp)
+	assert.True(t, opts.RawOTLP)
+}
+
+func TestLogsTableSchemaBodyJSON(t *testing.T) {
+	assert.Nil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{}), "body_json"))
+	cfg := LogsConfig{BodyJSON: true}
+	assert.True(t, cfg.rowOptions().BodyJSON)
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(logsTableSchema(defaultSchemaPreset, cfg), "body_json").Type)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(logsTableSchema("compat", cfg), "body_json").Type)
+}
@@ -0,0 +1,100 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"golang.org/x/sync/semaphore"
+)
+
+// rowBytesPerOTLPByte estimates the memory the rows converted from data take
+// per byte of its OTLP encoding. Rows are held both as values and encoded,
+// each about the size of the OTLP encoding.
+const rowBytesPerOTLPByte = 2
+
+// copyBytesPerOTLPByte estimates the memory a deep copy of data, such as the
+// one anonymize_attributes makes, takes per byte of its OTLP encoding.
+const copyBytesPerOTLPByte = 1
+
+var (
+	tracesSizer  ptrace.ProtoMarshaler
+	metricsSizer pmetric.ProtoMarshaler
+	logsSizer    plog.ProtoMarshaler
+)
+
+// memoryLimiter bounds the memory of the rows that the pushes of an exporter
+// hold between conversion and acknowledgement. Pushes acquire memory before
+// the anonymizer copies their data and before converting it, estimated from
+// its OTLP size, and wait for memory to be released when the limit is
+// reached, which pushes back on the sending queue instead of growing the heap
+// while BigQuery is slow.
+type memoryLimiter struct {
+	sem   *semaphore.Weighted
+	limit int64
+}
+
+// newMemoryLimiter returns a limiter for limitMiB mebibytes, or nil, which
+// never limits, when limitMiB is zero.
+func newMemoryLimiter(limitMiB int) *memoryLimiter {
+	if limitMiB <= 0 {
+		return nil
+	}
+	limit := int64(limitMiB) << 20
+	return &memoryLimiter{sem: semaphore.NewWeighted(limit), limit: limit}
+}
+
+// acquire waits until n bytes are available or ctx is done, and returns the
+// function releasing them. A request larger than the whole limit waits for
+// all of it, so that it can still be sent on its own.
+func (m *memoryLimiter) acquire(ctx context.Context, n int64) (func(), error) {
+	if m == nil || n <= 0 {
+		return func() {}, nil
+	}
+	n = min(n, m.limit)
+	if err := m.sem.Acquire(ctx, n); err != nil {
+		return nil, fmt.Errorf("wait for %d bytes below the memory limit: %w", n, err)
+	}
+	return func() { m.sem.Release(n) }, nil
+}
+
+// acquireTraces acquires the memory of the rows converted from td and, when
+// copied is set, of the copy of td the anonymizer makes.
+func (m *memoryLimiter) acquireTraces(ctx context.Context, td ptrace.Traces, copied bool) (func(), error) {
+	if m == nil {
+		return func() {}, nil
+	}
+	return m.acquire(ctx, bytesPerOTLPByte(copied)*int64(tracesSizer.TracesSize(td)))
+}
+
+// acquireMetrics acquires the memory of the rows converted from md and,
+// when copied is set, of the copy of md the anonymizer makes.
+func (m *memoryLimiter) acquireMetrics(ctx context.Context, md pmetric.Metrics, copied bool) (func(), error) {
+	if m == nil {
+		return func() {}, nil
+	}
+	return m.acquire(ctx, bytesPerOTLPByte(copied)*int64(metricsSizer.MetricsSize(md)))
+}
+
+// acquireLogs acquires the memory of the rows converted from ld and, when
+// copied is set, of the copy of ld the anonymizer makes.
+func (m *memoryLimiter) acquireLogs(ctx context.Context, ld plog.Logs, copied bool) (func(), error) {
+	if m == nil {
+		return func() {}, nil
+	}
+	return m.acquire(ctx, bytesPerOTLPByte(copied)*int64(logsSizer.LogsSize(ld)))
+}
+
+// bytesPerOTLPByte estimates the memory a push takes per byte of the OTLP
+// encoding of its data.
+func bytesPerOTLPByte(copied bool) int64 {
+	if copied {
+		return rowBytesPerOTLPByte + copyBytesPerOTLPByte
+	}
+	return rowBytesPerOTLPByte
+}
@@ -0,0 +1,103 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	assert.Nil(t, newMemoryLimiter(0))
+	release, err := newMemoryLimiter(0).acquire(t.Context(), 1<<30)
+	require.NoError(t, err)
+	release()
+
+	m := newMemoryLimiter(1)
+	releaseHalf, err := m.acquire(t.Context(), 512<<10)
+	require.NoError(t, err)
+
+	// The limit is reached, so the next append waits until its deadline.
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	_, err = m.acquire(ctx, 768<<10)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	releaseHalf()
+	// A request larger than the limit waits for all of it instead of
+	// blocking forever.
+	releaseAll, err := m.acquire(t.Context(), 4<<20)
+	require.NoError(t, err)
+	assert.False(t, m.sem.TryAcquire(1))
+	releaseAll()
+	assert.True(t, m.sem.TryAcquire(1<<20))
+}
+
+func TestMemoryLimiterAcquireSignals(t *testing.T) {
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	md := pmetric.NewMetrics()
+	md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetName("metric")
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+
+	var nilLimiter *memoryLimiter
+	release, err := nilLimiter.acquireTraces(t.Context(), td, false)
+	require.NoError(t, err)
+	release()
+
+	m := newMemoryLimiter(1)
+	for name, acquire := range map[string]func(context.Context) (func(), error){
+		"traces":  func(ctx context.Context) (func(), error) { return m.acquireTraces(ctx, td, false) },
+		"metrics": func(ctx context.Context) (func(), error) { return m.acquireMetrics(ctx, md, false) },
+		"logs":    func(ctx context.Context) (func(), error) { return m.acquireLogs(ctx, ld, true) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.True(t, m.sem.TryAcquire(m.limit))
+			// Nothing is left for the rows of the data, so the push waits
+			// until its deadline before converting it.
+			ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+			defer cancel()
+			_, err := acquire(ctx)
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			m.sem.Release(m.limit)
+
+			release, err := acquire(t.Context())
+			require.NoError(t, err)
+			assert.False(t, m.sem.TryAcquire(m.limit))
+			release()
+			assert.True(t, m.sem.TryAcquire(m.limit))
+			m.sem.Release(m.limit)
+		})
+	}
+}
+
+func TestMemoryLimiterAcquireCopy(t *testing.T) {
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+	size := int64(logsSizer.LogsSize(ld))
+
+	m := newMemoryLimiter(1)
+	release, err := m.acquireLogs(t.Context(), ld, false)
+	require.NoError(t, err)
+	assert.True(t, m.sem.TryAcquire(m.limit-rowBytesPerOTLPByte*size))
+	assert.False(t, m.sem.TryAcquire(1))
+	m.sem.Release(m.limit - rowBytesPerOTLPByte*size)
+	release()
+
+	// A copy of the data, e.g. by the anonymizer, is reserved as well.
+	release, err = m.acquireLogs(t.Context(), ld, true)
+	require.NoError(t, err)
+	assert.True(t, m.sem.TryAcquire(m.limit-(rowBytesPerOTLPByte+copyBytesPerOTLPByte)*size))
+	assert.False(t, m.sem.TryAcquire(1))
+	m.sem.Release(m.limit - (rowBytesPerOTLPByte+copyBytesPerOTLPByte)*size)
+	release()
+}
@@ -0,0 +1,196 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestMetricsTableSchemaUpsert(t *testing.T) {
+	assert.Nil(t, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{}), "series_id"))
+	assert.NotNil(t, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{Upsert: UpsertConfig{Enabled: true}}), "series_id"))
+}
+
+func TestMetricsTableSchemaRawOTLP(t *testing.T) {
+	assert.Nil(t, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{}), "raw_otlp"))
+	assert.NotNil(t, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{RawOTLP: true}), "raw_otlp"))
+	assert.True(t, MetricsConfig{RawOTLP: true}.rowOptions().RawOTLP)
+}
+
+func TestMetricsTableSchemaExponentialHistogramColumns(t *testing.T) {
+	assert.Nil(t, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{}), "scale"))
+	schema := metricsTableSchema(defaultSchemaPreset, MetricsConfig{ExponentialHistogramColumns: true})
+	assert.NotNil(t, schemaField(schema, "scale"))
+	assert.True(t, schemaField(schema, "positive_bucket_counts").Repeated)
+	assert.True(t, MetricsConfig{ExponentialHistogramColumns: true}.rowOptions().ExponentialHistogramColumns)
+}
+
+func TestMetricsTableSchemaNormalizeUnits(t *testing.T) {
+	assert.Nil(t, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{}), "normalized_unit"))
+	cfg := MetricsConfig{NormalizeUnits: true, GroupDataPoints: true}
+	assert.True(t, cfg.rowOptions().NormalizeUnits)
+	schema := metricsTableSchema(defaultSchemaPreset, cfg)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "normalized_unit").Type)
+	assert.Equal(t, bigquery.FloatFieldType, schemaField(schema, "unit_scale").Type)
+	assert.NotNil(t, schemaField(schemaField(schema, rowconv.DataPointsColumn).Schema, "normalized_value"))
+}
+
+func TestSetSeriesIDs(t *testing.T) {
+	rows := []row{
+		{"metric_name": "requests", "datapoint_attributes": `{"route":"/a"}`, "value_int": int64(1)},
+		{"metric_name": "requests", "datapoint_attributes": `{"route":"/a"}`, "value_int": int64(2)},
+		{"metric_name": "requests", "datapoint_attributes": `{"route":"/b"}`, "value_int": int64(1)},
+		{"metric_name": "latency", "datapoint_attributes": `{"route":"/a"}`, "value_int": int64(1)},
+	}
+	setSeriesIDs(rows)
+
+	ids := make([]string, len(rows))
+	for i, r := range rows {
+		ids[i] = r["series_id"].(string)
+		assert.Len(t, ids[i], 32)
+	}
+	// Values do not identify the series, attributes and names do.
+	assert.Equal(t, ids[0], ids[1])
+	assert.NotEqual(t, ids[0], ids[2])
+	assert.NotEqual(t, ids[0], ids[3])
+}
+
+func TestMetricsTableSchemaGroupDataPoints(t *testing.T) {
+	assert.Nil(t, schemaField(metricsTableSchema(defaultSchemaPreset, MetricsConfig{}), rowconv.DataPointsColumn))
+	cfg := MetricsConfig{GroupDataPoints: true, RawOTLP: true, ExponentialHistogramColumns: true}
+	assert.True(t, cfg.rowOptions().GroupDataPoints)
+	schema := metricsTableSchema(defaultSchemaPreset, cfg)
+	assert.NotNil(t, schemaField(schema, "metric_name"))
+	assert.NotNil(t, schemaField(schema, "resource_attributes"))
+	assert.NotNil(t, schemaField(schema, "datapoint_timestamp"))
+	assert.Nil(t, schemaField(schema, "value_double"))
+	dataPoints := schemaField(schema, rowconv.DataPointsColumn)
+	require.NotNil(t, dataPoints)
+	assert.Equal(t, bigquery.RecordFieldType, dataPoints.Type)
+	assert.True(t, dataPoints.Repeated)
+	for _, name := range []string{"datapoint_timestamp", "value_double", "datapoint_attributes", "positive_bucket_counts", "raw_otlp"} {
+		assert.NotNil(t, schemaField(dataPoints.Schema, name), name)
+	}
+	assert.Nil(t, schemaField(dataPoints.Schema, "metric_name"))
+}
+
+func TestEncodeRowGroupDataPoints(t *testing.T) {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("latency")
+	dps := m.SetEmptyExponentialHistogram().DataPoints()
+	for i := range 2 {
+		dp := dps.AppendEmpty()
+		dp.SetTimestamp(pcommon.Timestamp(i + 1))
+		dp.SetCount(uint64(i))
+		dp.Positive().BucketCounts().FromRaw([]uint64{1, 2})
+		dp.Attributes().PutInt("shard", int64(i))
+	}
+
+	for _, preset := range []string{defaultSchemaPreset, "compat", "structured"} {
+		cfg := MetricsConfig{GroupDataPoints: true, RawOTLP: true, ExponentialHistogramColumns: true}
+		desc, _, err := schemaDescriptor(metricsTableSchema(preset, cfg))
+		require.NoError(t, err, preset)
+		rows := rowconv.Metrics(md, cfg.rowOptions())
+		require.Len(t, rows, 1, preset)
+		b, err := encodeRow(desc, rows[0])
+		require.NoError(t, err, preset)
+		msg := dynamicpb.NewMessage(desc)
+		require.NoError(t, proto.Unmarshal(b, msg), preset)
+
+		assert.Equal(t, "latency", nestedField(msg, "metric_name").String(), preset)
+		records := nestedField(msg, rowconv.DataPointsColumn).List()
+		require.Equal(t, 2, records.Len(), preset)
+		record := records.Get(1).Message()
+		assert.Equal(t, int64(1), nestedField(record, "count").Int(), preset)
+		assert.Equal(t, 2, nestedField(record, "positive_bucket_counts").List().Len(), preset)
+		assert.NotEmpty(t, nestedField(record, "raw_otlp").Bytes(), preset)
+	}
+}
+
+func TestEncodeRowExemplarSpans(t *testing.T) {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("requests")
+	ex := m.SetEmptySum().DataPoints().AppendEmpty().Exemplars().AppendEmpty()
+	traceID := pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	spanID := pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8}
+	ex.SetTraceID(traceID)
+	ex.SetSpanID(spanID)
+
+	cfg := MetricsConfig{ExemplarSpans: true}
+	assert.True(t, cfg.rowOptions().ExemplarSpans)
+	for _, grouped := range []bool{false, true} {
+		cfg.GroupDataPoints = grouped
+		for _, idColumnsSetting := range []string{idColumnsString, idColumnsBytes} {
+			schema := withIDColumns(metricsTableSchema(defaultSchemaPreset, cfg), idColumnsSetting)
+			desc, _, err := schemaDescriptor(schema)
+			require.NoError(t, err)
+			opts := cfg.rowOptions()
+			opts.ExemplarSpanIDsAsBytes = idColumnsSetting == idColumnsBytes
+			rows := rowconv.Metrics(md, opts)
+			require.Len(t, rows, 1)
+			b, err := encodeRow(desc, rows[0])
+			require.NoError(t, err)
+			var msg protoreflect.Message = dynamicpb.NewMessage(desc)
+			require.NoError(t, proto.Unmarshal(b, msg.Interface()))
+
+			if grouped {
+				msg = nestedField(msg, rowconv.DataPointsColumn).List().Get(0).Message()
+			}
+			spans := nestedField(msg, rowconv.ExemplarSpansColumn).List()
+			require.Equal(t, 1, spans.Len())
+			span := spans.Get(0).Message()
+			if idColumnsSetting == idColumnsBytes {
+				assert.Equal(t, traceID[:], nestedField(span, "trace_id").Bytes())
+				assert.Equal(t, spanID[:], nestedField(span, "span_id").Bytes())
+			} else {
+				assert.Equal(t, traceID.String(), nestedField(span, "trace_id").String())
+				assert.Equal(t, spanID.String(), nestedField(span, "span_id").String())
+			}
+		}
+	}
+}
+
+func TestMetricNamesMetricName(t *testing.T) {
+	assert.Nil(t, MetricNamesConfig{}.metricName())
+
+	metric := pmetric.NewMetric()
+	metric.SetName("http.server.request.duration")
+	metric.SetUnit("s")
+	metric.SetEmptyHistogram()
+	counter := pmetric.NewMetric()
+	counter.SetName("system.network.io")
+	counter.SetUnit("By")
+	counter.SetEmptySum().SetIsMonotonic(true)
+
+	tests := []struct {
+		name   string
+		cfg    MetricNamesConfig
+		metric pmetric.Metric
+		want   string
+	}{
+		{name: "prefix", cfg: MetricNamesConfig{Prefix: "app."}, metric: metric, want: "app.http.server.request.duration"},
+		{name: "sanitize", cfg: MetricNamesConfig{Normalization: metricNamesSanitize}, metric: metric, want: "http_server_request_duration"},
+		{name: "prometheus", cfg: MetricNamesConfig{Normalization: metricNamesPrometheus}, metric: metric, want: "http_server_request_duration_seconds"},
+		{name: "prometheus counter", cfg: MetricNamesConfig{Normalization: metricNamesPrometheus, Prefix: "otel_"}, metric: counter, want: "otel_system_network_io_bytes_total"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.metricName()(tt.metric))
+			assert.NotNil(t, MetricsConfig{MetricNames: tt.cfg}.rowOptions().MetricName)
+		})
+	}
+}
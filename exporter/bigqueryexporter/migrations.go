@@ -0,0 +1,166 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// schemaVersionLabel is the table label holding the schema version of a
+	// table the exporter created or migrated.
+	schemaVersionLabel = "otel_schema_version"
+	// schemaVersion is the schema version of the tables the exporter
+	// creates. It is increased with every migration.
+	schemaVersion = 2
+	// unlabeledSchemaVersion is the schema version of tables without the
+	// label, which exporters created before tables were labeled.
+	unlabeledSchemaVersion = 1
+)
+
+// migration evolves tables from the previous schema version to version.
+// Migrations only add what is missing, so tables an earlier exporter
+// already brought up to date are migrated without changes.
+type migration struct {
+	version     int
+	description string
+	// columns are the columns the migration adds, per signal. Columns the
+	// target does not write, e.g. because of its schema preset, are
+	// skipped.
+	columns map[string][]string
+}
+
+// migrations are ordered by version, and the last one has schemaVersion.
+var migrations = []migration{
+	{
+		version:     2,
+		description: "add has_sum, has_min and has_max to the metrics table",
+		columns:     map[string][]string{"metrics": {"has_sum", "has_min", "has_max"}},
+	},
+}
+
+// withSchemaVersion returns the labels of created tables.
+func withSchemaVersion(labels map[string]string) map[string]string {
+	labeled := maps.Clone(labels)
+	if labeled == nil {
+		labeled = make(map[string]string, 1)
+	}
+	labeled[schemaVersionLabel] = strconv.Itoa(schemaVersion)
+	return labeled
+}
+
+// tableSchemaVersion returns the schema version of an existing table.
+func tableSchemaVersion(md *bigquery.TableMetadata) int {
+	version, err := strconv.Atoi(md.Labels[schemaVersionLabel])
+	if err != nil {
+		return unlabeledSchemaVersion
+	}
+	return version
+}
+
+// pendingMigrations returns the migrations of a table with version.
+func pendingMigrations(version int) []migration {
+	idx := slices.IndexFunc(migrations, func(m migration) bool { return m.version > version })
+	if idx < 0 {
+		return nil
+	}
+	return migrations[idx:]
+}
+
+// migrationColumns returns the columns of target that pending add.
+func migrationColumns(pending []migration, target signalTarget) bigquery.Schema {
+	signal := strings.TrimSuffix(target.name, dualWriteSuffix)
+	var columns bigquery.Schema
+	for _, m := range pending {
+		for _, name := range m.columns[signal] {
+			if renamed, ok := target.columnNames[name]; ok {
+				name = renamed
+			}
+			idx := slices.IndexFunc(target.schema, func(f *bigquery.FieldSchema) bool { return f.Name == name })
+			if idx >= 0 {
+				columns = append(columns, target.schema[idx])
+			}
+		}
+	}
+	return columns
+}
+
+func migrationDescriptions(pending []migration) []string {
+	descriptions := make([]string, len(pending))
+	for i, m := range pending {
+		descriptions[i] = fmt.Sprintf("%d: %s", m.version, m.description)
+	}
+	return descriptions
+}
+
+// migrateTable applies the pending migrations to an existing table and
+// labels it with the current schema version, and returns the metadata of
+// the migrated table. Tables are only changed with allow_schema_update;
+// otherwise migrations that would add columns are reported. Like adding
+// missing columns, the update is conditional on the table's etag and
+// recomputed when another collector changed the table concurrently.
+func (e *bigQueryExporter) migrateTable(ctx context.Context, table *bigquery.Table, target signalTarget, md *bigquery.TableMetadata) (*bigquery.TableMetadata, error) {
+	for attempt := 1; ; attempt++ {
+		version := tableSchemaVersion(md)
+		if version > schemaVersion {
+			e.logger.Warn("Existing table has a newer schema version; it was created or migrated by a newer exporter",
+				zap.String("signal", target.name), zap.String("table", target.tableID),
+				zap.Int("schema_version", version), zap.Int("exporter_schema_version", schemaVersion))
+			return md, nil
+		}
+		pending := pendingMigrations(version)
+		if len(pending) == 0 {
+			return md, nil
+		}
+		schema, added := mergeMissingColumns(migrationColumns(pending, target), md.Schema)
+		if !e.cfg.AllowSchemaUpdate {
+			if len(added) > 0 {
+				e.logger.Warn("Existing table has an older schema version; appends may fail until it is migrated, see allow_schema_update",
+					zap.String("signal", target.name), zap.String("table", target.tableID),
+					zap.Int("schema_version", version), zap.Strings("migrations", migrationDescriptions(pending)), zap.Strings("columns", added))
+			}
+			return md, nil
+		}
+		update := bigquery.TableMetadataToUpdate{}
+		if len(added) > 0 {
+			update.Schema = schema
+		}
+		update.SetLabel(schemaVersionLabel, strconv.Itoa(schemaVersion))
+		var migrated *bigquery.TableMetadata
+		err := e.retryControlPlane(ctx, "migrate table", func(ctx context.Context) error {
+			var err error
+			migrated, err = table.Update(ctx, update, md.ETag)
+			return err
+		})
+		if err == nil {
+			e.logger.Info("Migrated existing table", zap.String("signal", target.name), zap.String("table", target.tableID),
+				zap.Int("from_schema_version", version), zap.Int("to_schema_version", schemaVersion),
+				zap.Strings("migrations", migrationDescriptions(pending)), zap.Strings("columns", added))
+			return migrated, nil
+		}
+		var apiErr *googleapi.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusPreconditionFailed || attempt == maxSchemaUpdateAttempts {
+			return nil, fmt.Errorf("migrate %s table %s from schema version %d to %d: %w", target.name, target.tableID, version, schemaVersion, err)
+		}
+		err = e.retryControlPlane(ctx, "get table metadata", func(ctx context.Context) error {
+			var err error
+			md, err = table.Metadata(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get %s table %s metadata: %w", target.name, target.tableID, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWithSchemaVersion(t *testing.T) {
+	assert.Equal(t, map[string]string{"otel_schema_version": "2"}, withSchemaVersion(nil))
+	labels := map[string]string{"team": "observability"}
+	assert.Equal(t, map[string]string{"team": "observability", "otel_schema_version": "2"}, withSchemaVersion(labels))
+	assert.Len(t, labels, 1, "configured labels are not modified")
+}
+
+func TestTableSchemaVersion(t *testing.T) {
+	assert.Equal(t, 1, tableSchemaVersion(&bigquery.TableMetadata{}))
+	assert.Equal(t, 1, tableSchemaVersion(&bigquery.TableMetadata{Labels: map[string]string{"otel_schema_version": "two"}}))
+	assert.Equal(t, 2, tableSchemaVersion(&bigquery.TableMetadata{Labels: map[string]string{"otel_schema_version": "2"}}))
+}
+
+func TestMigrations(t *testing.T) {
+	for i, m := range migrations {
+		assert.Equal(t, i+2, m.version, "migrations are consecutive from the unlabeled version")
+		assert.NotEmpty(t, m.description)
+	}
+	assert.Equal(t, schemaVersion, migrations[len(migrations)-1].version)
+	assert.Len(t, pendingMigrations(unlabeledSchemaVersion), len(migrations))
+	assert.Empty(t, pendingMigrations(schemaVersion))
+}
+
+func TestMigrationColumns(t *testing.T) {
+	pending := pendingMigrations(unlabeledSchemaVersion)
+	schema := tableSchema(rowconv.MetricsSchema, defaultSchemaPreset, "")
+	columns := migrationColumns(pending, signalTarget{name: "metrics", schema: schema})
+	assert.Equal(t, []string{"has_sum", "has_min", "has_max"}, fieldNames(columns))
+	// Dual-write tables are migrated like the tables of their signal.
+	assert.Len(t, migrationColumns(pending, signalTarget{name: "metrics" + dualWriteSuffix, schema: schema}), 3)
+	assert.Empty(t, migrationColumns(pending, signalTarget{name: "logs", schema: schema}))
+}
+
+// migratingTable serves an existing table and applies updates to it. The
+// first preconditionFailures updates fail as if another collector changed
+// the table concurrently.
+type migratingTable struct {
+	mu                   sync.Mutex
+	table                map[string]any
+	preconditionFailures int
+	updates              []map[string]any
+}
+
+func (f *migratingTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method == http.MethodPatch {
+		if f.preconditionFailures > 0 {
+			f.preconditionFailures--
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`{"error":{"code":412,"message":"Precondition check failed."}}`))
+			return
+		}
+		var update map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		f.updates = append(f.updates, update)
+		for key, value := range update {
+			if labels, ok := value.(map[string]any); ok && key == "labels" {
+				// Patched labels are merged into the existing ones.
+				existing, _ := f.table["labels"].(map[string]any)
+				if existing == nil {
+					existing = map[string]any{}
+					f.table["labels"] = existing
+				}
+				maps.Copy(existing, labels)
+				continue
+			}
+			f.table[key] = value
+		}
+	}
+	_ = json.NewEncoder(w).Encode(f.table)
+}
+
+func newMigratingTable(t *testing.T, table map[string]any) (*bigquery.Table, *migratingTable) {
+	fake := &migratingTable{table: table}
+	client := newFakeBigQueryClient(t, fake)
+	return client.Dataset("d").Table("metric"), fake
+}
+
+func TestMigrateTable(t *testing.T) {
+	table, fake := newMigratingTable(t, map[string]any{
+		"tableReference": map[string]any{"projectId": "p", "datasetId": "d", "tableId": "metric"},
+		"schema":         map[string]any{"fields": []any{map[string]any{"name": "metric_name", "type": "STRING"}}},
+		"labels":         map[string]any{"team": "observability"},
+	})
+	fake.preconditionFailures = 1
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	target := signalTarget{name: "metrics", project: "p", dataset: "d", tableID: "metric", schema: tableSchema(rowconv.MetricsSchema, defaultSchemaPreset, "")}
+	md, err := table.Metadata(t.Context())
+	require.NoError(t, err)
+
+	// Without allow_schema_update the table is left as it is.
+	got, err := exp.migrateTable(t.Context(), table, target, md)
+	require.NoError(t, err)
+	assert.Same(t, md, got)
+	assert.Empty(t, fake.updates)
+
+	exp.cfg.AllowSchemaUpdate = true
+	got, err = exp.migrateTable(t.Context(), table, target, md)
+	require.NoError(t, err)
+	require.Len(t, fake.updates, 1)
+	assert.Equal(t, map[string]string{"team": "observability", "otel_schema_version": "2"}, got.Labels)
+	assert.Equal(t, []string{"metric_name", "has_sum", "has_min", "has_max"}, fieldNames(got.Schema))
+
+	// Migrated tables are not updated again.
+	got, err = exp.migrateTable(t.Context(), table, target, got)
+	require.NoError(t, err)
+	assert.Len(t, fake.updates, 1)
+	assert.Equal(t, 2, tableSchemaVersion(got))
+}
+
+func TestMigrateTableNewerVersion(t *testing.T) {
+	table, fake := newMigratingTable(t, map[string]any{
+		"tableReference": map[string]any{"projectId": "p", "datasetId": "d", "tableId": "metric"},
+		"labels":         map[string]any{"otel_schema_version": "99"},
+	})
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	cfg.AllowSchemaUpdate = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	md, err := table.Metadata(t.Context())
+	require.NoError(t, err)
+
+	_, err = exp.migrateTable(t.Context(), table, signalTarget{name: "metrics", tableID: "metric"}, md)
+	require.NoError(t, err)
+	assert.Empty(t, fake.updates)
+}
@@ -0,0 +1,184 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// isNestedField reports whether fd is a RECORD or REPEATED column, as
+// opposed to a scalar or a scalar wrapper.
+func isNestedField(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsList() {
+		return true
+	}
+	return fd.Kind() == protoreflect.MessageKind && !strings.HasPrefix(string(fd.Message().FullName()), "google.protobuf.")
+}
+
+// setNestedField sets a RECORD or REPEATED column of msg from the JSON the
+// converters write for the column, see rowconv.NestedFields.
+func setNestedField(msg *dynamicpb.Message, fd protoreflect.FieldDescriptor, value any) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected JSON string, got %T", value)
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var decoded any
+	if err := dec.Decode(&decoded); err != nil {
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+	return setJSONField(msg, fd, decoded)
+}
+
+// setScalarList sets a REPEATED scalar column of msg from a slice such as
+// the []int64 the converters write for repeated INTEGER columns.
+func setScalarList(msg *dynamicpb.Message, fd protoreflect.FieldDescriptor, value any) error {
+	elements := reflect.ValueOf(value)
+	if elements.Kind() != reflect.Slice {
+		return fmt.Errorf("expected slice, got %T", value)
+	}
+	list := msg.Mutable(fd).List()
+	for i := range elements.Len() {
+		v, err := toProtoreflectValue(fd.Kind(), elements.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		list.Append(v)
+	}
+	return nil
+}
+
+// setJSONField sets fd of msg from a decoded JSON value. NULL values and
+// keys without a field are skipped.
+func setJSONField(msg *dynamicpb.Message, fd protoreflect.FieldDescriptor, value any) error {
+	if value == nil {
+		return nil
+	}
+	if fd.IsList() {
+		elements, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("expected JSON array, got %T", value)
+		}
+		list := msg.Mutable(fd).List()
+		for i, e := range elements {
+			if fd.Kind() == protoreflect.MessageKind {
+				element := dynamicpb.NewMessage(fd.Message())
+				if err := setJSONFields(element, e); err != nil {
+					return fmt.Errorf("element %d: %w", i, err)
+				}
+				list.Append(protoreflect.ValueOfMessage(element))
+				continue
+			}
+			if e == nil {
+				continue
+			}
+			v, err := jsonScalar(fd.Kind(), e)
+			if err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+	if fd.Kind() == protoreflect.MessageKind {
+		return setJSONFields(msg.Mutable(fd).Message().Interface().(*dynamicpb.Message), value)
+	}
+	v, err := jsonScalar(fd.Kind(), value)
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+// setJSONFields sets the fields of msg from a decoded JSON object.
+func setJSONFields(msg *dynamicpb.Message, value any) error {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("expected JSON object, got %T", value)
+	}
+	fields := msg.Descriptor().Fields()
+	for key, v := range object {
+		fd := fields.ByName(protoreflect.Name(key))
+		if fd == nil {
+			continue
+		}
+		if err := setJSONField(msg, fd, v); err != nil {
+			return fmt.Errorf("field %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// jsonScalar converts a decoded JSON value to a field of kind. TIMESTAMP
+// fields are written as RFC 3339 strings, non-finite FLOAT64 values as the
+// strings "NaN", "+Inf" and "-Inf", BYTES fields as base64 strings, and JSON
+// fields hold any value.
+func jsonScalar(kind protoreflect.Kind, value any) (protoreflect.Value, error) {
+	switch kind {
+	case protoreflect.StringKind:
+		if s, ok := value.(string); ok {
+			return toProtoreflectValue(kind, s)
+		}
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(value); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return toProtoreflectValue(kind, strings.TrimSuffix(b.String(), "\n"))
+	case protoreflect.Int64Kind:
+		switch v := value.(type) {
+		case json.Number:
+			i, err := v.Int64()
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfInt64(i), nil
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return toProtoreflectValue(kind, t)
+		}
+	case protoreflect.DoubleKind:
+		switch v := value.(type) {
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfFloat64(f), nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfFloat64(f), nil
+		}
+	case protoreflect.BoolKind:
+		return toProtoreflectValue(kind, value)
+	case protoreflect.BytesKind:
+		if s, ok := value.(string); ok {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfBytes(b), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("cannot convert JSON %T to field kind %v", value, kind)
+}
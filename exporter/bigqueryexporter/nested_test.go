@@ -0,0 +1,143 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// encodeNested encodes r for the structured preset of schema and decodes the
+// encoded row.
+func encodeNested(t *testing.T, schema bigquery.Schema, r rowconv.Row) protoreflect.Message {
+	t.Helper()
+	desc, _, err := schemaDescriptor(tableSchema(schema, "structured", ""))
+	require.NoError(t, err)
+	b, err := encodeRow(desc, r)
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(b, msg))
+	return msg
+}
+
+func nestedField(msg protoreflect.Message, name string) protoreflect.Value {
+	return msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+func TestEncodeRowNestedTraces(t *testing.T) {
+	td := ptrace.NewTraces()
+	ss := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("lib")
+	ss.Scope().Attributes().PutStr("scope.key", "v")
+	span := ss.Spans().AppendEmpty()
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6789, time.UTC)
+	event := span.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	event.Attributes().PutStr("exception.message", "<boom>")
+	span.Events().AppendEmpty().SetName("second")
+	link := span.Links().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID{1})
+	link.SetFlags(1)
+
+	msg := encodeNested(t, rowconv.TracesSchema, rowconv.Traces(td, rowconv.TracesOptions{})[0])
+
+	events := nestedField(msg, "events").List()
+	require.Equal(t, 2, events.Len())
+	first := events.Get(0).Message()
+	assert.Equal(t, "exception", nestedField(first, "name").String())
+	assert.Equal(t, ts.UnixMicro(), nestedField(first, "timestamp").Int())
+	assert.JSONEq(t, `{"exception.message":"<boom>"}`, nestedField(first, "attributes").String())
+	// JSON subfields are written without HTML escaping.
+	assert.Contains(t, nestedField(first, "attributes").String(), "<boom>")
+	assert.Equal(t, "second", nestedField(events.Get(1).Message(), "name").String())
+
+	links := nestedField(msg, "links").List()
+	require.Equal(t, 1, links.Len())
+	assert.Equal(t, "01000000000000000000000000000000", nestedField(links.Get(0).Message(), "trace_id").String())
+	assert.Equal(t, int64(1), nestedField(links.Get(0).Message(), "flags").Int())
+
+	scope := nestedField(msg, "instrumentation_scope").Message()
+	assert.Equal(t, "lib", nestedField(scope, "name").String())
+	assert.JSONEq(t, `{"scope.key":"v"}`, nestedField(scope, "attributes").String())
+}
+
+func TestEncodeRowNestedMetrics(t *testing.T) {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	dp := metrics.AppendEmpty().SetEmptySummary().DataPoints().AppendEmpty()
+	q := dp.QuantileValues().AppendEmpty()
+	q.SetQuantile(0.99)
+	q.SetValue(math.Inf(1))
+	gauge := metrics.AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty()
+	ex := gauge.Exemplars().AppendEmpty()
+	ex.SetIntValue(42)
+	ex.FilteredAttributes().PutBool("sampled", true)
+
+	rows := rowconv.Metrics(md, rowconv.MetricsOptions{})
+	require.Len(t, rows, 2)
+
+	quantiles := nestedField(encodeNested(t, rowconv.MetricsSchema, rows[0]), "quantiles").List()
+	require.Equal(t, 1, quantiles.Len())
+	assert.Equal(t, 0.99, nestedField(quantiles.Get(0).Message(), "quantile").Float())
+	assert.True(t, math.IsInf(nestedField(quantiles.Get(0).Message(), "value").Float(), 1))
+
+	exemplars := nestedField(encodeNested(t, rowconv.MetricsSchema, rows[1]), "exemplars").List()
+	require.Equal(t, 1, exemplars.Len())
+	exemplar := exemplars.Get(0).Message()
+	assert.Equal(t, int64(42), nestedField(exemplar, "value_int").Int())
+	assert.False(t, exemplar.Has(exemplar.Descriptor().Fields().ByName("value_double")))
+	assert.JSONEq(t, `{"sampled":true}`, nestedField(exemplar, "filtered_attributes").String())
+}
+
+func TestEncodeRowNestedInvalid(t *testing.T) {
+	desc, _, err := schemaDescriptor(tableSchema(rowconv.LogsSchema, "structured", ""))
+	require.NoError(t, err)
+	for _, value := range []bigquery.Value{`{"name":`, `[]`, 42} {
+		_, err := encodeRow(desc, rowconv.Row{"instrumentation_scope": value})
+		assert.Error(t, err, "%v", value)
+	}
+}
+
+func TestEncodeRowRepeatedScalar(t *testing.T) {
+	desc, _, err := schemaDescriptor(rowconv.ExponentialHistogramFields)
+	require.NoError(t, err)
+	b, err := encodeRow(desc, rowconv.Row{"scale": int64(-2), "positive_bucket_counts": []int64{1, 0, 4}, "negative_bucket_counts": []int64{}})
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(b, msg))
+	counts := nestedField(msg, "positive_bucket_counts").List()
+	require.Equal(t, 3, counts.Len())
+	assert.Equal(t, int64(4), counts.Get(2).Int())
+	assert.Equal(t, 0, nestedField(msg, "negative_bucket_counts").List().Len())
+
+	_, err = encodeRow(desc, rowconv.Row{"positive_bucket_counts": 1})
+	assert.Error(t, err)
+}
+
+func TestEncodeRowBytes(t *testing.T) {
+	desc, _, err := schemaDescriptor(bigquery.Schema{rowconv.RawOTLPField})
+	require.NoError(t, err)
+	b, err := encodeRow(desc, rowconv.Row{"raw_otlp": []byte{0, 1, 2}})
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(b, msg))
+	assert.Equal(t, []byte{0, 1, 2}, nestedField(msg, "raw_otlp").Bytes())
+
+	_, err = encodeRow(desc, rowconv.Row{"raw_otlp": "not bytes"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// promotedTypes maps the configurable types of promoted columns to their
+// field types.
+var promotedTypes = map[string]bigquery.FieldType{
+	"STRING":  bigquery.StringFieldType,
+	"INT64":   bigquery.IntegerFieldType,
+	"FLOAT64": bigquery.FloatFieldType,
+	"BOOL":    bigquery.BooleanFieldType,
+}
+
+// reservedColumnPrefixes are the prefixes BigQuery reserves for column
+// names.
+var reservedColumnPrefixes = []string{"_TABLE_", "_FILE_", "_PARTITION", "_ROW_TIMESTAMP", "__ROOT__", "_COLIDENTIFIER", "_CHANGE_"}
+
+// optionalColumns are the columns options add to every signal table.
+var optionalColumns = slices.Concat(bigquery.Schema{watermarkField, eventDateField, expiresAtField, rowconv.RawOTLPField, resourceHashField, truncatedField, signalTypeField}, rowconv.ScopeFields, collectorFields)
+
+// column returns the name of the column of the promoted attribute.
+func (cfg PromotedAttributeConfig) column() string {
+	if cfg.Column != "" {
+		return cfg.Column
+	}
+	return sanitizeName(cfg.Key)
+}
+
+// sanitizeName returns name with every character other than letters,
+// digits and underscores replaced by an underscore.
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// fieldType returns the type of the column of the promoted attribute.
+func (cfg PromotedAttributeConfig) fieldType() bigquery.FieldType {
+	if cfg.Type == "" {
+		return bigquery.StringFieldType
+	}
+	return promotedTypes[cfg.Type]
+}
+
+// promotedAttributes returns the conversion options of promoted.
+func promotedAttributes(promoted []PromotedAttributeConfig) []rowconv.PromotedAttribute {
+	if len(promoted) == 0 {
+		return nil
+	}
+	out := make([]rowconv.PromotedAttribute, len(promoted))
+	for i, p := range promoted {
+		out[i] = rowconv.PromotedAttribute{Source: p.Source, Key: p.Key, Column: p.column(), Type: p.fieldType()}
+	}
+	return out
+}
+
+// guardedPromotedAttributes returns the conversion options of promoted,
+// with a cardinality guard for every attribute setting max_cardinality. A
+// guard logs a warning and counts the column in the promoted columns
+// stopped metric when it stops writing its column, whose values then are
+// only in the JSON attribute column. The column stays stopped until the
+// collector restarts, so that its clustering does not flap.
+func (e *bigQueryExporter) guardedPromotedAttributes(signal string, promoted []PromotedAttributeConfig) []rowconv.PromotedAttribute {
+	out := promotedAttributes(promoted)
+	for i, p := range promoted {
+		if p.MaxCardinality == 0 {
+			continue
+		}
+		column := out[i].Column
+		out[i].Guard = rowconv.NewCardinalityGuard(p.MaxCardinality, func() {
+			e.logger.Warn("Promoted column exceeded max_cardinality, its values are only written to the JSON attribute column until the collector restarts",
+				zap.String("signal", signal), zap.String("column", column), zap.Int("max_cardinality", p.MaxCardinality))
+			if e.telemetry != nil {
+				e.telemetry.ExporterBigqueryPromotedColumnsStopped.Add(context.Background(), 1, metric.WithAttributes(
+					attribute.String("signal", signal), attribute.String("column", column)))
+			}
+		})
+	}
+	return out
+}
+
+// validateColumnName checks that column is a valid column name BigQuery
+// does not reserve.
+func validateColumnName(field, column string) error {
+	if err := validateIdentifier(field, column); err != nil {
+		return err
+	}
+	upper := strings.ToUpper(column)
+	if slices.ContainsFunc(reservedColumnPrefixes, func(prefix string) bool { return strings.HasPrefix(upper, prefix) }) {
+		return fmt.Errorf("%s %q uses a prefix BigQuery reserves", field, column)
+	}
+	return nil
+}
+
+// validatePromotedAttributes validates the promoted attributes of a signal
+// whose records are named record. Promoted columns must not collide with
+// each other or with any column the table may have, reserved, whether or
+// not the option adding it is enabled, since a collision would silently
+// make one source overwrite the other.
+func validatePromotedAttributes(field, record string, promoted []PromotedAttributeConfig, reserved bigquery.Schema) error {
+	columns := make(map[string]string, len(promoted))
+	for i, p := range promoted {
+		entry := fmt.Sprintf("%s[%d]", field, i)
+		if p.Key == "" {
+			return fmt.Errorf("%s.key is required", entry)
+		}
+		if p.Source != rowconv.AttributeSourceResource && p.Source != record {
+			return fmt.Errorf("%s.source %q must be %q or %q", entry, p.Source, rowconv.AttributeSourceResource, record)
+		}
+		if _, ok := promotedTypes[p.Type]; !ok && p.Type != "" {
+			return fmt.Errorf("%s.type %q must be one of STRING, INT64, FLOAT64, BOOL", entry, p.Type)
+		}
+		if p.MaxCardinality < 0 {
+			return fmt.Errorf("%s.max_cardinality must not be negative", entry)
+		}
+		column := p.column()
+		if err := validateColumnName(entry+".column", column); err != nil {
+			return err
+		}
+		// BigQuery column names are case-insensitive.
+		if other, ok := columns[strings.ToLower(column)]; ok {
+			return fmt.Errorf("%s.column %q of attribute %q collides with the column of attribute %q", entry, column, p.Key, other)
+		}
+		columns[strings.ToLower(column)] = p.Key
+		if slices.ContainsFunc(reserved, func(f *bigquery.FieldSchema) bool { return strings.EqualFold(f.Name, column) }) {
+			return fmt.Errorf("%s.column %q of attribute %q collides with a column of the exporter, set column to another name", entry, column, p.Key)
+		}
+	}
+	return nil
+}
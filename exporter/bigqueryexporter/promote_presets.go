@@ -0,0 +1,126 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// promotePreset is a named set of promoted attributes selected with the
+// promote_presets setting.
+type promotePreset struct {
+	// resource lists the resource attributes promoted in the traces,
+	// metrics and logs tables.
+	resource []PromotedAttributeConfig
+	// traces, metrics and logs list the record attributes promoted in the
+	// table of their signal.
+	traces, metrics, logs []PromotedAttributeConfig
+}
+
+// promotePresets is the registry of presets selectable by name.
+var promotePresets = map[string]promotePreset{
+	// kubernetes promotes the workload attributes of the k8sattributes
+	// processor, the most common filters in Kubernetes fleets.
+	"kubernetes": {
+		resource: []PromotedAttributeConfig{
+			{Key: "k8s.namespace.name"},
+			{Key: "k8s.pod.name"},
+			{Key: "k8s.container.name"},
+			{Key: "k8s.node.name"},
+		},
+	},
+	// http promotes the HTTP semantic convention attributes of spans most
+	// queries filter or group by.
+	"http": {
+		traces: []PromotedAttributeConfig{
+			{Key: "http.request.method", Source: "span"},
+			{Key: "http.response.status_code", Source: "span", Type: "INT64"},
+			{Key: "http.route", Source: "span"},
+			{Key: "url.path", Source: "span"},
+			{Key: "server.address", Source: "span"},
+		},
+	},
+	// db promotes the database semantic convention attributes of client
+	// spans. Long queries can be truncated with traces.max_column_bytes.
+	"db": {
+		traces: []PromotedAttributeConfig{
+			{Key: "db.system", Source: "span"},
+			{Key: "db.namespace", Source: "span"},
+			{Key: "db.operation.name", Source: "span"},
+			{Key: "db.query.text", Source: "span"},
+		},
+	},
+	// genai promotes the generative AI semantic convention attributes of
+	// model call spans and events, for token usage and latency per model.
+	"genai": {
+		traces: genAIAttributes("span"),
+		logs:   genAIAttributes("log"),
+	},
+}
+
+func genAIAttributes(source string) []PromotedAttributeConfig {
+	return []PromotedAttributeConfig{
+		{Key: "gen_ai.system", Source: source},
+		{Key: "gen_ai.request.model", Source: source},
+		{Key: "gen_ai.usage.input_tokens", Source: source, Type: "INT64"},
+		{Key: "gen_ai.usage.output_tokens", Source: source, Type: "INT64"},
+	}
+}
+
+func promotePresetNames() []string {
+	return slices.Sorted(maps.Keys(promotePresets))
+}
+
+func validatePromotePresets(presets []string) error {
+	for i, name := range presets {
+		if _, ok := promotePresets[name]; !ok {
+			return fmt.Errorf("promote_presets[%d] %q is not supported, must be one of %s", i, name, strings.Join(promotePresetNames(), ", "))
+		}
+		if slices.Contains(presets[:i], name) {
+			return fmt.Errorf("promote_presets[%d] %q is listed twice", i, name)
+		}
+	}
+	return nil
+}
+
+// withPromotePresets returns cfg with the attributes of its promote presets
+// appended to the promoted attributes of every signal, or cfg itself when
+// it has none. Attributes whose column the signal already promotes are
+// skipped, so promoted_attributes can change how a preset attribute is
+// promoted.
+func (cfg *Config) withPromotePresets() *Config {
+	if len(cfg.PromotePresets) == 0 {
+		return cfg
+	}
+	expanded := *cfg
+	for _, name := range cfg.PromotePresets {
+		preset := promotePresets[name]
+		expanded.Traces.PromotedAttributes = appendPromoted(expanded.Traces.PromotedAttributes, preset.resource, preset.traces)
+		expanded.Metrics.PromotedAttributes = appendPromoted(expanded.Metrics.PromotedAttributes, preset.resource, preset.metrics)
+		expanded.Logs.PromotedAttributes = appendPromoted(expanded.Logs.PromotedAttributes, preset.resource, preset.logs)
+	}
+	return &expanded
+}
+
+// appendPromoted returns promoted with the resource and record attributes
+// of a preset appended, skipping those whose column promoted has.
+func appendPromoted(promoted, resource, record []PromotedAttributeConfig) []PromotedAttributeConfig {
+	out := slices.Clip(promoted)
+	for _, p := range slices.Concat(resource, record) {
+		if p.Source == "" {
+			p.Source = rowconv.AttributeSourceResource
+		}
+		column := strings.ToLower(p.column())
+		if slices.ContainsFunc(out, func(other PromotedAttributeConfig) bool { return strings.ToLower(other.column()) == column }) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"slices"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestWithPromotePresets(t *testing.T) {
+	cfg := createDefaultConfig()
+	assert.Same(t, cfg, cfg.withPromotePresets())
+
+	cfg.PromotePresets = []string{"kubernetes"}
+	cfg.Logs.PromotedAttributes = []PromotedAttributeConfig{{Key: "k8s.pod.name", Source: "resource", Column: "pod"}, {Key: "pod.name", Source: "log", Column: "k8s_pod_name"}}
+	expanded := cfg.withPromotePresets()
+	assert.Empty(t, cfg.Traces.PromotedAttributes, "cfg is not modified")
+
+	want := []PromotedAttributeConfig{
+		{Key: "k8s.namespace.name", Source: "resource"},
+		{Key: "k8s.pod.name", Source: "resource"},
+		{Key: "k8s.container.name", Source: "resource"},
+		{Key: "k8s.node.name", Source: "resource"},
+	}
+	assert.Equal(t, want, expanded.Traces.PromotedAttributes)
+	assert.Equal(t, want, expanded.Metrics.PromotedAttributes)
+	// The logs column k8s_pod_name is taken by promoted_attributes.
+	assert.Equal(t, append(slices.Clone(cfg.Logs.PromotedAttributes), want[0], want[2], want[3]), expanded.Logs.PromotedAttributes)
+}
+
+func TestSignalTargetsPromotePresets(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.PromotePresets = []string{"kubernetes"}
+	require.NoError(t, cfg.Validate())
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	for _, target := range exp.signalTargets() {
+		field := schemaField(target.schema, "k8s_namespace_name")
+		if assert.NotNil(t, field, target.name) {
+			assert.Equal(t, bigquery.StringFieldType, field.Type)
+		}
+	}
+	assert.Len(t, exp.cfg.Logs.rowOptions().PromotedAttributes, 4)
+}
+
+func TestHTTPPromotePreset(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.PromotePresets = []string{"http"}
+	expanded := cfg.withPromotePresets()
+	assert.Empty(t, expanded.Metrics.PromotedAttributes)
+	assert.Empty(t, expanded.Logs.PromotedAttributes)
+
+	schema := tracesTableSchema(defaultSchemaPreset, expanded.Traces)
+	assert.Equal(t, bigquery.IntegerFieldType, schemaField(schema, "http_response_status_code").Type)
+	for _, column := range []string{"http_request_method", "http_route", "url_path", "server_address"} {
+		assert.Equal(t, bigquery.StringFieldType, schemaField(schema, column).Type, column)
+	}
+}
+
+func TestDBPromotePreset(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.PromotePresets = []string{"db"}
+	cfg.Traces.MaxColumnBytes = map[string]int{"db_query_text": 16}
+	require.NoError(t, cfg.Validate())
+
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	schema := tracesTableSchema(defaultSchemaPreset, exp.cfg.Traces)
+	for _, column := range []string{"db_system", "db_namespace", "db_operation_name", "db_query_text"} {
+		assert.NotNil(t, schemaField(schema, column), column)
+	}
+	rows := []row{{"db_query_text": "SELECT * FROM orders WHERE id = ?"}}
+	exp.tracesTruncation.truncate(rows)
+	assert.Equal(t, row{"db_query_text": "SELECT * FROM or", truncatedColumn: true}, rows[0])
+}
+
+func TestGenAIPromotePreset(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.PromotePresets = []string{"genai"}
+	require.NoError(t, cfg.Validate())
+	expanded := cfg.withPromotePresets()
+	assert.Empty(t, expanded.Metrics.PromotedAttributes)
+	assert.Equal(t, "span", expanded.Traces.PromotedAttributes[0].Source)
+	assert.Equal(t, "log", expanded.Logs.PromotedAttributes[0].Source)
+
+	for name, schema := range map[string]bigquery.Schema{
+		"traces": tracesTableSchema(defaultSchemaPreset, expanded.Traces),
+		"logs":   logsTableSchema(defaultSchemaPreset, expanded.Logs),
+	} {
+		assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "gen_ai_request_model").Type, name)
+		assert.Equal(t, bigquery.IntegerFieldType, schemaField(schema, "gen_ai_usage_input_tokens").Type, name)
+		assert.Equal(t, bigquery.IntegerFieldType, schemaField(schema, "gen_ai_usage_output_tokens").Type, name)
+	}
+}
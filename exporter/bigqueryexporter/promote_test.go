@@ -0,0 +1,182 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadatatest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestPromotedAttributeColumn(t *testing.T) {
+	assert.Equal(t, "service_name", PromotedAttributeConfig{Key: "service.name"}.column())
+	assert.Equal(t, "k8s_pod_name", PromotedAttributeConfig{Key: "k8s.pod.name"}.column())
+	assert.Equal(t, "http_request_method_", PromotedAttributeConfig{Key: "http/request-method!"}.column())
+	assert.Equal(t, "pod", PromotedAttributeConfig{Key: "k8s.pod.name", Column: "pod"}.column())
+}
+
+func TestPromotedAttributes(t *testing.T) {
+	assert.Nil(t, promotedAttributes(nil))
+	assert.Equal(t, []rowconv.PromotedAttribute{
+		{Source: "resource", Key: "service.name", Column: "service_name", Type: bigquery.StringFieldType},
+		{Source: "span", Key: "http.response.status_code", Column: "http_status", Type: bigquery.IntegerFieldType},
+	}, promotedAttributes([]PromotedAttributeConfig{
+		{Source: "resource", Key: "service.name"},
+		{Source: "span", Key: "http.response.status_code", Column: "http_status", Type: "INT64"},
+	}))
+}
+
+func TestGuardedPromotedAttributes(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	set := exportertest.NewNopSettings(metadata.Type)
+	set.Logger = zap.New(core)
+	exp := newBigQueryExporter(t.Context(), createDefaultConfig(), set)
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+	var err error
+	exp.telemetry, err = metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	t.Cleanup(exp.telemetry.Shutdown)
+	promoted := exp.guardedPromotedAttributes("traces", []PromotedAttributeConfig{
+		{Source: "resource", Key: "service.name"},
+		{Source: "span", Key: "user.id", MaxCardinality: 2},
+	})
+	require.Len(t, promoted, 2)
+	assert.Nil(t, promoted[0].Guard)
+	require.NotNil(t, promoted[1].Guard)
+
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for _, user := range []string{"a", "b", "a", "c", "a"} {
+		spans.AppendEmpty().Attributes().PutStr("user.id", user)
+	}
+	rows := rowconv.Traces(td, rowconv.TracesOptions{PromotedAttributes: promoted})
+	var values []bigquery.Value
+	for _, r := range rows {
+		values = append(values, r["user_id"])
+	}
+	// The third distinct value stops the column, the JSON attributes keep
+	// every value.
+	assert.Equal(t, []bigquery.Value{"a", "b", "a", nil, nil}, values)
+	assert.JSONEq(t, `{"user.id":"c"}`, fmt.Sprint(rows[3]["span_attributes"]))
+	assert.True(t, promoted[1].Guard.Stopped())
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, map[string]any{"signal": "traces", "column": "user_id", "max_cardinality": int64(2)}, entry.ContextMap())
+	metadatatest.AssertEqualExporterBigqueryPromotedColumnsStopped(t, tel, []metricdata.DataPoint[int64]{
+		{Value: 1, Attributes: attribute.NewSet(attribute.String("signal", "traces"), attribute.String("column", "user_id"))},
+	}, metricdatatest.IgnoreTimestamp())
+}
+
+func TestValidatePromotedAttributes(t *testing.T) {
+	reserved := bigquery.Schema{{Name: "name"}, {Name: "status_class"}}
+	tests := []struct {
+		name     string
+		promoted []PromotedAttributeConfig
+		wantErr  string
+	}{
+		{
+			name: "valid",
+			promoted: []PromotedAttributeConfig{
+				{Source: "resource", Key: "service.name"},
+				{Source: "span", Key: "http.response.status_code", Column: "http_status", Type: "INT64"},
+				{Source: "span", Key: "retry", Type: "BOOL"},
+				{Source: "span", Key: "user.id", MaxCardinality: 1000},
+			},
+		},
+		{
+			name:     "negative max cardinality",
+			promoted: []PromotedAttributeConfig{{Source: "span", Key: "a", MaxCardinality: -1}},
+			wantErr:  "promoted_attributes[0].max_cardinality must not be negative",
+		},
+		{
+			name:     "missing key",
+			promoted: []PromotedAttributeConfig{{Source: "resource"}},
+			wantErr:  "promoted_attributes[0].key is required",
+		},
+		{
+			name:     "unknown source",
+			promoted: []PromotedAttributeConfig{{Source: "log", Key: "a"}},
+			wantErr:  `promoted_attributes[0].source "log" must be "resource" or "span"`,
+		},
+		{
+			name:     "unknown type",
+			promoted: []PromotedAttributeConfig{{Source: "span", Key: "a", Type: "INTEGER"}},
+			wantErr:  `promoted_attributes[0].type "INTEGER" must be one of STRING, INT64, FLOAT64, BOOL`,
+		},
+		{
+			name:     "invalid column",
+			promoted: []PromotedAttributeConfig{{Source: "span", Key: "a", Column: "1a"}},
+			wantErr:  "promoted_attributes[0].column must match",
+		},
+		{
+			name:     "reserved prefix",
+			promoted: []PromotedAttributeConfig{{Source: "span", Key: "_partitiontime"}},
+			wantErr:  `promoted_attributes[0].column "_partitiontime" uses a prefix BigQuery reserves`,
+		},
+		{
+			name: "duplicate column",
+			promoted: []PromotedAttributeConfig{
+				{Source: "resource", Key: "service.name"},
+				{Source: "span", Key: "other", Column: "Service_Name"},
+			},
+			wantErr: `promoted_attributes[1].column "Service_Name" of attribute "other" collides with the column of attribute "service.name"`,
+		},
+		{
+			name:     "built-in column",
+			promoted: []PromotedAttributeConfig{{Source: "span", Key: "name"}},
+			wantErr:  `promoted_attributes[0].column "name" of attribute "name" collides with a column of the exporter`,
+		},
+		{
+			name:     "optional column",
+			promoted: []PromotedAttributeConfig{{Source: "span", Key: "status.class"}},
+			wantErr:  `promoted_attributes[0].column "status_class" of attribute "status.class" collides with a column of the exporter`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePromotedAttributes("promoted_attributes", "span", tt.promoted, reserved)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTableSchemasPromotedAttributes(t *testing.T) {
+	promoted := []PromotedAttributeConfig{{Source: "resource", Key: "service.name"}, {Source: "resource", Key: "host.cpu", Type: "FLOAT64"}}
+	for name, schema := range map[string]bigquery.Schema{
+		"traces":  tracesTableSchema("", TracesConfig{PromotedAttributes: promoted}),
+		"metrics": metricsTableSchema("", MetricsConfig{PromotedAttributes: promoted}),
+		"logs":    logsTableSchema("", LogsConfig{PromotedAttributes: promoted}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NotNil(t, schemaField(schema, "service_name"), "service_name")
+			field := schemaField(schema, "host_cpu")
+			require.NotNil(t, field)
+			assert.Equal(t, bigquery.FloatFieldType, field.Type)
+			assert.False(t, field.Required)
+		})
+	}
+}
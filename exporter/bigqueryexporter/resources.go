@@ -0,0 +1,159 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+
+	"cloud.google.com/go/bigquery"
+)
+
+const (
+	resourcesSignal    = "resources"
+	resourceHashColumn = "resource_hash"
+)
+
+// resourceColumns are the columns of signal rows that normalize_resources
+// moves to the resource table.
+var resourceColumns = []string{"resource_attributes", "resource_schema_url"}
+
+var resourceHashField = &bigquery.FieldSchema{Name: resourceHashColumn, Type: bigquery.StringFieldType, Required: false}
+
+// resourcesSchema is the schema of the resource table, which holds every
+// distinct resource of the signal tables once per resource_hash.
+var resourcesSchema = bigquery.Schema{
+	{Name: resourceHashColumn, Type: bigquery.StringFieldType, Required: true},
+	{Name: "resource_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "resource_schema_url", Type: bigquery.StringFieldType, Required: false},
+}
+
+// withResourceHash returns schema with the resource columns replaced by the
+// resource_hash column when normalize is set, or with the resource_hash
+// column appended when only hash is set. schema is not modified.
+func withResourceHash(schema bigquery.Schema, normalize, hash bool) bigquery.Schema {
+	if !normalize {
+		if hash {
+			return append(slices.Clip(schema), resourceHashField)
+		}
+		return schema
+	}
+	out := make(bigquery.Schema, 0, len(schema))
+	for _, field := range schema {
+		switch field.Name {
+		case "resource_attributes":
+			out = append(out, resourceHashField)
+		case "resource_schema_url":
+		default:
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+// resourceHash returns the hash identifying the resource of a row, a hash
+// of its serialized attributes and schema URL.
+func resourceHash(r row) string {
+	h := fnv.New128a()
+	for _, column := range resourceColumns {
+		fmt.Fprintf(h, "%v\x00", r[column])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// setResourceHashes sets the resource_hash column of rows, which keep their
+// resource columns.
+func setResourceHashes(rows []row) {
+	for _, r := range rows {
+		r[resourceHashColumn] = resourceHash(r)
+	}
+}
+
+// normalizeResources replaces the resource columns of rows by their
+// resource_hash and returns a row of the resource table for every distinct
+// resource, in the order they first occur.
+func normalizeResources(rows []row) []row {
+	var resources []row
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		hash := resourceHash(r)
+		if !seen[hash] {
+			seen[hash] = true
+			resources = append(resources, row{
+				resourceHashColumn:    hash,
+				"resource_attributes": r["resource_attributes"],
+				"resource_schema_url": r["resource_schema_url"],
+			})
+		}
+		for _, column := range resourceColumns {
+			delete(r, column)
+		}
+		r[resourceHashColumn] = hash
+	}
+	return resources
+}
+
+// resourceCache remembers the hashes of the resources written to the
+// resource table, so every resource is written once per collector process.
+// It forgets all hashes once it holds size of them, after which resources
+// are written again.
+type resourceCache struct {
+	size int
+
+	mu      sync.Mutex
+	written map[string]bool
+}
+
+func newResourceCache(size int) *resourceCache {
+	return &resourceCache{size: size, written: make(map[string]bool)}
+}
+
+// unwritten returns the resources whose hash has not been added.
+func (c *resourceCache) unwritten(resources []row) []row {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []row
+	for _, r := range resources {
+		if !c.written[r[resourceHashColumn].(string)] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// add remembers hashes as written.
+func (c *resourceCache) add(hashes []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.written)+len(hashes) > c.size {
+		clear(c.written)
+	}
+	for _, hash := range hashes {
+		c.written[hash] = true
+	}
+}
+
+// appendResources replaces the resource columns of rows by their
+// resource_hash and appends the resources that were not written before to
+// the resource table. Signal rows are only written after their resources,
+// so every resource_hash has a row in the resource table.
+func (e *bigQueryExporter) appendResources(ctx context.Context, rows []row) error {
+	resources := e.resources.unwritten(normalizeResources(rows))
+	if len(resources) == 0 {
+		return nil
+	}
+	hashes := make([]string, len(resources))
+	for i, r := range resources {
+		hashes[i] = r[resourceHashColumn].(string)
+	}
+	if err := appendTableRows(ctx, e.resourcesAppender, nil, resources); err != nil {
+		return fmt.Errorf("append resource rows: %w", err)
+	}
+	e.resources.add(hashes)
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWithResourceHash(t *testing.T) {
+	assert.Equal(t, rowconv.LogsSchema, withResourceHash(rowconv.LogsSchema, false, false))
+
+	schema := withResourceHash(rowconv.LogsSchema, true, false)
+	assert.Len(t, schema, len(rowconv.LogsSchema)-1)
+	assert.Nil(t, schemaField(schema, "resource_attributes"))
+	assert.Nil(t, schemaField(schema, "resource_schema_url"))
+	assert.NotNil(t, schemaField(schema, resourceHashColumn))
+	assert.NotNil(t, schemaField(rowconv.LogsSchema, "resource_attributes"), "input schema is not modified")
+
+	schema = withResourceHash(rowconv.LogsSchema, false, true)
+	assert.Len(t, schema, len(rowconv.LogsSchema)+1)
+	assert.NotNil(t, schemaField(schema, "resource_attributes"))
+	assert.Equal(t, resourceHashField, schema[len(schema)-1])
+	assert.Len(t, withResourceHash(rowconv.LogsSchema, true, true), len(rowconv.LogsSchema)-1)
+}
+
+func TestSetResourceHashes(t *testing.T) {
+	rows := []row{
+		{"name": "a", "resource_attributes": `{"service.name":"x"}`, "resource_schema_url": ""},
+		{"name": "b", "resource_attributes": `{"service.name":"y"}`, "resource_schema_url": ""},
+		{"name": "c", "resource_attributes": `{"service.name":"x"}`, "resource_schema_url": ""},
+	}
+	normalized := []row{{"resource_attributes": `{"service.name":"x"}`, "resource_schema_url": ""}}
+	normalizeResources(normalized)
+
+	setResourceHashes(rows)
+	assert.Equal(t, `{"service.name":"x"}`, rows[0]["resource_attributes"])
+	assert.Equal(t, normalized[0][resourceHashColumn], rows[0][resourceHashColumn], "hashes match those of normalize_resources")
+	assert.NotEqual(t, rows[0][resourceHashColumn], rows[1][resourceHashColumn])
+	assert.Equal(t, rows[0][resourceHashColumn], rows[2][resourceHashColumn])
+}
+
+func TestNormalizeResources(t *testing.T) {
+	rows := []row{
+		{"name": "a", "resource_attributes": `{"service.name":"x"}`, "resource_schema_url": ""},
+		{"name": "b", "resource_attributes": `{"service.name":"y"}`, "resource_schema_url": ""},
+		{"name": "c", "resource_attributes": `{"service.name":"x"}`, "resource_schema_url": ""},
+		{"name": "d", "resource_attributes": `{"service.name":"x"}`, "resource_schema_url": "https://opentelemetry.io/schemas/1.26.0"},
+	}
+	want := []row{
+		{"resource_attributes": `{"service.name":"x"}`, "resource_schema_url": ""},
+		{"resource_attributes": `{"service.name":"y"}`, "resource_schema_url": ""},
+		{"resource_attributes": `{"service.name":"x"}`, "resource_schema_url": "https://opentelemetry.io/schemas/1.26.0"},
+	}
+	for _, r := range want {
+		r[resourceHashColumn] = resourceHash(r)
+	}
+
+	resources := normalizeResources(rows)
+	assert.Equal(t, want, resources)
+	assert.Equal(t, row{"name": "a", resourceHashColumn: want[0][resourceHashColumn]}, rows[0])
+	assert.Equal(t, want[1][resourceHashColumn], rows[1][resourceHashColumn])
+	assert.Equal(t, rows[0][resourceHashColumn], rows[2][resourceHashColumn])
+	assert.Equal(t, want[2][resourceHashColumn], rows[3][resourceHashColumn])
+}
+
+func TestResourceCache(t *testing.T) {
+	cache := newResourceCache(2)
+	resources := []row{{resourceHashColumn: "a"}, {resourceHashColumn: "b"}, {resourceHashColumn: "c"}}
+	assert.Equal(t, resources, cache.unwritten(resources))
+
+	cache.add([]string{"a", "b"})
+	assert.Equal(t, resources[2:], cache.unwritten(resources))
+
+	// The cache forgets every hash once it is full.
+	cache.add([]string{"c"})
+	assert.Equal(t, resources[:2], cache.unwritten(resources))
+}
+
+func TestSignalTargetsNormalizeResources(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.NormalizeResources.Enabled = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	targets := map[string]signalTarget{}
+	for _, target := range exp.signalTargets() {
+		targets[target.name] = target
+	}
+	for _, name := range []string{"traces", "metrics", "logs"} {
+		assert.Nil(t, schemaField(targets[name].schema, "resource_attributes"), name)
+		assert.NotNil(t, schemaField(targets[name].schema, resourceHashColumn), name)
+	}
+	require.Contains(t, targets, resourcesSignal)
+	assert.Equal(t, "resource", targets[resourcesSignal].tableID)
+	assert.Equal(t, "otel", targets[resourcesSignal].dataset)
+	assert.Equal(t, []string{resourceHashColumn}, targets[resourcesSignal].clusteringFields)
+	assert.NotNil(t, schemaField(targets[resourcesSignal].schema, "resource_attributes"))
+
+	cfg.NormalizeResources.Enabled = false
+	exp = newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	for _, target := range exp.signalTargets() {
+		assert.NotEqual(t, resourcesSignal, target.name)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+)
+
+// newDefaultStartupRetryConfig returns the default startup_retry settings,
+// which bound start to about a minute of retries.
+func newDefaultStartupRetryConfig() configretry.BackOffConfig {
+	return configretry.BackOffConfig{
+		Enabled:             true,
+		InitialInterval:     time.Second,
+		RandomizationFactor: backoff.DefaultRandomizationFactor,
+		Multiplier:          backoff.DefaultMultiplier,
+		MaxInterval:         10 * time.Second,
+		MaxElapsedTime:      time.Minute,
+	}
+}
+
+// retryControlPlane runs op and retries transient failures with the
+// startup_retry backoff. It wraps the dataset and table calls made during
+// start, which would otherwise fail the collector on a single 503.
+func (e *bigQueryExporter) retryControlPlane(ctx context.Context, name string, op func(context.Context) error) error {
+	cfg := e.cfg.StartupRetry
+	if !cfg.Enabled {
+		return op(ctx)
+	}
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = cfg.InitialInterval
+	b.RandomizationFactor = cfg.RandomizationFactor
+	b.Multiplier = cfg.Multiplier
+	b.MaxInterval = cfg.MaxInterval
+
+	_, err := backoff.Retry(ctx, func() (struct{}, error) {
+		err := op(ctx)
+		if err != nil && !isTransientControlPlaneError(err) {
+			return struct{}{}, backoff.Permanent(err)
+		}
+		return struct{}{}, err
+	},
+		backoff.WithBackOff(b),
+		backoff.WithMaxElapsedTime(cfg.MaxElapsedTime),
+		backoff.WithNotify(func(err error, next time.Duration) {
+			e.logger.Warn("BigQuery control-plane call failed, retrying",
+				zap.String("operation", name), zap.Duration("interval", next), zap.Error(err))
+		}),
+	)
+	return err
+}
+
+// tableAppends appends the rows of a batch to the tables it is written to,
+// such as the traces table and its span events and links tables.
+type tableAppends struct {
+	wg     sync.WaitGroup
+	tables []*tableAppend
+}
+
+// tableAppend is the append of a batch's rows to one table.
+type tableAppend struct {
+	// rows names the rows in errors, e.g. "span event".
+	rows       string
+	appendRows func(context.Context) error
+	// retry appends the rows again after appendRows failed.
+	retry func(context.Context) error
+	err   error
+}
+
+// start runs appendRows concurrently with the appends to the other tables.
+func (a *tableAppends) start(ctx context.Context, rows string, appendRows, retry func(context.Context) error) {
+	t := &tableAppend{rows: rows, appendRows: appendRows, retry: retry}
+	a.tables = append(a.tables, t)
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		t.err = t.append(ctx, t.appendRows)
+	}()
+}
+
+func (t *tableAppend) append(ctx context.Context, appendRows func(context.Context) error) error {
+	if err := appendRows(ctx); err != nil {
+		return fmt.Errorf("append %s rows: %w", t.rows, err)
+	}
+	return nil
+}
+
+// wait waits for the appends and returns the error of the batch. When some
+// tables failed while others succeeded, only the failed appends are retried,
+// with the retry_on_failure backoff, since exporterhelper would append the
+// whole batch to every table again. Tables still failing then fail the
+// batch with a permanent error, so the tables that succeeded are not
+// written twice.
+func (e *bigQueryExporter) waitTableAppends(ctx context.Context, a *tableAppends) error {
+	a.wg.Wait()
+	var failed []*tableAppend
+	for _, t := range a.tables {
+		if t.err != nil {
+			failed = append(failed, t)
+		}
+	}
+	errs := make([]error, len(failed))
+	if len(failed) == len(a.tables) {
+		for i, t := range failed {
+			errs[i] = t.err
+		}
+		return errors.Join(errs...)
+	}
+	var wg sync.WaitGroup
+	for i, t := range failed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = e.retryTableAppend(ctx, t)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return consumererror.NewPermanent(err)
+	}
+	return nil
+}
+
+// retryTableAppend retries the failed append t until it succeeds, fails
+// permanently or the retry_on_failure backoff or ctx expire. It returns the
+// error of the last attempt.
+func (e *bigQueryExporter) retryTableAppend(ctx context.Context, t *tableAppend) error {
+	cfg := e.cfg.BackOffConfig
+	if !cfg.Enabled || consumererror.IsPermanent(t.err) {
+		return t.err
+	}
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = cfg.InitialInterval
+	b.RandomizationFactor = cfg.RandomizationFactor
+	b.Multiplier = cfg.Multiplier
+	b.MaxInterval = cfg.MaxInterval
+
+	last := t.err
+	notify := func(err error, next time.Duration) {
+		e.logger.Warn("Append to one table of a batch failed while the others succeeded, retrying the table",
+			zap.String("rows", t.rows), zap.Duration("interval", next), zap.Error(err))
+	}
+	notify(last, cfg.InitialInterval)
+	if sleepContext(ctx, cfg.InitialInterval) != nil {
+		return last
+	}
+	_, err := backoff.Retry(ctx, func() (struct{}, error) {
+		last = t.append(ctx, t.retry)
+		if last != nil && consumererror.IsPermanent(last) {
+			return struct{}{}, backoff.Permanent(last)
+		}
+		return struct{}{}, last
+	},
+		backoff.WithBackOff(b),
+		backoff.WithMaxElapsedTime(cfg.MaxElapsedTime),
+		backoff.WithNotify(notify),
+	)
+	if err != nil {
+		return last
+	}
+	return nil
+}
+
+// isTransientControlPlaneError reports whether a REST call failed with a
+// status or network error that is likely to succeed when retried.
+func isTransientControlPlaneError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
+)
+
+func TestIsTransientControlPlaneError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &googleapi.Error{Code: http.StatusServiceUnavailable}, want: true},
+		{err: fmt.Errorf("get dataset: %w", &googleapi.Error{Code: http.StatusTooManyRequests}), want: true},
+		{err: &googleapi.Error{Code: http.StatusNotFound}, want: false},
+		{err: &googleapi.Error{Code: http.StatusForbidden}, want: false},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{err: context.DeadlineExceeded, want: false},
+		{err: errors.New("invalid schema"), want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isTransientControlPlaneError(tt.err), tt.err.Error())
+	}
+}
+
+func TestRetryControlPlane(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.InitialInterval = time.Millisecond
+	cfg.StartupRetry.MaxInterval = time.Millisecond
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+
+	calls := 0
+	err := exp.retryControlPlane(t.Context(), "test", func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return &googleapi.Error{Code: http.StatusServiceUnavailable}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	notFound := &googleapi.Error{Code: http.StatusNotFound}
+	err = exp.retryControlPlane(t.Context(), "test", func(context.Context) error {
+		calls++
+		return notFound
+	})
+	assert.ErrorIs(t, err, notFound)
+	assert.Equal(t, 1, calls)
+
+	cfg.StartupRetry.Enabled = false
+	calls = 0
+	err = exp.retryControlPlane(t.Context(), "test", func(context.Context) error {
+		calls++
+		return &googleapi.Error{Code: http.StatusServiceUnavailable}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWaitTableAppends(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.BackOffConfig.InitialInterval = time.Millisecond
+	cfg.BackOffConfig.MaxInterval = time.Millisecond
+	cfg.BackOffConfig.MaxElapsedTime = 50 * time.Millisecond
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	failing := func(context.Context) error { return errors.New("unavailable") }
+	succeeding := func(context.Context) error { return nil }
+
+	// A batch failing for every table is retried as a whole.
+	var appends tableAppends
+	appends.start(t.Context(), "traces", failing, failing)
+	appends.start(t.Context(), "span event", failing, failing)
+	err := exp.waitTableAppends(t.Context(), &appends)
+	require.ErrorContains(t, err, "append traces rows: unavailable")
+	assert.False(t, consumererror.IsPermanent(err))
+
+	// Only the failed table is retried while the others succeeded.
+	calls := 0
+	appends = tableAppends{}
+	appends.start(t.Context(), "traces", succeeding, failing)
+	appends.start(t.Context(), "span event", failing, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, exp.waitTableAppends(t.Context(), &appends))
+	assert.Equal(t, 3, calls)
+
+	// A table still failing after the retries drops the batch, which would
+	// otherwise be written again to the tables that succeeded.
+	appends = tableAppends{}
+	appends.start(t.Context(), "traces", succeeding, failing)
+	appends.start(t.Context(), "span event", failing, failing)
+	err = exp.waitTableAppends(t.Context(), &appends)
+	require.ErrorContains(t, err, "append span event rows: unavailable")
+	assert.True(t, consumererror.IsPermanent(err))
+}
+
+// failFirstAppend returns a Storage Write server failing the first append
+// to table, and the number of appends to it.
+func failFirstAppend(table string) (*fakeWriteServer, *atomic.Int32) {
+	var appends atomic.Int32
+	return &fakeWriteServer{appendResponse: func(stream string, _ [][]byte) *storagepb.AppendRowsResponse {
+		if strings.Contains(stream, "/tables/"+table+"/") && appends.Add(1) == 1 {
+			return &storagepb.AppendRowsResponse{Response: &storagepb.AppendRowsResponse_Error{
+				Error: status.New(codes.Aborted, "aborted").Proto(),
+			}}
+		}
+		return nil
+	}}, &appends
+}
+
+// newTableAppender returns a default-stream appender of table on client.
+func newTableAppender(t *testing.T, client *managedwriter.Client, table string, schema bigquery.Schema) *storageAppender {
+	tb, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	a, err := newStorageAppender(t.Context(), client, zap.NewNop(), tb, "p", "d", table, schema,
+		WriteConfig{Mode: writeModeDefault, Workers: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, a.close(context.Background())) })
+	return a
+}
+
+func TestPushTracesRetriesFailedChildTable(t *testing.T) {
+	srv, linkAppends := failFirstAppend("span_link")
+	client := newFakeWriteClient(t, srv)
+	cfg := createDefaultConfig()
+	cfg.Traces.ChildTables = true
+	cfg.BackOffConfig.InitialInterval = time.Millisecond
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.tracesAppender = newTableAppender(t, client, "trace", signalSchema(cfg, cfg.SchemaPreset, "traces", ""))
+	exp.spanEventsAppender = newTableAppender(t, client, "span_event", spanEventsTableSchema(cfg.SchemaPreset, cfg.Traces))
+	exp.spanLinksAppender = newTableAppender(t, client, "span_link", spanLinksTableSchema(cfg.SchemaPreset, cfg.Traces))
+
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("span")
+	span.Events().AppendEmpty().SetName("event")
+	span.Links().AppendEmpty()
+
+	// The failed append of the link is retried without writing the span and
+	// its event again.
+	require.NoError(t, exp.pushTraces(t.Context(), td))
+	assert.Equal(t, int32(2), linkAppends.Load())
+	for _, table := range []string{"trace", "span_event", "span_link"} {
+		assert.Len(t, srv.tableRows(table), 1, table)
+	}
+}
+
+func TestPushLogsRetriesFailedEntityEvents(t *testing.T) {
+	srv, entityAppends := failFirstAppend("entity")
+	client := newFakeWriteClient(t, srv)
+	cfg := createDefaultConfig()
+	cfg.Logs.EntityEvents = true
+	cfg.BackOffConfig.InitialInterval = time.Millisecond
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.logsAppender = newTableAppender(t, client, "log", signalSchema(cfg, cfg.SchemaPreset, "logs", ""))
+	exp.entitiesAppender = newTableAppender(t, client, "entity", tableSchema(rowconv.EntitiesSchema, cfg.SchemaPreset, ""))
+
+	events := experimentalmetricmetadata.NewEntityEventsSlice()
+	event := events.AppendEmpty()
+	event.ID().PutStr("k8s.pod.uid", "pod-1")
+	event.SetEntityDelete().SetEntityType("k8s.pod")
+	ld := events.ConvertAndMoveToLogs()
+	ld.ResourceLogs().At(0).ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+
+	require.NoError(t, exp.pushLogs(t.Context(), ld))
+	assert.Equal(t, int32(2), entityAppends.Load())
+	assert.Len(t, srv.tableRows("log"), 1)
+	assert.Len(t, srv.tableRows("entity"), 1)
+}
@@ -0,0 +1,349 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+const defaultSchemaPreset = "default"
+
+// Values of the per-signal json_columns setting.
+const (
+	jsonColumnsJSON   = "json"
+	jsonColumnsString = "string"
+)
+
+// schemaPreset is a named set of schema options applied to every signal
+// table. Presets are selected with the schema_preset setting.
+type schemaPreset struct {
+	// omit lists columns that are left out of every table.
+	omit []string
+	// jsonAsString creates JSON columns as STRING while keeping the same
+	// serialized content.
+	jsonAsString bool
+	// nested creates the JSON columns of rowconv.NestedFields as RECORD
+	// columns.
+	nested bool
+}
+
+// schemaPresets is the registry of presets selectable by name.
+var schemaPresets = map[string]schemaPreset{
+	// default is the full schema documented in the README.
+	defaultSchemaPreset: {},
+	// compat stores attributes, events, links and other nested values as
+	// STRING columns for environments where the JSON type is unavailable.
+	"compat": {jsonAsString: true},
+	// slim drops span events, span links and exemplars, which usually account
+	// for most of the stored bytes.
+	"slim": {omit: []string{"events", "links", "exemplars"}},
+	// structured stores span events and links, exemplars, quantiles and the
+	// instrumentation scope as typed RECORD columns.
+	"structured": {nested: true},
+}
+
+func schemaPresetNames() []string {
+	names := make([]string, 0, len(schemaPresets))
+	for name := range schemaPresets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// apply returns a copy of schema with the preset options applied.
+func (p schemaPreset) apply(schema bigquery.Schema) bigquery.Schema {
+	out := make(bigquery.Schema, 0, len(schema))
+	for _, field := range schema {
+		if slices.Contains(p.omit, field.Name) {
+			continue
+		}
+		if nested, ok := rowconv.NestedFields[field.Name]; ok && p.nested && field.Type == bigquery.JSONFieldType {
+			field = nested
+		}
+		if p.jsonAsString {
+			field = jsonFieldAsString(field)
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+// jsonFieldAsString returns field with JSON columns, including those of
+// RECORD subfields, typed as STRING. field is returned unchanged when it has
+// none.
+func jsonFieldAsString(field *bigquery.FieldSchema) *bigquery.FieldSchema {
+	switch field.Type {
+	case bigquery.JSONFieldType:
+		cloned := *field
+		cloned.Type = bigquery.StringFieldType
+		return &cloned
+	case bigquery.RecordFieldType:
+		cloned := *field
+		cloned.Schema = make(bigquery.Schema, len(field.Schema))
+		for i, sub := range field.Schema {
+			cloned.Schema[i] = jsonFieldAsString(sub)
+		}
+		return &cloned
+	default:
+		return field
+	}
+}
+
+// tableSchema returns schema with the named preset applied. jsonColumns
+// overrides the preset's choice of JSON or STRING columns when set.
+func tableSchema(schema bigquery.Schema, presetName, jsonColumns string) bigquery.Schema {
+	preset := schemaPresets[presetName]
+	switch jsonColumns {
+	case jsonColumnsJSON:
+		preset.jsonAsString = false
+	case jsonColumnsString:
+		preset.jsonAsString = true
+	}
+	return preset.apply(schema)
+}
+
+// columnDiff is a column of a table that cannot hold the column the
+// exporter writes. table and want describe the column as its type and mode,
+// or are empty when the table lacks the column or the exporter does not
+// write it.
+type columnDiff struct {
+	column      string
+	table, want string
+}
+
+// schemaDiff lists the incompatible columns of a table. As an error it
+// reads as a column-by-column report.
+type schemaDiff []columnDiff
+
+func (d schemaDiff) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d column(s) differ from the schema the exporter writes:\n", len(d))
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  COLUMN\tTABLE\tEXPORTER")
+	for _, c := range d {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\n", c.column, cmp.Or(c.table, "(missing)"), cmp.Or(c.want, "(not written)"))
+	}
+	_ = w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// incompatible returns the differences other than missing columns, which
+// allow_schema_update can add.
+func (d schemaDiff) incompatible() schemaDiff {
+	return slices.DeleteFunc(slices.Clone(d), func(c columnDiff) bool { return c.table == "" })
+}
+
+// checkSchemaCompatible reports the columns of want that cannot be written
+// to a table with the schema table. Additional table columns are accepted
+// unless they are REQUIRED, since the exporter never sets them.
+func checkSchemaCompatible(want, table bigquery.Schema) error {
+	if diff := diffSchemas(want, table); len(diff) > 0 {
+		return diff
+	}
+	return nil
+}
+
+// diffSchemas returns the columns of want that cannot be written to a table
+// with the schema table, see checkSchemaCompatible.
+func diffSchemas(want, table bigquery.Schema) schemaDiff {
+	return diffFields("", want, table)
+}
+
+func diffFields(prefix string, want, table bigquery.Schema) schemaDiff {
+	var diff schemaDiff
+	for _, field := range want {
+		name := prefix + field.Name
+		idx := slices.IndexFunc(table, func(existing *bigquery.FieldSchema) bool {
+			return strings.EqualFold(existing.Name, field.Name)
+		})
+		if idx < 0 {
+			diff = append(diff, columnDiff{column: name, want: describeColumn(field)})
+			continue
+		}
+		existing := table[idx]
+		switch {
+		case existing.Type != field.Type,
+			existing.Repeated != field.Repeated,
+			existing.Required && !field.Required:
+			diff = append(diff, columnDiff{column: name, table: describeColumn(existing), want: describeColumn(field)})
+		case field.Type == bigquery.RecordFieldType:
+			diff = append(diff, diffFields(name+".", field.Schema, existing.Schema)...)
+		}
+	}
+	for _, existing := range table {
+		if existing.Required && !slices.ContainsFunc(want, func(field *bigquery.FieldSchema) bool {
+			return strings.EqualFold(existing.Name, field.Name)
+		}) {
+			diff = append(diff, columnDiff{column: prefix + existing.Name, table: describeColumn(existing)})
+		}
+	}
+	return diff
+}
+
+// describeColumn returns the type and mode of a column, e.g. "STRING
+// REQUIRED".
+func describeColumn(field *bigquery.FieldSchema) string {
+	switch {
+	case field.Repeated:
+		return string(field.Type) + " REPEATED"
+	case field.Required:
+		return string(field.Type) + " REQUIRED"
+	default:
+		return string(field.Type) + " NULLABLE"
+	}
+}
+
+// mergeMissingColumns returns table with the columns of want it lacks
+// appended as NULLABLE columns, since BigQuery only allows adding NULLABLE
+// or REPEATED columns to existing tables. Missing subfields of RECORD
+// columns are added as well. added lists the added columns.
+func mergeMissingColumns(want, table bigquery.Schema) (merged bigquery.Schema, added []string) {
+	return mergeFields("", want, table)
+}
+
+func mergeFields(prefix string, want, table bigquery.Schema) (bigquery.Schema, []string) {
+	merged := slices.Clone(table)
+	var added []string
+	for _, field := range want {
+		idx := slices.IndexFunc(merged, func(existing *bigquery.FieldSchema) bool {
+			return strings.EqualFold(existing.Name, field.Name)
+		})
+		if idx < 0 {
+			column := *field
+			column.Required = false
+			merged = append(merged, &column)
+			added = append(added, prefix+field.Name)
+			continue
+		}
+		existing := merged[idx]
+		if field.Type != bigquery.RecordFieldType || existing.Type != bigquery.RecordFieldType {
+			continue
+		}
+		subfields, subAdded := mergeFields(prefix+field.Name+".", field.Schema, existing.Schema)
+		if len(subAdded) > 0 {
+			record := *existing
+			record.Schema = subfields
+			merged[idx] = &record
+			added = append(added, subAdded...)
+		}
+	}
+	return merged, added
+}
+
+// withPolicyTags returns schema with the policy tag of tags applied to each
+// column it maps. schema is not modified.
+func withPolicyTags(schema bigquery.Schema, tags map[string]string) bigquery.Schema {
+	if len(tags) == 0 {
+		return schema
+	}
+	out := make(bigquery.Schema, 0, len(schema))
+	for _, field := range schema {
+		if tag, ok := tags[field.Name]; ok {
+			tagged := *field
+			tagged.PolicyTags = &bigquery.PolicyTagList{Names: []string{tag}}
+			field = &tagged
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+// missingPolicyTags returns the columns of table lacking the policy tag
+// want has for them.
+func missingPolicyTags(want, table bigquery.Schema) []string {
+	var missing []string
+	for _, field := range want {
+		if field.PolicyTags == nil {
+			continue
+		}
+		idx := slices.IndexFunc(table, func(existing *bigquery.FieldSchema) bool {
+			return strings.EqualFold(existing.Name, field.Name)
+		})
+		if idx < 0 {
+			continue
+		}
+		if tags := table[idx].PolicyTags; tags == nil || !slices.Equal(tags.Names, field.PolicyTags.Names) {
+			missing = append(missing, field.Name)
+		}
+	}
+	return missing
+}
+
+// withCollation returns schema with the collation of collations applied to
+// each column it maps. schema is not modified.
+func withCollation(schema bigquery.Schema, collations map[string]string) bigquery.Schema {
+	if len(collations) == 0 {
+		return schema
+	}
+	out := make(bigquery.Schema, 0, len(schema))
+	for _, field := range schema {
+		if collation, ok := collations[field.Name]; ok {
+			collated := *field
+			collated.Collation = collation
+			field = &collated
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+// signalSchema returns the schema of the traces, metrics or logs table with
+// preset and jsonColumns applied, the optional columns cfg enables and the
+// policy tags and collations of the signal.
+func signalSchema(cfg *Config, preset, signal, jsonColumns string) bigquery.Schema {
+	var (
+		schema     bigquery.Schema
+		retention  time.Duration
+		policyTags map[string]string
+		collations map[string]string
+	)
+	switch signal {
+	case "traces":
+		tracesCfg := cfg.Traces
+		tracesCfg.JSONColumns = jsonColumns
+		schema = tracesTableSchema(preset, tracesCfg)
+		retention, policyTags, collations = cfg.Traces.RowRetention, cfg.Traces.PolicyTags, cfg.Traces.Collation
+	case "metrics":
+		metricsCfg := cfg.Metrics
+		metricsCfg.JSONColumns = jsonColumns
+		schema = metricsTableSchema(preset, metricsCfg)
+		retention, policyTags, collations = cfg.Metrics.RowRetention, cfg.Metrics.PolicyTags, cfg.Metrics.Collation
+	case "logs":
+		logsCfg := cfg.Logs
+		logsCfg.JSONColumns = jsonColumns
+		schema = logsTableSchema(preset, logsCfg)
+		retention, policyTags, collations = cfg.Logs.RowRetention, cfg.Logs.PolicyTags, cfg.Logs.Collation
+	}
+	schema = withCollectorColumns(withResourceHash(withIDColumns(schema, cfg.IDColumns), cfg.NormalizeResources.Enabled, cfg.ResourceHash), cfg.CollectorColumns)
+	schema = withExpiresAt(withEventDate(withWatermark(schema, cfg.Watermark), cfg.EventDate.Enabled), retention)
+	return withCollation(withPolicyTags(schema, policyTags), collations)
+}
+
+// missingCollations returns the columns of table lacking the collation want
+// has for them.
+func missingCollations(want, table bigquery.Schema) []string {
+	var missing []string
+	for _, field := range want {
+		if field.Collation == "" {
+			continue
+		}
+		idx := slices.IndexFunc(table, func(existing *bigquery.FieldSchema) bool {
+			return strings.EqualFold(existing.Name, field.Name)
+		})
+		if idx >= 0 && table[idx].Collation != field.Collation {
+			missing = append(missing, field.Name)
+		}
+	}
+	return missing
+}
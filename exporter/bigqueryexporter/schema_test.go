@@ -0,0 +1,296 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconvtest"
+)
+
+func schemaField(schema bigquery.Schema, name string) *bigquery.FieldSchema {
+	for _, field := range schema {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func fieldNames(schema bigquery.Schema) []string {
+	names := make([]string, len(schema))
+	for i, field := range schema {
+		names[i] = field.Name
+	}
+	return names
+}
+
+func TestSchemaPresetNames(t *testing.T) {
+	assert.Equal(t, []string{"compat", "default", "slim", "structured"}, schemaPresetNames())
+}
+
+func TestSchemaPresetDefault(t *testing.T) {
+	for _, schema := range []bigquery.Schema{rowconv.TracesSchema, rowconv.MetricsSchema, rowconv.LogsSchema} {
+		assert.Equal(t, schema, schemaPresets[defaultSchemaPreset].apply(schema))
+	}
+}
+
+func TestSchemaPresetCompat(t *testing.T) {
+	schema := schemaPresets["compat"].apply(rowconv.TracesSchema)
+	require.Len(t, schema, len(rowconv.TracesSchema))
+	for _, field := range schema {
+		assert.NotEqual(t, bigquery.JSONFieldType, field.Type, field.Name)
+	}
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "span_attributes").Type)
+	// The shared schema must not be modified.
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(rowconv.TracesSchema, "span_attributes").Type)
+}
+
+func TestSchemaPresetSlim(t *testing.T) {
+	preset := schemaPresets["slim"]
+	traces := preset.apply(rowconv.TracesSchema)
+	assert.Nil(t, schemaField(traces, "events"))
+	assert.Nil(t, schemaField(traces, "links"))
+	assert.NotNil(t, schemaField(traces, "span_attributes"))
+	assert.Nil(t, schemaField(preset.apply(rowconv.MetricsSchema), "exemplars"))
+	assert.Len(t, preset.apply(rowconv.LogsSchema), len(rowconv.LogsSchema))
+}
+
+func TestSchemaPresetNested(t *testing.T) {
+	preset := schemaPresets["structured"]
+	traces := preset.apply(rowconv.TracesSchema)
+	require.Len(t, traces, len(rowconv.TracesSchema))
+	for _, name := range []string{"events", "links", "instrumentation_scope"} {
+		assert.Equal(t, bigquery.RecordFieldType, schemaField(traces, name).Type, name)
+	}
+	assert.True(t, schemaField(traces, "events").Repeated)
+	assert.False(t, schemaField(traces, "instrumentation_scope").Repeated)
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(traces, "span_attributes").Type)
+	metrics := preset.apply(rowconv.MetricsSchema)
+	assert.Equal(t, bigquery.RecordFieldType, schemaField(metrics, "exemplars").Type)
+	assert.Equal(t, bigquery.RecordFieldType, schemaField(metrics, "quantiles").Type)
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(metrics, "bucket_counts").Type)
+
+	// STRING JSON columns apply to the subfields as well.
+	logs := tableSchema(rowconv.LogsSchema, "structured", jsonColumnsString)
+	scope := schemaField(logs, "instrumentation_scope")
+	assert.Equal(t, bigquery.RecordFieldType, scope.Type)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(scope.Schema, "attributes").Type)
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(rowconv.NestedFields["instrumentation_scope"].Schema, "attributes").Type)
+}
+
+func TestTableSchemaJSONColumns(t *testing.T) {
+	schema := tableSchema(rowconv.LogsSchema, defaultSchemaPreset, jsonColumnsString)
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "log_attributes").Type)
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(rowconv.LogsSchema, "log_attributes").Type)
+
+	schema = tableSchema(rowconv.LogsSchema, "compat", jsonColumnsJSON)
+	assert.Equal(t, bigquery.JSONFieldType, schemaField(schema, "log_attributes").Type)
+
+	schema = tableSchema(rowconv.LogsSchema, "compat", "")
+	assert.Equal(t, bigquery.StringFieldType, schemaField(schema, "log_attributes").Type)
+}
+
+// TestSchemaPresetsRoundTrip checks that the data stored by every preset
+// converts back to data stored identically.
+func TestSchemaPresetsRoundTrip(t *testing.T) {
+	in := fuzzInput{name: "GET /users", key: "http.method", value: "GET", id: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, number: 1.5, ts: 1700000000000000123, n: 3}
+	for _, preset := range schemaPresetNames() {
+		t.Run(preset, func(t *testing.T) {
+			tracesSchema := tracesTableSchema(preset, TracesConfig{JSONColumns: jsonColumnsJSON})
+			traces := rowconvtest.Normalize(tracesSchema, rowconv.Traces(in.traces(), rowconv.TracesOptions{}))
+			td, err := rowconvtest.Traces(traces)
+			require.NoError(t, err)
+			assert.Equal(t, traces, rowconvtest.Normalize(tracesSchema, rowconv.Traces(td, rowconv.TracesOptions{})))
+
+			metricsSchema := tableSchema(rowconv.MetricsSchema, preset, jsonColumnsJSON)
+			metrics := rowconvtest.Normalize(metricsSchema, rowconv.Metrics(in.metrics(), rowconv.MetricsOptions{}))
+			md, err := rowconvtest.Metrics(metrics)
+			require.NoError(t, err)
+			assert.Equal(t, metrics, rowconvtest.Normalize(metricsSchema, rowconv.Metrics(md, rowconv.MetricsOptions{})))
+
+			logsSchema := tableSchema(rowconv.LogsSchema, preset, jsonColumnsJSON)
+			logs := rowconvtest.Normalize(logsSchema, rowconv.Logs(in.logs(), rowconv.LogsOptions{}))
+			ld, err := rowconvtest.Logs(logs)
+			require.NoError(t, err)
+			assert.Equal(t, logs, rowconvtest.Normalize(logsSchema, rowconv.Logs(ld, rowconv.LogsOptions{})))
+		})
+	}
+}
+
+func TestCheckSchemaCompatible(t *testing.T) {
+	want := bigquery.Schema{
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "count", Type: bigquery.IntegerFieldType},
+		{Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType},
+		}},
+	}
+	require.NoError(t, checkSchemaCompatible(want, want))
+	require.NoError(t, checkSchemaCompatible(rowconv.TracesSchema, rowconv.TracesSchema))
+
+	extra := append(bigquery.Schema{{Name: "NAME", Type: bigquery.StringFieldType}}, want[1:]...)
+	extra = append(extra, &bigquery.FieldSchema{Name: "note", Type: bigquery.StringFieldType})
+	assert.NoError(t, checkSchemaCompatible(want, extra), "column names are case-insensitive and extra columns are accepted")
+
+	tests := []struct {
+		name  string
+		table bigquery.Schema
+		want  schemaDiff
+	}{
+		{
+			name:  "missing column",
+			table: bigquery.Schema{want[0], want[2]},
+			want:  schemaDiff{{column: "count", want: "INTEGER NULLABLE"}},
+		},
+		{
+			name:  "different type",
+			table: bigquery.Schema{want[0], {Name: "count", Type: bigquery.StringFieldType}, want[2]},
+			want:  schemaDiff{{column: "count", table: "STRING NULLABLE", want: "INTEGER NULLABLE"}},
+		},
+		{
+			name: "not repeated",
+			table: bigquery.Schema{want[0], want[1], {Name: "events", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType},
+			}}},
+			want: schemaDiff{{column: "events", table: "RECORD NULLABLE", want: "RECORD REPEATED"}},
+		},
+		{
+			name:  "required column",
+			table: bigquery.Schema{{Name: "name", Type: bigquery.StringFieldType, Required: true}, want[1], want[2]},
+			want:  schemaDiff{{column: "name", table: "STRING REQUIRED", want: "STRING NULLABLE"}},
+		},
+		{
+			name:  "additional required column",
+			table: bigquery.Schema{want[0], want[1], want[2], {Name: "tenant", Type: bigquery.StringFieldType, Required: true}},
+			want:  schemaDiff{{column: "tenant", table: "STRING REQUIRED"}},
+		},
+		{
+			name: "nested column",
+			table: bigquery.Schema{want[0], want[1], {Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.JSONFieldType},
+			}}},
+			want: schemaDiff{{column: "events.name", table: "JSON NULLABLE", want: "STRING NULLABLE"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkSchemaCompatible(want, tt.table))
+		})
+	}
+}
+
+func TestSchemaDiffError(t *testing.T) {
+	diff := schemaDiff{
+		{column: "span_attributes", table: "STRING NULLABLE", want: "JSON NULLABLE"},
+		{column: "status_class", want: "STRING NULLABLE"},
+		{column: "tenant", table: "STRING REQUIRED"},
+	}
+	assert.EqualError(t, diff, `3 column(s) differ from the schema the exporter writes:
+  COLUMN            TABLE             EXPORTER
+  span_attributes   STRING NULLABLE   JSON NULLABLE
+  status_class      (missing)         STRING NULLABLE
+  tenant            STRING REQUIRED   (not written)`)
+	assert.Equal(t, schemaDiff{diff[0], diff[2]}, diff.incompatible())
+	assert.Len(t, diff, 3, "incompatible does not modify the diff")
+}
+
+func TestMergeMissingColumns(t *testing.T) {
+	table := bigquery.Schema{
+		{Name: "Name", Type: bigquery.StringFieldType, Required: true},
+		{Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType},
+		}},
+		{Name: "note", Type: bigquery.StringFieldType},
+	}
+	want := bigquery.Schema{
+		{Name: "name", Type: bigquery.StringFieldType, Required: true},
+		{Name: "count", Type: bigquery.IntegerFieldType, Required: true},
+		{Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType},
+			{Name: "attributes", Type: bigquery.JSONFieldType},
+		}},
+	}
+
+	merged, added := mergeMissingColumns(want, table)
+	assert.Equal(t, []string{"count", "events.attributes"}, added)
+	assert.Equal(t, bigquery.Schema{
+		{Name: "Name", Type: bigquery.StringFieldType, Required: true},
+		{Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType},
+			{Name: "attributes", Type: bigquery.JSONFieldType},
+		}},
+		{Name: "note", Type: bigquery.StringFieldType},
+		{Name: "count", Type: bigquery.IntegerFieldType},
+	}, merged)
+	require.NoError(t, checkSchemaCompatible(want, merged))
+	// The table's schema must not be modified.
+	assert.Len(t, table, 3)
+	assert.Len(t, table[1].Schema, 1)
+
+	merged, added = mergeMissingColumns(want, merged)
+	assert.Empty(t, added)
+	assert.Len(t, merged, 4)
+}
+
+func TestWithPolicyTags(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "body", Type: bigquery.StringFieldType},
+		{Name: "severity_text", Type: bigquery.StringFieldType},
+	}
+	tag := "projects/p/locations/us/taxonomies/1/policyTags/2"
+	tagged := withPolicyTags(schema, map[string]string{"body": tag})
+	assert.Equal(t, &bigquery.PolicyTagList{Names: []string{tag}}, schemaField(tagged, "body").PolicyTags)
+	assert.Nil(t, schemaField(tagged, "severity_text").PolicyTags)
+	assert.Nil(t, schema[0].PolicyTags, "the input schema is not modified")
+	assert.Equal(t, schema, withPolicyTags(schema, nil))
+}
+
+func TestMissingPolicyTags(t *testing.T) {
+	tag := &bigquery.PolicyTagList{Names: []string{"projects/p/locations/us/taxonomies/1/policyTags/2"}}
+	want := bigquery.Schema{
+		{Name: "body", Type: bigquery.StringFieldType, PolicyTags: tag},
+		{Name: "log_attributes", Type: bigquery.JSONFieldType, PolicyTags: tag},
+		{Name: "new_column", Type: bigquery.StringFieldType, PolicyTags: tag},
+		{Name: "severity_text", Type: bigquery.StringFieldType},
+	}
+	table := bigquery.Schema{
+		{Name: "body", Type: bigquery.StringFieldType, PolicyTags: tag},
+		{Name: "log_attributes", Type: bigquery.JSONFieldType},
+		{Name: "severity_text", Type: bigquery.StringFieldType},
+	}
+	assert.Equal(t, []string{"log_attributes"}, missingPolicyTags(want, table))
+}
+
+func TestWithCollation(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "body", Type: bigquery.StringFieldType},
+		{Name: "severity_text", Type: bigquery.StringFieldType},
+	}
+	collated := withCollation(schema, map[string]string{"severity_text": "und:ci"})
+	assert.Equal(t, "und:ci", schemaField(collated, "severity_text").Collation)
+	assert.Empty(t, schemaField(collated, "body").Collation)
+	assert.Empty(t, schema[1].Collation, "the input schema is not modified")
+	assert.Equal(t, schema, withCollation(schema, nil))
+}
+
+func TestMissingCollations(t *testing.T) {
+	want := bigquery.Schema{
+		{Name: "body", Type: bigquery.StringFieldType},
+		{Name: "severity_text", Type: bigquery.StringFieldType, Collation: "und:ci"},
+		{Name: "trace_id", Type: bigquery.StringFieldType, Collation: "und:ci"},
+		{Name: "new_column", Type: bigquery.StringFieldType, Collation: "und:ci"},
+	}
+	table := bigquery.Schema{
+		{Name: "body", Type: bigquery.StringFieldType, Collation: "und:ci"},
+		{Name: "severity_text", Type: bigquery.StringFieldType},
+		{Name: "trace_id", Type: bigquery.StringFieldType, Collation: "und:ci"},
+	}
+	assert.Equal(t, []string{"severity_text"}, missingCollations(want, table))
+}
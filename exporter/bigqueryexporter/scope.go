@@ -0,0 +1,42 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// Values of the per-signal scope_columns setting. Scope columns are not
+// written when it is empty.
+const (
+	scopeColumnsAlongside = "alongside"
+	scopeColumnsInstead   = "instead"
+)
+
+// withScopeColumns returns schema with the rowconv.ScopeFields columns
+// appended when setting is set, and without the instrumentation_scope
+// column when it is instead. schema is not modified.
+func withScopeColumns(schema bigquery.Schema, setting string) bigquery.Schema {
+	if setting == "" {
+		return schema
+	}
+	if setting == scopeColumnsInstead {
+		schema = slices.DeleteFunc(slices.Clone(schema), func(f *bigquery.FieldSchema) bool { return f.Name == "instrumentation_scope" })
+	}
+	return append(schema, rowconv.ScopeFields...)
+}
+
+func validateScopeColumns(field, value string) error {
+	switch value {
+	case "", scopeColumnsAlongside, scopeColumnsInstead:
+		return nil
+	default:
+		return fmt.Errorf("%s %q is not supported, must be one of %s, %s", field, value, scopeColumnsAlongside, scopeColumnsInstead)
+	}
+}
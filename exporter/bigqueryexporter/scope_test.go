@@ -0,0 +1,38 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWithScopeColumns(t *testing.T) {
+	assert.Equal(t, rowconv.LogsSchema, withScopeColumns(rowconv.LogsSchema, ""))
+
+	alongside := withScopeColumns(rowconv.LogsSchema, scopeColumnsAlongside)
+	assert.NotNil(t, schemaField(alongside, "instrumentation_scope"))
+	assert.NotNil(t, schemaField(alongside, "scope_name"))
+	assert.NotNil(t, schemaField(alongside, "scope_version"))
+
+	instead := withScopeColumns(rowconv.LogsSchema, scopeColumnsInstead)
+	assert.Nil(t, schemaField(instead, "instrumentation_scope"))
+	assert.NotNil(t, schemaField(instead, "scope_name"))
+	assert.NotNil(t, schemaField(rowconv.LogsSchema, "instrumentation_scope"), "input schema is not modified")
+}
+
+func TestScopeColumnsTableSchemas(t *testing.T) {
+	assert.Nil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{}), "scope_name"))
+	assert.NotNil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{ScopeColumns: scopeColumnsAlongside}), "scope_name"))
+	assert.Nil(t, schemaField(metricsTableSchema("structured", MetricsConfig{ScopeColumns: scopeColumnsInstead}), "instrumentation_scope"))
+	assert.NotNil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{ScopeColumns: scopeColumnsInstead}), "scope_version"))
+
+	assert.False(t, TracesConfig{}.rowOptions().ScopeColumns)
+	assert.True(t, TracesConfig{ScopeColumns: scopeColumnsAlongside}.rowOptions().ScopeColumns)
+	assert.True(t, MetricsConfig{ScopeColumns: scopeColumnsInstead}.rowOptions().ScopeColumns)
+	assert.True(t, LogsConfig{ScopeColumns: scopeColumnsInstead}.rowOptions().ScopeColumns)
+}
@@ -0,0 +1,42 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func (cfg SeverityConfig) validate(field string) error {
+	seen := make(map[string]string, len(cfg.TextMapping))
+	for _, text := range slices.Sorted(maps.Keys(cfg.TextMapping)) {
+		level := cfg.TextMapping[text]
+		if !slices.Contains(rowconv.SeverityLevels, level) {
+			return fmt.Errorf("%s.text_mapping: level %q of %q is not supported, must be one of %s", field, level, text, strings.Join(rowconv.SeverityLevels, ", "))
+		}
+		key := strings.ToLower(strings.TrimSpace(text))
+		if other, ok := seen[key]; ok {
+			return fmt.Errorf("%s.text_mapping: %q and %q map the same text", field, other, text)
+		}
+		seen[key] = text
+	}
+	return nil
+}
+
+// options returns the conversion options of cfg, or nil when the severity
+// column is disabled.
+func (cfg SeverityConfig) options() *rowconv.SeverityOptions {
+	if !cfg.Enabled {
+		return nil
+	}
+	mapping := make(map[string]string, len(cfg.TextMapping))
+	for text, level := range cfg.TextMapping {
+		mapping[strings.ToLower(strings.TrimSpace(text))] = level
+	}
+	return &rowconv.SeverityOptions{TextMapping: mapping}
+}
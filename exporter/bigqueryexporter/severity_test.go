@@ -0,0 +1,22 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverityConfigValidate(t *testing.T) {
+	assert.NoError(t, SeverityConfig{TextMapping: map[string]string{"sev-3": "ERROR", "chatty": "DEBUG"}}.validate("logs.severity"))
+	assert.ErrorContains(t, SeverityConfig{TextMapping: map[string]string{"sev-3": "error"}}.validate("logs.severity"), `level "error" of "sev-3"`)
+	assert.ErrorContains(t, SeverityConfig{TextMapping: map[string]string{"Warn": "WARN", "warn": "ERROR"}}.validate("logs.severity"), "map the same text")
+}
+
+func TestSeverityConfigOptions(t *testing.T) {
+	assert.Nil(t, SeverityConfig{TextMapping: map[string]string{"a": "INFO"}}.options())
+	opts := SeverityConfig{Enabled: true, TextMapping: map[string]string{" Sev-3 ": "ERROR"}}.options()
+	assert.Equal(t, map[string]string{"sev-3": "ERROR"}, opts.TextMapping)
+}
@@ -0,0 +1,323 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// shardIdleTimeout is how long the appender of a shard other than the
+// current one stays open without appends.
+const shardIdleTimeout = time.Hour
+
+// shardIdleCheckInterval is how often idle shards are looked for while
+// shards other than the current one are open.
+const shardIdleCheckInterval = 10 * time.Minute
+
+// shardOpenTimeout bounds creating the table of a new shard and opening its
+// appender.
+const shardOpenTimeout = time.Minute
+
+// isTableTemplate reports whether a table name contains time placeholders.
+func isTableTemplate(name string) bool {
+	return strings.Contains(name, "%")
+}
+
+// expandTableTemplate replaces the placeholders %Y, %m, %d and %H of a
+// table name template with the UTC year, month, day and hour of t.
+func expandTableTemplate(template string, t time.Time) (string, error) {
+	t = t.UTC()
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' {
+			b.WriteByte(template[i])
+			continue
+		}
+		if i+1 == len(template) {
+			return "", fmt.Errorf("table name template %q ends with %%", template)
+		}
+		i++
+		switch template[i] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		default:
+			return "", fmt.Errorf("table name template %q has unsupported placeholder %%%c, must be one of %%Y, %%m, %%d, %%H", template, template[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// tableShards routes the rows of a time-sharded table to the shard of their
+// event time and opens the appenders of new shards on demand.
+type tableShards struct {
+	logger   *zap.Logger
+	template string
+	// open creates the shard table when needed and opens its appender.
+	open func(ctx context.Context, tableID string) (*storageAppender, error)
+	// window bounds the event times rows are routed by.
+	window ShardWindowConfig
+	now    func() time.Time
+	// opening makes concurrent appends to a new shard wait for a single
+	// open, without holding mu while it creates the table.
+	opening singleflight.Group
+
+	mu     sync.Mutex
+	shards map[string]*shard
+	// idleTimer closes idle shards. It runs while shards other than the
+	// pinned one are open.
+	idleTimer *time.Timer
+	closed    bool
+}
+
+type shard struct {
+	appender *storageAppender
+	// pinned is set for the shard opened during start, which other code
+	// refers to and which is closed with the exporter.
+	pinned   bool
+	refs     int
+	lastUsed time.Time
+}
+
+func newTableShards(logger *zap.Logger, template, current string, appender *storageAppender, window ShardWindowConfig, open func(context.Context, string) (*storageAppender, error)) *tableShards {
+	return &tableShards{
+		logger:   logger,
+		template: template,
+		open:     open,
+		window:   window,
+		now:      time.Now,
+		shards: map[string]*shard{
+			current: {appender: appender, pinned: true, lastUsed: time.Now()},
+		},
+	}
+}
+
+// split groups rows by the shard of the first timestamp after the Unix
+// epoch in columns. Rows without such a timestamp, or whose timestamp is
+// outside of the shard window, go to the current shard. The shards are
+// returned in ascending order.
+func (s *tableShards) split(rows []row, columns []string) (tableIDs []string, groups map[string][]row, err error) {
+	groups = make(map[string][]row)
+	now := s.now()
+	oldest, newest := now.Add(-s.window.Past), now.Add(s.window.Future)
+	for _, r := range rows {
+		ts := now
+		for _, column := range columns {
+			if t, ok := r[column].(time.Time); ok && t.UnixNano() > 0 {
+				if !t.Before(oldest) && !t.After(newest) {
+					ts = t
+				}
+				break
+			}
+		}
+		tableID, err := expandTableTemplate(s.template, ts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := groups[tableID]; !ok {
+			tableIDs = append(tableIDs, tableID)
+		}
+		groups[tableID] = append(groups[tableID], r)
+	}
+	slices.Sort(tableIDs)
+	return tableIDs, groups, nil
+}
+
+// acquire returns the appender of a shard, opening it when needed, and a
+// function releasing it. A shard outlives the push opening it and is opened
+// once for every push waiting for it, so it is opened without the
+// cancellation of ctx; a push whose ctx is done stops waiting for it.
+func (s *tableShards) acquire(ctx context.Context, tableID string) (*storageAppender, func(), error) {
+	s.mu.Lock()
+	sh, ok := s.shards[tableID]
+	if ok {
+		defer s.mu.Unlock()
+		return sh.appender, s.refLocked(sh), nil
+	}
+	s.mu.Unlock()
+
+	opening := s.opening.DoChan(tableID, func() (any, error) {
+		openCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shardOpenTimeout)
+		defer cancel()
+		return s.openShard(openCtx, tableID)
+	})
+	var opened singleflight.Result
+	select {
+	case opened = <-opening:
+	case <-ctx.Done():
+		return nil, nil, fmt.Errorf("wait for table shard %s: %w", tableID, ctx.Err())
+	}
+	if opened.Err != nil {
+		return nil, nil, opened.Err
+	}
+	sh = opened.Val.(*shard)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sh.appender, s.refLocked(sh), nil
+}
+
+// refLocked marks sh as used and returns the function releasing it. Shards
+// in use are not closed as idle.
+func (s *tableShards) refLocked(sh *shard) func() {
+	sh.refs++
+	sh.lastUsed = s.now()
+	return func() {
+		s.mu.Lock()
+		sh.refs--
+		s.mu.Unlock()
+	}
+}
+
+// openShard opens the appender of a shard and adds it to the shards.
+func (s *tableShards) openShard(ctx context.Context, tableID string) (*shard, error) {
+	s.mu.Lock()
+	sh, ok := s.shards[tableID]
+	s.mu.Unlock()
+	if ok {
+		// Opened by a call that completed after this one looked for it.
+		return sh, nil
+	}
+	appender, err := s.open(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		if err := appender.close(ctx); err != nil {
+			s.logger.Warn("Failed to close table shard opened during shutdown", zap.String("table", tableID), zap.Error(err))
+		}
+		return nil, fmt.Errorf("table shard %s opened during shutdown", tableID)
+	}
+	s.logger.Info("Opened table shard", zap.String("table", tableID))
+	sh = &shard{appender: appender, lastUsed: s.now()}
+	s.shards[tableID] = sh
+	if s.idleTimer == nil {
+		s.idleTimer = time.AfterFunc(shardIdleCheckInterval, s.closeIdle)
+	}
+	return sh, nil
+}
+
+// closeIdle closes the shards without appends for shardIdleTimeout, other
+// than the pinned one, and checks again later while such shards remain.
+func (s *tableShards) closeIdle() {
+	s.mu.Lock()
+	now := s.now()
+	idle := make(map[string]*shard)
+	var open int
+	for tableID, sh := range s.shards {
+		if sh.pinned {
+			continue
+		}
+		if sh.refs > 0 || now.Sub(sh.lastUsed) < shardIdleTimeout {
+			open++
+			continue
+		}
+		delete(s.shards, tableID)
+		idle[tableID] = sh
+	}
+	if !s.closed && open > 0 {
+		s.idleTimer.Reset(shardIdleCheckInterval)
+	} else {
+		s.idleTimer = nil
+	}
+	s.mu.Unlock()
+
+	// Closing commits the rows still pending, so it happens without mu.
+	ctx, cancel := context.WithTimeout(context.Background(), shardIdleCheckInterval)
+	defer cancel()
+	for tableID, sh := range idle {
+		if err := sh.appender.close(ctx); err != nil {
+			s.logger.Warn("Failed to close idle table shard", zap.String("table", tableID), zap.Error(err))
+			continue
+		}
+		s.logger.Debug("Closed idle table shard", zap.String("table", tableID))
+	}
+}
+
+// appenders returns the appenders of the open shards other than the pinned
+// one.
+func (s *tableShards) appenders() []*storageAppender {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var appenders []*storageAppender
+	for _, sh := range s.shards {
+		if !sh.pinned {
+			appenders = append(appenders, sh.appender)
+		}
+	}
+	return appenders
+}
+
+// close closes the appenders of every shard except the pinned one.
+func (s *tableShards) close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+	var errs []error
+	for tableID, sh := range s.shards {
+		if sh.pinned {
+			continue
+		}
+		delete(s.shards, tableID)
+		if err := sh.appender.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close table shard %s: %w", tableID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// appendTableRows appends rows to the table of appender or, when shards is
+// set, to the shards of their event time held in columns. Each table's
+// watermark column is set from its own rows.
+func appendTableRows(ctx context.Context, appender *storageAppender, shards *tableShards, rows []row, columns ...string) error {
+	setEventDates(rows, appender.eventDates, columns)
+	setExpiresAt(rows, appender.retention, time.Now(), columns)
+	if shards == nil {
+		defer appender.watermarks.begin(rows, columns...)()
+		return appendStorageRows(ctx, appender, rows)
+	}
+	tableIDs, groups, err := shards.split(rows, columns)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, tableID := range tableIDs {
+		shardAppender, release, err := shards.acquire(ctx, tableID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("open table shard %s: %w", tableID, err))
+			continue
+		}
+		end := shardAppender.watermarks.begin(groups[tableID], columns...)
+		err = appendStorageRows(ctx, shardAppender, groups[tableID])
+		end()
+		release()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,243 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExpandTableTemplate(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 7, 30, 0, 0, time.FixedZone("UTC+9", 9*60*60))
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{template: "logs_%Y%m%d", want: "logs_20240430"},
+		{template: "logs_%Y%m", want: "logs_202404"},
+		{template: "traces_%Y%m%d%H", want: "traces_2024043022"},
+		{template: "logs_%s", wantErr: true},
+		{template: "logs_%", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := expandTableTemplate(tt.template, ts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTableName(t *testing.T) {
+	tableID, template := tableName("log")
+	assert.Equal(t, "log", tableID)
+	assert.Empty(t, template)
+
+	before := time.Now().UTC()
+	tableID, template = tableName("log_%Y%m%d")
+	after := time.Now().UTC()
+	assert.Equal(t, "log_%Y%m%d", template)
+	assert.Contains(t, []string{"log_" + before.Format("20060102"), "log_" + after.Format("20060102")}, tableID)
+}
+
+func TestTableShardsSplit(t *testing.T) {
+	now := time.Date(2024, 5, 2, 0, 30, 0, 0, time.UTC)
+	s := newTableShards(zap.NewNop(), "log_%Y%m%d", "log_20240502", &storageAppender{}, createDefaultConfig().Dataset.ShardWindow, nil)
+	s.now = func() time.Time { return now }
+
+	rows := []row{
+		{"log_timestamp": time.Date(2024, 5, 2, 0, 1, 0, 0, time.UTC)},
+		{"log_timestamp": time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)},
+		{"log_timestamp": time.Unix(0, 0).UTC(), "observed_timestamp": time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{"log_timestamp": time.Unix(0, 0).UTC(), "observed_timestamp": time.Unix(0, 0).UTC()},
+	}
+	tableIDs, groups, err := s.split(rows, []string{"log_timestamp", "observed_timestamp"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log_20240501", "log_20240502"}, tableIDs)
+	assert.Equal(t, []row{rows[1], rows[2]}, groups["log_20240501"])
+	assert.Equal(t, []row{rows[0], rows[3]}, groups["log_20240502"])
+}
+
+func TestTableShardsSplitWindow(t *testing.T) {
+	now := time.Date(2024, 5, 2, 0, 30, 0, 0, time.UTC)
+	s := newTableShards(zap.NewNop(), "log_%Y%m%d", "log_20240502", &storageAppender{}, ShardWindowConfig{Past: 48 * time.Hour, Future: time.Hour}, nil)
+	s.now = func() time.Time { return now }
+
+	rows := []row{
+		{"log_timestamp": time.Date(2024, 4, 30, 1, 0, 0, 0, time.UTC)},
+		// Outside of the window, the rows go to the current shard rather
+		// than creating shards years away.
+		{"log_timestamp": time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{"log_timestamp": time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"log_timestamp": time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC)},
+		{"log_timestamp": time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	tableIDs, groups, err := s.split(rows, []string{"log_timestamp"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log_20240430", "log_20240502"}, tableIDs)
+	assert.Equal(t, []row{rows[0]}, groups["log_20240430"])
+	assert.Equal(t, []row{rows[1], rows[2], rows[3], rows[4]}, groups["log_20240502"])
+}
+
+func TestTableShardsAcquire(t *testing.T) {
+	current := &storageAppender{tableRef: "log_20240502"}
+	var opened []string
+	s := newTableShards(zap.NewNop(), "log_%Y%m%d", "log_20240502", current, ShardWindowConfig{}, func(_ context.Context, tableID string) (*storageAppender, error) {
+		if tableID == "log_19990101" {
+			return nil, errors.New("permission denied")
+		}
+		opened = append(opened, tableID)
+		return &storageAppender{tableRef: tableID}, nil
+	})
+
+	a, release, err := s.acquire(t.Context(), "log_20240502")
+	require.NoError(t, err)
+	assert.Same(t, current, a)
+	release()
+
+	a, release, err = s.acquire(t.Context(), "log_20240501")
+	require.NoError(t, err)
+	assert.Equal(t, "log_20240501", a.tableRef)
+	release()
+	again, release, err := s.acquire(t.Context(), "log_20240501")
+	require.NoError(t, err)
+	assert.Same(t, a, again)
+	release()
+	assert.Equal(t, []string{"log_20240501"}, opened)
+
+	_, _, err = s.acquire(t.Context(), "log_19990101")
+	assert.ErrorContains(t, err, "permission denied")
+	assert.NotContains(t, s.shards, "log_19990101")
+	assert.Zero(t, s.shards["log_20240501"].refs)
+}
+
+func TestTableShardsOpenConcurrently(t *testing.T) {
+	current := &storageAppender{tableRef: "log_20240502"}
+	started, unblock := make(chan struct{}), make(chan struct{})
+	var opens atomic.Int32
+	s := newTableShards(zap.NewNop(), "log_%Y%m%d", "log_20240502", current, ShardWindowConfig{}, func(_ context.Context, tableID string) (*storageAppender, error) {
+		if opens.Add(1) == 1 {
+			close(started)
+		}
+		<-unblock
+		return &storageAppender{tableRef: tableID}, nil
+	})
+	t.Cleanup(func() {
+		s.mu.Lock()
+		s.idleTimer.Stop()
+		s.mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	appenders := make([]*storageAppender, 2)
+	for i := range appenders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, release, err := s.acquire(context.Background(), "log_20240501")
+			assert.NoError(t, err)
+			release()
+			appenders[i] = a
+		}()
+	}
+	<-started
+
+	// Creating a shard table does not hold up appends to the other shards.
+	a, release, err := s.acquire(t.Context(), "log_20240502")
+	require.NoError(t, err)
+	assert.Same(t, current, a)
+	release()
+
+	close(unblock)
+	wg.Wait()
+	assert.Equal(t, int32(1), opens.Load())
+	assert.Same(t, appenders[0], appenders[1])
+}
+
+func TestTableShardsOutlivePush(t *testing.T) {
+	srv := &fakeWriteServer{}
+	client := newFakeWriteClient(t, srv)
+	current := &storageAppender{tableRef: "log_20240502"}
+	s := newTableShards(zap.NewNop(), "log_%Y%m%d", "log_20240502", current, ShardWindowConfig{}, func(ctx context.Context, tableID string) (*storageAppender, error) {
+		return newStorageAppender(ctx, client, zap.NewNop(), nil, "p", "d", tableID,
+			bigquery.Schema{{Name: "body", Type: bigquery.StringFieldType}}, WriteConfig{Mode: writeModeDefault, Workers: 1})
+	})
+	t.Cleanup(func() { require.NoError(t, s.close(context.Background())) })
+
+	// The shard is opened by a push whose ctx is done before the next one.
+	ctx, cancel := context.WithCancel(t.Context())
+	a, release, err := s.acquire(ctx, "log_20240501")
+	require.NoError(t, err)
+	require.NoError(t, appendStorageRows(ctx, a, []row{{"body": "a"}}))
+	release()
+	cancel()
+
+	again, release, err := s.acquire(t.Context(), "log_20240501")
+	require.NoError(t, err)
+	assert.Same(t, a, again)
+	require.NoError(t, appendStorageRows(t.Context(), again, []row{{"body": "b"}}))
+	release()
+	assert.Len(t, srv.committedRows(), 2)
+}
+
+func TestTableShardsCloseIdle(t *testing.T) {
+	client := newFakeWriteClient(t, &fakeWriteServer{})
+	open := func(ctx context.Context, tableID string) (*storageAppender, error) {
+		return newStorageAppender(ctx, client, zap.NewNop(), nil, "p", "d", tableID,
+			bigquery.Schema{{Name: "body", Type: bigquery.StringFieldType}}, WriteConfig{Mode: writeModeDefault, Workers: 1})
+	}
+	current, err := open(t.Context(), "log_20240502")
+	require.NoError(t, err)
+	now := time.Date(2024, 5, 2, 0, 30, 0, 0, time.UTC)
+	s := newTableShards(zap.NewNop(), "log_%Y%m%d", "log_20240502", current, ShardWindowConfig{}, open)
+	s.now = func() time.Time { return now }
+
+	idle, release, err := s.acquire(t.Context(), "log_20240501")
+	require.NoError(t, err)
+	release()
+	busy, releaseBusy, err := s.acquire(t.Context(), "log_20240430")
+	require.NoError(t, err)
+	require.NotNil(t, s.idleTimer)
+
+	now = now.Add(shardIdleTimeout)
+	s.closeIdle()
+	assert.NotContains(t, s.shards, "log_20240501")
+	assert.Contains(t, s.shards, "log_20240430")
+	assert.Contains(t, s.shards, "log_20240502")
+	assert.NotNil(t, s.idleTimer)
+	assert.True(t, isClosed(idle.done))
+
+	// The timer stops once only the current shard is left.
+	releaseBusy()
+	s.closeIdle()
+	assert.NotContains(t, s.shards, "log_20240430")
+	assert.Nil(t, s.idleTimer)
+	assert.True(t, isClosed(busy.done))
+
+	require.NoError(t, s.close(t.Context()))
+	require.NoError(t, current.close(t.Context()))
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"go.uber.org/zap"
+)
+
+// snapshotID returns the name of the snapshot of table taken at now.
+func (cfg SchemaSnapshotConfig) snapshotID(table string, now time.Time) (string, error) {
+	suffix, err := expandTableTemplate(cfg.Suffix, now)
+	if err != nil {
+		return "", err
+	}
+	return table + suffix, nil
+}
+
+// snapshotTable takes a snapshot of table in its dataset before the
+// exporter changes its schema, and sets the snapshot to expire after the
+// configured retention. A snapshot with the same name, taken by another
+// collector or an earlier start within the same period of the suffix, is
+// kept instead.
+func (e *bigQueryExporter) snapshotTable(ctx context.Context, table *bigquery.Table, target signalTarget) error {
+	cfg := e.cfg.SchemaSnapshot
+	now := time.Now()
+	snapshotID, err := cfg.snapshotID(target.tableID, now)
+	if err != nil {
+		return err
+	}
+	snapshot := *table
+	snapshot.TableID = snapshotID
+	copier := snapshot.CopierFrom(table)
+	copier.OperationType = bigquery.SnapshotOperation
+	copier.DestinationEncryptionConfig = e.encryptionConfig()
+	err = e.retryControlPlane(ctx, "snapshot table", func(ctx context.Context) error {
+		job, err := copier.Run(ctx)
+		if err != nil {
+			return err
+		}
+		status, err := job.Wait(ctx)
+		if err != nil {
+			return err
+		}
+		return status.Err()
+	})
+	if isAlreadyExists(err) {
+		e.logger.Info("Table snapshot exists; keeping it", zap.String("signal", target.name), zap.String("table", target.tableID), zap.String("snapshot", snapshotID))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("snapshot %s table %s to %s: %w", target.name, target.tableID, snapshotID, err)
+	}
+	if cfg.Retention > 0 {
+		err = e.retryControlPlane(ctx, "update snapshot expiration", func(ctx context.Context) error {
+			_, err := snapshot.Update(ctx, bigquery.TableMetadataToUpdate{ExpirationTime: now.Add(cfg.Retention)}, "")
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("set expiration of snapshot %s: %w", snapshotID, err)
+		}
+	}
+	e.logger.Info("Took table snapshot before changing its schema", zap.String("signal", target.name), zap.String("table", target.tableID),
+		zap.String("snapshot", snapshotID), zap.Duration("retention", cfg.Retention))
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestSchemaSnapshotID(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	id, err := SchemaSnapshotConfig{Suffix: "_snapshot_%Y%m%d%H"}.snapshotID("metric", now)
+	require.NoError(t, err)
+	assert.Equal(t, "metric_snapshot_2024050110", id)
+}
+
+// fakeSnapshots serves the snapshot copy jobs and the expiration updates
+// of snapshotTable. Jobs with a destination in existing fail with 409.
+type fakeSnapshots struct {
+	mu       sync.Mutex
+	existing map[string]bool
+	jobs     []map[string]any
+	updates  map[string]map[string]any
+}
+
+func (f *fakeSnapshots) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/jobs"):
+		var job map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&job)
+		f.jobs = append(f.jobs, job)
+		dst := job["configuration"].(map[string]any)["copy"].(map[string]any)["destinationTable"].(map[string]any)["tableId"].(string)
+		if f.existing[dst] {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":{"code":409,"message":"Already Exists: Table p:d.` + dst + `"}}`))
+			return
+		}
+		job["status"] = map[string]any{"state": "DONE"}
+		_ = json.NewEncoder(w).Encode(job)
+	case r.Method == http.MethodPatch:
+		var update map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		f.updates[r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]] = update
+		_ = json.NewEncoder(w).Encode(update)
+	default:
+		_, _ = w.Write([]byte(`{"jobReference":{"projectId":"p","jobId":"j"},"status":{"state":"DONE"}}`))
+	}
+}
+
+func TestSnapshotTable(t *testing.T) {
+	fake := &fakeSnapshots{existing: map[string]bool{}, updates: map[string]map[string]any{}}
+	client := newFakeBigQueryClient(t, fake)
+
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	cfg.AllowSchemaUpdate = true
+	cfg.SchemaSnapshot = SchemaSnapshotConfig{Enabled: true, Suffix: "_before_upgrade", Retention: 24 * time.Hour}
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	target := signalTarget{name: "metrics", project: "p", dataset: "d", tableID: "metric"}
+
+	require.NoError(t, exp.snapshotTable(t.Context(), client.Dataset("d").Table("metric"), target))
+	require.Len(t, fake.jobs, 1)
+	copyCfg := fake.jobs[0]["configuration"].(map[string]any)["copy"].(map[string]any)
+	assert.Equal(t, "SNAPSHOT", copyCfg["operationType"])
+	assert.Equal(t, "metric", copyCfg["sourceTables"].([]any)[0].(map[string]any)["tableId"])
+	assert.Equal(t, "metric_before_upgrade", copyCfg["destinationTable"].(map[string]any)["tableId"])
+	assert.Contains(t, fake.updates["metric_before_upgrade"], "expirationTime")
+
+	// An existing snapshot is kept.
+	fake.existing["metric_before_upgrade"] = true
+	fake.updates = map[string]map[string]any{}
+	require.NoError(t, exp.snapshotTable(t.Context(), client.Dataset("d").Table("metric"), target))
+	assert.Empty(t, fake.updates)
+}
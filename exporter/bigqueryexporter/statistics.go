@@ -0,0 +1,170 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"go.uber.org/zap"
+)
+
+const statisticsSignal = "statistics"
+
+// statisticsSchema is the schema of the append statistics table. Every flush
+// writes the counts gathered since the previous one, so the totals of an
+// hour are the sum of its rows.
+var statisticsSchema = bigquery.Schema{
+	{Name: "hour", Type: bigquery.TimestampFieldType, Required: true},
+	{Name: "reported_at", Type: bigquery.TimestampFieldType, Required: true},
+	{Name: "signal", Type: bigquery.StringFieldType, Required: true},
+	{Name: "destination_table", Type: bigquery.StringFieldType, Required: true},
+	{Name: "rows_written", Type: bigquery.IntegerFieldType, Required: true},
+	{Name: "bytes_written", Type: bigquery.IntegerFieldType, Required: true},
+	{Name: "failed_appends", Type: bigquery.IntegerFieldType, Required: true},
+	{Name: "failed_rows", Type: bigquery.IntegerFieldType, Required: true},
+	{Name: "dropped_rows", Type: bigquery.IntegerFieldType, Required: true},
+}
+
+type statisticsKey struct {
+	hour   time.Time
+	signal string
+	table  string
+}
+
+type statisticsCounts struct {
+	rowsWritten   int64
+	bytesWritten  int64
+	failedAppends int64
+	failedRows    int64
+	droppedRows   int64
+}
+
+func (c *statisticsCounts) add(o statisticsCounts) {
+	c.rowsWritten += o.rowsWritten
+	c.bytesWritten += o.bytesWritten
+	c.failedAppends += o.failedAppends
+	c.failedRows += o.failedRows
+	c.droppedRows += o.droppedRows
+}
+
+// appendStatistics gathers hourly append counts of every table until they
+// are flushed to the statistics table.
+type appendStatistics struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	counts map[statisticsKey]*statisticsCounts
+}
+
+func newAppendStatistics() *appendStatistics {
+	return &appendStatistics{now: time.Now, counts: make(map[statisticsKey]*statisticsCounts)}
+}
+
+// table returns the recorder for one destination table. It returns nil, which
+// records nothing, when s is nil.
+func (s *appendStatistics) table(signal, table string) *tableStatistics {
+	if s == nil {
+		return nil
+	}
+	return &tableStatistics{parent: s, signal: signal, table: table}
+}
+
+func (s *appendStatistics) add(key statisticsKey, c statisticsCounts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts, ok := s.counts[key]
+	if !ok {
+		counts = &statisticsCounts{}
+		s.counts[key] = counts
+	}
+	counts.add(c)
+}
+
+// drain returns the counts gathered so far and resets them.
+func (s *appendStatistics) drain() map[statisticsKey]*statisticsCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := s.counts
+	s.counts = make(map[statisticsKey]*statisticsCounts)
+	return counts
+}
+
+// restore adds back counts that could not be flushed.
+func (s *appendStatistics) restore(counts map[statisticsKey]*statisticsCounts) {
+	for key, c := range counts {
+		s.add(key, *c)
+	}
+}
+
+func statisticsToRows(counts map[statisticsKey]*statisticsCounts, reportedAt time.Time) []row {
+	rows := make([]row, 0, len(counts))
+	for key, c := range counts {
+		rows = append(rows, row{
+			"hour":              key.hour,
+			"reported_at":       reportedAt,
+			"signal":            key.signal,
+			"destination_table": key.table,
+			"rows_written":      c.rowsWritten,
+			"bytes_written":     c.bytesWritten,
+			"failed_appends":    c.failedAppends,
+			"failed_rows":       c.failedRows,
+			"dropped_rows":      c.droppedRows,
+		})
+	}
+	return rows
+}
+
+// tableStatistics records the append counts of one destination table.
+type tableStatistics struct {
+	parent *appendStatistics
+	signal string
+	table  string
+}
+
+func (t *tableStatistics) record(c statisticsCounts) {
+	if t == nil {
+		return
+	}
+	hour := t.parent.now().UTC().Truncate(time.Hour)
+	t.parent.add(statisticsKey{hour: hour, signal: t.signal, table: t.table}, c)
+}
+
+// statisticsLoop flushes the append statistics every flush interval until
+// the exporter shuts down.
+func (e *bigQueryExporter) statisticsLoop() {
+	defer e.statisticsWG.Done()
+	interval := e.cfg.Statistics.FlushInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.statisticsDone:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := e.flushStatistics(ctx); err != nil {
+			e.logger.Warn("Failed to write append statistics, retrying with the next flush", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// flushStatistics writes the counts gathered since the previous flush. Counts
+// that cannot be written are kept for the next flush.
+func (e *bigQueryExporter) flushStatistics(ctx context.Context) error {
+	counts := e.statistics.drain()
+	if len(counts) == 0 {
+		return nil
+	}
+	if err := appendStorageRows(ctx, e.statisticsAppender, statisticsToRows(counts, time.Now())); err != nil {
+		e.statistics.restore(counts)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestAppendStatistics(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 59, 30, 0, time.UTC)
+	stats := newAppendStatistics()
+	stats.now = func() time.Time { return now }
+
+	traces := stats.table("traces", "p.d.trace")
+	logs := stats.table("logs", "p.d.log")
+	traces.record(statisticsCounts{rowsWritten: 10, bytesWritten: 100})
+	traces.record(statisticsCounts{failedAppends: 1, failedRows: 5})
+	logs.record(statisticsCounts{droppedRows: 1})
+	now = now.Add(time.Minute)
+	traces.record(statisticsCounts{rowsWritten: 2, bytesWritten: 20})
+
+	counts := stats.drain()
+	assert.Empty(t, stats.drain())
+	assert.Equal(t, map[statisticsKey]*statisticsCounts{
+		{hour: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), signal: "traces", table: "p.d.trace"}: {rowsWritten: 10, bytesWritten: 100, failedAppends: 1, failedRows: 5},
+		{hour: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), signal: "logs", table: "p.d.log"}:     {droppedRows: 1},
+		{hour: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), signal: "traces", table: "p.d.trace"}: {rowsWritten: 2, bytesWritten: 20},
+	}, counts)
+
+	// Counts that could not be flushed are merged with newer ones.
+	traces.record(statisticsCounts{rowsWritten: 1, bytesWritten: 10})
+	stats.restore(counts)
+	restored := stats.drain()
+	assert.Equal(t, &statisticsCounts{rowsWritten: 3, bytesWritten: 30}, restored[statisticsKey{hour: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), signal: "traces", table: "p.d.trace"}])
+
+	reportedAt := now
+	rows := statisticsToRows(restored, reportedAt)
+	require.Len(t, rows, 3)
+	desc, _, err := schemaDescriptor(statisticsSchema)
+	require.NoError(t, err)
+	for _, r := range rows {
+		assert.Equal(t, reportedAt, r["reported_at"])
+		_, err := encodeRow(desc, r)
+		require.NoError(t, err)
+	}
+}
+
+func TestAppendStatisticsDisabled(t *testing.T) {
+	var stats *appendStatistics
+	stats.table("traces", "p.d.trace").record(statisticsCounts{rowsWritten: 1})
+
+	exp := newBigQueryExporter(t.Context(), createDefaultConfig(), exportertest.NewNopSettings(metadata.Type))
+	for _, target := range exp.signalTargets() {
+		assert.NotEqual(t, statisticsSignal, target.name)
+	}
+}
+
+func TestStatisticsTarget(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Statistics.Enabled = true
+	cfg.Logs.Project = "security-project"
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.project = "default-project"
+
+	targets := exp.signalTargets()
+	target := targets[len(targets)-1]
+	assert.Equal(t, statisticsSignal, target.name)
+	assert.Equal(t, "default-project", target.project)
+	assert.Equal(t, "append_statistics", target.tableID)
+	assert.Equal(t, PartitioningConfig{Field: "hour", Granularity: "DAY"}, target.partitioning)
+}
+
+func TestFlushStatisticsPendingMode(t *testing.T) {
+	srv := &fakeWriteServer{}
+	client := newFakeWriteClient(t, srv)
+	exp := newBigQueryExporter(t.Context(), createDefaultConfig(), exportertest.NewNopSettings(metadata.Type))
+	exp.statistics = newAppendStatistics()
+	var err error
+	exp.statisticsAppender, err = newStorageAppender(t.Context(), client, zap.NewNop(), nil, "p", "d", "statistics",
+		statisticsSchema, WriteConfig{Mode: writeModePending, Workers: 1})
+	require.NoError(t, err)
+
+	// Like the statistics loop, every flush has a context of its own, which
+	// is done once the flush returns.
+	for range 2 {
+		exp.statistics.table("traces", "p.d.trace").record(statisticsCounts{rowsWritten: 1})
+		ctx, cancel := context.WithCancel(t.Context())
+		require.NoError(t, exp.flushStatistics(ctx))
+		cancel()
+	}
+	assert.Empty(t, exp.statistics.drain())
+	assert.Len(t, srv.committedRows(), 2)
+	require.NoError(t, exp.statisticsAppender.close(t.Context()))
+}
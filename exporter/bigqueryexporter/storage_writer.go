@@ -7,82 +7,815 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
 	"cloud.google.com/go/bigquery/storage/managedwriter"
 	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	"cloud.google.com/go/civil"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
 )
 
-func newStorageWriteClient(ctx context.Context, projectID string) (*managedwriter.Client, error) {
-	return managedwriter.NewClient(ctx, projectID)
+func newStorageWriteClient(ctx context.Context, projectID string, opts ...option.ClientOption) (*managedwriter.Client, error) {
+	return managedwriter.NewClient(ctx, projectID, opts...)
 }
 
+const (
+	writeModeDefault = "default"
+	writeModePending = "pending"
+
+	writeCompressionNone = "none"
+)
+
+// storageAppender writes rows to a single table through a managed stream.
+//
+// In the default write mode rows are appended to the table's default stream
+// and become visible immediately. In the pending write mode rows are appended
+// to an application-created pending stream which is finalized and committed
+// atomically, either after every append or on aligned wall-clock windows.
+//
+// Each appender has its own workers, so appends to one table never wait on
+// another table. Appends that cannot get a worker before their deadline fail
+// instead of queueing behind a stalled stream.
 type storageAppender struct {
-	stream *managedwriter.ManagedStream
-	desc   protoreflect.MessageDescriptor
+	client    *managedwriter.Client
+	logger    *zap.Logger
+	telemetry *metadata.TelemetryBuilder
+	// columnNames renames the columns of rows before they are encoded.
+	columnNames map[string]string
+	// watermarks sets the watermark column of rows. It is nil unless the
+	// watermark option is enabled for the table.
+	watermarks *watermarks
+	// eventDates is the time zone of the event_date column of rows. It is
+	// nil unless event_date is enabled for the table.
+	eventDates *time.Location
+	// retention is the row retention of the expires_at column, or zero
+	// unless row_retention is set for the table.
+	retention time.Duration
+	// statistics records append counts for the statistics table. It is nil
+	// when the statistics table is disabled and for the table itself.
+	statistics *tableStatistics
+	// degradation drops the columns omitted by the slim preset under quota
+	// pressure. It is nil unless adaptive_slim is enabled.
+	degradation *slimDegradation
+	tableRef    string
+	// schema is the writer schema rows are currently encoded with. It is
+	// replaced when the table's schema no longer matches it.
+	schema atomic.Pointer[writerSchema]
+	// tableSchema reads the current schema of the table. It is nil when the
+	// configured scopes do not allow reading table metadata.
+	tableSchema func(context.Context) (bigquery.Schema, error)
+	// recreateTable creates the table again after it was deleted while the
+	// exporter is running. It is nil unless tables are auto-created.
+	recreateTable func(context.Context) error
+	// changeType is written to the _CHANGE_TYPE pseudo-column of every row
+	// of tables written with upsert, and empty otherwise.
+	changeType string
+	// recreateMu serializes recreating the table, so workers whose appends
+	// failed together recreate it only once.
+	recreateMu sync.Mutex
+	mode       string
+	interval   time.Duration
+	timeout    time.Duration
+	// debugSampleRate is the fraction of append results logged at debug
+	// level.
+	debugSampleRate float64
+	// traceID annotates the streams of the table with write.trace_labels.
+	traceID string
+
+	// workers holds a token for every append in progress. With a single
+	// worker it also guards the stream and offset state below, which only
+	// change in the pending mode.
+	workers chan struct{}
+	// streamMu guards replacing stream while other workers append, which
+	// happens when the table was recreated.
+	streamMu sync.RWMutex
+	stream   *managedwriter.ManagedStream
+	pending  int64
+	// uncommitted are the pending streams replaced by a new one whose
+	// commit failed. With a commit interval they are committed again with
+	// the next window.
+	uncommitted []uncommittedStream
+	// nextOffset is the offset at which the next append on the current
+	// stream is expected to be acknowledged.
+	nextOffset int64
+
+	done chan struct{}
+	wg   sync.WaitGroup
 }
 
 func newStorageAppender(
 	ctx context.Context,
 	client *managedwriter.Client,
+	logger *zap.Logger,
+	telemetry *metadata.TelemetryBuilder,
 	projectID, datasetID, tableID string,
 	schema bigquery.Schema,
+	write WriteConfig,
 ) (*storageAppender, error) {
+	msgDesc, normalized, err := schemaDescriptor(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &storageAppender{
+		client:          client,
+		logger:          logger,
+		telemetry:       telemetry,
+		tableRef:        managedwriter.TableParentFromParts(projectID, datasetID, tableID),
+		mode:            write.Mode,
+		interval:        write.CommitInterval,
+		timeout:         write.AppendTimeout,
+		debugSampleRate: write.DebugLogSampleRate,
+		traceID:         write.traceID(),
+		workers:         make(chan struct{}, max(write.Workers, 1)),
+		done:            make(chan struct{}),
+	}
+	a.schema.Store(&writerSchema{desc: msgDesc, normalized: normalized})
+	if a.stream, err = a.openStream(ctx); err != nil {
+		return nil, err
+	}
+	if a.mode == writeModePending && a.interval > 0 {
+		a.wg.Add(1)
+		go a.commitLoop()
+	}
+	return a, nil
+}
+
+// writerSchema is the descriptor rows are encoded with and its normalized
+// form sent to the Storage Write API.
+type writerSchema struct {
+	desc       protoreflect.MessageDescriptor
+	normalized *descriptorpb.DescriptorProto
+}
+
+// schemaDescriptor returns the message descriptor rows of schema are encoded
+// with, and its normalized form sent to the Storage Write API.
+func schemaDescriptor(schema bigquery.Schema) (protoreflect.MessageDescriptor, *descriptorpb.DescriptorProto, error) {
 	storageSchema, err := adapt.BQSchemaToStorageTableSchema(schema)
 	if err != nil {
-		return nil, fmt.Errorf("convert schema to storage schema: %w", err)
+		return nil, nil, fmt.Errorf("convert schema to storage schema: %w", err)
 	}
 
 	desc, err := adapt.StorageSchemaToProto2Descriptor(storageSchema, "root")
 	if err != nil {
-		return nil, fmt.Errorf("convert storage schema to descriptor: %w", err)
+		return nil, nil, fmt.Errorf("convert storage schema to descriptor: %w", err)
 	}
 
 	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
 	if !ok {
-		return nil, errors.New("adapted descriptor is not a message descriptor")
+		return nil, nil, errors.New("adapted descriptor is not a message descriptor")
 	}
 
 	normalized, err := adapt.NormalizeDescriptor(msgDesc)
 	if err != nil {
-		return nil, fmt.Errorf("normalize descriptor: %w", err)
+		return nil, nil, fmt.Errorf("normalize descriptor: %w", err)
 	}
+	return msgDesc, normalized, nil
+}
 
-	tableRef := fmt.Sprintf("projects/%s/datasets/%s/tables/%s", projectID, datasetID, tableID)
-	stream, err := client.NewManagedStream(
-		ctx,
-		managedwriter.WithDestinationTable(tableRef),
-		managedwriter.WithType(managedwriter.DefaultStream),
-		managedwriter.WithSchemaDescriptor(normalized),
-	)
+// openStream opens a stream for subsequent appends. A stream lives as long
+// as the context it is opened with, so it is opened without the
+// cancellation of ctx, which usually is that of a single push or commit.
+func (a *storageAppender) openStream(ctx context.Context) (*managedwriter.ManagedStream, error) {
+	streamType := managedwriter.DefaultStream
+	if a.mode == writeModePending {
+		streamType = managedwriter.PendingStream
+	}
+	opts := []managedwriter.WriterOption{
+		managedwriter.WithDestinationTable(a.tableRef),
+		managedwriter.WithType(streamType),
+		managedwriter.WithSchemaDescriptor(a.schema.Load().normalized),
+	}
+	if a.traceID != "" {
+		opts = append(opts, managedwriter.WithTraceID(a.traceID))
+	}
+	stream, err := a.client.NewManagedStream(context.WithoutCancel(ctx), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("create managed stream: %w", err)
 	}
-	return &storageAppender{stream: stream, desc: msgDesc}, nil
+	return stream, nil
 }
 
-func appendStorageRows(ctx context.Context, appender *storageAppender, rows []map[string]bigquery.Value) error {
+// maxAppendRequestBytes bounds the serialized rows sent in one AppendRows
+// request. The Storage Write API rejects requests over 10 MB, which leaves
+// room for the writer schema and request framing.
+const maxAppendRequestBytes = 9 << 20
+
+// appendStorageRows encodes rows and appends them to the appender's table.
+// The rows are kept until they are acknowledged, so they can be encoded
+// again when the table's schema changed in the meantime.
+func appendStorageRows(ctx context.Context, appender *storageAppender, rows []map[string]bigquery.Value) (err error) {
+	defer func() { appender.degradation.record(err) }()
+	if appender.degradation.active() {
+		if n := slimRows(rows); n > 0 {
+			appender.telemetry.ExporterBigquerySlimRows.Add(ctx, int64(n), metric.WithAttributes(attribute.String("table", appender.tableRef)))
+		}
+	}
+	renameColumns(rows, appender.columnNames)
+	ws := appender.schema.Load()
 	serialized := make([][]byte, 0, len(rows))
-	for _, row := range rows {
-		b, err := encodeRow(appender.desc, row)
+	kept := make([]row, 0, len(rows))
+	for i, row := range rows {
+		if appender.changeType != "" {
+			row[changeTypeColumn] = appender.changeType
+		}
+		b, err := encodeRow(ws.desc, row)
 		if err != nil {
-			return err
+			appender.statistics.record(statisticsCounts{failedAppends: 1, failedRows: int64(len(rows))})
+			return consumererror.NewPermanent(fmt.Errorf("encode row %d: %w", i, err))
+		}
+		if len(b) > maxAppendRequestBytes {
+			// A single row this large can never be written, so drop it
+			// instead of failing the rest of the batch with it.
+			appender.logger.Warn("Dropping row larger than the Storage Write request limit",
+				zap.String("table", appender.tableRef), zap.Int("bytes", len(b)))
+			appender.statistics.record(statisticsCounts{droppedRows: 1})
+			appender.telemetry.ExporterBigqueryOversizedRows.Add(ctx, 1, metric.WithAttributes(attribute.String("table", appender.tableRef)))
+			continue
 		}
 		serialized = append(serialized, b)
+		kept = append(kept, row)
+	}
+
+	if appender.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, appender.timeout)
+		defer cancel()
+	}
+	if err := appender.acquire(ctx); err != nil {
+		appender.statistics.record(statisticsCounts{failedAppends: 1, failedRows: int64(len(serialized))})
+		return err
+	}
+	defer appender.release()
+
+	// Without a commit interval, a push is committed as a whole, and is
+	// retried as a whole when it fails.
+	perPush := appender.mode == writeModePending && appender.interval == 0
+	if perPush && appender.pending > 0 {
+		// An earlier push failed and its stream could not be replaced then.
+		if err := appender.abandonLocked(ctx); err != nil {
+			appender.statistics.record(statisticsCounts{failedAppends: 1, failedRows: int64(len(serialized))})
+			return err
+		}
+	}
+	start := 0
+	for _, chunk := range chunkRows(serialized, maxAppendRequestBytes) {
+		if err := appendChunkLocked(ctx, appender, ws, kept[start:start+len(chunk)], chunk); err != nil {
+			if perPush && appender.pending > 0 {
+				if abandonErr := appender.abandonLocked(ctx); abandonErr != nil {
+					appender.logger.Warn("Failed to discard the rows of a failed push, discarding them with the next push",
+						zap.String("table", appender.tableRef), zap.Error(abandonErr))
+				}
+			}
+			return err
+		}
+		start += len(chunk)
+	}
+	if perPush {
+		if err := appender.commitLocked(ctx); err != nil {
+			return newAppendError(err, len(serialized), nil)
+		}
+	}
+	return nil
+}
+
+// appendChunkLocked appends serialized, the encoding of rows with ws. Rows
+// are encoded again when the writer schema changed since, and once more
+// with the table's current schema when BigQuery rejects them for having
+// fields the table does not.
+func appendChunkLocked(ctx context.Context, appender *storageAppender, ws *writerSchema, rows []row, serialized [][]byte) error {
+	failed := statisticsCounts{failedAppends: 1, failedRows: int64(len(rows))}
+
+	var opts []managedwriter.AppendOption
+	if current := appender.schema.Load(); current != ws {
+		var err error
+		if serialized, err = encodeRows(current.desc, rows); err != nil {
+			appender.statistics.record(failed)
+			return consumererror.NewPermanent(err)
+		}
+		opts = append(opts, managedwriter.UpdateSchemaDescriptor(current.normalized))
+		ws = current
+	}
+
+	stream := appender.currentStream()
+	resp, err := appender.appendRows(ctx, stream, serialized, opts...)
+	if err != nil && isTableNotFound(err) && appender.recreateTable != nil {
+		if recreateErr := appender.recreate(ctx, stream); recreateErr != nil {
+			err = errors.Join(err, recreateErr)
+		} else {
+			resp, err = appender.appendRows(ctx, appender.currentStream(), serialized, opts...)
+		}
+		if err != nil && isTableNotFound(err) {
+			// Appends to a recreated table may be rejected for a while, and
+			// recreating it may fail transiently, so the batch is retried
+			// rather than dropped.
+			appender.logAppendResult(ctx, len(rows), 0, resp, err)
+			appender.statistics.record(failed)
+			return &appendError{code: errorCode(err), rows: len(rows), retryable: true, err: err}
+		}
 	}
+	if err != nil && storageErrorCode(err) == storagepb.StorageError_SCHEMA_MISMATCH_EXTRA_FIELDS && appender.tableSchema != nil {
+		current, refreshErr := appender.refreshSchema(ctx)
+		if refreshErr != nil {
+			appender.statistics.record(failed)
+			return newAppendError(errors.Join(err, refreshErr), len(rows), resp)
+		}
+		if serialized, err = encodeRows(current.desc, rows); err != nil {
+			appender.statistics.record(failed)
+			return consumererror.NewPermanent(err)
+		}
+		resp, err = appender.appendRows(ctx, appender.currentStream(), serialized, managedwriter.UpdateSchemaDescriptor(current.normalized))
+	}
+	written := statisticsCounts{rowsWritten: int64(len(rows))}
+	for _, r := range serialized {
+		written.bytesWritten += int64(len(r))
+	}
+	appender.logAppendResult(ctx, len(rows), written.bytesWritten, resp, err)
+	if err != nil {
+		appender.statistics.record(failed)
+		return newAppendError(err, len(rows), resp)
+	}
+	appender.statistics.record(written)
+	if appender.mode == writeModePending {
+		appender.checkOffset(ctx, resp, len(rows))
+		appender.pending += int64(len(rows))
+	}
+	return nil
+}
+
+func (a *storageAppender) appendRows(ctx context.Context, stream *managedwriter.ManagedStream, serialized [][]byte, opts ...managedwriter.AppendOption) (*storagepb.AppendRowsResponse, error) {
+	result, err := stream.AppendRows(ctx, serialized, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return result.FullResponse(ctx)
+}
 
-	result, err := appender.stream.AppendRows(ctx, serialized)
+// currentStream returns the stream appends are sent to.
+func (a *storageAppender) currentStream() *managedwriter.ManagedStream {
+	a.streamMu.RLock()
+	defer a.streamMu.RUnlock()
+	return a.stream
+}
+
+// recreate creates the table again after an append to failed reported it
+// as not found, and replaces the stream with one on the new table. Rows
+// appended to a pending stream of the deleted table are lost with it.
+// Nothing is done when another worker already replaced failed.
+func (a *storageAppender) recreate(ctx context.Context, failed *managedwriter.ManagedStream) error {
+	a.recreateMu.Lock()
+	defer a.recreateMu.Unlock()
+	if a.currentStream() != failed {
+		return nil
+	}
+	if err := a.recreateTable(ctx); err != nil {
+		return fmt.Errorf("recreate deleted table: %w", err)
+	}
+	next, err := a.openStream(ctx)
 	if err != nil {
 		return err
 	}
-	_, err = result.GetResult(ctx)
+	a.streamMu.Lock()
+	a.stream = next
+	a.streamMu.Unlock()
+	if err := failed.Close(); err != nil && !errors.Is(err, io.EOF) {
+		a.logger.Debug("Failed to close stream of deleted table", zap.String("stream", failed.StreamName()), zap.Error(err))
+	}
+	fields := []zap.Field{zap.String("table", a.tableRef)}
+	if a.mode == writeModePending {
+		fields = append(fields, zap.Int64("lost_pending_rows", a.pending))
+		a.statistics.record(statisticsCounts{failedAppends: 1, failedRows: a.pending})
+		a.pending = 0
+		a.nextOffset = 0
+	}
+	a.logger.Warn("Table was deleted while the exporter is running; recreated it and resumed appends", fields...)
+	return nil
+}
+
+// logAppendResult logs a sample of append results at debug level together
+// with the trace context of the export, so collector traces can be matched
+// with issues seen on the BigQuery side.
+func (a *storageAppender) logAppendResult(ctx context.Context, rows int, size int64, resp *storagepb.AppendRowsResponse, err error) {
+	if a.debugSampleRate <= 0 || !a.logger.Core().Enabled(zapcore.DebugLevel) || rand.Float64() >= a.debugSampleRate {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("table", a.tableRef),
+		zap.Int("rows", rows),
+		zap.Int64("bytes", size),
+		zap.Int("row_errors", len(resp.GetRowErrors())),
+		zap.Bool("updated_schema", resp.GetUpdatedSchema() != nil),
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, zap.String("trace_id", sc.TraceID().String()), zap.String("span_id", sc.SpanID().String()))
+	}
+	if stream := resp.GetWriteStream(); stream != "" {
+		fields = append(fields, zap.String("write_stream", stream))
+	}
+	if offset := resp.GetAppendResult().GetOffset(); offset != nil {
+		fields = append(fields, zap.Int64("offset", offset.GetValue()))
+	}
+	if respErr := resp.GetError(); respErr != nil {
+		fields = append(fields, zap.String("response_error", respErr.GetMessage()))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	a.logger.Debug("Storage Write append result", fields...)
+}
+
+// refreshSchema replaces the writer schema with the table's current schema.
+func (a *storageAppender) refreshSchema(ctx context.Context) (*writerSchema, error) {
+	schema, err := a.tableSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read current table schema: %w", err)
+	}
+	if a.changeType != "" {
+		schema = withChangeType(schema)
+	}
+	desc, normalized, err := schemaDescriptor(schema)
+	if err != nil {
+		return nil, err
+	}
+	ws := &writerSchema{desc: desc, normalized: normalized}
+	a.schema.Store(ws)
+	a.logger.Info("Table schema no longer matches the rows, encoding rows with the current table schema",
+		zap.String("table", a.tableRef), zap.Int("columns", len(schema)))
+	return ws, nil
+}
+
+func encodeRows(desc protoreflect.MessageDescriptor, rows []row) ([][]byte, error) {
+	serialized := make([][]byte, len(rows))
+	for i, r := range rows {
+		b, err := encodeRow(desc, r)
+		if err != nil {
+			return nil, fmt.Errorf("encode row %d: %w", i, err)
+		}
+		serialized[i] = b
+	}
+	return serialized, nil
+}
+
+// acquire waits for a free worker, or until ctx is done.
+func (a *storageAppender) acquire(ctx context.Context) error {
+	select {
+	case a.workers <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for a free worker on %s: %w", a.tableRef, ctx.Err())
+	}
+}
+
+func (a *storageAppender) release() {
+	<-a.workers
+}
+
+// chunkRows splits serialized rows into consecutive chunks of at most
+// maxBytes, each holding at least one row.
+func chunkRows(rows [][]byte, maxBytes int) [][][]byte {
+	var (
+		chunks [][][]byte
+		start  int
+		size   int
+	)
+	for i, r := range rows {
+		if i > start && size+len(r) > maxBytes {
+			chunks = append(chunks, rows[start:i])
+			start, size = i, 0
+		}
+		size += len(r)
+	}
+	if start < len(rows) {
+		chunks = append(chunks, rows[start:])
+	}
+	return chunks
+}
+
+// checkOffset compares the offset at which BigQuery acknowledged an append
+// with the number of rows previously acknowledged on the stream. A higher
+// offset means rows were written without the exporter seeing their
+// acknowledgement, e.g. an append that timed out client side and will be
+// retried; a lower one means acknowledged appends overlap. Both are reported
+// as an early warning of duplicated or missing rows. Only pending streams
+// acknowledge appends with an offset.
+func (a *storageAppender) checkOffset(ctx context.Context, resp *storagepb.AppendRowsResponse, rows int) {
+	offset := resp.GetAppendResult().GetOffset()
+	if offset == nil {
+		return
+	}
+	if got := offset.GetValue(); got != a.nextOffset {
+		anomaly := "gap"
+		if got < a.nextOffset {
+			anomaly = "duplicate"
+		}
+		a.logger.Warn("Storage Write append acknowledged at an unexpected offset",
+			zap.String("table", a.tableRef),
+			zap.String("anomaly", anomaly),
+			zap.Int64("expected_offset", a.nextOffset),
+			zap.Int64("acknowledged_offset", got))
+		a.telemetry.ExporterBigqueryStorageWriteOffsetAnomalies.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("table", a.tableRef),
+			attribute.String("anomaly", anomaly),
+		))
+	}
+	a.nextOffset = offset.GetValue() + int64(rows)
+}
+
+// warmUp sends an append without rows, which establishes the gRPC connection
+// and the append stream and sends the writer schema ahead of the first batch.
+// BigQuery rejects the empty request with InvalidArgument once the stream is
+// open, so that response is expected.
+func (a *storageAppender) warmUp(ctx context.Context) error {
+	if err := a.acquire(ctx); err != nil {
+		return err
+	}
+	defer a.release()
+
+	result, err := a.stream.AppendRows(ctx, nil)
+	if err == nil {
+		_, err = result.GetResult(ctx)
+	}
+	if err != nil && errorCode(err) != codes.InvalidArgument {
+		return err
+	}
+	return nil
+}
+
+// commitLoop commits the pending stream at every multiple of the commit
+// interval, so that all rows acknowledged within a window become visible
+// together when the window closes.
+func (a *storageAppender) commitLoop() {
+	defer a.wg.Done()
+	for {
+		timer := time.NewTimer(time.Until(nextCommitWindow(time.Now(), a.interval)))
+		select {
+		case <-a.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), a.interval)
+		if err := a.commit(ctx); err != nil {
+			a.logger.Error("Failed to commit pending streams", zap.String("table", a.tableRef), zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// nextCommitWindow returns the end of the wall-clock window containing now.
+func nextCommitWindow(now time.Time, interval time.Duration) time.Time {
+	return now.Truncate(interval).Add(interval)
+}
+
+func (a *storageAppender) commit(ctx context.Context) error {
+	if err := a.acquire(ctx); err != nil {
+		return err
+	}
+	defer a.release()
+	return a.commitLocked(ctx)
+}
+
+// commitLocked replaces the current pending stream with a new one for
+// subsequent appends and commits every uncommitted stream. Without a
+// commit interval the export is retried when the commit fails, so the
+// streams are discarded. With one, the rows were acknowledged when they were
+// appended, so the streams are kept and committed together with the next
+// window, until BigQuery reports they can never be committed.
+func (a *storageAppender) commitLocked(ctx context.Context) error {
+	if a.pending > 0 {
+		next, err := a.openStream(ctx)
+		if err != nil {
+			return err
+		}
+		a.uncommitted = append(a.uncommitted, uncommittedStream{stream: a.stream, rows: a.pending})
+		a.stream = next
+		a.pending = 0
+		a.nextOffset = 0
+	}
+	if len(a.uncommitted) == 0 {
+		return nil
+	}
+	err := a.commitStreamsLocked(ctx)
+	if err != nil && a.interval == 0 {
+		a.statistics.record(statisticsCounts{failedAppends: 1, failedRows: uncommittedRows(a.uncommitted)})
+		a.uncommitted = nil
+	}
 	return err
 }
 
+// abandonLocked replaces the pending stream with a new one without
+// committing it, so that the rows a failed push appended before failing
+// never become visible, as the push is retried with all of its rows.
+func (a *storageAppender) abandonLocked(ctx context.Context) error {
+	next, err := a.openStream(ctx)
+	if err != nil {
+		return fmt.Errorf("discard %d rows of a failed push: %w", a.pending, err)
+	}
+	a.streamMu.Lock()
+	previous := a.stream
+	a.stream = next
+	a.streamMu.Unlock()
+	a.statistics.record(statisticsCounts{failedRows: a.pending})
+	a.pending = 0
+	a.nextOffset = 0
+	if err := previous.Close(); err != nil && !errors.Is(err, io.EOF) {
+		a.logger.Debug("Failed to close abandoned stream", zap.String("stream", previous.StreamName()), zap.Error(err))
+	}
+	return nil
+}
+
+// uncommittedStream is a pending stream replaced by a new one, whose rows
+// are not committed yet.
+type uncommittedStream struct {
+	stream    *managedwriter.ManagedStream
+	rows      int64
+	finalized bool
+}
+
+func uncommittedRows(streams []uncommittedStream) int64 {
+	var rows int64
+	for _, s := range streams {
+		rows += s.rows
+	}
+	return rows
+}
+
+// commitStreamsLocked finalizes the uncommitted streams and commits them in
+// one batch, which BigQuery commits atomically. Streams BigQuery reports as
+// unable to ever commit are dropped with lostLocked, the others are kept
+// for the next attempt.
+func (a *storageAppender) commitStreamsLocked(ctx context.Context) error {
+	names := make([]string, len(a.uncommitted))
+	for i := range a.uncommitted {
+		s := &a.uncommitted[i]
+		names[i] = s.stream.StreamName()
+		if s.finalized {
+			continue
+		}
+		// A stream whose finalization was acknowledged only by BigQuery
+		// reports it as finalized on the next attempt.
+		if _, err := s.stream.Finalize(ctx); err != nil && storageErrorCode(err) != storagepb.StorageError_STREAM_FINALIZED {
+			return fmt.Errorf("commit %d rows: finalize stream: %w", uncommittedRows(a.uncommitted), err)
+		}
+		s.finalized = true
+		if err := s.stream.Close(); err != nil && !errors.Is(err, io.EOF) {
+			a.logger.Debug("Failed to close finalized stream", zap.String("stream", s.stream.StreamName()), zap.Error(err))
+		}
+	}
+
+	rows := uncommittedRows(a.uncommitted)
+	resp, err := a.client.BatchCommitWriteStreams(ctx, &storagepb.BatchCommitWriteStreamsRequest{
+		Parent:       a.tableRef,
+		WriteStreams: names,
+	})
+	if err != nil {
+		if !isRetryableCode(errorCode(err)) {
+			a.lostLocked(ctx, a.uncommitted)
+			a.uncommitted = nil
+		}
+		return fmt.Errorf("commit %d rows: batch commit: %w", rows, err)
+	}
+	streamErrs := resp.GetStreamErrors()
+	if len(streamErrs) == 0 {
+		a.uncommitted = nil
+		return nil
+	}
+
+	// A failed batch commits none of its streams.
+	failed := make(map[string]storagepb.StorageError_StorageErrorCode, len(streamErrs))
+	for _, streamErr := range streamErrs {
+		failed[streamErr.GetEntity()] = streamErr.GetCode()
+	}
+	var kept, lost []uncommittedStream
+	for _, s := range a.uncommitted {
+		switch failed[s.stream.StreamName()] {
+		case storagepb.StorageError_STREAM_ALREADY_COMMITTED:
+			// An earlier attempt committed the stream without the exporter
+			// seeing the response.
+		case storagepb.StorageError_STREAM_NOT_FOUND, storagepb.StorageError_INVALID_STREAM_STATE:
+			lost = append(lost, s)
+		default:
+			kept = append(kept, s)
+		}
+	}
+	a.uncommitted = kept
+	if len(lost) > 0 {
+		a.lostLocked(ctx, lost)
+	}
+	return fmt.Errorf("commit %d rows: batch commit: %s", rows, streamErrs[0].GetErrorMessage())
+}
+
+// lostLocked reports the rows of streams that will never be committed. With
+// a commit interval their exports were already acknowledged, so the rows are
+// lost; without one the exports are retried.
+func (a *storageAppender) lostLocked(ctx context.Context, streams []uncommittedStream) {
+	rows := uncommittedRows(streams)
+	a.statistics.record(statisticsCounts{failedAppends: 1, failedRows: rows})
+	if a.interval == 0 {
+		return
+	}
+	a.telemetry.ExporterBigqueryStorageWriteLostRows.Add(ctx, rows, metric.WithAttributes(attribute.String("table", a.tableRef)))
+	a.logger.Error("Lost acknowledged rows of pending streams that cannot be committed",
+		zap.String("table", a.tableRef), zap.Int("streams", len(streams)), zap.Int64("rows", rows))
+}
+
+// reconnect moves the appender to client, e.g. one authenticating with
+// rotated credentials. It waits for appends in progress and, in the pending
+// mode, commits the rows appended so far before replacing the stream. A
+// closed appender, e.g. of an idle shard, has nothing to move.
+func (a *storageAppender) reconnect(ctx context.Context, client *managedwriter.Client) error {
+	for range cap(a.workers) {
+		if err := a.acquire(ctx); err != nil {
+			return err
+		}
+		defer a.release()
+	}
+	select {
+	case <-a.done:
+		return nil
+	default:
+	}
+	if a.mode == writeModePending {
+		// The streams are committed through the client they were opened
+		// with. Rows the commit fails for are committed with the next
+		// window.
+		if err := a.commitLocked(ctx); err != nil {
+			a.logger.Warn("Failed to commit pending streams before reconnecting", zap.String("table", a.tableRef), zap.Error(err))
+		}
+		if a.pending > 0 {
+			return fmt.Errorf("move %d pending rows off the current stream", a.pending)
+		}
+		// Streams kept for the next window are finalized through the
+		// client they were opened with.
+		if len(a.uncommitted) > 0 {
+			return fmt.Errorf("commit %d rows of streams opened with the previous client", uncommittedRows(a.uncommitted))
+		}
+	}
+	a.client = client
+	next, err := a.openStream(ctx)
+	if err != nil {
+		return err
+	}
+	a.streamMu.Lock()
+	previous := a.stream
+	a.stream = next
+	a.streamMu.Unlock()
+	a.nextOffset = 0
+	if err := previous.Close(); err != nil && !errors.Is(err, io.EOF) {
+		a.logger.Debug("Failed to close previous stream", zap.String("stream", previous.StreamName()), zap.Error(err))
+	}
+	return nil
+}
+
+// close stops the commit loop, waits for appends in progress, commits any
+// rows still pending and closes the underlying stream.
+func (a *storageAppender) close(ctx context.Context) error {
+	close(a.done)
+	a.wg.Wait()
+
+	for range cap(a.workers) {
+		if err := a.acquire(ctx); err != nil {
+			return err
+		}
+		defer a.release()
+	}
+	var commitErr error
+	if a.mode == writeModePending {
+		commitErr = a.commitLocked(ctx)
+		// Streams still uncommitted have no later window to be committed
+		// with.
+		if len(a.uncommitted) > 0 {
+			a.lostLocked(ctx, a.uncommitted)
+			a.uncommitted = nil
+		}
+	}
+	if err := a.stream.Close(); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Join(commitErr, err)
+	}
+	return commitErr
+}
+
 func encodeRow(desc protoreflect.MessageDescriptor, row map[string]bigquery.Value) ([]byte, error) {
 	msg := dynamicpb.NewMessage(desc)
 	fields := desc.Fields()
@@ -105,6 +838,12 @@ func encodeRow(desc protoreflect.MessageDescriptor, row map[string]bigquery.Valu
 }
 
 func setFieldValue(msg *dynamicpb.Message, fd protoreflect.FieldDescriptor, value bigquery.Value) error {
+	if isNestedField(fd) {
+		if _, ok := value.(string); !ok && fd.IsList() && fd.Kind() != protoreflect.MessageKind {
+			return setScalarList(msg, fd, value)
+		}
+		return setNestedField(msg, fd, value)
+	}
 	switch fd.Kind() {
 	case protoreflect.MessageKind:
 		wrapped, err := dynamicWrapperValue(fd.Message(), value)
@@ -146,12 +885,24 @@ func toProtoreflectValue(kind protoreflect.Kind, value any) (protoreflect.Value,
 			return protoreflect.Value{}, err
 		}
 		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, ok := value.([]byte)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected []byte, got %T", value)
+		}
+		return protoreflect.ValueOfBytes(b), nil
 	case protoreflect.BoolKind:
 		b, err := asBool(value)
 		if err != nil {
 			return protoreflect.Value{}, err
 		}
 		return protoreflect.ValueOfBool(b), nil
+	case protoreflect.Int32Kind:
+		d, err := asDate(value)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt32(d), nil
 	case protoreflect.Int64Kind:
 		i, err := asInt64(value)
 		if err != nil {
@@ -169,12 +920,14 @@ func toProtoreflectValue(kind protoreflect.Kind, value any) (protoreflect.Value,
 	}
 }
 
+// asString returns value with invalid UTF-8 sequences replaced, since
+// BigQuery rejects rows with invalid STRING and JSON values.
 func asString(value any) (string, error) {
 	s, ok := value.(string)
 	if !ok {
 		return "", fmt.Errorf("expected string, got %T", value)
 	}
-	return s, nil
+	return strings.ToValidUTF8(s, "\uFFFD"), nil
 }
 
 func asBool(value any) (bool, error) {
@@ -204,6 +957,16 @@ func asInt64(value any) (int64, error) {
 	}
 }
 
+// asDate returns a DATE value as the number of days since the Unix epoch,
+// its encoding in the Storage Write API.
+func asDate(value any) (int32, error) {
+	d, ok := value.(civil.Date)
+	if !ok {
+		return 0, fmt.Errorf("expected civil.Date, got %T", value)
+	}
+	return int32(d.DaysSince(civil.Date{Year: 1970, Month: time.January, Day: 1})), nil
+}
+
 func asFloat64(value any) (float64, error) {
 	switch n := value.(type) {
 	case float64:
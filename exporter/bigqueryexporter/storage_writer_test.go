@@ -0,0 +1,391 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadatatest"
+)
+
+func TestNextCommitWindow(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{
+			name:     "mid minute",
+			now:      base.Add(42 * time.Second),
+			interval: time.Minute,
+			want:     base.Add(time.Minute),
+		},
+		{
+			name:     "on boundary",
+			now:      base,
+			interval: time.Minute,
+			want:     base.Add(time.Minute),
+		},
+		{
+			name:     "five minute windows",
+			now:      base.Add(3 * time.Minute),
+			interval: 5 * time.Minute,
+			want:     base.Add(5 * time.Minute),
+		},
+		{
+			name:     "hourly windows",
+			now:      base,
+			interval: time.Hour,
+			want:     time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nextCommitWindow(tt.now, tt.interval))
+		})
+	}
+}
+
+func TestCheckOffset(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+	tb, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	t.Cleanup(tb.Shutdown)
+
+	a := &storageAppender{logger: zap.NewNop(), telemetry: tb, tableRef: "projects/p/datasets/d/tables/t"}
+	ack := func(offset int64) *storagepb.AppendRowsResponse {
+		return &storagepb.AppendRowsResponse{Response: &storagepb.AppendRowsResponse_AppendResult_{
+			AppendResult: &storagepb.AppendRowsResponse_AppendResult{Offset: wrapperspb.Int64(offset)},
+		}}
+	}
+
+	a.checkOffset(t.Context(), ack(0), 10)
+	a.checkOffset(t.Context(), ack(10), 5)
+	// An append of 3 rows was written but its acknowledgement was lost.
+	a.checkOffset(t.Context(), ack(18), 2)
+	a.checkOffset(t.Context(), ack(15), 5)
+	// Default stream responses carry no offset.
+	a.checkOffset(t.Context(), &storagepb.AppendRowsResponse{}, 5)
+	assert.Equal(t, int64(20), a.nextOffset)
+
+	metadatatest.AssertEqualExporterBigqueryStorageWriteOffsetAnomalies(t, tel, []metricdata.DataPoint[int64]{
+		{Value: 1, Attributes: attribute.NewSet(attribute.String("table", a.tableRef), attribute.String("anomaly", "gap"))},
+		{Value: 1, Attributes: attribute.NewSet(attribute.String("table", a.tableRef), attribute.String("anomaly", "duplicate"))},
+	}, metricdatatest.IgnoreTimestamp())
+}
+
+func TestCommitIntervalFailedCommit(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+	tb, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	t.Cleanup(tb.Shutdown)
+
+	var commitErr error
+	var commitResp *storagepb.BatchCommitWriteStreamsResponse
+	srv := &fakeWriteServer{commitResponse: func([]string) (*storagepb.BatchCommitWriteStreamsResponse, error) {
+		return commitResp, commitErr
+	}}
+	client := newFakeWriteClient(t, srv)
+	a, err := newStorageAppender(t.Context(), client, zap.NewNop(), tb, "p", "d", "t",
+		bigquery.Schema{{Name: "name", Type: bigquery.StringFieldType}},
+		WriteConfig{Mode: writeModePending, CommitInterval: 24 * time.Hour, Workers: 1})
+	require.NoError(t, err)
+	appendRows := func(names ...string) {
+		rows := make([]map[string]bigquery.Value, len(names))
+		for i, name := range names {
+			rows[i] = map[string]bigquery.Value{"name": name}
+		}
+		require.NoError(t, appendStorageRows(t.Context(), a, rows))
+	}
+
+	// The rows of a window whose commit fails are committed with the next
+	// window.
+	appendRows("a", "b")
+	commitErr = status.Error(codes.Internal, "internal")
+	require.ErrorContains(t, a.commit(t.Context()), "commit 2 rows")
+	assert.Empty(t, srv.committedRows())
+	appendRows("c")
+	commitErr = nil
+	require.NoError(t, a.commit(t.Context()))
+	assert.Len(t, srv.committedRows(), 3)
+	assert.Empty(t, a.uncommitted)
+
+	// Streams BigQuery can no longer commit are reported as lost.
+	appendRows("d")
+	commitResp = &storagepb.BatchCommitWriteStreamsResponse{StreamErrors: []*storagepb.StorageError{{
+		Code:         storagepb.StorageError_STREAM_NOT_FOUND,
+		Entity:       a.currentStream().StreamName(),
+		ErrorMessage: "stream not found",
+	}}}
+	require.ErrorContains(t, a.commit(t.Context()), "stream not found")
+	assert.Empty(t, a.uncommitted)
+
+	// Streams still uncommitted on shutdown are lost as well.
+	appendRows("e", "f")
+	commitResp = nil
+	commitErr = status.Error(codes.Internal, "internal")
+	require.Error(t, a.close(t.Context()))
+	assert.Len(t, srv.committedRows(), 3)
+
+	metadatatest.AssertEqualExporterBigqueryStorageWriteLostRows(t, tel, []metricdata.DataPoint[int64]{
+		{Value: 3, Attributes: attribute.NewSet(attribute.String("table", a.tableRef))},
+	}, metricdatatest.IgnoreTimestamp())
+}
+
+func TestPendingStreamOutlivesPush(t *testing.T) {
+	srv := &fakeWriteServer{}
+	client := newFakeWriteClient(t, srv)
+	a, err := newStorageAppender(t.Context(), client, zap.NewNop(), nil, "p", "d", "t",
+		bigquery.Schema{{Name: "name", Type: bigquery.StringFieldType}},
+		WriteConfig{Mode: writeModePending, Workers: 1})
+	require.NoError(t, err)
+
+	// The stream replacing the committed one is opened during the first
+	// push, and must keep working once that push is done.
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, appendStorageRows(ctx, a, []row{{"name": "a"}}))
+	cancel()
+	require.NoError(t, appendStorageRows(t.Context(), a, []row{{"name": "b"}}))
+	assert.Len(t, srv.committedRows(), 2)
+	require.NoError(t, a.close(t.Context()))
+}
+
+func TestPendingPushFailsAsAWhole(t *testing.T) {
+	var appends atomic.Int32
+	srv := &fakeWriteServer{appendResponse: func(string, [][]byte) *storagepb.AppendRowsResponse {
+		if appends.Add(1) == 2 {
+			return &storagepb.AppendRowsResponse{Response: &storagepb.AppendRowsResponse_Error{
+				Error: status.New(codes.Internal, "internal").Proto(),
+			}}
+		}
+		return nil
+	}}
+	client := newFakeWriteClient(t, srv)
+	a, err := newStorageAppender(t.Context(), client, zap.NewNop(), nil, "p", "d", "t",
+		bigquery.Schema{{Name: "name", Type: bigquery.StringFieldType}},
+		WriteConfig{Mode: writeModePending, Workers: 1})
+	require.NoError(t, err)
+
+	// The rows are appended in two requests, and the second one fails.
+	large := strings.Repeat("x", maxAppendRequestBytes/2+1)
+	rows := func() []row { return []row{{"name": large}, {"name": large}} }
+	require.Error(t, appendStorageRows(t.Context(), a, rows()))
+	assert.Zero(t, a.pending)
+
+	// The retried push does not commit the first request again.
+	require.NoError(t, appendStorageRows(t.Context(), a, rows()))
+	assert.Len(t, srv.committedRows(), 2)
+	require.NoError(t, a.close(t.Context()))
+}
+
+func TestOversizedRows(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+	tb, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	t.Cleanup(tb.Shutdown)
+
+	srv := &fakeWriteServer{}
+	client := newFakeWriteClient(t, srv)
+	a, err := newStorageAppender(t.Context(), client, zap.NewNop(), tb, "p", "d", "t",
+		bigquery.Schema{{Name: "name", Type: bigquery.StringFieldType}},
+		WriteConfig{Mode: writeModeDefault, Workers: 1})
+	require.NoError(t, err)
+
+	// A row that cannot fit a request is dropped, the others are written.
+	require.NoError(t, appendStorageRows(t.Context(), a, []row{{"name": strings.Repeat("x", maxAppendRequestBytes)}, {"name": "a"}}))
+	assert.Len(t, srv.committedRows(), 1)
+	metadatatest.AssertEqualExporterBigqueryOversizedRows(t, tel, []metricdata.DataPoint[int64]{
+		{Value: 1, Attributes: attribute.NewSet(attribute.String("table", a.tableRef))},
+	}, metricdatatest.IgnoreTimestamp())
+	require.NoError(t, a.close(t.Context()))
+}
+
+func TestAppendWorkersPerTable(t *testing.T) {
+	newAppender := func(table string) *storageAppender {
+		return &storageAppender{
+			logger:   zap.NewNop(),
+			tableRef: table,
+			mode:     writeModeDefault,
+			timeout:  50 * time.Millisecond,
+			workers:  make(chan struct{}, 2),
+		}
+	}
+	stalled := newAppender("projects/p/datasets/d/tables/metric")
+	healthy := newAppender("projects/p/datasets/d/tables/trace")
+
+	// Every worker of the metrics table is stuck in an append.
+	require.NoError(t, stalled.acquire(t.Context()))
+	require.NoError(t, stalled.acquire(t.Context()))
+
+	err := appendStorageRows(t.Context(), stalled, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, consumererror.IsPermanent(err))
+
+	require.NoError(t, appendStorageRows(t.Context(), healthy, nil))
+	assert.Empty(t, healthy.workers)
+
+	stalled.release()
+	require.NoError(t, appendStorageRows(t.Context(), stalled, nil))
+}
+
+func TestRefreshSchema(t *testing.T) {
+	full := bigquery.Schema{
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "dropped", Type: bigquery.StringFieldType},
+	}
+	desc, normalized, err := schemaDescriptor(full)
+	require.NoError(t, err)
+	a := &storageAppender{logger: zap.NewNop(), tableRef: "projects/p/datasets/d/tables/t"}
+	stale := &writerSchema{desc: desc, normalized: normalized}
+	a.schema.Store(stale)
+
+	// The dropped column was removed from the table.
+	a.tableSchema = func(context.Context) (bigquery.Schema, error) { return full[:1], nil }
+	current, err := a.refreshSchema(t.Context())
+	require.NoError(t, err)
+	assert.Same(t, current, a.schema.Load())
+	assert.Nil(t, current.desc.Fields().ByName("dropped"))
+
+	// Rows keep the dropped value until they are encoded again.
+	rows := []row{{"name": "a", "dropped": "x"}}
+	serialized, err := encodeRows(current.desc, rows)
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(current.desc)
+	require.NoError(t, proto.Unmarshal(serialized[0], msg))
+	assert.Equal(t, "a", msg.Get(current.desc.Fields().ByName("name")).String())
+
+	a.tableSchema = func(context.Context) (bigquery.Schema, error) { return nil, errors.New("permission denied") }
+	_, err = a.refreshSchema(t.Context())
+	require.ErrorContains(t, err, "read current table schema")
+	assert.Same(t, current, a.schema.Load())
+}
+
+func TestRefreshSchemaChangeType(t *testing.T) {
+	table := bigquery.Schema{{Name: "series_id", Type: bigquery.StringFieldType}}
+	a := &storageAppender{logger: zap.NewNop(), tableRef: "projects/p/datasets/d/tables/t", changeType: changeTypeUpsert}
+	a.tableSchema = func(context.Context) (bigquery.Schema, error) { return table, nil }
+
+	// The pseudo-column is part of the writer schema, not of the table.
+	current, err := a.refreshSchema(t.Context())
+	require.NoError(t, err)
+	assert.NotNil(t, current.desc.Fields().ByName(changeTypeColumn))
+}
+
+func TestRecreate(t *testing.T) {
+	var calls int
+	a := &storageAppender{logger: zap.NewNop(), tableRef: "projects/p/datasets/d/tables/t"}
+	a.recreateTable = func(context.Context) error {
+		calls++
+		return errors.New("permission denied")
+	}
+
+	// Another worker already replaced the stream the append failed on.
+	require.NoError(t, a.recreate(t.Context(), &managedwriter.ManagedStream{}))
+	assert.Zero(t, calls)
+
+	require.ErrorContains(t, a.recreate(t.Context(), nil), "recreate deleted table: permission denied")
+	assert.Equal(t, 1, calls)
+}
+
+func TestRecreatedStreamOutlivesPush(t *testing.T) {
+	var deleted atomic.Bool
+	deleted.Store(true)
+	srv := &fakeWriteServer{appendResponse: func(string, [][]byte) *storagepb.AppendRowsResponse {
+		if deleted.Swap(false) {
+			return &storagepb.AppendRowsResponse{Response: &storagepb.AppendRowsResponse_Error{
+				Error: status.New(codes.NotFound, "Requested entity was not found").Proto(),
+			}}
+		}
+		return nil
+	}}
+	client := newFakeWriteClient(t, srv)
+	a, err := newStorageAppender(t.Context(), client, zap.NewNop(), nil, "p", "d", "t",
+		bigquery.Schema{{Name: "name", Type: bigquery.StringFieldType}},
+		WriteConfig{Mode: writeModeDefault, Workers: 1})
+	require.NoError(t, err)
+	a.recreateTable = func(context.Context) error { return nil }
+
+	// The stream of the recreated table is opened during the first push,
+	// and must keep working once that push is done.
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, appendStorageRows(ctx, a, []row{{"name": "a"}}))
+	cancel()
+	require.NoError(t, appendStorageRows(t.Context(), a, []row{{"name": "b"}}))
+	assert.Len(t, srv.committedRows(), 2)
+	require.NoError(t, a.close(t.Context()))
+}
+
+func TestLogAppendResult(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	a := &storageAppender{logger: zap.New(core), tableRef: "projects/p/datasets/d/tables/t"}
+	resp := &storagepb.AppendRowsResponse{
+		WriteStream: "projects/p/datasets/d/tables/t/streams/_default",
+		Response: &storagepb.AppendRowsResponse_AppendResult_{
+			AppendResult: &storagepb.AppendRowsResponse_AppendResult{Offset: wrapperspb.Int64(42)},
+		},
+	}
+	ctx := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	}))
+
+	a.logAppendResult(ctx, 10, 1024, resp, nil)
+	assert.Zero(t, logs.Len(), "sampling is disabled by default")
+
+	a.debugSampleRate = 1
+	a.logAppendResult(ctx, 10, 1024, resp, nil)
+	a.logAppendResult(t.Context(), 5, 512, nil, errors.New("unavailable"))
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "01020300000000000000000000000000", fields["trace_id"])
+	assert.Equal(t, "0405060000000000", fields["span_id"])
+	assert.Equal(t, int64(42), fields["offset"])
+	assert.Equal(t, int64(10), fields["rows"])
+	assert.Equal(t, "projects/p/datasets/d/tables/t/streams/_default", fields["write_stream"])
+
+	fields = entries[1].ContextMap()
+	assert.NotContains(t, fields, "trace_id")
+	assert.NotContains(t, fields, "offset")
+	assert.Equal(t, "unavailable", fields["error"])
+
+	// Nothing is logged unless the logger is at debug level.
+	infoCore, infoLogs := observer.New(zapcore.InfoLevel)
+	a.logger = zap.New(infoCore)
+	a.logAppendResult(ctx, 10, 1024, resp, nil)
+	assert.Zero(t, infoLogs.Len())
+}
@@ -4,130 +4,70 @@
 package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
 
 import (
-	"encoding/json"
-	"time"
+	"slices"
 
 	"cloud.google.com/go/bigquery"
-	"go.opentelemetry.io/collector/pdata/ptrace"
-)
 
-var tracesSchema = bigquery.Schema{
-	{Name: "trace_id", Type: bigquery.StringFieldType, Required: true},
-	{Name: "span_id", Type: bigquery.StringFieldType, Required: true},
-	{Name: "parent_span_id", Type: bigquery.StringFieldType, Required: false},
-	{Name: "trace_state", Type: bigquery.StringFieldType, Required: false},
-	{Name: "name", Type: bigquery.StringFieldType, Required: true},
-	{Name: "kind", Type: bigquery.StringFieldType, Required: false},
-	{Name: "start_time", Type: bigquery.TimestampFieldType, Required: true},
-	{Name: "end_time", Type: bigquery.TimestampFieldType, Required: true},
-	{Name: "status_code", Type: bigquery.StringFieldType, Required: false},
-	{Name: "status_message", Type: bigquery.StringFieldType, Required: false},
-	{Name: "flags", Type: bigquery.IntegerFieldType, Required: false},
-	{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType, Required: false},
-	{Name: "dropped_events_count", Type: bigquery.IntegerFieldType, Required: false},
-	{Name: "dropped_links_count", Type: bigquery.IntegerFieldType, Required: false},
-	{Name: "resource_attributes", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "resource_schema_url", Type: bigquery.StringFieldType, Required: false},
-	{Name: "span_attributes", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "events", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "links", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "instrumentation_scope", Type: bigquery.JSONFieldType, Required: false},
-	{Name: "scope_schema_url", Type: bigquery.StringFieldType, Required: false},
-}
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
 
-func tracesToRows(td ptrace.Traces) []row {
-	var rows []row
-	for _, rs := range td.ResourceSpans().All() {
-		for _, ss := range rs.ScopeSpans().All() {
-			for _, span := range ss.Spans().All() {
-				rows = append(rows, row{
-					"trace_id":                 traceIDToHex(span.TraceID()),
-					"span_id":                  spanIDToHex(span.SpanID()),
-					"parent_span_id":           spanIDToHex(span.ParentSpanID()),
-					"trace_state":              span.TraceState().AsRaw(),
-					"name":                     span.Name(),
-					"kind":                     spanKindToString(span.Kind()),
-					"start_time":               span.StartTimestamp().AsTime(),
-					"end_time":                 span.EndTimestamp().AsTime(),
-					"status_code":              statusCodeToString(span.Status().Code()),
-					"status_message":           span.Status().Message(),
-					"flags":                    int64(span.Flags()),
-					"dropped_attributes_count": int64(span.DroppedAttributesCount()),
-					"dropped_events_count":     int64(span.DroppedEventsCount()),
-					"dropped_links_count":      int64(span.DroppedLinksCount()),
-					"resource_attributes":      attributesToJSON(rs.Resource().Attributes()),
-					"resource_schema_url":      rs.SchemaUrl(),
-					"span_attributes":          attributesToJSON(span.Attributes()),
-					"events":                   eventsToJSON(span.Events()),
-					"links":                    linksToJSON(span.Links()),
-					"instrumentation_scope":    scopeToJSON(ss.Scope()),
-					"scope_schema_url":         ss.SchemaUrl(),
-				})
-			}
-		}
+// tracesTableSchema returns the traces table schema with the preset applied
+// and the optional columns enabled in cfg.
+func tracesTableSchema(preset string, cfg TracesConfig) bigquery.Schema {
+	schema := tableSchema(withIntegerEnums(withKeyValueAttributes(rowconv.TracesSchema, cfg.AttributesEncoding), cfg.EnumEncoding), preset, cfg.JSONColumns)
+	if cfg.StatusClass {
+		schema = append(schema, rowconv.StatusClassField)
 	}
-
-	return rows
-}
-
-func spanKindToString(kind ptrace.SpanKind) string {
-	switch kind {
-	case ptrace.SpanKindInternal:
-		return "INTERNAL"
-	case ptrace.SpanKindServer:
-		return "SERVER"
-	case ptrace.SpanKindClient:
-		return "CLIENT"
-	case ptrace.SpanKindProducer:
-		return "PRODUCER"
-	case ptrace.SpanKindConsumer:
-		return "CONSUMER"
-	default:
-		return "UNSPECIFIED"
+	if cfg.FlagColumns {
+		schema = append(schema, rowconv.FlagFields...)
 	}
-}
-
-func statusCodeToString(code ptrace.StatusCode) string {
-	switch code {
-	case ptrace.StatusCodeOk:
-		return "OK"
-	case ptrace.StatusCodeError:
-		return "ERROR"
-	default:
-		return "UNSET"
+	if cfg.CountColumns {
+		schema = append(schema, rowconv.CountFields...)
 	}
-}
-
-func eventsToJSON(events ptrace.SpanEventSlice) string {
-	if events.Len() == 0 {
-		return "[]"
+	if cfg.SpanHierarchy {
+		schema = append(schema, rowconv.SpanHierarchyFields...)
+	}
+	schema = withScopeColumns(schema, cfg.ScopeColumns)
+	if cfg.RawOTLP {
+		schema = append(schema, rowconv.RawOTLPField)
 	}
-	result := make([]map[string]any, 0, events.Len())
-	for _, e := range events.All() {
-		result = append(result, map[string]any{
-			"timestamp":                e.Timestamp().AsTime().Format(time.RFC3339Nano),
-			"name":                     e.Name(),
-			"attributes":               json.RawMessage(attributesToJSON(e.Attributes())),
-			"dropped_attributes_count": e.DroppedAttributesCount(),
+	schema = append(schema, rowconv.PromotedFields(promotedAttributes(cfg.PromotedAttributes))...)
+	schema = append(schema, rowconv.ComputedFields(computedColumns(cfg.ComputedColumns))...)
+	if cfg.ChildTables {
+		schema = slices.DeleteFunc(schema, func(f *bigquery.FieldSchema) bool {
+			return f.Name == "events" || f.Name == "links"
 		})
 	}
-	return marshalJSON(result)
+	return withTruncated(schema, cfg.MaxColumnBytes)
 }
 
-func linksToJSON(links ptrace.SpanLinkSlice) string {
-	if links.Len() == 0 {
-		return "[]"
-	}
-	result := make([]map[string]any, 0, links.Len())
-	for _, l := range links.All() {
-		result = append(result, map[string]any{
-			"trace_id":                 traceIDToHex(l.TraceID()),
-			"span_id":                  spanIDToHex(l.SpanID()),
-			"trace_state":              l.TraceState().AsRaw(),
-			"attributes":               json.RawMessage(attributesToJSON(l.Attributes())),
-			"dropped_attributes_count": l.DroppedAttributesCount(),
-			"flags":                    int64(l.Flags()),
-		})
+// rowOptions returns the conversion options of cfg.
+func (cfg TracesConfig) rowOptions() rowconv.TracesOptions {
+	return rowconv.TracesOptions{
+		IncludeEventNames:  cfg.IncludeEventNames,
+		StatusClass:        cfg.StatusClass,
+		FlagColumns:        cfg.FlagColumns,
+		CountColumns:       cfg.CountColumns,
+		SpanHierarchy:      cfg.SpanHierarchy,
+		OmitEventsAndLinks: cfg.ChildTables,
+		PromotedAttributes: promotedAttributes(cfg.PromotedAttributes),
+		AttributeFilters:   cfg.AttributeFilters.filters(),
+		KeyValueAttributes: cfg.AttributesEncoding == attributesEncodingKeyValue,
+		ScopeColumns:       cfg.ScopeColumns != "",
+		RawOTLP:            cfg.RawOTLP,
+		IntegerEnums:       cfg.EnumEncoding == enumEncodingInt64,
+		ComputedColumns:    computedColumns(cfg.ComputedColumns),
 	}
-	return marshalJSON(result)
+}
+
+// spanEventsTableSchema returns the span event table schema with the preset
+// applied. The max_column_bytes of cfg apply to its columns as well.
+func spanEventsTableSchema(preset string, cfg TracesConfig) bigquery.Schema {
+	return withTruncated(tableSchema(rowconv.SpanEventsSchema, preset, cfg.JSONColumns), cfg.MaxColumnBytes)
+}
+
+// spanLinksTableSchema returns the span link table schema with the preset
+// applied. The max_column_bytes of cfg apply to its columns as well.
+func spanLinksTableSchema(preset string, cfg TracesConfig) bigquery.Schema {
+	return withTruncated(tableSchema(rowconv.SpanLinksSchema, preset, cfg.JSONColumns), cfg.MaxColumnBytes)
 }
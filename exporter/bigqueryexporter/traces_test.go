@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracesTableSchemaStatusClass(t *testing.T) {
+	assert.Nil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{}), "status_class"))
+	assert.NotNil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{StatusClass: true}), "status_class"))
+}
+
+func TestTracesTableSchemaFlagColumns(t *testing.T) {
+	assert.Nil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{}), "sampled"))
+	schema := tracesTableSchema(defaultSchemaPreset, TracesConfig{FlagColumns: true})
+	assert.NotNil(t, schemaField(schema, "sampled"))
+	assert.NotNil(t, schemaField(schema, "has_remote_parent"))
+}
+
+func TestTracesTableSchemaCountColumns(t *testing.T) {
+	assert.Nil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{}), "events_count"))
+	schema := tracesTableSchema(defaultSchemaPreset, TracesConfig{CountColumns: true})
+	assert.Equal(t, bigquery.IntegerFieldType, schemaField(schema, "events_count").Type)
+	assert.Equal(t, bigquery.IntegerFieldType, schemaField(schema, "links_count").Type)
+
+	// The counts stay when events and links move to their own tables.
+	schema = tracesTableSchema(defaultSchemaPreset, TracesConfig{CountColumns: true, ChildTables: true})
+	assert.NotNil(t, schemaField(schema, "events_count"))
+}
+
+func TestTracesTableSchemaSpanHierarchy(t *testing.T) {
+	assert.Nil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{}), "depth"))
+	schema := tracesTableSchema(defaultSchemaPreset, TracesConfig{SpanHierarchy: true})
+	assert.NotNil(t, schemaField(schema, "depth"))
+	assert.NotNil(t, schemaField(schema, "is_leaf"))
+}
+
+func TestTracesTableSchemaChildTables(t *testing.T) {
+	assert.NotNil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{}), "events"))
+	schema := tracesTableSchema(defaultSchemaPreset, TracesConfig{ChildTables: true})
+	assert.Nil(t, schemaField(schema, "events"))
+	assert.Nil(t, schemaField(schema, "links"))
+	assert.NotNil(t, schemaField(schema, "span_attributes"))
+}
+
+func TestTracesTableSchemaRawOTLP(t *testing.T) {
+	assert.Nil(t, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{}), "raw_otlp"))
+	assert.Equal(t, bigquery.BytesFieldType, schemaField(tracesTableSchema(defaultSchemaPreset, TracesConfig{RawOTLP: true}), "raw_otlp").Type)
+}
+
+func TestTracesRowOptions(t *testing.T) {
+	cfg := TracesConfig{IncludeEventNames: []string{"exception"}, StatusClass: true, FlagColumns: true, CountColumns: true, SpanHierarchy: true, ChildTables: true, RawOTLP: true}
+	opts := cfg.rowOptions()
+	assert.Equal(t, []string{"exception"}, opts.IncludeEventNames)
+	assert.True(t, opts.StatusClass)
+	assert.True(t, opts.FlagColumns)
+	assert.True(t, opts.CountColumns)
+	assert.True(t, opts.SpanHierarchy)
+	assert.True(t, opts.OmitEventsAndLinks)
+	assert.True(t, opts.RawOTLP)
+}
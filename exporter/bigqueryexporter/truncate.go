@@ -0,0 +1,193 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"unicode/utf8"
+
+	"cloud.google.com/go/bigquery"
+)
+
+const truncatedColumn = "truncated"
+
+// truncatedField is the column flagging rows with a value truncated by
+// max_column_bytes.
+var truncatedField = &bigquery.FieldSchema{Name: truncatedColumn, Type: bigquery.BooleanFieldType, Required: false}
+
+// withTruncated returns schema with the truncated column appended when
+// limits are configured for any of its columns.
+func withTruncated(schema bigquery.Schema, limits map[string]int) bigquery.Schema {
+	if len(tableLimits(limits, schema)) == 0 {
+		return schema
+	}
+	return append(schema, truncatedField)
+}
+
+// tableLimits returns the limits of the columns of schema. The limits of
+// the traces section also apply to the span event and link tables, so
+// they may name columns of other tables.
+func tableLimits(limits map[string]int, schema bigquery.Schema) map[string]int {
+	var table map[string]int
+	for _, f := range schema {
+		if limit, ok := limits[f.Name]; ok {
+			if table == nil {
+				table = make(map[string]int)
+			}
+			table[f.Name] = limit
+		}
+	}
+	return table
+}
+
+// validateMaxColumnBytes checks that limits only apply to STRING, JSON and
+// RECORD columns of schema, whose values the exporter holds as text.
+func validateMaxColumnBytes(field string, limits map[string]int, schema bigquery.Schema) error {
+	for _, column := range slices.Sorted(maps.Keys(limits)) {
+		idx := slices.IndexFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == column })
+		if idx < 0 {
+			return fmt.Errorf("%s: %q is not a column of the table", field, column)
+		}
+		switch schema[idx].Type {
+		case bigquery.StringFieldType, bigquery.JSONFieldType, bigquery.RecordFieldType:
+		default:
+			return fmt.Errorf("%s: column %q is %s, only STRING, JSON and RECORD columns can be truncated", field, column, schema[idx].Type)
+		}
+		if limits[column] <= 0 {
+			return fmt.Errorf("%s[%s] must be positive", field, column)
+		}
+	}
+	return nil
+}
+
+// columnTruncation truncates the values of columns exceeding their limit.
+type columnTruncation struct {
+	limits map[string]int
+	// json holds the columns serialized as JSON, which are truncated to
+	// valid JSON.
+	json map[string]bool
+}
+
+// newColumnTruncation returns the truncation of limits for a table of
+// schema, which is that of the default preset so JSON columns are known
+// regardless of the preset. It returns nil when no limit applies to a
+// column of schema.
+func newColumnTruncation(limits map[string]int, schema bigquery.Schema) *columnTruncation {
+	limits = tableLimits(limits, schema)
+	if len(limits) == 0 {
+		return nil
+	}
+	t := &columnTruncation{limits: limits, json: make(map[string]bool)}
+	for _, f := range schema {
+		if f.Type == bigquery.JSONFieldType || f.Type == bigquery.RecordFieldType {
+			t.json[f.Name] = true
+		}
+	}
+	return t
+}
+
+// truncate truncates the values of rows exceeding their limit and sets the
+// truncated column of every row.
+func (t *columnTruncation) truncate(rows []row) {
+	if t == nil {
+		return
+	}
+	for _, r := range rows {
+		truncated := false
+		for column, limit := range t.limits {
+			s, ok := r[column].(string)
+			if !ok || len(s) <= limit {
+				continue
+			}
+			truncated = true
+			if !t.json[column] {
+				r[column] = truncateString(s, limit)
+				continue
+			}
+			if v, ok := truncateJSON(s, limit); ok {
+				r[column] = v
+			} else {
+				delete(r, column)
+			}
+		}
+		r[truncatedColumn] = truncated
+	}
+}
+
+// truncateString returns the longest prefix of s of at most limit bytes
+// that does not split a UTF-8 character.
+func truncateString(s string, limit int) string {
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
+
+// truncateJSON returns the JSON array or object s with its trailing
+// elements, or the keys sorting last, dropped until it fits in limit
+// bytes. Elements are kept as written, so the result is a prefix of s for
+// arrays and of the sorted object otherwise. It returns false for other
+// JSON values, which cannot be shortened and stay valid.
+func truncateJSON(s string, limit int) (string, bool) {
+	var b bytes.Buffer
+	switch trimmed := bytes.TrimSpace([]byte(s)); {
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elems); err != nil {
+			return "", false
+		}
+		b.WriteByte('[')
+		for _, elem := range elems {
+			if !appendJSONMember(&b, nil, elem, limit) {
+				break
+			}
+		}
+		b.WriteByte(']')
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &members); err != nil {
+			return "", false
+		}
+		b.WriteByte('{')
+		for _, key := range slices.Sorted(maps.Keys(members)) {
+			quoted, _ := json.Marshal(key)
+			if !appendJSONMember(&b, quoted, members[key], limit) {
+				break
+			}
+		}
+		b.WriteByte('}')
+	default:
+		return "", false
+	}
+	return b.String(), true
+}
+
+// appendJSONMember appends the element or the key and value of an object
+// member to the array or object in b, unless it would not fit in limit
+// bytes including the closing bracket.
+func appendJSONMember(b *bytes.Buffer, key, value []byte, limit int) bool {
+	size := len(key) + len(value) + 1
+	if b.Len() > 1 {
+		size++
+	}
+	if key != nil {
+		size++
+	}
+	if b.Len()+size > limit {
+		return false
+	}
+	if b.Len() > 1 {
+		b.WriteByte(',')
+	}
+	if key != nil {
+		b.Write(key)
+		b.WriteByte(':')
+	}
+	b.Write(value)
+	return true
+}
@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWithTruncated(t *testing.T) {
+	assert.Nil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{}), truncatedColumn))
+	assert.NotNil(t, schemaField(logsTableSchema(defaultSchemaPreset, LogsConfig{MaxColumnBytes: map[string]int{"body": 10}}), truncatedColumn))
+	schema := metricsTableSchema(defaultSchemaPreset, MetricsConfig{GroupDataPoints: true, MaxColumnBytes: map[string]int{rowconv.DataPointsColumn: 10}})
+	assert.NotNil(t, schemaField(schema, truncatedColumn), "truncated is a top-level column of grouped data points")
+
+	// The traces limits apply to the tables having their columns.
+	cfg := TracesConfig{ChildTables: true, MaxColumnBytes: map[string]int{"event_attributes": 10}}
+	assert.Nil(t, schemaField(tracesTableSchema(defaultSchemaPreset, cfg), truncatedColumn))
+	assert.NotNil(t, schemaField(spanEventsTableSchema(defaultSchemaPreset, cfg), truncatedColumn))
+	assert.Nil(t, schemaField(spanLinksTableSchema(defaultSchemaPreset, cfg), truncatedColumn))
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "abc", truncateString("abcdef", 3))
+	// é is two bytes and is not split.
+	assert.Equal(t, "ab", truncateString("abé", 3))
+	assert.Empty(t, truncateString("é", 1))
+}
+
+func TestTruncateJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		want  string
+		ok    bool
+	}{
+		{name: "array", in: `[{"name":"a"},{"name":"b"},{"name":"c"}]`, limit: 28, want: `[{"name":"a"},{"name":"b"}]`, ok: true},
+		{name: "first element too large", in: `[{"name":"aaaaaaaa"}]`, limit: 10, want: `[]`, ok: true},
+		{name: "object keeps keys sorting first", in: `{"b":"2","a":"1","c":"3"}`, limit: 18, want: `{"a":"1","b":"2"}`, ok: true},
+		{name: "values are kept as written", in: `{"a":1.50,"b":[1,2,3]}`, limit: 12, want: `{"a":1.50}`, ok: true},
+		{name: "scalar", in: `"a long string"`, limit: 5, ok: false},
+		{name: "invalid", in: `[1,`, limit: 2, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := truncateJSON(tt.in, tt.limit)
+			assert.Equal(t, tt.ok, ok)
+			if !tt.ok {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len(got), tt.limit)
+			assert.True(t, json.Valid([]byte(got)))
+		})
+	}
+}
+
+func TestColumnTruncation(t *testing.T) {
+	var none *columnTruncation
+	none.truncate([]row{{"body": "abc"}})
+
+	cfg := LogsConfig{BodyJSON: true, MaxColumnBytes: map[string]int{"body": 4, "body_json": 4, "log_attributes": 12}}
+	truncation := newColumnTruncation(cfg.MaxColumnBytes, logsTableSchema(defaultSchemaPreset, cfg))
+	rows := []row{
+		{"body": "short", "body_json": `"short"`, "log_attributes": `{"a":"1","b":"2"}`},
+		{"body": "ok", "log_attributes": `{}`},
+	}
+	truncation.truncate(rows)
+	assert.Equal(t, row{"body": "shor", "log_attributes": `{"a":"1"}`, truncatedColumn: true}, rows[0])
+	assert.Equal(t, row{"body": "ok", "log_attributes": `{}`, truncatedColumn: false}, rows[1])
+}
+
+func TestColumnTruncationOfSpanEvents(t *testing.T) {
+	cfg := TracesConfig{ChildTables: true, MaxColumnBytes: map[string]int{"span_attributes": 4, "event_attributes": 10}}
+	assert.Nil(t, newColumnTruncation(cfg.MaxColumnBytes, spanLinksTableSchema(defaultSchemaPreset, cfg)))
+	truncation := newColumnTruncation(cfg.MaxColumnBytes, spanEventsTableSchema(defaultSchemaPreset, cfg))
+	rows := []row{{"event_name": "exception", "event_attributes": `{"a":"1","b":"2"}`}}
+	truncation.truncate(rows)
+	assert.Equal(t, row{"event_name": "exception", "event_attributes": `{"a":"1"}`, truncatedColumn: true}, rows[0])
+}
@@ -0,0 +1,132 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.uber.org/zap"
+)
+
+// udfVersion is part of the description of the helper UDFs. It is
+// increased whenever their definitions change, so upgraded exporters
+// replace the UDFs earlier versions created.
+const udfVersion = 1
+
+// udfMarker ends the description of every helper UDF and tells them apart
+// from routines created by others, which are left alone.
+const udfMarker = "Managed by the OpenTelemetry Collector BigQuery exporter"
+
+// helperUDF is a SQL function created in every dataset the exporter writes
+// to when create_udfs is enabled. All of them take a JSON attributes column
+// and an attribute key.
+type helperUDF struct {
+	name        string
+	description string
+	returnType  string
+	body        string
+}
+
+// helperUDFs extract attribute values with the LAX conversion functions,
+// which return NULL for missing keys and values of other types instead of
+// failing the query. The key is looked up with the JSON subscript
+// operator, so keys containing dots such as service.name need no quoting.
+var helperUDFs = []helperUDF{
+	{
+		name:        "attr",
+		description: "Returns the attribute key of a JSON attributes column as a STRING.",
+		returnType:  "STRING",
+		body:        "LAX_STRING(attributes[key])",
+	},
+	{
+		name:        "attr_int64",
+		description: "Returns the attribute key of a JSON attributes column as an INT64.",
+		returnType:  "INT64",
+		body:        "LAX_INT64(attributes[key])",
+	},
+	{
+		name:        "attr_float64",
+		description: "Returns the attribute key of a JSON attributes column as a FLOAT64.",
+		returnType:  "FLOAT64",
+		body:        "LAX_FLOAT64(attributes[key])",
+	},
+	{
+		name:        "attr_bool",
+		description: "Returns the attribute key of a JSON attributes column as a BOOL.",
+		returnType:  "BOOL",
+		body:        "LAX_BOOL(attributes[key])",
+	},
+}
+
+// metadata returns the definition of the UDF.
+func (udf helperUDF) metadata() *bigquery.RoutineMetadata {
+	return &bigquery.RoutineMetadata{
+		Type:        bigquery.ScalarFunctionRoutine,
+		Language:    "SQL",
+		Description: fmt.Sprintf("%s %s, version %d.", udf.description, udfMarker, udfVersion),
+		Arguments: []*bigquery.RoutineArgument{
+			{Name: "attributes", DataType: &bigquery.StandardSQLDataType{TypeKind: "JSON"}},
+			{Name: "key", DataType: &bigquery.StandardSQLDataType{TypeKind: "STRING"}},
+		},
+		ReturnType: &bigquery.StandardSQLDataType{TypeKind: udf.returnType},
+		Body:       udf.body,
+	}
+}
+
+// ensureUDFs creates the helper UDFs in the dataset of target, and replaces
+// those an exporter with another udfVersion created. Routines with the name
+// of a helper UDF that the exporter did not create are left alone.
+func (e *bigQueryExporter) ensureUDFs(ctx context.Context, client *bigquery.Client, target signalTarget) error {
+	for _, udf := range helperUDFs {
+		want := udf.metadata()
+		routine := client.Dataset(target.dataset).Routine(udf.name)
+		var md *bigquery.RoutineMetadata
+		err := e.retryControlPlane(ctx, "get UDF metadata", func(ctx context.Context) error {
+			var err error
+			md, err = routine.Metadata(ctx)
+			return err
+		})
+		if err == nil {
+			if !strings.Contains(md.Description, udfMarker) {
+				e.logger.Warn("A routine with the name of a helper UDF exists; not replacing it",
+					zap.String("dataset", target.dataset), zap.String("udf", udf.name))
+				continue
+			}
+			if md.Description == want.Description && md.Body == want.Body {
+				continue
+			}
+			err = e.retryControlPlane(ctx, "update UDF", func(ctx context.Context) error {
+				_, err := routine.Update(ctx, &bigquery.RoutineMetadataToUpdate{
+					Type:        want.Type,
+					Language:    want.Language,
+					Description: want.Description,
+					Arguments:   want.Arguments,
+					ReturnType:  want.ReturnType,
+					Body:        want.Body,
+				}, md.ETag)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("update UDF %s.%s: %w", target.dataset, udf.name, err)
+			}
+			e.logger.Info("Updated UDF", zap.String("dataset", target.dataset), zap.String("udf", udf.name), zap.Int("version", udfVersion))
+			continue
+		}
+		err = e.retryControlPlane(ctx, "create UDF", func(ctx context.Context) error {
+			return routine.Create(ctx, want)
+		})
+		if isAlreadyExists(err) {
+			// Another collector created the UDF concurrently.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("create UDF %s.%s: %w", target.dataset, udf.name, err)
+		}
+		e.logger.Info("Created UDF", zap.String("project", target.project), zap.String("dataset", target.dataset), zap.String("udf", udf.name))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+// fakeRoutines serves the routine calls ensureUDFs makes. routines maps
+// routine IDs to their resources.
+type fakeRoutines struct {
+	mu       sync.Mutex
+	routines map[string]map[string]any
+	requests []string
+}
+
+func (f *fakeRoutines) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	routineID := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.requests = append(f.requests, r.Method+" "+routineID)
+	switch r.Method {
+	case http.MethodGet:
+		routine, ok := f.routines[routineID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Not found"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(routine)
+	case http.MethodPost, http.MethodPut:
+		var routine map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&routine)
+		routineID = routine["routineReference"].(map[string]any)["routineId"].(string)
+		f.routines[routineID] = routine
+		_ = json.NewEncoder(w).Encode(routine)
+	}
+}
+
+func newFakeRoutinesClient(t *testing.T, routines map[string]map[string]any) (*bigquery.Client, *fakeRoutines) {
+	fake := &fakeRoutines{routines: routines}
+	client := newFakeBigQueryClient(t, fake)
+	return client, fake
+}
+
+func newUDFsExporter(t *testing.T) *bigQueryExporter {
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	cfg.CreateUDFs = true
+	return newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+}
+
+func TestEnsureUDFsCreates(t *testing.T) {
+	client, fake := newFakeRoutinesClient(t, map[string]map[string]any{})
+	exp := newUDFsExporter(t)
+	target := signalTarget{name: "traces", project: "p", dataset: "d", tableID: "spans"}
+
+	require.NoError(t, exp.ensureUDFs(t.Context(), client, target))
+	require.Len(t, fake.routines, len(helperUDFs))
+	attr := fake.routines["attr_int64"]
+	assert.Equal(t, "LAX_INT64(attributes[key])", attr["definitionBody"])
+	assert.Equal(t, "SCALAR_FUNCTION", attr["routineType"])
+	assert.Equal(t, map[string]any{"typeKind": "INT64"}, attr["returnType"])
+	assert.Contains(t, attr["description"], "version 1.")
+
+	// UDFs that are up to date are left alone.
+	fake.requests = nil
+	require.NoError(t, exp.ensureUDFs(t.Context(), client, target))
+	for _, request := range fake.requests {
+		assert.True(t, strings.HasPrefix(request, http.MethodGet), request)
+	}
+}
+
+func TestEnsureUDFsUpdatesOtherVersions(t *testing.T) {
+	client, fake := newFakeRoutinesClient(t, map[string]map[string]any{
+		"attr": {
+			"routineReference": map[string]any{"projectId": "p", "datasetId": "d", "routineId": "attr"},
+			"etag":             "e1",
+			"definitionBody":   "JSON_VALUE(attributes[key])",
+			"description":      "Old. " + udfMarker + ", version 0.",
+		},
+		"attr_bool": {
+			"routineReference": map[string]any{"projectId": "p", "datasetId": "d", "routineId": "attr_bool"},
+			"definitionBody":   "TRUE",
+			"description":      "Written by hand.",
+		},
+	})
+	exp := newUDFsExporter(t)
+
+	require.NoError(t, exp.ensureUDFs(t.Context(), client, signalTarget{name: "logs", project: "p", dataset: "d", tableID: "log"}))
+	assert.Contains(t, fake.requests, http.MethodPut+" attr")
+	assert.Equal(t, "LAX_STRING(attributes[key])", fake.routines["attr"]["definitionBody"])
+	// Routines the exporter did not create are not replaced.
+	assert.NotContains(t, fake.requests, http.MethodPut+" attr_bool")
+	assert.Equal(t, "TRUE", fake.routines["attr_bool"]["definitionBody"])
+}
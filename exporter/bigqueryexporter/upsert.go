@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// changeTypeColumn is the pseudo-column of the Storage Write API that makes
+// a row an upsert or a delete of the row with the same primary key. It is
+// part of the writer schema but not of the table.
+const (
+	changeTypeColumn = "_CHANGE_TYPE"
+	changeTypeUpsert = "UPSERT"
+)
+
+var changeTypeField = &bigquery.FieldSchema{Name: changeTypeColumn, Type: bigquery.StringFieldType, Required: false}
+
+// upsertKeys are the primary keys of the tables of signals written with
+// upsert.
+var upsertKeys = map[string][]string{
+	"traces":  {"trace_id", "span_id"},
+	"metrics": {seriesIDColumn},
+}
+
+func (cfg UpsertConfig) validate(field string, write WriteConfig) error {
+	if cfg.MaxStaleness < 0 {
+		return fmt.Errorf("%s.max_staleness must not be negative", field)
+	}
+	if !cfg.Enabled {
+		if cfg.MaxStaleness > 0 {
+			return fmt.Errorf("%s.max_staleness requires %s.enabled", field, field)
+		}
+		return nil
+	}
+	if write.Mode != writeModeDefault {
+		return fmt.Errorf("%s requires write.mode: %s, change data capture is only supported on the default stream", field, writeModeDefault)
+	}
+	return nil
+}
+
+// primaryKey returns the primary key of tables written with cfg, or nil
+// when upsert is disabled.
+func (cfg UpsertConfig) primaryKey(signal string) []string {
+	if !cfg.Enabled {
+		return nil
+	}
+	return upsertKeys[signal]
+}
+
+// tableConstraints returns the constraints of a table created with
+// primaryKey, or nil without one.
+func tableConstraints(primaryKey []string) *bigquery.TableConstraints {
+	if len(primaryKey) == 0 {
+		return nil
+	}
+	return &bigquery.TableConstraints{PrimaryKey: &bigquery.PrimaryKey{Columns: primaryKey}}
+}
+
+// maxStaleness returns the max_staleness option of a created table, or nil
+// when it is not set.
+func maxStaleness(d time.Duration) *bigquery.IntervalValue {
+	if d <= 0 {
+		return nil
+	}
+	return bigquery.IntervalValueFromDuration(d)
+}
+
+// checkPrimaryKey returns an error unless the table described by md has
+// primaryKey, since upserts into a table without it are rejected.
+func checkPrimaryKey(md *bigquery.TableMetadata, primaryKey []string) error {
+	if len(primaryKey) == 0 {
+		return nil
+	}
+	var existing []string
+	if md.TableConstraints != nil && md.TableConstraints.PrimaryKey != nil {
+		existing = md.TableConstraints.PrimaryKey.Columns
+	}
+	if slices.Equal(existing, primaryKey) {
+		return nil
+	}
+	if len(existing) == 0 {
+		return fmt.Errorf("upsert requires the primary key (%s), but the table has none", strings.Join(primaryKey, ", "))
+	}
+	return fmt.Errorf("upsert requires the primary key (%s), but the table has the primary key (%s)", strings.Join(primaryKey, ", "), strings.Join(existing, ", "))
+}
+
+// withChangeType returns the writer schema of a table written with upsert.
+func withChangeType(schema bigquery.Schema) bigquery.Schema {
+	return append(slices.Clip(schema), changeTypeField)
+}
@@ -0,0 +1,89 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpsertConfigValidate(t *testing.T) {
+	defaultWrite := WriteConfig{Mode: writeModeDefault}
+	tests := []struct {
+		name    string
+		cfg     UpsertConfig
+		write   WriteConfig
+		wantErr string
+	}{
+		{name: "disabled", write: WriteConfig{Mode: writeModePending}},
+		{name: "enabled", cfg: UpsertConfig{Enabled: true, MaxStaleness: time.Hour}, write: defaultWrite},
+		{
+			name:    "pending mode",
+			cfg:     UpsertConfig{Enabled: true},
+			write:   WriteConfig{Mode: writeModePending},
+			wantErr: "traces.upsert requires write.mode: default",
+		},
+		{
+			name:    "negative max staleness",
+			cfg:     UpsertConfig{Enabled: true, MaxStaleness: -time.Minute},
+			write:   defaultWrite,
+			wantErr: "traces.upsert.max_staleness must not be negative",
+		},
+		{
+			name:    "max staleness without enabled",
+			cfg:     UpsertConfig{MaxStaleness: time.Minute},
+			write:   defaultWrite,
+			wantErr: "traces.upsert.max_staleness requires traces.upsert.enabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate("traces.upsert", tt.write)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUpsertConfigPrimaryKey(t *testing.T) {
+	assert.Nil(t, UpsertConfig{}.primaryKey("traces"))
+	assert.Equal(t, []string{"trace_id", "span_id"}, UpsertConfig{Enabled: true}.primaryKey("traces"))
+	assert.Equal(t, []string{"series_id"}, UpsertConfig{Enabled: true}.primaryKey("metrics"))
+	assert.Nil(t, UpsertConfig{Enabled: true}.primaryKey("logs"))
+}
+
+func TestTableConstraints(t *testing.T) {
+	assert.Nil(t, tableConstraints(nil))
+	assert.Equal(t, &bigquery.TableConstraints{PrimaryKey: &bigquery.PrimaryKey{Columns: []string{"series_id"}}}, tableConstraints([]string{"series_id"}))
+}
+
+func TestMaxStaleness(t *testing.T) {
+	assert.Nil(t, maxStaleness(0))
+	assert.Equal(t, bigquery.IntervalValueFromDuration(15*time.Minute), maxStaleness(15*time.Minute))
+}
+
+func TestCheckPrimaryKey(t *testing.T) {
+	key := []string{"trace_id", "span_id"}
+	withKey := func(columns ...string) *bigquery.TableMetadata {
+		return &bigquery.TableMetadata{TableConstraints: &bigquery.TableConstraints{PrimaryKey: &bigquery.PrimaryKey{Columns: columns}}}
+	}
+
+	assert.NoError(t, checkPrimaryKey(&bigquery.TableMetadata{}, nil))
+	assert.NoError(t, checkPrimaryKey(withKey("trace_id", "span_id"), key))
+	assert.EqualError(t, checkPrimaryKey(&bigquery.TableMetadata{}, key), "upsert requires the primary key (trace_id, span_id), but the table has none")
+	assert.EqualError(t, checkPrimaryKey(withKey("span_id"), key), "upsert requires the primary key (trace_id, span_id), but the table has the primary key (span_id)")
+}
+
+func TestWithChangeType(t *testing.T) {
+	schema := bigquery.Schema{{Name: "trace_id", Type: bigquery.StringFieldType}}
+	got := withChangeType(schema)
+	assert.Len(t, schema, 1)
+	assert.Equal(t, bigquery.Schema{schema[0], changeTypeField}, got)
+}
@@ -0,0 +1,219 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.uber.org/zap"
+)
+
+// helperView is a convenience view created next to a signal table when
+// create_views is enabled.
+type helperView struct {
+	// suffix is appended to the table name to name the view.
+	suffix      string
+	description string
+	// query returns the query of the view over the fully qualified table.
+	query func(table string) string
+}
+
+// helperViews are the views created per signal. The queries only read
+// columns of every schema preset, JSON_VALUE accepts both JSON and STRING
+// columns and LENGTH both STRING and BYTES IDs.
+var helperViews = map[string]helperView{
+	"traces": {
+		suffix:      "_summary",
+		description: "One row per trace with its root span, duration and span count.",
+		query: func(table string) string {
+			return `SELECT
+  trace_id,
+  ANY_VALUE(IF(IFNULL(LENGTH(parent_span_id), 0) = 0, name, NULL)) AS root_span_name,
+  ANY_VALUE(IF(IFNULL(LENGTH(parent_span_id), 0) = 0, JSON_VALUE(resource_attributes, '$."service.name"'), NULL)) AS root_service_name,
+  MIN(start_time) AS start_time,
+  MAX(end_time) AS end_time,
+  TIMESTAMP_DIFF(MAX(end_time), MIN(start_time), MICROSECOND) / 1000 AS duration_ms,
+  COUNT(*) AS span_count,
+  COUNTIF(status_code = 'ERROR') AS error_count
+FROM ` + table + `
+GROUP BY trace_id`
+		},
+	},
+	"metrics": {
+		suffix:      "_long",
+		description: "One row per data point with the integer and double values coalesced into a single value column.",
+		query: func(table string) string {
+			return `SELECT
+  metric_name,
+  metric_type,
+  metric_unit,
+  datapoint_timestamp,
+  start_timestamp,
+  COALESCE(value_double, CAST(value_int AS FLOAT64)) AS value,
+  count,
+  sum,
+  JSON_VALUE(resource_attributes, '$."service.name"') AS service_name,
+  resource_attributes,
+  datapoint_attributes
+FROM ` + table
+		},
+	},
+	"logs": {
+		suffix:      "_latest",
+		description: "Log records observed in the last 24 hours.",
+		query: func(table string) string {
+			return `SELECT
+  IF(log_timestamp > TIMESTAMP_SECONDS(0), log_timestamp, observed_timestamp) AS timestamp,
+  JSON_VALUE(resource_attributes, '$."service.name"') AS service_name,
+  *
+FROM ` + table + `
+WHERE observed_timestamp >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL 24 HOUR)`
+		},
+	},
+}
+
+// ensureView creates the helper view of target, or updates its query when
+// an earlier version of the exporter created it differently. Existing
+// tables that are not views are left alone.
+func (e *bigQueryExporter) ensureView(ctx context.Context, client *bigquery.Client, target signalTarget) error {
+	view, ok := helperViews[target.name]
+	if !ok {
+		return nil
+	}
+	viewID := target.tableID + view.suffix
+	query := view.query(fmt.Sprintf("`%s.%s.%s`", target.project, target.dataset, target.tableID))
+	table := client.Dataset(target.dataset).Table(viewID)
+	var md *bigquery.TableMetadata
+	err := e.retryControlPlane(ctx, "get view metadata", func(ctx context.Context) error {
+		var err error
+		md, err = table.Metadata(ctx)
+		return err
+	})
+	if err == nil {
+		if md.Type != bigquery.ViewTable {
+			e.logger.Warn("A table with the name of a helper view exists; not creating the view",
+				zap.String("signal", target.name), zap.String("view", viewID))
+			return nil
+		}
+		if md.ViewQuery == query {
+			return nil
+		}
+		err = e.retryControlPlane(ctx, "update view", func(ctx context.Context) error {
+			_, err := table.Update(ctx, bigquery.TableMetadataToUpdate{ViewQuery: query}, md.ETag)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("update %s view %s: %w", target.name, viewID, err)
+		}
+		e.logger.Info("Updated view", zap.String("signal", target.name), zap.String("view", viewID))
+		return nil
+	}
+	err = e.retryControlPlane(ctx, "create view", func(ctx context.Context) error {
+		return table.Create(ctx, &bigquery.TableMetadata{
+			ViewQuery:   query,
+			Description: view.description,
+			Labels:      e.cfg.Dataset.TableLabels,
+		})
+	})
+	if isAlreadyExists(err) {
+		// Another collector created the view concurrently.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("create %s view %s: %w", target.name, viewID, err)
+	}
+	e.logger.Info("Created view", zap.String("signal", target.name), zap.String("project", target.project), zap.String("dataset", target.dataset), zap.String("view", viewID))
+	return nil
+}
+
+// rollupQuery returns the query of the metric rollup materialized view over
+// the fully qualified metrics table. Resources are identified by a hash of
+// their attributes, since materialized views cannot group by JSON or
+// RECORD columns.
+func rollupQuery(table string, schema bigquery.Schema, granularity string) string {
+	resource := "resource_attributes"
+	if idx := slices.IndexFunc(schema, func(f *bigquery.FieldSchema) bool { return f.Name == resource }); idx >= 0 && schema[idx].Type != bigquery.StringFieldType {
+		resource = "TO_JSON_STRING(resource_attributes)"
+	}
+	return `SELECT
+  TIMESTAMP_TRUNC(datapoint_timestamp, ` + granularity + `) AS bucket_timestamp,
+  metric_name,
+  metric_type,
+  metric_unit,
+  FARM_FINGERPRINT(` + resource + `) AS resource_hash,
+  COUNT(*) AS datapoint_count,
+  SUM(COALESCE(value_double, CAST(value_int AS FLOAT64))) AS value_sum,
+  AVG(COALESCE(value_double, CAST(value_int AS FLOAT64))) AS value_avg,
+  MIN(COALESCE(value_double, CAST(value_int AS FLOAT64))) AS value_min,
+  MAX(COALESCE(value_double, CAST(value_int AS FLOAT64))) AS value_max
+FROM ` + table + `
+GROUP BY bucket_timestamp, metric_name, metric_type, metric_unit, resource_hash`
+}
+
+// ensureRollupView creates the metric rollup materialized view of the
+// metrics target, or updates its refresh interval. BigQuery cannot change
+// the query of a materialized view, so a view with a different query, e.g.
+// after changing metrics.rollup.granularity, is only logged.
+func (e *bigQueryExporter) ensureRollupView(ctx context.Context, client *bigquery.Client, target signalTarget) error {
+	cfg := e.cfg.Metrics.Rollup
+	viewID := target.tableID + "_rollup"
+	query := rollupQuery(fmt.Sprintf("`%s.%s.%s`", target.project, target.dataset, target.tableID), target.schema, cfg.Granularity)
+	table := client.Dataset(target.dataset).Table(viewID)
+	var md *bigquery.TableMetadata
+	err := e.retryControlPlane(ctx, "get materialized view metadata", func(ctx context.Context) error {
+		var err error
+		md, err = table.Metadata(ctx)
+		return err
+	})
+	if err == nil {
+		if md.Type != bigquery.MaterializedView || md.MaterializedView == nil {
+			e.logger.Warn("A table with the name of the metric rollup view exists; not creating the view",
+				zap.String("view", viewID))
+			return nil
+		}
+		if md.MaterializedView.Query != query {
+			e.logger.Warn("The metric rollup view has a different query; drop it to recreate it with the current one",
+				zap.String("view", viewID))
+		}
+		if md.MaterializedView.EnableRefresh && md.MaterializedView.RefreshInterval == cfg.RefreshInterval {
+			return nil
+		}
+		err = e.retryControlPlane(ctx, "update materialized view", func(ctx context.Context) error {
+			_, err := table.Update(ctx, bigquery.TableMetadataToUpdate{MaterializedView: &bigquery.MaterializedViewDefinition{
+				Query:           md.MaterializedView.Query,
+				EnableRefresh:   true,
+				RefreshInterval: cfg.RefreshInterval,
+			}}, md.ETag)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("update metric rollup view %s: %w", viewID, err)
+		}
+		e.logger.Info("Updated refresh interval of metric rollup view", zap.String("view", viewID), zap.Duration("refresh_interval", cfg.RefreshInterval))
+		return nil
+	}
+	err = e.retryControlPlane(ctx, "create materialized view", func(ctx context.Context) error {
+		return table.Create(ctx, &bigquery.TableMetadata{
+			MaterializedView: &bigquery.MaterializedViewDefinition{
+				Query:           query,
+				EnableRefresh:   true,
+				RefreshInterval: cfg.RefreshInterval,
+			},
+			Description: "Data points aggregated per " + strings.ToLower(cfg.Granularity) + ", metric and resource.",
+			Labels:      e.cfg.Dataset.TableLabels,
+		})
+	})
+	if isAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("create metric rollup view %s: %w", viewID, err)
+	}
+	e.logger.Info("Created metric rollup view", zap.String("project", target.project), zap.String("dataset", target.dataset), zap.String("view", viewID))
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// fakeViews serves the table metadata calls ensureView makes. tables maps
+// table IDs to their metadata.
+type fakeViews struct {
+	mu       sync.Mutex
+	tables   map[string]map[string]any
+	requests []string
+}
+
+func (f *fakeViews) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method)
+	tableID := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	switch r.Method {
+	case http.MethodGet:
+		table, ok := f.tables[tableID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"Not found"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(table)
+	case http.MethodPost, http.MethodPatch:
+		var table map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&table)
+		if r.Method == http.MethodPost {
+			ref := table["tableReference"].(map[string]any)
+			tableID = ref["tableId"].(string)
+			table["type"] = "VIEW"
+			if _, ok := table["materializedView"]; ok {
+				table["type"] = "MATERIALIZED_VIEW"
+			}
+		} else {
+			table["type"] = f.tables[tableID]["type"]
+		}
+		f.tables[tableID] = table
+		_ = json.NewEncoder(w).Encode(table)
+	}
+}
+
+func newFakeViewsClient(t *testing.T, tables map[string]map[string]any) (*bigquery.Client, *fakeViews) {
+	fake := &fakeViews{tables: tables}
+	client := newFakeBigQueryClient(t, fake)
+	return client, fake
+}
+
+func newViewsExporter(t *testing.T) *bigQueryExporter {
+	cfg := createDefaultConfig()
+	cfg.StartupRetry.Enabled = false
+	cfg.CreateViews = true
+	cfg.Dataset.TableLabels = map[string]string{"team": "observability"}
+	return newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+}
+
+func TestEnsureViewCreates(t *testing.T) {
+	client, fake := newFakeViewsClient(t, map[string]map[string]any{})
+	exp := newViewsExporter(t)
+	target := signalTarget{name: "traces", project: "p", dataset: "d", tableID: "spans"}
+
+	require.NoError(t, exp.ensureView(t.Context(), client, target))
+	view := fake.tables["spans_summary"]
+	require.NotNil(t, view)
+	assert.Contains(t, view["view"].(map[string]any)["query"], "FROM `p.d.spans`")
+	assert.Equal(t, map[string]any{"team": "observability"}, view["labels"])
+
+	// A view that is up to date is left alone.
+	fake.requests = nil
+	require.NoError(t, exp.ensureView(t.Context(), client, target))
+	assert.Equal(t, []string{http.MethodGet}, fake.requests)
+}
+
+func TestEnsureViewUpdatesQuery(t *testing.T) {
+	client, fake := newFakeViewsClient(t, map[string]map[string]any{
+		"log_latest": {"type": "VIEW", "etag": "e1", "view": map[string]any{"query": "SELECT 1"}},
+	})
+	exp := newViewsExporter(t)
+
+	require.NoError(t, exp.ensureView(t.Context(), client, signalTarget{name: "logs", project: "p", dataset: "d", tableID: "log"}))
+	assert.Equal(t, []string{http.MethodGet, http.MethodPatch}, fake.requests)
+	assert.Contains(t, fake.tables["log_latest"]["view"].(map[string]any)["query"], "INTERVAL 24 HOUR")
+}
+
+func TestEnsureViewSkipsTables(t *testing.T) {
+	client, fake := newFakeViewsClient(t, map[string]map[string]any{
+		"metric_long": {"type": "TABLE"},
+	})
+	exp := newViewsExporter(t)
+
+	require.NoError(t, exp.ensureView(t.Context(), client, signalTarget{name: "metrics", project: "p", dataset: "d", tableID: "metric"}))
+	assert.Equal(t, []string{http.MethodGet}, fake.requests)
+	// Tables without a helper view are skipped without calls.
+	require.NoError(t, exp.ensureView(t.Context(), client, signalTarget{name: "entities", project: "p", dataset: "d", tableID: "entity"}))
+	assert.Len(t, fake.requests, 1)
+}
+
+func TestRollupQuery(t *testing.T) {
+	query := rollupQuery("`p.d.metric`", tableSchema(rowconv.MetricsSchema, "", jsonColumnsJSON), "MINUTE")
+	assert.Contains(t, query, "TIMESTAMP_TRUNC(datapoint_timestamp, MINUTE) AS bucket_timestamp")
+	assert.Contains(t, query, "FARM_FINGERPRINT(TO_JSON_STRING(resource_attributes)) AS resource_hash")
+	assert.Contains(t, query, "FROM `p.d.metric`")
+
+	// STRING columns already hold serialized JSON.
+	query = rollupQuery("`p.d.metric`", tableSchema(rowconv.MetricsSchema, "", jsonColumnsString), "HOUR")
+	assert.Contains(t, query, "TIMESTAMP_TRUNC(datapoint_timestamp, HOUR) AS bucket_timestamp")
+	assert.Contains(t, query, "FARM_FINGERPRINT(resource_attributes) AS resource_hash")
+
+	query = rollupQuery("`p.d.metric`", metricsTableSchema("", MetricsConfig{AttributesEncoding: attributesEncodingKeyValue}), "HOUR")
+	assert.Contains(t, query, "FARM_FINGERPRINT(TO_JSON_STRING(resource_attributes)) AS resource_hash")
+}
+
+func TestEnsureRollupView(t *testing.T) {
+	client, fake := newFakeViewsClient(t, map[string]map[string]any{})
+	exp := newViewsExporter(t)
+	exp.cfg.Metrics.Rollup.Enabled = true
+	target := signalTarget{name: "metrics", project: "p", dataset: "d", tableID: "metric", schema: rowconv.MetricsSchema}
+
+	require.NoError(t, exp.ensureRollupView(t.Context(), client, target))
+	view := fake.tables["metric_rollup"]
+	require.NotNil(t, view)
+	mv := view["materializedView"].(map[string]any)
+	assert.Contains(t, mv["query"], "FROM `p.d.metric`")
+	assert.Equal(t, true, mv["enableRefresh"])
+	assert.Equal(t, "1800000", mv["refreshIntervalMs"])
+	assert.Equal(t, map[string]any{"team": "observability"}, view["labels"])
+
+	// A view that is up to date is left alone.
+	fake.requests = nil
+	require.NoError(t, exp.ensureRollupView(t.Context(), client, target))
+	assert.Equal(t, []string{http.MethodGet}, fake.requests)
+
+	// A changed refresh interval is applied, a changed query is not.
+	exp.cfg.Metrics.Rollup.RefreshInterval = time.Hour
+	exp.cfg.Metrics.Rollup.Granularity = "HOUR"
+	fake.requests = nil
+	require.NoError(t, exp.ensureRollupView(t.Context(), client, target))
+	assert.Equal(t, []string{http.MethodGet, http.MethodPatch}, fake.requests)
+	mv = fake.tables["metric_rollup"]["materializedView"].(map[string]any)
+	assert.Equal(t, "3600000", mv["refreshIntervalMs"])
+	assert.Contains(t, mv["query"], "MINUTE")
+}
+
+func TestEnsureRollupViewSkipsTables(t *testing.T) {
+	client, fake := newFakeViewsClient(t, map[string]map[string]any{
+		"metric_rollup": {"type": "TABLE"},
+	})
+	exp := newViewsExporter(t)
+	exp.cfg.Metrics.Rollup.Enabled = true
+
+	require.NoError(t, exp.ensureRollupView(t.Context(), client, signalTarget{name: "metrics", project: "p", dataset: "d", tableID: "metric", schema: rowconv.MetricsSchema}))
+	assert.Equal(t, []string{http.MethodGet}, fake.requests)
+}
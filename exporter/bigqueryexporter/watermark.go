@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+const watermarkColumn = "watermark"
+
+var watermarkField = &bigquery.FieldSchema{Name: watermarkColumn, Type: bigquery.TimestampFieldType, Required: false}
+
+// watermarks tracks the oldest event timestamp of every batch that is being
+// exported to one table. A row's watermark is the oldest event timestamp of
+// all batches in flight when its batch started, so rows with older events
+// may still arrive until the watermark has passed them.
+//
+// The watermark is best-effort: it only sees batches while they are being
+// appended. Batches waiting in the sending queue or in retry backoff are
+// not tracked, and a failed batch stops counting when its append returns,
+// so its rows may arrive later below the watermark of other rows.
+type watermarks struct {
+	mu       sync.Mutex
+	next     uint64
+	inFlight map[uint64]time.Time
+}
+
+func newWatermarks() *watermarks {
+	return &watermarks{inFlight: make(map[uint64]time.Time)}
+}
+
+// begin registers the batch rows, whose event timestamps are held in
+// columns, and sets the watermark column of every row. end must be called
+// once the batch has been written or has failed; until then the batch
+// holds back the watermark of other batches, as described on watermarks.
+// begin is a no-op on a nil receiver, so callers need not check whether
+// the column is enabled.
+func (w *watermarks) begin(rows []row, columns ...string) (end func()) {
+	if w == nil || len(rows) == 0 {
+		return func() {}
+	}
+	oldest, ok := oldestTimestamp(rows, columns)
+
+	w.mu.Lock()
+	id := w.next
+	w.next++
+	if ok {
+		w.inFlight[id] = oldest
+	}
+	var watermark bigquery.Value
+	for _, ts := range w.inFlight {
+		if !ok || ts.Before(oldest) {
+			oldest, ok = ts, true
+		}
+	}
+	if ok {
+		watermark = oldest
+	}
+	w.mu.Unlock()
+
+	for _, r := range rows {
+		r[watermarkColumn] = watermark
+	}
+	return func() {
+		w.mu.Lock()
+		delete(w.inFlight, id)
+		w.mu.Unlock()
+	}
+}
+
+// oldestTimestamp returns the oldest timestamp in columns of rows. For each
+// row the first column holding a timestamp after the Unix epoch is used, so
+// unset timestamps neither count nor hide a fallback column.
+func oldestTimestamp(rows []row, columns []string) (time.Time, bool) {
+	var (
+		oldest time.Time
+		found  bool
+	)
+	for _, r := range rows {
+		for _, column := range columns {
+			ts, ok := r[column].(time.Time)
+			if !ok || ts.UnixNano() <= 0 {
+				continue
+			}
+			if !found || ts.Before(oldest) {
+				oldest, found = ts, true
+			}
+			break
+		}
+	}
+	return oldest, found
+}
+
+// withWatermark returns schema with the watermark column appended when
+// enabled.
+func withWatermark(schema bigquery.Schema, enabled bool) bigquery.Schema {
+	if !enabled {
+		return schema
+	}
+	return append(slices.Clip(schema), watermarkField)
+}
@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestWatermarks(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	w := newWatermarks()
+
+	slow := []row{{"start_time": base.Add(time.Minute)}, {"start_time": base}}
+	endSlow := w.begin(slow, "start_time")
+	assert.Equal(t, base, slow[0][watermarkColumn])
+	assert.Equal(t, base, slow[1][watermarkColumn])
+
+	// A batch with newer events started while the slow batch is in flight
+	// is held back to the slow batch's oldest event.
+	fast := []row{{"start_time": base.Add(time.Hour)}}
+	w.begin(fast, "start_time")()
+	assert.Equal(t, base, fast[0][watermarkColumn])
+
+	endSlow()
+	next := []row{{"start_time": base.Add(2 * time.Hour)}}
+	w.begin(next, "start_time")()
+	assert.Equal(t, base.Add(2*time.Hour), next[0][watermarkColumn])
+	assert.Empty(t, w.inFlight)
+}
+
+func TestWatermarksFallbackColumn(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	w := newWatermarks()
+
+	rows := []row{
+		{"log_timestamp": time.Unix(0, 0).UTC(), "observed_timestamp": base},
+		{"log_timestamp": base.Add(time.Minute), "observed_timestamp": base.Add(-time.Hour)},
+	}
+	w.begin(rows, "log_timestamp", "observed_timestamp")()
+	assert.Equal(t, base, rows[0][watermarkColumn])
+
+	// Rows without any timestamp get no watermark.
+	rows = []row{{"log_timestamp": time.Unix(0, 0).UTC(), "observed_timestamp": time.Unix(0, 0).UTC()}}
+	w.begin(rows, "log_timestamp", "observed_timestamp")()
+	assert.Contains(t, rows[0], watermarkColumn)
+	assert.Nil(t, rows[0][watermarkColumn])
+}
+
+func TestWatermarksDisabled(t *testing.T) {
+	var w *watermarks
+	rows := []row{{"start_time": time.Now()}}
+	w.begin(rows, "start_time")()
+	assert.NotContains(t, rows[0], watermarkColumn)
+}
+
+func TestWithWatermark(t *testing.T) {
+	assert.Equal(t, rowconv.LogsSchema, withWatermark(rowconv.LogsSchema, false))
+
+	schema := withWatermark(rowconv.LogsSchema, true)
+	assert.Len(t, schema, len(rowconv.LogsSchema)+1)
+	assert.Equal(t, bigquery.TimestampFieldType, schemaField(schema, watermarkColumn).Type)
+	assert.Nil(t, schemaField(rowconv.LogsSchema, watermarkColumn))
+}
@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter"
+
+import (
+	"fmt"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+)
+
+const (
+	wideEventsSignal = "wide_events"
+	signalTypeColumn = "signal_type"
+)
+
+// signalTypeField tells the rows of the wide events table apart. It holds
+// the signal of the row: traces, metrics or logs.
+var signalTypeField = &bigquery.FieldSchema{Name: signalTypeColumn, Type: bigquery.StringFieldType, Required: true}
+
+// wideEventsSchema returns the schema of the wide events table: the
+// signalTypeField followed by the columns of schemas, in order and without
+// duplicates. The columns are NULLABLE, since rows only set the columns of
+// their signal. Columns several schemas share must have the same type and
+// mode.
+func wideEventsSchema(schemas ...bigquery.Schema) (bigquery.Schema, error) {
+	var wide bigquery.Schema
+	for _, schema := range schemas {
+		if diff := diffSchemas(schema, wide).incompatible(); len(diff) > 0 {
+			return nil, fmt.Errorf("column %s is %s in one signal table and %s in another", diff[0].column, diff[0].table, diff[0].want)
+		}
+		wide, _ = mergeMissingColumns(schema, wide)
+	}
+	return slices.Concat(bigquery.Schema{signalTypeField}, wide), nil
+}
+
+// wideEventsTarget returns the target of the wide events table, which
+// replaces the traces, metrics and logs targets.
+func (e *bigQueryExporter) wideEventsTarget(targets []signalTarget) signalTarget {
+	schemas := make([]bigquery.Schema, len(targets))
+	for i, target := range targets {
+		schemas[i] = target.schema
+	}
+	// The signal schemas are checked with the configuration.
+	schema, _ := wideEventsSchema(schemas...)
+	return signalTarget{
+		name:             wideEventsSignal,
+		project:          e.project,
+		credentials:      e.cfg.Dataset.Credentials,
+		dataset:          e.cfg.Dataset.ID,
+		schema:           schema,
+		appender:         &e.wideEventsAppender,
+		clusteringFields: e.cfg.WideEvents.ClusteringFields,
+		partitioning:     PartitioningConfig{Granularity: string(bigquery.DayPartitioningType)},
+		watermark:        e.cfg.Watermark,
+		eventDate:        e.cfg.EventDate.location(),
+	}
+}
+
+// setSignalTypes sets the signal_type column of rows to signal.
+func setSignalTypes(rows []row, signal string) {
+	for _, r := range rows {
+		r[signalTypeColumn] = signal
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/bigqueryexporter/internal/metadata"
+)
+
+func TestWideEventsSchema(t *testing.T) {
+	schema, err := wideEventsSchema(
+		bigquery.Schema{
+			{Name: "trace_id", Type: bigquery.StringFieldType, Required: true},
+			{Name: "span_name", Type: bigquery.StringFieldType},
+		},
+		bigquery.Schema{
+			{Name: "metric_name", Type: bigquery.StringFieldType, Required: true},
+		},
+		bigquery.Schema{
+			{Name: "trace_id", Type: bigquery.StringFieldType},
+			{Name: "body", Type: bigquery.StringFieldType},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, bigquery.Schema{
+		signalTypeField,
+		{Name: "trace_id", Type: bigquery.StringFieldType},
+		{Name: "span_name", Type: bigquery.StringFieldType},
+		{Name: "metric_name", Type: bigquery.StringFieldType},
+		{Name: "body", Type: bigquery.StringFieldType},
+	}, schema)
+
+	_, err = wideEventsSchema(
+		bigquery.Schema{{Name: "tenant", Type: bigquery.StringFieldType}},
+		bigquery.Schema{{Name: "tenant", Type: bigquery.IntegerFieldType}},
+	)
+	assert.EqualError(t, err, "column tenant is STRING NULLABLE in one signal table and INTEGER NULLABLE in another")
+}
+
+func TestSignalTargetsWideEvents(t *testing.T) {
+	cfg := createDefaultConfig()
+	cfg.Dataset.ID = "otel"
+	cfg.Watermark = true
+	cfg.WideEvents.Enabled = true
+	cfg.Logs.EntityEvents = true
+	exp := newBigQueryExporter(t.Context(), cfg, exportertest.NewNopSettings(metadata.Type))
+	exp.project = "p"
+
+	targets := map[string]signalTarget{}
+	for _, target := range exp.signalTargets() {
+		targets[target.name] = target
+	}
+	require.Len(t, targets, 2)
+	assert.Contains(t, targets, "entities")
+	wide := targets[wideEventsSignal]
+	assert.Equal(t, "wide_events", wide.tableID)
+	assert.Equal(t, "p", wide.project)
+	assert.Equal(t, "otel", wide.dataset)
+	assert.Same(t, &exp.wideEventsAppender, wide.appender)
+	assert.Equal(t, []string{signalTypeColumn}, wide.clusteringFields)
+	assert.True(t, wide.watermark)
+	assert.Equal(t, signalTypeField, wide.schema[0])
+	for _, column := range []string{"start_time", "datapoint_timestamp", "log_timestamp", watermarkColumn} {
+		assert.NotNil(t, schemaField(wide.schema, column), column)
+	}
+	for _, field := range wide.schema[1:] {
+		assert.False(t, field.Required, field.Name)
+	}
+}
+
+func TestSetSignalTypes(t *testing.T) {
+	rows := []row{{"span_name": "a"}, {"span_name": "b"}}
+	setSignalTypes(rows, "traces")
+	assert.Equal(t, []row{
+		{"span_name": "a", signalTypeColumn: "traces"},
+		{"span_name": "b", signalTypeColumn: "traces"},
+	}, rows)
+}
@@ -0,0 +1,185 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bigqueryexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeWriteServer is an in-memory Storage Write API. Streams are created,
+// appended to, finalized and committed the way BigQuery does, and the hooks
+// inject failures.
+type fakeWriteServer struct {
+	storagepb.UnimplementedBigQueryWriteServer
+
+	mu      sync.Mutex
+	streams map[string]*fakeWriteStream
+	created []string
+	// appendResponse, when set, replaces the response to an append of
+	// rows to stream. Returning nil acknowledges the append.
+	appendResponse func(stream string, rows [][]byte) *storagepb.AppendRowsResponse
+	// commitResponse, when set, is called before streams are committed.
+	// The streams are committed unless it returns an error or a response.
+	commitResponse func(streams []string) (*storagepb.BatchCommitWriteStreamsResponse, error)
+}
+
+type fakeWriteStream struct {
+	typ       storagepb.WriteStream_Type
+	rows      [][]byte
+	finalized bool
+	committed bool
+}
+
+// newFakeWriteClient starts srv and returns a Storage Write client
+// connected to it.
+func newFakeWriteClient(t *testing.T, srv *fakeWriteServer) *managedwriter.Client {
+	client, err := managedwriter.NewClient(t.Context(), "p", startFakeWriteServer(t, srv)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// startFakeWriteServer starts srv and returns the options of clients
+// connecting to it.
+func startFakeWriteServer(t *testing.T, srv *fakeWriteServer) []option.ClientOption {
+	srv.streams = map[string]*fakeWriteStream{}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	// Requests are up to maxAppendRequestBytes, like those BigQuery takes.
+	gsrv := grpc.NewServer(grpc.MaxRecvMsgSize(2 * maxAppendRequestBytes))
+	storagepb.RegisterBigQueryWriteServer(gsrv, srv)
+	go func() { _ = gsrv.Serve(lis) }()
+	t.Cleanup(gsrv.Stop)
+	return []option.ClientOption{
+		option.WithEndpoint(lis.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+}
+
+// committedRows returns the rows visible in the table: those of committed
+// pending streams and of default streams.
+func (s *fakeWriteServer) committedRows() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows [][]byte
+	for _, name := range s.created {
+		if stream := s.streams[name]; stream.committed || stream.typ == storagepb.WriteStream_COMMITTED {
+			rows = append(rows, stream.rows...)
+		}
+	}
+	return rows
+}
+
+// tableRows returns the rows appended to the default stream of table.
+func (s *fakeWriteServer) tableRows(table string) [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream("projects/p/datasets/d/tables/" + table + "/streams/_default").rows
+}
+
+func (s *fakeWriteServer) stream(name string) *fakeWriteStream {
+	stream, ok := s.streams[name]
+	if !ok {
+		// Default streams exist without being created.
+		stream = &fakeWriteStream{typ: storagepb.WriteStream_COMMITTED}
+		s.streams[name] = stream
+		s.created = append(s.created, name)
+	}
+	return stream
+}
+
+func (s *fakeWriteServer) CreateWriteStream(_ context.Context, req *storagepb.CreateWriteStreamRequest) (*storagepb.WriteStream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := fmt.Sprintf("%s/streams/s%d", req.GetParent(), len(s.created))
+	s.streams[name] = &fakeWriteStream{typ: req.GetWriteStream().GetType()}
+	s.created = append(s.created, name)
+	return &storagepb.WriteStream{Name: name, Type: req.GetWriteStream().GetType()}, nil
+}
+
+func (*fakeWriteServer) GetWriteStream(_ context.Context, req *storagepb.GetWriteStreamRequest) (*storagepb.WriteStream, error) {
+	return &storagepb.WriteStream{Name: req.GetName(), Location: "us"}, nil
+}
+
+func (s *fakeWriteServer) AppendRows(srv storagepb.BigQueryWrite_AppendRowsServer) error {
+	var name string
+	for {
+		req, err := srv.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetWriteStream() != "" {
+			name = req.GetWriteStream()
+		}
+		rows := req.GetProtoRows().GetRows().GetSerializedRows()
+		var resp *storagepb.AppendRowsResponse
+		if s.appendResponse != nil {
+			resp = s.appendResponse(name, rows)
+		}
+		if resp == nil {
+			s.mu.Lock()
+			stream := s.stream(name)
+			offset := int64(len(stream.rows))
+			stream.rows = append(stream.rows, rows...)
+			s.mu.Unlock()
+			result := &storagepb.AppendRowsResponse_AppendResult{}
+			if stream.typ == storagepb.WriteStream_PENDING {
+				result.Offset = wrapperspb.Int64(offset)
+			}
+			resp = &storagepb.AppendRowsResponse{Response: &storagepb.AppendRowsResponse_AppendResult_{AppendResult: result}}
+		}
+		resp.WriteStream = name
+		if err := srv.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *fakeWriteServer) FinalizeWriteStream(_ context.Context, req *storagepb.FinalizeWriteStreamRequest) (*storagepb.FinalizeWriteStreamResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream := s.stream(req.GetName())
+	stream.finalized = true
+	return &storagepb.FinalizeWriteStreamResponse{RowCount: int64(len(stream.rows))}, nil
+}
+
+func (s *fakeWriteServer) BatchCommitWriteStreams(_ context.Context, req *storagepb.BatchCommitWriteStreamsRequest) (*storagepb.BatchCommitWriteStreamsResponse, error) {
+	if s.commitResponse != nil {
+		if resp, err := s.commitResponse(req.GetWriteStreams()); err != nil || resp != nil {
+			return resp, err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range req.GetWriteStreams() {
+		if stream := s.stream(name); !stream.finalized || stream.committed {
+			return &storagepb.BatchCommitWriteStreamsResponse{StreamErrors: []*storagepb.StorageError{{
+				Code:   storagepb.StorageError_INVALID_STREAM_STATE,
+				Entity: name,
+			}}}, nil
+		}
+	}
+	for _, name := range req.GetWriteStreams() {
+		s.streams[name].committed = true
+	}
+	return &storagepb.BatchCommitWriteStreamsResponse{}, nil
+}
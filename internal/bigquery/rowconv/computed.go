@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ComputedColumn is a column whose value is computed from the record a row
+// is converted from, e.g. by an OTTL expression.
+type ComputedColumn struct {
+	// Column is the name of the NULLABLE column.
+	Column string
+	// Type is the type of the column: STRING, INTEGER, FLOAT or BOOLEAN.
+	// Values are converted like those of promoted attributes.
+	Type bigquery.FieldType
+}
+
+// ComputedFields returns the columns of computed.
+func ComputedFields(computed []ComputedColumn) bigquery.Schema {
+	schema := make(bigquery.Schema, 0, len(computed))
+	for _, c := range computed {
+		schema = append(schema, &bigquery.FieldSchema{Name: c.Column, Type: c.Type, Required: false})
+	}
+	return schema
+}
+
+// SpanValues returns the values of the computed columns of a span, in the
+// order of the columns.
+type SpanValues func(rs ptrace.ResourceSpans, ss ptrace.ScopeSpans, span ptrace.Span) []pcommon.Value
+
+// DataPointValues returns the values of the computed columns of a data
+// point, one of the data point types of pmetric, in the order of the
+// columns.
+type DataPointValues func(rm pmetric.ResourceMetrics, sm pmetric.ScopeMetrics, metric pmetric.Metric, dataPoint any) []pcommon.Value
+
+// LogValues returns the values of the computed columns of a log record, in
+// the order of the columns.
+type LogValues func(rl plog.ResourceLogs, sl plog.ScopeLogs, lr plog.LogRecord) []pcommon.Value
+
+// setComputedColumns sets the columns of computed to values. Empty values
+// and values that cannot be converted to the column type are NULL.
+func setComputedColumns(r Row, computed []ComputedColumn, values []pcommon.Value) {
+	for i, c := range computed {
+		r[c.Column] = promotedValue(values[i], c.Type)
+	}
+}
+
+// dataPoints returns the data points of metric.
+func dataPoints(metric pmetric.Metric) []any {
+	var dps []any
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for _, dp := range metric.Gauge().DataPoints().All() {
+			dps = append(dps, dp)
+		}
+	case pmetric.MetricTypeSum:
+		for _, dp := range metric.Sum().DataPoints().All() {
+			dps = append(dps, dp)
+		}
+	case pmetric.MetricTypeHistogram:
+		for _, dp := range metric.Histogram().DataPoints().All() {
+			dps = append(dps, dp)
+		}
+	case pmetric.MetricTypeSummary:
+		for _, dp := range metric.Summary().DataPoints().All() {
+			dps = append(dps, dp)
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for _, dp := range metric.ExponentialHistogram().DataPoints().All() {
+			dps = append(dps, dp)
+		}
+	}
+	return dps
+}
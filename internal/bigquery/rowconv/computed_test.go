@@ -0,0 +1,92 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+var testComputed = []ComputedColumn{
+	{Column: "tenant", Type: bigquery.StringFieldType},
+	{Column: "is_error", Type: bigquery.BooleanFieldType},
+}
+
+func TestComputedFields(t *testing.T) {
+	schema := ComputedFields(testComputed)
+	require.Len(t, schema, 2)
+	assert.Equal(t, "is_error", schema[1].Name)
+	assert.Equal(t, bigquery.BooleanFieldType, schema[1].Type)
+	assert.False(t, schema[1].Required)
+	assert.Empty(t, ComputedFields(nil))
+}
+
+func TestTracesToRowsComputedColumns(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("tenant", "acme")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	spans.AppendEmpty().Status().SetCode(ptrace.StatusCodeError)
+	spans.AppendEmpty()
+
+	values := func(rs ptrace.ResourceSpans, _ ptrace.ScopeSpans, span ptrace.Span) []pcommon.Value {
+		tenant, _ := rs.Resource().Attributes().Get("tenant")
+		return []pcommon.Value{tenant, pcommon.NewValueBool(span.Status().Code() == ptrace.StatusCodeError)}
+	}
+	rows := Traces(td, TracesOptions{ComputedColumns: testComputed, ComputedValues: values})
+	require.Len(t, rows, 2)
+	assert.Equal(t, "acme", rows[0]["tenant"])
+	assert.Equal(t, true, rows[0]["is_error"])
+	assert.Equal(t, false, rows[1]["is_error"])
+
+	assert.NotContains(t, Traces(td, TracesOptions{ComputedColumns: testComputed})[0], "tenant")
+}
+
+func TestMetricsToRowsComputedColumns(t *testing.T) {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	dps := metrics.AppendEmpty().SetEmptySum().DataPoints()
+	dps.AppendEmpty().Attributes().PutStr("tenant", "a")
+	dps.AppendEmpty().Attributes().PutStr("tenant", "b")
+	metrics.AppendEmpty().SetEmptySummary().DataPoints().AppendEmpty().Attributes().PutStr("tenant", "c")
+
+	values := func(_ pmetric.ResourceMetrics, _ pmetric.ScopeMetrics, _ pmetric.Metric, dataPoint any) []pcommon.Value {
+		var attrs pcommon.Map
+		switch dp := dataPoint.(type) {
+		case pmetric.NumberDataPoint:
+			attrs = dp.Attributes()
+		case pmetric.SummaryDataPoint:
+			attrs = dp.Attributes()
+		}
+		tenant, _ := attrs.Get("tenant")
+		return []pcommon.Value{tenant, pcommon.NewValueEmpty()}
+	}
+	rows := Metrics(md, MetricsOptions{ComputedColumns: testComputed, ComputedValues: values})
+	require.Len(t, rows, 3)
+	for i, want := range []string{"a", "b", "c"} {
+		assert.Equal(t, want, rows[i]["tenant"])
+		assert.Contains(t, rows[i], "is_error")
+		assert.Nil(t, rows[i]["is_error"])
+	}
+}
+
+func TestLogsToRowsComputedColumns(t *testing.T) {
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+
+	values := func(plog.ResourceLogs, plog.ScopeLogs, plog.LogRecord) []pcommon.Value {
+		return []pcommon.Value{pcommon.NewValueInt(42), pcommon.NewValueStr("true")}
+	}
+	rows := Logs(ld, LogsOptions{ComputedColumns: testComputed, ComputedValues: values})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "42", rows[0]["tenant"])
+	assert.Equal(t, true, rows[0]["is_error"])
+}
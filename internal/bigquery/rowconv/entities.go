@@ -0,0 +1,85 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// Entity events are carried as log records in scopes marked with the
+// otel.entity.event_as_log attribute, see pkg/experimentalmetricmetadata.
+const (
+	entityEventAsLogScopeAttr = "otel.entity.event_as_log"
+	entityEventTypeAttr       = "otel.entity.event.type"
+	entityIDAttr              = "otel.entity.id"
+	entityTypeAttr            = "otel.entity.type"
+	entityIntervalAttr        = "otel.entity.interval"
+	entityAttributesAttr      = "otel.entity.attributes"
+)
+
+// EntitiesSchema is the schema of the table of entity events.
+var EntitiesSchema = bigquery.Schema{
+	{Name: "event_timestamp", Type: bigquery.TimestampFieldType, Required: false},
+	{Name: "event_type", Type: bigquery.StringFieldType, Required: false},
+	{Name: "entity_type", Type: bigquery.StringFieldType, Required: false},
+	{Name: "entity_id", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "entity_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "interval_ms", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "resource_attributes", Type: bigquery.JSONFieldType, Required: false},
+}
+
+// isEntityEventScope reports whether the log records of sl are entity events.
+func isEntityEventScope(sl plog.ScopeLogs) bool {
+	v, ok := sl.Scope().Attributes().Get(entityEventAsLogScopeAttr)
+	return ok && v.Type() == pcommon.ValueTypeBool && v.Bool()
+}
+
+// EntityEvents converts the entity events carried as log records into rows
+// of the EntitiesSchema table.
+func EntityEvents(ld plog.Logs) []Row {
+	var rows []Row
+	for _, rl := range ld.ResourceLogs().All() {
+		for _, sl := range rl.ScopeLogs().All() {
+			if !isEntityEventScope(sl) {
+				continue
+			}
+			for _, lr := range sl.LogRecords().All() {
+				attrs := lr.Attributes()
+				r := Row{
+					"event_timestamp":     lr.Timestamp().AsTime(),
+					"event_type":          stringAttribute(attrs, entityEventTypeAttr),
+					"entity_type":         stringAttribute(attrs, entityTypeAttr),
+					"entity_id":           mapAttributeToJSON(attrs, entityIDAttr),
+					"entity_attributes":   mapAttributeToJSON(attrs, entityAttributesAttr),
+					"interval_ms":         nil,
+					"resource_attributes": attributesToJSON(rl.Resource().Attributes()),
+				}
+				if v, ok := attrs.Get(entityIntervalAttr); ok && v.Type() == pcommon.ValueTypeInt {
+					r["interval_ms"] = v.Int()
+				}
+				rows = append(rows, r)
+			}
+		}
+	}
+	return rows
+}
+
+func stringAttribute(attrs pcommon.Map, key string) string {
+	if v, ok := attrs.Get(key); ok {
+		return v.AsString()
+	}
+	return ""
+}
+
+// mapAttributeToJSON serializes the map attribute key, or an empty object
+// when it is missing. The experimentalmetricmetadata accessors are not used
+// because they add missing maps, and exporters must not modify their input.
+func mapAttributeToJSON(attrs pcommon.Map, key string) string {
+	if v, ok := attrs.Get(key); ok && v.Type() == pcommon.ValueTypeMap {
+		return attributesToJSON(v.Map())
+	}
+	return "{}"
+}
@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
+)
+
+func generateEntityEvents() plog.Logs {
+	events := experimentalmetricmetadata.NewEntityEventsSlice()
+
+	state := events.AppendEmpty()
+	state.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000000, 0)))
+	state.ID().PutStr("k8s.pod.uid", "pod-1")
+	details := state.SetEntityState()
+	details.SetEntityType("k8s.pod")
+	details.SetInterval(5 * time.Minute)
+	details.Attributes().PutStr("k8s.pod.phase", "Running")
+
+	deleted := events.AppendEmpty()
+	deleted.ID().PutStr("k8s.pod.uid", "pod-2")
+	deleted.SetEntityDelete().SetEntityType("k8s.pod")
+
+	ld := events.ConvertAndMoveToLogs()
+	ld.ResourceLogs().At(0).Resource().Attributes().PutStr("k8s.cluster.name", "prod")
+	return ld
+}
+
+func TestEntityEventsToRows(t *testing.T) {
+	ld := generateEntityEvents()
+	ld.MarkReadOnly()
+
+	rows := EntityEvents(ld)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "entity_state", rows[0]["event_type"])
+	assert.Equal(t, "k8s.pod", rows[0]["entity_type"])
+	assert.JSONEq(t, `{"k8s.pod.uid":"pod-1"}`, rows[0]["entity_id"].(string))
+	assert.JSONEq(t, `{"k8s.pod.phase":"Running"}`, rows[0]["entity_attributes"].(string))
+	assert.Equal(t, int64(300000), rows[0]["interval_ms"])
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), rows[0]["event_timestamp"])
+	assert.JSONEq(t, `{"k8s.cluster.name":"prod"}`, rows[0]["resource_attributes"].(string))
+
+	assert.Equal(t, "entity_delete", rows[1]["event_type"])
+	assert.Equal(t, "{}", rows[1]["entity_attributes"])
+	assert.Nil(t, rows[1]["interval_ms"])
+}
+
+func TestLogsToRowsSkipsEntityEvents(t *testing.T) {
+	ld := generateEntityEvents()
+	ld.ResourceLogs().At(0).ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("regular")
+
+	assert.Len(t, Logs(ld, LogsOptions{}), 3)
+	rows := Logs(ld, LogsOptions{EntityEvents: true})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "regular", rows[0]["body"])
+}
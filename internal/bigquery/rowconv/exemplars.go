@@ -0,0 +1,74 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"encoding/base64"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// ExemplarSpansColumn is the repeated RECORD column holding the trace and
+// span IDs of the exemplars of a data point, written with
+// MetricsOptions.ExemplarSpans.
+const ExemplarSpansColumn = "exemplar_spans"
+
+// ExemplarSpansField is the ExemplarSpansColumn field. Its IDs are hex
+// STRING values like the trace_id and span_id columns of the traces table.
+var ExemplarSpansField = &bigquery.FieldSchema{Name: ExemplarSpansColumn, Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+	{Name: "trace_id", Type: bigquery.StringFieldType},
+	{Name: "span_id", Type: bigquery.StringFieldType},
+}}
+
+// dataPointExemplars returns the exemplars of the data points of metric.
+// Summaries have none.
+func dataPointExemplars(metric pmetric.Metric) []pmetric.ExemplarSlice {
+	var exemplars []pmetric.ExemplarSlice
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for _, dp := range metric.Gauge().DataPoints().All() {
+			exemplars = append(exemplars, dp.Exemplars())
+		}
+	case pmetric.MetricTypeSum:
+		for _, dp := range metric.Sum().DataPoints().All() {
+			exemplars = append(exemplars, dp.Exemplars())
+		}
+	case pmetric.MetricTypeHistogram:
+		for _, dp := range metric.Histogram().DataPoints().All() {
+			exemplars = append(exemplars, dp.Exemplars())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for _, dp := range metric.ExponentialHistogram().DataPoints().All() {
+			exemplars = append(exemplars, dp.Exemplars())
+		}
+	}
+	return exemplars
+}
+
+// exemplarSpansColumn serializes the IDs of the exemplars with a trace ID
+// as records of the ExemplarSpansColumn. IDs are hex encoded, or base64
+// encoded for BYTES fields when bytesIDs is set.
+func exemplarSpansColumn(exemplars pmetric.ExemplarSlice, bytesIDs bool) string {
+	spans := make([]map[string]string, 0, exemplars.Len())
+	for _, ex := range exemplars.All() {
+		if ex.TraceID().IsEmpty() {
+			continue
+		}
+		traceID, spanID := ex.TraceID(), ex.SpanID()
+		span := map[string]string{
+			"trace_id": traceIDToHex(traceID),
+			"span_id":  spanIDToHex(spanID),
+		}
+		if bytesIDs {
+			span["trace_id"] = base64.StdEncoding.EncodeToString(traceID[:])
+			span["span_id"] = ""
+			if !spanID.IsEmpty() {
+				span["span_id"] = base64.StdEncoding.EncodeToString(spanID[:])
+			}
+		}
+		spans = append(spans, span)
+	}
+	return marshalJSON(spans)
+}
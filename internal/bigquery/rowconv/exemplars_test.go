@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func exemplarMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	dp := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyHistogram().DataPoints().AppendEmpty()
+	ex := dp.Exemplars().AppendEmpty()
+	ex.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	ex.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	// Exemplars without a trace ID cannot be joined to a span.
+	dp.Exemplars().AppendEmpty().SetDoubleValue(1)
+	return md
+}
+
+func TestMetricsToRowsExemplarSpans(t *testing.T) {
+	rows := Metrics(exemplarMetrics(), MetricsOptions{ExemplarSpans: true})
+	require.Len(t, rows, 1)
+	assert.JSONEq(t, `[{"trace_id":"0102030405060708090a0b0c0d0e0f10","span_id":"0102030405060708"}]`, rows[0][ExemplarSpansColumn].(string))
+
+	rows = Metrics(exemplarMetrics(), MetricsOptions{ExemplarSpans: true, ExemplarSpanIDsAsBytes: true})
+	assert.JSONEq(t, `[{"trace_id":"AQIDBAUGBwgJCgsMDQ4PEA==","span_id":"AQIDBAUGBwg="}]`, rows[0][ExemplarSpansColumn].(string))
+
+	assert.NotContains(t, Metrics(exemplarMetrics(), MetricsOptions{})[0], ExemplarSpansColumn)
+}
+
+func TestMetricsToRowsExemplarSpansWithoutExemplars(t *testing.T) {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	metrics.AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+	metrics.AppendEmpty().SetEmptySummary().DataPoints().AppendEmpty()
+	rows := Metrics(md, MetricsOptions{ExemplarSpans: true})
+	require.Len(t, rows, 2)
+	assert.Equal(t, "[]", rows[0][ExemplarSpansColumn])
+	assert.NotContains(t, rows[1], ExemplarSpansColumn)
+}
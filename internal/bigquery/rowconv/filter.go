@@ -0,0 +1,90 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"regexp"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// AttributeFilter selects the attributes written to an attribute column.
+// An attribute is written when it matches the include lists, or they are
+// both empty, and matches neither exclude list. A nil filter writes every
+// attribute.
+type AttributeFilter struct {
+	// Include and Exclude hold exact attribute keys.
+	Include map[string]bool
+	Exclude map[string]bool
+	// IncludePatterns and ExcludePatterns match attribute keys.
+	IncludePatterns []*regexp.Regexp
+	ExcludePatterns []*regexp.Regexp
+}
+
+// AttributeFilters are the filters of the attribute columns of a signal.
+type AttributeFilters struct {
+	// Resource filters the resource_attributes column.
+	Resource *AttributeFilter
+	// Scope filters the attributes of the instrumentation_scope column.
+	Scope *AttributeFilter
+	// Record filters the attributes of spans, data points or log records.
+	Record *AttributeFilter
+}
+
+// keep reports whether the attribute key is written.
+func (f *AttributeFilter) keep(key string) bool {
+	if len(f.Include) > 0 || len(f.IncludePatterns) > 0 {
+		if !f.Include[key] && !matchesAny(f.IncludePatterns, key) {
+			return false
+		}
+	}
+	return !f.Exclude[key] && !matchesAny(f.ExcludePatterns, key)
+}
+
+func matchesAny(patterns []*regexp.Regexp, key string) bool {
+	for _, p := range patterns {
+		if p.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the attributes of attrs the filter keeps, as raw values.
+func (f *AttributeFilter) filter(attrs pcommon.Map) map[string]any {
+	raw := make(map[string]any, attrs.Len())
+	for k, v := range attrs.All() {
+		if f.keep(k) {
+			raw[k] = v.AsRaw()
+		}
+	}
+	return raw
+}
+
+// attributesToJSON serializes the attributes of attrs the filter keeps.
+func (f *AttributeFilter) attributesToJSON(attrs pcommon.Map) string {
+	if f == nil {
+		return attributesToJSON(attrs)
+	}
+	raw := f.filter(attrs)
+	if len(raw) == 0 {
+		return "{}"
+	}
+	return marshalJSON(raw)
+}
+
+// scopeToJSON serializes scope with the attributes the filter keeps.
+func (f *AttributeFilter) scopeToJSON(scope pcommon.InstrumentationScope) string {
+	if f == nil {
+		return scopeToJSON(scope)
+	}
+	m := map[string]any{
+		"name":    scope.Name(),
+		"version": scope.Version(),
+	}
+	if raw := f.filter(scope.Attributes()); len(raw) > 0 {
+		m["attributes"] = raw
+	}
+	return marshalJSON(m)
+}
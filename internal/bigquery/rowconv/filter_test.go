@@ -0,0 +1,161 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestAttributeFilterKeep(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter AttributeFilter
+		keep   []string
+		drop   []string
+	}{
+		{
+			name:   "exclude",
+			filter: AttributeFilter{Exclude: map[string]bool{"secret": true}, ExcludePatterns: []*regexp.Regexp{regexp.MustCompile(`^http\.request\.header\.`)}},
+			keep:   []string{"service.name", "http.request.method"},
+			drop:   []string{"secret", "http.request.header.authorization"},
+		},
+		{
+			name:   "include",
+			filter: AttributeFilter{Include: map[string]bool{"service.name": true}, IncludePatterns: []*regexp.Regexp{regexp.MustCompile(`^k8s\.`)}},
+			keep:   []string{"service.name", "k8s.pod.name"},
+			drop:   []string{"process.command_line"},
+		},
+		{
+			name:   "exclude wins",
+			filter: AttributeFilter{IncludePatterns: []*regexp.Regexp{regexp.MustCompile(`^k8s\.`)}, Exclude: map[string]bool{"k8s.pod.uid": true}},
+			keep:   []string{"k8s.pod.name"},
+			drop:   []string{"k8s.pod.uid"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range tt.keep {
+				assert.True(t, tt.filter.keep(key), key)
+			}
+			for _, key := range tt.drop {
+				assert.False(t, tt.filter.keep(key), key)
+			}
+		})
+	}
+}
+
+func TestAttributeFilterJSON(t *testing.T) {
+	attrs := pcommon.NewMap()
+	attrs.PutStr("keep", "v")
+	attrs.PutStr("drop", "v")
+	var none *AttributeFilter
+	assert.JSONEq(t, `{"keep":"v","drop":"v"}`, none.attributesToJSON(attrs))
+
+	filter := &AttributeFilter{Exclude: map[string]bool{"drop": true}}
+	assert.JSONEq(t, `{"keep":"v"}`, filter.attributesToJSON(attrs))
+	all := &AttributeFilter{Exclude: map[string]bool{"drop": true, "keep": true}}
+	assert.Equal(t, "{}", all.attributesToJSON(attrs))
+
+	scope := pcommon.NewInstrumentationScope()
+	scope.SetName("lib")
+	attrs.CopyTo(scope.Attributes())
+	assert.JSONEq(t, `{"name":"lib","version":"","attributes":{"keep":"v"}}`, filter.scopeToJSON(scope))
+	assert.JSONEq(t, `{"name":"lib","version":""}`, all.scopeToJSON(scope))
+}
+
+var testFilters = AttributeFilters{
+	Resource: &AttributeFilter{Exclude: map[string]bool{"host.id": true}},
+	Scope:    &AttributeFilter{Exclude: map[string]bool{"scope.secret": true}},
+	Record:   &AttributeFilter{Include: map[string]bool{"http.status": true}},
+}
+
+// fillFilteredAttributes sets attributes of which testFilters drop one each.
+func fillFilteredAttributes(resource pcommon.Map, scope pcommon.InstrumentationScope, record pcommon.Map) {
+	resource.PutStr("service.name", "checkout")
+	resource.PutStr("host.id", "h1")
+	scope.Attributes().PutStr("scope.secret", "s")
+	record.PutInt("http.status", 200)
+	record.PutStr("http.body", "large")
+}
+
+func assertFilteredRow(t *testing.T, r Row, recordColumn string) {
+	t.Helper()
+	assert.JSONEq(t, `{"service.name":"checkout"}`, r["resource_attributes"].(string))
+	assert.JSONEq(t, `{"name":"","version":""}`, r["instrumentation_scope"].(string))
+	assert.JSONEq(t, `{"http.status":200}`, r[recordColumn].(string))
+}
+
+func TestTracesToRowsAttributeFilters(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	ss := rs.ScopeSpans().AppendEmpty()
+	span := ss.Spans().AppendEmpty()
+	fillFilteredAttributes(rs.Resource().Attributes(), ss.Scope(), span.Attributes())
+
+	rows := Traces(td, TracesOptions{
+		AttributeFilters:   testFilters,
+		PromotedAttributes: []PromotedAttribute{{Source: "span", Key: "http.body", Column: "http_body", Type: "STRING"}},
+	})
+	require.Len(t, rows, 1)
+	assertFilteredRow(t, rows[0], "span_attributes")
+	// Dropped attributes can still be promoted.
+	assert.Equal(t, "large", rows[0]["http_body"])
+}
+
+func TestSpanEventsAndLinksAttributeFilters(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	event := span.Events().AppendEmpty()
+	fillFilteredAttributes(pcommon.NewMap(), pcommon.NewInstrumentationScope(), event.Attributes())
+	link := span.Links().AppendEmpty()
+	fillFilteredAttributes(pcommon.NewMap(), pcommon.NewInstrumentationScope(), link.Attributes())
+
+	events := SpanEvents(td, nil, testFilters.Record)
+	require.Len(t, events, 1)
+	assert.JSONEq(t, `{"http.status":200}`, events[0]["event_attributes"].(string))
+	links := SpanLinks(td, testFilters.Record)
+	require.Len(t, links, 1)
+	assert.JSONEq(t, `{"http.status":200}`, links[0]["link_attributes"].(string))
+
+	rows := Traces(td, TracesOptions{AttributeFilters: testFilters})
+	require.Len(t, rows, 1)
+	assert.JSONEq(t, `[{"timestamp":"1970-01-01T00:00:00Z","name":"","attributes":{"http.status":200},"dropped_attributes_count":0}]`, rows[0]["events"].(string))
+	assert.Contains(t, rows[0]["links"], `"attributes":{"http.status":200}`)
+}
+
+func TestLogsToRowsAttributeFilters(t *testing.T) {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	sl := rl.ScopeLogs().AppendEmpty()
+	lr := sl.LogRecords().AppendEmpty()
+	fillFilteredAttributes(rl.Resource().Attributes(), sl.Scope(), lr.Attributes())
+
+	rows := Logs(ld, LogsOptions{AttributeFilters: testFilters})
+	require.Len(t, rows, 1)
+	assertFilteredRow(t, rows[0], "log_attributes")
+}
+
+func TestMetricsToRowsAttributeFilters(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+	metrics := sm.Metrics()
+	fillFilteredAttributes(rm.Resource().Attributes(), sm.Scope(), metrics.AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().Attributes())
+	fillFilteredAttributes(pcommon.NewMap(), pcommon.NewInstrumentationScope(), metrics.AppendEmpty().SetEmptyHistogram().DataPoints().AppendEmpty().Attributes())
+	fillFilteredAttributes(pcommon.NewMap(), pcommon.NewInstrumentationScope(), metrics.AppendEmpty().SetEmptySummary().DataPoints().AppendEmpty().Attributes())
+
+	rows := Metrics(md, MetricsOptions{AttributeFilters: testFilters})
+	require.Len(t, rows, 3)
+	for _, r := range rows {
+		assertFilteredRow(t, r, "datapoint_attributes")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// DataPointsColumn is the REPEATED RECORD column holding the data points of
+// a metric when MetricsOptions.GroupDataPoints is set.
+const DataPointsColumn = "datapoints"
+
+// MetricColumns are the columns describing a metric rather than one of its
+// data points. With MetricsOptions.GroupDataPoints rows hold them once,
+// together with the earliest datapoint_timestamp, and every other column in
+// the records of DataPointsColumn.
+var MetricColumns = []string{
+	"metric_name",
+	"metric_description",
+	"metric_unit",
+	"normalized_unit",
+	"unit_scale",
+	"metric_type",
+	"aggregation_temporality",
+	"is_monotonic",
+	"resource_attributes",
+	"resource_schema_url",
+	"instrumentation_scope",
+	"scope_schema_url",
+	"scope_name",
+	"scope_version",
+}
+
+// dataPointJSONColumns are the data point columns holding serialized JSON,
+// which is embedded as is in the records of DataPointsColumn.
+var dataPointJSONColumns = []string{"exemplars", "quantiles", "bucket_counts", "explicit_bounds", "datapoint_attributes", ExemplarSpansColumn}
+
+// groupDataPoints returns the row of a metric holding the data point rows
+// of the metric in DataPointsColumn, serialized as JSON like the other
+// nested columns.
+func groupDataPoints(rows []Row) Row {
+	grouped := Row{}
+	for _, column := range MetricColumns {
+		if v, ok := rows[0][column]; ok {
+			grouped[column] = v
+		}
+	}
+	dataPoints := make([]map[string]any, 0, len(rows))
+	var earliest time.Time
+	for i, r := range rows {
+		dataPoint := make(map[string]any, len(r))
+		for column, v := range r {
+			if slices.Contains(MetricColumns, column) {
+				continue
+			}
+			if s, ok := v.(string); ok && slices.Contains(dataPointJSONColumns, column) {
+				v = json.RawMessage(s)
+			}
+			dataPoint[column] = v
+		}
+		dataPoints = append(dataPoints, dataPoint)
+		if ts, ok := r["datapoint_timestamp"].(time.Time); ok && (i == 0 || ts.Before(earliest)) {
+			earliest = ts
+		}
+	}
+	grouped["datapoint_timestamp"] = earliest
+	grouped[DataPointsColumn] = marshalJSON(dataPoints)
+	return grouped
+}
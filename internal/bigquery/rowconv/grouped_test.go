@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestMetricsToRowsGroupDataPoints(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "svc")
+	metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("cpu")
+	dps := gauge.SetEmptyGauge().DataPoints()
+	late := time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC)
+	early := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	dp := dps.AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(late))
+	dp.SetDoubleValue(math.NaN())
+	dp.Attributes().PutStr("cpu", "0")
+	dp = dps.AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(early))
+	dp.SetIntValue(2)
+	hist := metrics.AppendEmpty()
+	hist.SetName("latency")
+	hdp := hist.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.BucketCounts().FromRaw([]uint64{1, 2})
+	hdp.ExplicitBounds().FromRaw([]float64{10})
+	metrics.AppendEmpty().SetName("empty")
+
+	flat := Metrics(md, MetricsOptions{})
+	rows := Metrics(md, MetricsOptions{GroupDataPoints: true})
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "cpu", rows[0]["metric_name"])
+	assert.Equal(t, "GAUGE", rows[0]["metric_type"])
+	assert.Equal(t, flat[0]["resource_attributes"], rows[0]["resource_attributes"])
+	assert.Equal(t, early, rows[0]["datapoint_timestamp"])
+	assert.NotContains(t, rows[0], "value_int")
+	var dataPoints []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(rows[0][DataPointsColumn].(string)), &dataPoints))
+	require.Len(t, dataPoints, 2)
+	assert.Equal(t, "NaN", dataPoints[0]["value_double"])
+	assert.Equal(t, map[string]any{"cpu": "0"}, dataPoints[0]["datapoint_attributes"])
+	assert.Equal(t, late.Format(time.RFC3339Nano), dataPoints[0]["datapoint_timestamp"])
+	assert.InDelta(t, 2, dataPoints[1]["value_int"], 0)
+	assert.NotContains(t, dataPoints[0], "metric_name")
+
+	assert.Equal(t, "latency", rows[1]["metric_name"])
+	require.NoError(t, json.Unmarshal([]byte(rows[1][DataPointsColumn].(string)), &dataPoints))
+	require.Len(t, dataPoints, 1)
+	assert.Equal(t, []any{1.0, 2.0}, dataPoints[0]["bucket_counts"])
+	assert.Equal(t, []any{10.0}, dataPoints[0]["explicit_bounds"])
+}
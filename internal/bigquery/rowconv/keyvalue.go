@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"cmp"
+	"slices"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// KeyValueFields are the subfields of the typed attribute records written
+// to KeyValueAttributeColumns when KeyValueAttributes is set. Each
+// attribute sets the value field of its type; maps, slices and bytes are
+// written to json_value and empty values set no value field.
+var KeyValueFields = bigquery.Schema{
+	{Name: "key", Type: bigquery.StringFieldType},
+	{Name: "string_value", Type: bigquery.StringFieldType},
+	{Name: "int_value", Type: bigquery.IntegerFieldType},
+	{Name: "double_value", Type: bigquery.FloatFieldType},
+	{Name: "bool_value", Type: bigquery.BooleanFieldType},
+	{Name: "json_value", Type: bigquery.JSONFieldType},
+}
+
+// KeyValueAttributeColumns are the attribute columns written as repeated
+// KeyValueFields records when KeyValueAttributes is set. The attributes of
+// the instrumentation scope, span events, span links and exemplars stay
+// JSON.
+var KeyValueAttributeColumns = []string{"resource_attributes", "span_attributes", "datapoint_attributes", "log_attributes"}
+
+// attributesColumn serializes the attributes of attrs the filter keeps as a
+// JSON object, or as the JSON array of their KeyValueFields records when
+// keyValues is set.
+func (f *AttributeFilter) attributesColumn(attrs pcommon.Map, keyValues bool) string {
+	if !keyValues {
+		return f.attributesToJSON(attrs)
+	}
+	records := make([]map[string]any, 0, attrs.Len())
+	for k, v := range attrs.All() {
+		if f == nil || f.keep(k) {
+			records = append(records, keyValueRecord(k, v))
+		}
+	}
+	if len(records) == 0 {
+		return "[]"
+	}
+	// Sorted like the keys of the JSON object, so equal attributes always
+	// serialize the same way.
+	slices.SortFunc(records, func(a, b map[string]any) int {
+		return cmp.Compare(a["key"].(string), b["key"].(string))
+	})
+	return marshalJSON(records)
+}
+
+// keyValueRecord returns the KeyValueFields record of the attribute k.
+func keyValueRecord(k string, v pcommon.Value) map[string]any {
+	record := map[string]any{"key": k}
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		record["string_value"] = v.Str()
+	case pcommon.ValueTypeInt:
+		record["int_value"] = v.Int()
+	case pcommon.ValueTypeDouble:
+		record["double_value"] = v.Double()
+	case pcommon.ValueTypeBool:
+		record["bool_value"] = v.Bool()
+	case pcommon.ValueTypeMap, pcommon.ValueTypeSlice, pcommon.ValueTypeBytes:
+		record["json_value"] = v.AsRaw()
+	}
+	return record
+}
@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestAttributesColumnKeyValues(t *testing.T) {
+	attrs := pcommon.NewMap()
+	attrs.PutStr("str", "value")
+	attrs.PutInt("int", 1)
+	attrs.PutDouble("double", 1)
+	attrs.PutDouble("nan", math.NaN())
+	attrs.PutBool("bool", true)
+	attrs.PutEmptyBytes("bytes").FromRaw([]byte{0x01})
+	attrs.PutEmptyMap("map").PutStr("k", "v")
+	attrs.PutEmptySlice("slice").AppendEmpty().SetInt(2)
+	attrs.PutEmpty("empty")
+
+	want := `[{"bool_value":true,"key":"bool"},` +
+		`{"json_value":"AQ==","key":"bytes"},` +
+		`{"double_value":1,"key":"double"},` +
+		`{"key":"empty"},` +
+		`{"int_value":1,"key":"int"},` +
+		`{"json_value":{"k":"v"},"key":"map"},` +
+		`{"double_value":"NaN","key":"nan"},` +
+		`{"json_value":[2],"key":"slice"},` +
+		`{"key":"str","string_value":"value"}]`
+	var filter *AttributeFilter
+	assert.JSONEq(t, want, filter.attributesColumn(attrs, true))
+	assert.Equal(t, filter.attributesToJSON(attrs), filter.attributesColumn(attrs, false))
+	assert.Equal(t, "[]", filter.attributesColumn(pcommon.NewMap(), true))
+
+	filter = &AttributeFilter{Include: map[string]bool{"int": true}}
+	assert.JSONEq(t, `[{"int_value":1,"key":"int"}]`, filter.attributesColumn(attrs, true))
+}
+
+func TestToRowsKeyValueAttributes(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "svc")
+	rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().Attributes().PutInt("http.status_code", 200)
+	rows := Traces(td, TracesOptions{KeyValueAttributes: true})
+	require.Len(t, rows, 1)
+	assert.JSONEq(t, `[{"key":"service.name","string_value":"svc"}]`, rows[0]["resource_attributes"].(string))
+	assert.JSONEq(t, `[{"key":"http.status_code","int_value":200}]`, rows[0]["span_attributes"].(string))
+
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "svc")
+	rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes().PutBool("retry", true)
+	logRows := Logs(ld, LogsOptions{KeyValueAttributes: true})
+	require.Len(t, logRows, 1)
+	assert.JSONEq(t, `[{"key":"service.name","string_value":"svc"}]`, logRows[0]["resource_attributes"].(string))
+	assert.JSONEq(t, `[{"bool_value":true,"key":"retry"}]`, logRows[0]["log_attributes"].(string))
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "svc")
+	dps := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints()
+	dps.AppendEmpty().Attributes().PutDouble("ratio", 0.5)
+	dps.AppendEmpty()
+	metricRows := Metrics(md, MetricsOptions{KeyValueAttributes: true})
+	require.Len(t, metricRows, 2)
+	assert.JSONEq(t, `[{"key":"service.name","string_value":"svc"}]`, metricRows[0]["resource_attributes"].(string))
+	assert.JSONEq(t, `[{"double_value":0.5,"key":"ratio"}]`, metricRows[0]["datapoint_attributes"].(string))
+	assert.Equal(t, "[]", metricRows[1]["datapoint_attributes"])
+
+	assert.JSONEq(t, `{"service.name":"svc"}`, Metrics(md, MetricsOptions{})[0]["resource_attributes"].(string))
+}
@@ -0,0 +1,167 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestLogsToRows(t *testing.T) {
+	ld := testdata.GenerateLogsOneLogRecord()
+	rows := Logs(ld, LogsOptions{})
+	require.Len(t, rows, 1)
+
+	row := rows[0]
+	assert.Equal(t, "This is a log message", row["body"])
+	assert.Equal(t, "Info", row["severity_text"])
+	assert.NotEmpty(t, row["trace_id"])
+	assert.NotEmpty(t, row["span_id"])
+	assert.Contains(t, row["resource_attributes"].(string), "resource-attr")
+	// New fields
+	assert.IsType(t, int64(0), row["dropped_attributes_count"])
+	assert.Empty(t, row["resource_schema_url"])
+	assert.Empty(t, row["scope_schema_url"])
+}
+
+func TestLogsToRowsMultiple(t *testing.T) {
+	ld := testdata.GenerateLogsManyLogRecordsSameResource(4)
+	rows := Logs(ld, LogsOptions{})
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, "This is a log message", rows[0]["body"])
+	assert.Equal(t, "something happened", rows[1]["body"])
+}
+
+func TestLogsToRowsEmpty(t *testing.T) {
+	assert.Empty(t, Logs(testdata.GenerateLogsNoLogRecords(), LogsOptions{}))
+}
+
+func TestLogsToRowsPartitionTimestamp(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	withTime := records.AppendEmpty()
+	withTime.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(10, 0)))
+	withTime.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Unix(20, 0)))
+	observedOnly := records.AppendEmpty()
+	observedOnly.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Unix(30, 0)))
+
+	rows := Logs(ld, LogsOptions{})
+	require.Len(t, rows, 2)
+	assert.NotContains(t, rows[0], "partition_timestamp")
+
+	rows = Logs(ld, LogsOptions{PartitionTimestamp: PartitionTimestampEvent})
+	require.Len(t, rows, 2)
+	assert.Equal(t, time.Unix(10, 0).UTC(), rows[0]["partition_timestamp"])
+	assert.Equal(t, time.Unix(30, 0).UTC(), rows[1]["partition_timestamp"])
+
+	rows = Logs(ld, LogsOptions{PartitionTimestamp: PartitionTimestampObserved})
+	require.Len(t, rows, 2)
+	assert.Equal(t, time.Unix(20, 0).UTC(), rows[0]["partition_timestamp"])
+	assert.Equal(t, time.Unix(30, 0).UTC(), rows[1]["partition_timestamp"])
+}
+
+func TestLogsToRowsTraceContextFromAttributes(t *testing.T) {
+	const (
+		traceHex = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanHex  = "00f067aa0ba902b7"
+	)
+
+	tests := []struct {
+		name      string
+		attrs     map[string]any
+		enabled   bool
+		wantTrace string
+		wantSpan  string
+	}{
+		{
+			name:      "trace_id and span_id attributes",
+			attrs:     map[string]any{"trace_id": traceHex, "span_id": spanHex},
+			enabled:   true,
+			wantTrace: traceHex,
+			wantSpan:  spanHex,
+		},
+		{
+			name:      "traceparent attribute",
+			attrs:     map[string]any{"traceparent": "00-" + traceHex + "-" + spanHex + "-01"},
+			enabled:   true,
+			wantTrace: traceHex,
+			wantSpan:  spanHex,
+		},
+		{
+			name:      "invalid trace_id falls back to traceparent",
+			attrs:     map[string]any{"trace_id": "not-a-trace-id", "traceparent": "00-" + traceHex + "-" + spanHex + "-01"},
+			enabled:   true,
+			wantTrace: traceHex,
+			wantSpan:  spanHex,
+		},
+		{
+			name:      "all zero trace id is ignored",
+			attrs:     map[string]any{"trace_id": "00000000000000000000000000000000"},
+			enabled:   true,
+			wantTrace: "00000000000000000000000000000000",
+			wantSpan:  "",
+		},
+		{
+			name:      "disabled",
+			attrs:     map[string]any{"trace_id": traceHex, "span_id": spanHex},
+			enabled:   false,
+			wantTrace: "00000000000000000000000000000000",
+			wantSpan:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ld := plog.NewLogs()
+			lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			require.NoError(t, lr.Attributes().FromRaw(tt.attrs))
+
+			rows := Logs(ld, LogsOptions{TraceContextFromAttributes: tt.enabled})
+			require.Len(t, rows, 1)
+			assert.Equal(t, tt.wantTrace, rows[0]["trace_id"])
+			assert.Equal(t, tt.wantSpan, rows[0]["span_id"])
+		})
+	}
+}
+
+func TestLogsToRowsKeepsRecordTraceContext(t *testing.T) {
+	ld := testdata.GenerateLogsOneLogRecord()
+	lr := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	lr.Attributes().PutStr("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	rows := Logs(ld, LogsOptions{TraceContextFromAttributes: true})
+	require.Len(t, rows, 1)
+	assert.Equal(t, traceIDToHex(lr.TraceID()), rows[0]["trace_id"])
+}
+
+func TestLogsToRowsBodyJSON(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	body := records.AppendEmpty().Body().SetEmptyMap()
+	body.PutStr("message", "started")
+	body.PutEmptyMap("http").PutInt("status", 200)
+	records.AppendEmpty().Body().SetEmptySlice().AppendEmpty().SetStr("a")
+	records.AppendEmpty().Body().SetStr(`{"not":"parsed"}`)
+	records.AppendEmpty()
+
+	rows := Logs(ld, LogsOptions{})
+	require.Len(t, rows, 4)
+	assert.NotContains(t, rows[0], "body_json")
+
+	rows = Logs(ld, LogsOptions{BodyJSON: true})
+	require.Len(t, rows, 4)
+	assert.JSONEq(t, `{"message":"started","http":{"status":200}}`, rows[0]["body_json"].(string))
+	assert.JSONEq(t, `["a"]`, rows[1]["body_json"].(string))
+	assert.JSONEq(t, `"{\"not\":\"parsed\"}"`, rows[2]["body_json"].(string))
+	assert.Equal(t, `{"not":"parsed"}`, rows[2]["body"])
+	assert.NotContains(t, rows[3], "body_json")
+}
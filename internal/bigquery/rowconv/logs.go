@@ -0,0 +1,209 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// LogsSchema is the schema of the table of log records.
+var LogsSchema = bigquery.Schema{
+	{Name: "observed_timestamp", Type: bigquery.TimestampFieldType, Required: false},
+	{Name: "log_timestamp", Type: bigquery.TimestampFieldType, Required: false},
+	{Name: "trace_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "span_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "severity_number", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "severity_text", Type: bigquery.StringFieldType, Required: false},
+	{Name: "body", Type: bigquery.StringFieldType, Required: false},
+	{Name: "flags", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "resource_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "resource_schema_url", Type: bigquery.StringFieldType, Required: false},
+	{Name: "log_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "instrumentation_scope", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "scope_schema_url", Type: bigquery.StringFieldType, Required: false},
+}
+
+// PartitionTimestampField is the column of the time the logs table is
+// partitioned on, written with LogsOptions.PartitionTimestamp.
+var PartitionTimestampField = &bigquery.FieldSchema{Name: "partition_timestamp", Type: bigquery.TimestampFieldType, Required: false}
+
+// BodyJSONField is the optional column holding the body of a log record as
+// a JSON value, so map and slice bodies can be queried with JSON functions.
+var BodyJSONField = &bigquery.FieldSchema{Name: "body_json", Type: bigquery.JSONFieldType, Required: false}
+
+// Sources of the partition_timestamp column.
+const (
+	// PartitionTimestampEvent is the time of the record, or its observed
+	// time when the record has none.
+	PartitionTimestampEvent = "event"
+	// PartitionTimestampObserved is the observed time of the record.
+	PartitionTimestampObserved = "observed"
+)
+
+// LogsOptions configures the conversion of log records.
+type LogsOptions struct {
+	// TraceContextFromAttributes reads the trace and span IDs of records
+	// without them from the trace_id and span_id attributes.
+	TraceContextFromAttributes bool
+	// EntityEvents skips scopes carrying entity events, which EntityEvents
+	// converts instead.
+	EntityEvents bool
+	// PartitionTimestamp is the source of the PartitionTimestampField
+	// column, which is omitted when empty.
+	PartitionTimestamp string
+	// PromotedAttributes are copied from the resource or log record
+	// attributes into their columns.
+	PromotedAttributes []PromotedAttribute
+	// AttributeFilters select the resource, scope and log record
+	// attributes written to the attribute columns.
+	AttributeFilters AttributeFilters
+	// KeyValueAttributes writes the KeyValueAttributeColumns as repeated
+	// KeyValueFields records rather than JSON objects.
+	KeyValueAttributes bool
+	// Severity sets the SeverityField column when not nil.
+	Severity *SeverityOptions
+	// ScopeColumns sets the ScopeFields columns.
+	ScopeColumns bool
+	// RawOTLP sets the RawOTLPField column.
+	RawOTLP bool
+	// BodyJSON sets the BodyJSONField column.
+	BodyJSON bool
+	// ComputedColumns are set to the values ComputedValues returns for
+	// every log record. They are not set when ComputedValues is nil.
+	ComputedColumns []ComputedColumn
+	ComputedValues  LogValues
+}
+
+// Logs converts log records into rows of the LogsSchema table.
+func Logs(ld plog.Logs, opts LogsOptions) []Row {
+	var rows []Row
+	for _, rl := range ld.ResourceLogs().All() {
+		for _, sl := range rl.ScopeLogs().All() {
+			if opts.EntityEvents && isEntityEventScope(sl) {
+				continue
+			}
+			for _, lr := range sl.LogRecords().All() {
+				traceID, spanID := lr.TraceID(), lr.SpanID()
+				if opts.TraceContextFromAttributes && traceID.IsEmpty() {
+					traceID, spanID = traceContextFromAttributes(lr.Attributes(), spanID)
+				}
+				r := Row{
+					"observed_timestamp":       lr.ObservedTimestamp().AsTime(),
+					"log_timestamp":            lr.Timestamp().AsTime(),
+					"trace_id":                 traceIDToHex(traceID),
+					"span_id":                  spanIDToHex(spanID),
+					"severity_number":          int64(lr.SeverityNumber()),
+					"severity_text":            lr.SeverityText(),
+					"body":                     bodyToString(lr.Body()),
+					"flags":                    int64(uint32(lr.Flags())),
+					"dropped_attributes_count": int64(lr.DroppedAttributesCount()),
+					"resource_attributes":      opts.AttributeFilters.Resource.attributesColumn(rl.Resource().Attributes(), opts.KeyValueAttributes),
+					"resource_schema_url":      rl.SchemaUrl(),
+					"log_attributes":           opts.AttributeFilters.Record.attributesColumn(lr.Attributes(), opts.KeyValueAttributes),
+					"instrumentation_scope":    opts.AttributeFilters.Scope.scopeToJSON(sl.Scope()),
+					"scope_schema_url":         sl.SchemaUrl(),
+				}
+				if opts.ScopeColumns {
+					setScopeColumns(r, sl.Scope())
+				}
+				if opts.BodyJSON && lr.Body().Type() != pcommon.ValueTypeEmpty {
+					r["body_json"] = marshalJSON(lr.Body().AsRaw())
+				}
+				if opts.Severity != nil {
+					r["severity"] = opts.Severity.normalizedSeverity(lr)
+				}
+				if opts.PartitionTimestamp != "" {
+					r["partition_timestamp"] = partitionTimestamp(lr, opts.PartitionTimestamp).AsTime()
+				}
+				setPromotedAttributes(r, opts.PromotedAttributes, rl.Resource().Attributes(), lr.Attributes())
+				if len(opts.ComputedColumns) > 0 && opts.ComputedValues != nil {
+					setComputedColumns(r, opts.ComputedColumns, opts.ComputedValues(rl, sl, lr))
+				}
+				if opts.RawOTLP {
+					r["raw_otlp"] = rawLogRecord(rl, sl, lr)
+				}
+				rows = append(rows, r)
+			}
+		}
+	}
+
+	return rows
+}
+
+// partitionTimestamp returns the time of lr the source selects.
+func partitionTimestamp(lr plog.LogRecord, source string) pcommon.Timestamp {
+	if source == PartitionTimestampEvent && lr.Timestamp() != 0 {
+		return lr.Timestamp()
+	}
+	return lr.ObservedTimestamp()
+}
+
+func bodyToString(body pcommon.Value) string {
+	switch body.Type() {
+	case pcommon.ValueTypeMap, pcommon.ValueTypeSlice:
+		return marshalJSON(body.AsRaw())
+	case pcommon.ValueTypeEmpty:
+		return ""
+	default:
+		return body.AsString()
+	}
+}
+
+// traceContextFromAttributes returns the trace and span IDs carried in the
+// trace_id/span_id attributes or in a W3C traceparent attribute, as set by
+// some logging bridges. spanID is returned unchanged when the attributes do
+// not carry a span ID.
+func traceContextFromAttributes(attrs pcommon.Map, spanID pcommon.SpanID) (pcommon.TraceID, pcommon.SpanID) {
+	if v, ok := attrs.Get("trace_id"); ok {
+		if traceID, ok := parseTraceID(v.AsString()); ok {
+			if v, ok := attrs.Get("span_id"); ok && spanID.IsEmpty() {
+				if parsed, ok := parseSpanID(v.AsString()); ok {
+					spanID = parsed
+				}
+			}
+			return traceID, spanID
+		}
+	}
+	if v, ok := attrs.Get("traceparent"); ok {
+		// version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+		parts := strings.Split(strings.TrimSpace(v.AsString()), "-")
+		if len(parts) >= 4 {
+			if traceID, ok := parseTraceID(parts[1]); ok {
+				if parsed, ok := parseSpanID(parts[2]); ok && spanID.IsEmpty() {
+					spanID = parsed
+				}
+				return traceID, spanID
+			}
+		}
+	}
+	return pcommon.NewTraceIDEmpty(), spanID
+}
+
+func parseTraceID(s string) (pcommon.TraceID, bool) {
+	var id pcommon.TraceID
+	if len(s) != hex.EncodedLen(len(id)) {
+		return id, false
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return pcommon.NewTraceIDEmpty(), false
+	}
+	return id, !id.IsEmpty()
+}
+
+func parseSpanID(s string) (pcommon.SpanID, bool) {
+	var id pcommon.SpanID
+	if len(s) != hex.EncodedLen(len(id)) {
+		return id, false
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return pcommon.NewSpanIDEmpty(), false
+	}
+	return id, !id.IsEmpty()
+}
@@ -0,0 +1,141 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestMetricsToRowsAllTypes(t *testing.T) {
+	md := testdata.GeneratMetricsAllTypesWithSampleDatapoints()
+	rows := Metrics(md, MetricsOptions{})
+	require.Len(t, rows, 12)
+
+	types := map[string]int{}
+	for _, r := range rows {
+		types[r["metric_type"].(string)]++
+	}
+	assert.Equal(t, 4, types["GAUGE"])
+	assert.Equal(t, 4, types["SUM"])
+	assert.Equal(t, 2, types["HISTOGRAM"])
+	assert.Equal(t, 2, types["SUMMARY"])
+
+	for _, r := range rows {
+		assert.Contains(t, r["resource_attributes"].(string), "resource-attr")
+		assert.Empty(t, r["resource_schema_url"])
+		assert.Empty(t, r["scope_schema_url"])
+		assert.IsType(t, int64(0), r["flags"])
+	}
+}
+
+func TestMetricsToRowsGaugeValues(t *testing.T) {
+	md := testdata.GenerateMetricsOneMetric()
+	rows := Metrics(md, MetricsOptions{})
+	require.Len(t, rows, 2)
+
+	for _, r := range rows {
+		assert.Equal(t, "SUM", r["metric_type"])
+		assert.NotNil(t, r["value_int"])
+		assert.Equal(t, "CUMULATIVE", r["aggregation_temporality"])
+		assert.True(t, r["is_monotonic"].(bool))
+	}
+}
+
+func TestMetricsToRowsEmpty(t *testing.T) {
+	assert.Empty(t, Metrics(pmetric.NewMetrics(), MetricsOptions{}))
+}
+
+func TestMetricsJSONDefaults(t *testing.T) {
+	assert.Equal(t, "[]", bucketCountsToJSON(nil))
+	assert.Equal(t, "[]", explicitBoundsToJSON(nil))
+	assert.Equal(t, "[]", quantilesToJSON(pmetric.NewSummaryDataPointValueAtQuantileSlice()))
+	assert.Equal(t, "[]", exemplarsToJSON(pmetric.NewExemplarSlice()))
+}
+
+func TestMetricsToRowsStatisticsPresence(t *testing.T) {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	hist := metrics.AppendEmpty().SetEmptyHistogram().DataPoints()
+	withZeroSum := hist.AppendEmpty()
+	withZeroSum.SetSum(0)
+	withZeroSum.SetMin(-1)
+	hist.AppendEmpty()
+
+	exp := metrics.AppendEmpty().SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+	exp.SetMax(2)
+	metrics.AppendEmpty().SetEmptySummary().DataPoints().AppendEmpty()
+	metrics.AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+
+	rows := Metrics(md, MetricsOptions{})
+	require.Len(t, rows, 5)
+	tests := []struct {
+		name                    string
+		hasSum, hasMin, hasMax  any
+		sum, minValue, maxValue any
+	}{
+		{name: "histogram with zero sum", hasSum: true, hasMin: true, hasMax: false, sum: 0.0, minValue: -1.0, maxValue: nil},
+		{name: "histogram without statistics", hasSum: false, hasMin: false, hasMax: false, sum: nil, minValue: nil, maxValue: nil},
+		{name: "exponential histogram with max", hasSum: false, hasMin: false, hasMax: true, sum: nil, minValue: nil, maxValue: 2.0},
+		{name: "summary", hasSum: true, hasMin: false, hasMax: false, sum: 0.0, minValue: nil, maxValue: nil},
+		{name: "gauge", hasSum: nil, hasMin: nil, hasMax: nil, sum: nil, minValue: nil, maxValue: nil},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rows[i]
+			assert.Equal(t, tt.hasSum, r["has_sum"])
+			assert.Equal(t, tt.hasMin, r["has_min"])
+			assert.Equal(t, tt.hasMax, r["has_max"])
+			assert.Equal(t, tt.sum, r["sum"])
+			assert.Equal(t, tt.minValue, r["min"])
+			assert.Equal(t, tt.maxValue, r["max"])
+		})
+	}
+}
+
+func TestMetricsToRowsExponentialHistogramColumns(t *testing.T) {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	dp := metrics.AppendEmpty().SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+	dp.SetScale(-2)
+	dp.SetZeroCount(3)
+	dp.Positive().SetOffset(-1)
+	dp.Positive().BucketCounts().FromRaw([]uint64{1, 0, 4})
+	dp.Negative().SetOffset(5)
+	metrics.AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+
+	rows := Metrics(md, MetricsOptions{ExponentialHistogramColumns: true})
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(-2), rows[0]["scale"])
+	assert.Equal(t, int64(3), rows[0]["zero_count"])
+	assert.Equal(t, int64(-1), rows[0]["positive_offset"])
+	assert.Equal(t, []int64{1, 0, 4}, rows[0]["positive_bucket_counts"])
+	assert.Equal(t, int64(5), rows[0]["negative_offset"])
+	assert.Equal(t, []int64{}, rows[0]["negative_bucket_counts"])
+	// The JSON column keeps the buckets as well.
+	assert.Contains(t, rows[0]["bucket_counts"], `"scale":-2`)
+	for _, field := range ExponentialHistogramFields {
+		assert.NotContains(t, rows[1], field.Name)
+	}
+
+	rows = Metrics(md, MetricsOptions{})
+	assert.NotContains(t, rows[0], "scale")
+}
+
+func TestMetricsToRowsMetricName(t *testing.T) {
+	md := testdata.GenerateMetricsOneMetric()
+	rows := Metrics(md, MetricsOptions{MetricName: func(metric pmetric.Metric) string { return "app." + metric.Name() }})
+	require.NotEmpty(t, rows)
+	name := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name()
+	for _, r := range rows {
+		assert.Equal(t, "app."+name, r["metric_name"])
+	}
+	assert.Equal(t, name, Metrics(md, MetricsOptions{})[0]["metric_name"])
+}
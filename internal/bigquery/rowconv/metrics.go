@@ -0,0 +1,461 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"encoding/json"
+	"maps"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// MetricsSchema is the schema of the table of metric data points.
+var MetricsSchema = bigquery.Schema{
+	{Name: "metric_name", Type: bigquery.StringFieldType, Required: true},
+	{Name: "metric_description", Type: bigquery.StringFieldType, Required: false},
+	{Name: "metric_unit", Type: bigquery.StringFieldType, Required: false},
+	{Name: "metric_type", Type: bigquery.StringFieldType, Required: true},
+	{Name: "aggregation_temporality", Type: bigquery.StringFieldType, Required: false},
+	{Name: "is_monotonic", Type: bigquery.BooleanFieldType, Required: false},
+	{Name: "datapoint_timestamp", Type: bigquery.TimestampFieldType, Required: true},
+	{Name: "start_timestamp", Type: bigquery.TimestampFieldType, Required: false},
+	{Name: "value_int", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "value_double", Type: bigquery.FloatFieldType, Required: false},
+	{Name: "exemplars", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "flags", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "quantiles", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "sum", Type: bigquery.FloatFieldType, Required: false},
+	{Name: "min", Type: bigquery.FloatFieldType, Required: false},
+	{Name: "max", Type: bigquery.FloatFieldType, Required: false},
+	{Name: "has_sum", Type: bigquery.BooleanFieldType, Required: false},
+	{Name: "has_min", Type: bigquery.BooleanFieldType, Required: false},
+	{Name: "has_max", Type: bigquery.BooleanFieldType, Required: false},
+	{Name: "bucket_counts", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "explicit_bounds", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "zero_threshold", Type: bigquery.FloatFieldType, Required: false},
+	{Name: "resource_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "resource_schema_url", Type: bigquery.StringFieldType, Required: false},
+	{Name: "datapoint_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "instrumentation_scope", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "scope_schema_url", Type: bigquery.StringFieldType, Required: false},
+}
+
+// ExponentialHistogramFields are the optional columns holding the buckets
+// of exponential histogram data points, enabled with
+// MetricsOptions.ExponentialHistogramColumns. They are NULL or empty for
+// other metric types.
+var ExponentialHistogramFields = bigquery.Schema{
+	{Name: "scale", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "zero_count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "positive_offset", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "positive_bucket_counts", Type: bigquery.IntegerFieldType, Repeated: true},
+	{Name: "negative_offset", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "negative_bucket_counts", Type: bigquery.IntegerFieldType, Repeated: true},
+}
+
+// MetricsOptions configures the conversion of metric data points.
+type MetricsOptions struct {
+	// ExponentialHistogramColumns sets the ExponentialHistogramFields
+	// columns of exponential histogram data points.
+	ExponentialHistogramColumns bool
+	// PromotedAttributes are copied from the resource or data point
+	// attributes into their columns.
+	PromotedAttributes []PromotedAttribute
+	// AttributeFilters select the resource, scope and data point
+	// attributes written to the attribute columns.
+	AttributeFilters AttributeFilters
+	// KeyValueAttributes writes the KeyValueAttributeColumns as repeated
+	// KeyValueFields records rather than JSON objects.
+	KeyValueAttributes bool
+	// ScopeColumns sets the ScopeFields columns.
+	ScopeColumns bool
+	// RawOTLP sets the RawOTLPField column.
+	RawOTLP bool
+	// ExemplarSpans sets the ExemplarSpansField column.
+	ExemplarSpans bool
+	// ExemplarSpanIDsAsBytes base64 encodes the IDs of the ExemplarSpansField
+	// column for BYTES fields rather than hex encoding them.
+	ExemplarSpanIDsAsBytes bool
+	// NormalizeUnits sets the UnitFields columns.
+	NormalizeUnits bool
+	// MetricName returns the metric_name of a metric when not nil, e.g. a
+	// sanitized or prefixed name. It defaults to the name of the metric.
+	MetricName func(metric pmetric.Metric) string
+	// ComputedColumns are set to the values ComputedValues returns for
+	// every data point. They are not set when ComputedValues is nil.
+	ComputedColumns []ComputedColumn
+	ComputedValues  DataPointValues
+	// GroupDataPoints converts every metric into a single row holding its
+	// data points in DataPointsColumn.
+	GroupDataPoints bool
+}
+
+// Metrics converts metric data points into rows of the MetricsSchema table,
+// one row per data point.
+func Metrics(md pmetric.Metrics, opts MetricsOptions) []Row {
+	var rows []Row
+	for _, rm := range md.ResourceMetrics().All() {
+		for _, sm := range rm.ScopeMetrics().All() {
+			for _, metric := range sm.Metrics().All() {
+				metricRows := metricToRows(metric, rm.Resource().Attributes(), rm.SchemaUrl(), sm.Scope(), sm.SchemaUrl(), opts.AttributeFilters)
+				if opts.ExponentialHistogramColumns && metric.Type() == pmetric.MetricTypeExponentialHistogram {
+					for i, dp := range metric.ExponentialHistogram().DataPoints().All() {
+						setExponentialHistogramColumns(metricRows[i], dp)
+					}
+				}
+				if opts.ScopeColumns {
+					for _, r := range metricRows {
+						setScopeColumns(r, sm.Scope())
+					}
+				}
+				if opts.KeyValueAttributes {
+					resourceAttrs := opts.AttributeFilters.Resource.attributesColumn(rm.Resource().Attributes(), true)
+					for i, attrs := range dataPointAttributes(metric) {
+						metricRows[i]["resource_attributes"] = resourceAttrs
+						metricRows[i]["datapoint_attributes"] = opts.AttributeFilters.Record.attributesColumn(attrs, true)
+					}
+				}
+				if len(opts.PromotedAttributes) > 0 {
+					// Rows are in the order of the data points.
+					for i, attrs := range dataPointAttributes(metric) {
+						setPromotedAttributes(metricRows[i], opts.PromotedAttributes, rm.Resource().Attributes(), attrs)
+					}
+				}
+				if len(opts.ComputedColumns) > 0 && opts.ComputedValues != nil {
+					for i, dp := range dataPoints(metric) {
+						setComputedColumns(metricRows[i], opts.ComputedColumns, opts.ComputedValues(rm, sm, metric, dp))
+					}
+				}
+				if opts.RawOTLP {
+					for i, raw := range rawDataPoints(rm, sm, metric) {
+						metricRows[i]["raw_otlp"] = raw
+					}
+				}
+				if opts.MetricName != nil {
+					name := opts.MetricName(metric)
+					for _, r := range metricRows {
+						r["metric_name"] = name
+					}
+				}
+				if opts.NormalizeUnits {
+					setNormalizedUnit(metricRows, metric.Unit())
+				}
+				if opts.ExemplarSpans {
+					for i, exemplars := range dataPointExemplars(metric) {
+						metricRows[i][ExemplarSpansColumn] = exemplarSpansColumn(exemplars, opts.ExemplarSpanIDsAsBytes)
+					}
+				}
+				if opts.GroupDataPoints && len(metricRows) > 0 {
+					metricRows = []Row{groupDataPoints(metricRows)}
+				}
+				rows = append(rows, metricRows...)
+			}
+		}
+	}
+	return rows
+}
+
+// dataPointAttributes returns the attributes of the data points of metric.
+func dataPointAttributes(metric pmetric.Metric) []pcommon.Map {
+	var attrs []pcommon.Map
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for _, dp := range metric.Gauge().DataPoints().All() {
+			attrs = append(attrs, dp.Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		for _, dp := range metric.Sum().DataPoints().All() {
+			attrs = append(attrs, dp.Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		for _, dp := range metric.Histogram().DataPoints().All() {
+			attrs = append(attrs, dp.Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		for _, dp := range metric.Summary().DataPoints().All() {
+			attrs = append(attrs, dp.Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for _, dp := range metric.ExponentialHistogram().DataPoints().All() {
+			attrs = append(attrs, dp.Attributes())
+		}
+	}
+	return attrs
+}
+
+func metricToRows(metric pmetric.Metric, resourceAttrs pcommon.Map, resourceSchemaURL string, scope pcommon.InstrumentationScope, scopeSchemaURL string, filters AttributeFilters) []Row {
+	baseRow := metricBaseRow(metric, filters.Resource.attributesToJSON(resourceAttrs), resourceSchemaURL, filters.Scope.scopeToJSON(scope), scopeSchemaURL)
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		return gaugeToRows(metric.Gauge(), baseRow, filters.Record)
+	case pmetric.MetricTypeSum:
+		return sumToRows(metric.Sum(), baseRow, filters.Record)
+	case pmetric.MetricTypeHistogram:
+		return histogramToRows(metric.Histogram(), baseRow, filters.Record)
+	case pmetric.MetricTypeSummary:
+		return summaryToRows(metric.Summary(), baseRow, filters.Record)
+	case pmetric.MetricTypeExponentialHistogram:
+		return exponentialHistogramToRows(metric.ExponentialHistogram(), baseRow, filters.Record)
+	default:
+		return nil
+	}
+}
+
+func gaugeToRows(gauge pmetric.Gauge, base Row, filter *AttributeFilter) []Row {
+	return numberDataPointsToRows(gauge.DataPoints(), base, "GAUGE", filter)
+}
+
+func sumToRows(sum pmetric.Sum, base Row, filter *AttributeFilter) []Row {
+	base["aggregation_temporality"] = aggregationTemporalityToString(sum.AggregationTemporality())
+	base["is_monotonic"] = sum.IsMonotonic()
+	return numberDataPointsToRows(sum.DataPoints(), base, "SUM", filter)
+}
+
+func histogramToRows(hist pmetric.Histogram, base Row, filter *AttributeFilter) []Row {
+	dps := hist.DataPoints()
+	rows := make([]Row, 0, dps.Len())
+
+	base["aggregation_temporality"] = aggregationTemporalityToString(hist.AggregationTemporality())
+
+	for _, dp := range dps.All() {
+		r := cloneMetricRow(base, "HISTOGRAM")
+		setCommonDataPointFields(r, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), filter.attributesToJSON(dp.Attributes()))
+		r["exemplars"] = exemplarsToJSON(dp.Exemplars())
+		r["count"] = dp.Count()
+		setStatistics(r, dp.HasSum(), dp.Sum(), dp.HasMin(), dp.Min(), dp.HasMax(), dp.Max())
+		r["bucket_counts"] = bucketCountsToJSON(dp.BucketCounts().AsRaw())
+		r["explicit_bounds"] = explicitBoundsToJSON(dp.ExplicitBounds().AsRaw())
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func summaryToRows(summary pmetric.Summary, base Row, filter *AttributeFilter) []Row {
+	dps := summary.DataPoints()
+	rows := make([]Row, 0, dps.Len())
+
+	for _, dp := range dps.All() {
+		r := cloneMetricRow(base, "SUMMARY")
+		setCommonDataPointFields(r, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), filter.attributesToJSON(dp.Attributes()))
+		r["count"] = dp.Count()
+		// Summary points always carry a sum and never a min or max.
+		setStatistics(r, true, dp.Sum(), false, 0, false, 0)
+		r["quantiles"] = quantilesToJSON(dp.QuantileValues())
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+func exponentialHistogramToRows(hist pmetric.ExponentialHistogram, base Row, filter *AttributeFilter) []Row {
+	dps := hist.DataPoints()
+	rows := make([]Row, 0, dps.Len())
+	base["aggregation_temporality"] = aggregationTemporalityToString(hist.AggregationTemporality())
+	for _, dp := range dps.All() {
+		r := cloneMetricRow(base, "EXPONENTIAL_HISTOGRAM")
+		setCommonDataPointFields(r, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), filter.attributesToJSON(dp.Attributes()))
+		r["exemplars"] = exemplarsToJSON(dp.Exemplars())
+		r["count"] = dp.Count()
+		setStatistics(r, dp.HasSum(), dp.Sum(), dp.HasMin(), dp.Min(), dp.HasMax(), dp.Max())
+		r["zero_threshold"] = dp.ZeroThreshold()
+		r["bucket_counts"] = exponentialBucketInfoToJSON(dp)
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// setStatistics sets the optional sum, min and max of a distribution point
+// together with whether each was provided, so a missing value is NULL and
+// never confused with zero.
+func setStatistics(row Row, hasSum bool, sum float64, hasMin bool, minValue float64, hasMax bool, maxValue float64) {
+	row["has_sum"] = hasSum
+	row["has_min"] = hasMin
+	row["has_max"] = hasMax
+	if hasSum {
+		row["sum"] = sum
+	}
+	if hasMin {
+		row["min"] = minValue
+	}
+	if hasMax {
+		row["max"] = maxValue
+	}
+}
+
+func setCommonDataPointFields(row Row, ts, start pcommon.Timestamp, flags pmetric.DataPointFlags, attrs string) {
+	row["datapoint_timestamp"] = ts.AsTime()
+	row["start_timestamp"] = start.AsTime()
+	row["flags"] = int64(flags)
+	row["datapoint_attributes"] = attrs
+}
+
+func metricBaseRow(metric pmetric.Metric, resourceAttrs, resourceSchemaURL, scope, scopeSchemaURL string) Row {
+	return Row{
+		"metric_name":             metric.Name(),
+		"metric_description":      metric.Description(),
+		"metric_unit":             metric.Unit(),
+		"metric_type":             "",
+		"aggregation_temporality": "",
+		"is_monotonic":            false,
+		"datapoint_timestamp":     time.Time{},
+		"start_timestamp":         time.Time{},
+		"value_int":               nil,
+		"value_double":            nil,
+		"exemplars":               "[]",
+		"flags":                   int64(0),
+		"quantiles":               "[]",
+		"count":                   nil,
+		"sum":                     nil,
+		"min":                     nil,
+		"max":                     nil,
+		"has_sum":                 nil,
+		"has_min":                 nil,
+		"has_max":                 nil,
+		"bucket_counts":           "[]",
+		"explicit_bounds":         "[]",
+		"zero_threshold":          nil,
+		"resource_attributes":     resourceAttrs,
+		"resource_schema_url":     resourceSchemaURL,
+		"datapoint_attributes":    attributesToJSON(pcommon.NewMap()),
+		"instrumentation_scope":   scope,
+		"scope_schema_url":        scopeSchemaURL,
+	}
+}
+
+func cloneMetricRow(base Row, metricType string) Row {
+	r := make(Row, len(base))
+	maps.Copy(r, base)
+	r["metric_type"] = metricType
+	return r
+}
+
+func numberDataPointsToRows(dps pmetric.NumberDataPointSlice, base Row, metricType string, filter *AttributeFilter) []Row {
+	rows := make([]Row, 0, dps.Len())
+	for _, dp := range dps.All() {
+		r := cloneMetricRow(base, metricType)
+		setCommonDataPointFields(r, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), filter.attributesToJSON(dp.Attributes()))
+		r["exemplars"] = exemplarsToJSON(dp.Exemplars())
+		setNumberValue(r, dp)
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func bucketCountsToJSON(values []uint64) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	return marshalJSON(values)
+}
+
+func explicitBoundsToJSON(values []float64) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	return marshalJSON(values)
+}
+
+func quantilesToJSON(qvs pmetric.SummaryDataPointValueAtQuantileSlice) string {
+	if qvs.Len() == 0 {
+		return "[]"
+	}
+
+	quantiles := make([]map[string]any, 0, qvs.Len())
+	for _, qv := range qvs.All() {
+		quantiles = append(quantiles, map[string]any{
+			"quantile": qv.Quantile(),
+			"value":    qv.Value(),
+		})
+	}
+
+	return marshalJSON(quantiles)
+}
+
+func exponentialBucketInfoToJSON(dp pmetric.ExponentialHistogramDataPoint) string {
+	bucketInfo := map[string]any{
+		"scale":      dp.Scale(),
+		"zero_count": dp.ZeroCount(),
+		"positive": map[string]any{
+			"offset":        dp.Positive().Offset(),
+			"bucket_counts": dp.Positive().BucketCounts().AsRaw(),
+		},
+		"negative": map[string]any{
+			"offset":        dp.Negative().Offset(),
+			"bucket_counts": dp.Negative().BucketCounts().AsRaw(),
+		},
+	}
+	return marshalJSON(bucketInfo)
+}
+
+// setExponentialHistogramColumns sets the ExponentialHistogramFields
+// columns of row from dp.
+func setExponentialHistogramColumns(row Row, dp pmetric.ExponentialHistogramDataPoint) {
+	row["scale"] = int64(dp.Scale())
+	row["zero_count"] = int64(dp.ZeroCount())
+	row["positive_offset"] = int64(dp.Positive().Offset())
+	row["positive_bucket_counts"] = bucketCountsToInt64(dp.Positive().BucketCounts().AsRaw())
+	row["negative_offset"] = int64(dp.Negative().Offset())
+	row["negative_bucket_counts"] = bucketCountsToInt64(dp.Negative().BucketCounts().AsRaw())
+}
+
+func bucketCountsToInt64(counts []uint64) []int64 {
+	out := make([]int64, len(counts))
+	for i, c := range counts {
+		out[i] = int64(c)
+	}
+	return out
+}
+
+func setNumberValue(row Row, dp pmetric.NumberDataPoint) {
+	switch dp.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		row["value_int"] = dp.IntValue()
+		row["value_double"] = nil
+	case pmetric.NumberDataPointValueTypeDouble:
+		row["value_int"] = nil
+		row["value_double"] = dp.DoubleValue()
+	}
+}
+
+func aggregationTemporalityToString(at pmetric.AggregationTemporality) string {
+	switch at {
+	case pmetric.AggregationTemporalityCumulative:
+		return "CUMULATIVE"
+	case pmetric.AggregationTemporalityDelta:
+		return "DELTA"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+func exemplarsToJSON(exemplars pmetric.ExemplarSlice) string {
+	if exemplars.Len() == 0 {
+		return "[]"
+	}
+
+	result := make([]map[string]any, 0, exemplars.Len())
+	for _, ex := range exemplars.All() {
+		m := map[string]any{
+			"timestamp":           ex.Timestamp().AsTime().Format(time.RFC3339Nano),
+			"trace_id":            traceIDToHex(ex.TraceID()),
+			"span_id":             spanIDToHex(ex.SpanID()),
+			"filtered_attributes": json.RawMessage(attributesToJSON(ex.FilteredAttributes())),
+		}
+
+		switch ex.ValueType() {
+		case pmetric.ExemplarValueTypeInt:
+			m["value_int"] = ex.IntValue()
+		case pmetric.ExemplarValueTypeDouble:
+			m["value_double"] = ex.DoubleValue()
+		}
+
+		result = append(result, m)
+	}
+
+	return marshalJSON(result)
+}
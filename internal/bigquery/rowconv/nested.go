@@ -0,0 +1,44 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import "cloud.google.com/go/bigquery"
+
+// NestedFields are RECORD columns that can replace the JSON columns of the
+// same name. Their subfields are the keys of the JSON the converters write
+// for the column, so rows need no other conversion: the JSON is decoded
+// into the RECORD when the row is encoded. Attributes stay JSON.
+var NestedFields = map[string]*bigquery.FieldSchema{
+	"events": {Name: "events", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+		{Name: "timestamp", Type: bigquery.TimestampFieldType},
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "attributes", Type: bigquery.JSONFieldType},
+		{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType},
+	}},
+	"links": {Name: "links", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+		{Name: "trace_id", Type: bigquery.StringFieldType},
+		{Name: "span_id", Type: bigquery.StringFieldType},
+		{Name: "trace_state", Type: bigquery.StringFieldType},
+		{Name: "attributes", Type: bigquery.JSONFieldType},
+		{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType},
+		{Name: "flags", Type: bigquery.IntegerFieldType},
+	}},
+	"exemplars": {Name: "exemplars", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+		{Name: "timestamp", Type: bigquery.TimestampFieldType},
+		{Name: "value_int", Type: bigquery.IntegerFieldType},
+		{Name: "value_double", Type: bigquery.FloatFieldType},
+		{Name: "trace_id", Type: bigquery.StringFieldType},
+		{Name: "span_id", Type: bigquery.StringFieldType},
+		{Name: "filtered_attributes", Type: bigquery.JSONFieldType},
+	}},
+	"quantiles": {Name: "quantiles", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+		{Name: "quantile", Type: bigquery.FloatFieldType},
+		{Name: "value", Type: bigquery.FloatFieldType},
+	}},
+	"instrumentation_scope": {Name: "instrumentation_scope", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "version", Type: bigquery.StringFieldType},
+		{Name: "attributes", Type: bigquery.JSONFieldType},
+	}},
+}
@@ -0,0 +1,163 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"math"
+	"strconv"
+	"sync"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// AttributeSourceResource promotes an attribute of the resource. Any other
+// source promotes an attribute of the span, data point or log record.
+const AttributeSourceResource = "resource"
+
+// PromotedAttribute copies an attribute into a column of its own, so it
+// can be queried, clustered and partitioned on without reading the JSON
+// attribute column, which keeps the attribute as well.
+type PromotedAttribute struct {
+	// Source is AttributeSourceResource or the signal's record.
+	Source string
+	// Key is the attribute key.
+	Key string
+	// Column is the name of the NULLABLE column.
+	Column string
+	// Type is the type of the column: STRING, INTEGER, FLOAT or BOOLEAN.
+	Type bigquery.FieldType
+	// Guard limits the distinct values of the column. The column is
+	// promoted without limit when it is nil.
+	Guard *CardinalityGuard
+}
+
+// CardinalityGuard counts the distinct values of a promoted column across
+// batches. Once more than its maximum were seen, the column is no longer
+// promoted and its values are only kept in the JSON attribute column, so
+// an attribute such as a request ID cannot degrade the clustering of the
+// table. It is safe for concurrent use.
+type CardinalityGuard struct {
+	max      int
+	exceeded func()
+
+	mu      sync.Mutex
+	values  map[bigquery.Value]struct{}
+	stopped bool
+}
+
+// NewCardinalityGuard returns a guard allowing up to maxValues distinct
+// values. exceeded is called once when the guard stops the column.
+func NewCardinalityGuard(maxValues int, exceeded func()) *CardinalityGuard {
+	return &CardinalityGuard{max: maxValues, exceeded: exceeded, values: make(map[bigquery.Value]struct{})}
+}
+
+// allow records value and reports whether the column may still hold it.
+func (g *CardinalityGuard) allow(value bigquery.Value) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return false
+	}
+	if _, ok := g.values[value]; ok {
+		return true
+	}
+	if len(g.values) < g.max {
+		g.values[value] = struct{}{}
+		return true
+	}
+	g.stopped = true
+	// The values are no longer needed once the column is stopped.
+	g.values = nil
+	if g.exceeded != nil {
+		g.exceeded()
+	}
+	return false
+}
+
+// Stopped reports whether the column exceeded its maximum and is no longer
+// promoted.
+func (g *CardinalityGuard) Stopped() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stopped
+}
+
+// PromotedFields returns the columns of promoted.
+func PromotedFields(promoted []PromotedAttribute) bigquery.Schema {
+	schema := make(bigquery.Schema, 0, len(promoted))
+	for _, p := range promoted {
+		schema = append(schema, &bigquery.FieldSchema{Name: p.Column, Type: p.Type, Required: false})
+	}
+	return schema
+}
+
+// setPromotedAttributes sets the columns of promoted from the resource and
+// record attributes. Missing attributes, values that cannot be converted to
+// the column type and all values of columns stopped by their Guard are
+// NULL.
+func setPromotedAttributes(r Row, promoted []PromotedAttribute, resource, record pcommon.Map) {
+	for _, p := range promoted {
+		attrs := record
+		if p.Source == AttributeSourceResource {
+			attrs = resource
+		}
+		var value bigquery.Value
+		if v, ok := attrs.Get(p.Key); ok {
+			value = promotedValue(v, p.Type)
+		}
+		if value != nil && p.Guard != nil && !p.Guard.allow(value) {
+			value = nil
+		}
+		r[p.Column] = value
+	}
+}
+
+// promotedValue converts v to typ the way the BigQuery LAX conversion
+// functions do: numbers and strings holding numbers or booleans are
+// converted, other values are NULL. STRING columns hold any value but empty
+// ones, maps and slices as JSON.
+func promotedValue(v pcommon.Value, typ bigquery.FieldType) bigquery.Value {
+	if v.Type() == pcommon.ValueTypeEmpty {
+		return nil
+	}
+	switch typ {
+	case bigquery.StringFieldType:
+		return v.AsString()
+	case bigquery.IntegerFieldType:
+		switch v.Type() {
+		case pcommon.ValueTypeInt:
+			return v.Int()
+		case pcommon.ValueTypeDouble:
+			if f := v.Double(); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+				return int64(f)
+			}
+		case pcommon.ValueTypeStr:
+			if i, err := strconv.ParseInt(v.Str(), 10, 64); err == nil {
+				return i
+			}
+		}
+	case bigquery.FloatFieldType:
+		switch v.Type() {
+		case pcommon.ValueTypeDouble:
+			return v.Double()
+		case pcommon.ValueTypeInt:
+			return float64(v.Int())
+		case pcommon.ValueTypeStr:
+			if f, err := strconv.ParseFloat(v.Str(), 64); err == nil {
+				return f
+			}
+		}
+	case bigquery.BooleanFieldType:
+		switch v.Type() {
+		case pcommon.ValueTypeBool:
+			return v.Bool()
+		case pcommon.ValueTypeStr:
+			if b, err := strconv.ParseBool(v.Str()); err == nil {
+				return b
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+var testPromoted = []PromotedAttribute{
+	{Source: AttributeSourceResource, Key: "service.name", Column: "service_name", Type: bigquery.StringFieldType},
+	{Source: "record", Key: "http.status", Column: "http_status", Type: bigquery.IntegerFieldType},
+	{Source: "record", Key: "missing", Column: "missing", Type: bigquery.BooleanFieldType},
+}
+
+func TestPromotedFields(t *testing.T) {
+	schema := PromotedFields(testPromoted)
+	require.Len(t, schema, 3)
+	assert.Equal(t, "http_status", schema[1].Name)
+	assert.Equal(t, bigquery.IntegerFieldType, schema[1].Type)
+	assert.False(t, schema[1].Required)
+	assert.Empty(t, PromotedFields(nil))
+}
+
+func TestPromotedValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value pcommon.Value
+		typ   bigquery.FieldType
+		want  bigquery.Value
+	}{
+		{name: "string", value: pcommon.NewValueStr("a"), typ: bigquery.StringFieldType, want: "a"},
+		{name: "int as string", value: pcommon.NewValueInt(7), typ: bigquery.StringFieldType, want: "7"},
+		{name: "map as string", value: mapValue(map[string]any{"k": "v"}), typ: bigquery.StringFieldType, want: `{"k":"v"}`},
+		{name: "empty", value: pcommon.NewValueEmpty(), typ: bigquery.StringFieldType, want: nil},
+		{name: "int", value: pcommon.NewValueInt(200), typ: bigquery.IntegerFieldType, want: int64(200)},
+		{name: "integral double as int", value: pcommon.NewValueDouble(3), typ: bigquery.IntegerFieldType, want: int64(3)},
+		{name: "fractional double as int", value: pcommon.NewValueDouble(3.5), typ: bigquery.IntegerFieldType, want: nil},
+		{name: "string as int", value: pcommon.NewValueStr("404"), typ: bigquery.IntegerFieldType, want: int64(404)},
+		{name: "invalid string as int", value: pcommon.NewValueStr("ok"), typ: bigquery.IntegerFieldType, want: nil},
+		{name: "double", value: pcommon.NewValueDouble(1.5), typ: bigquery.FloatFieldType, want: 1.5},
+		{name: "int as double", value: pcommon.NewValueInt(2), typ: bigquery.FloatFieldType, want: float64(2)},
+		{name: "string as double", value: pcommon.NewValueStr("0.25"), typ: bigquery.FloatFieldType, want: 0.25},
+		{name: "bool as double", value: pcommon.NewValueBool(true), typ: bigquery.FloatFieldType, want: nil},
+		{name: "bool", value: pcommon.NewValueBool(true), typ: bigquery.BooleanFieldType, want: true},
+		{name: "string as bool", value: pcommon.NewValueStr("false"), typ: bigquery.BooleanFieldType, want: false},
+		{name: "int as bool", value: pcommon.NewValueInt(1), typ: bigquery.BooleanFieldType, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, promotedValue(tt.value, tt.typ))
+		})
+	}
+}
+
+func mapValue(raw map[string]any) pcommon.Value {
+	v := pcommon.NewValueMap()
+	_ = v.Map().FromRaw(raw)
+	return v
+}
+
+func TestTracesToRowsPromotedAttributes(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.Attributes().PutInt("http.status", 500)
+
+	rows := Traces(td, TracesOptions{PromotedAttributes: testPromoted})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "checkout", rows[0]["service_name"])
+	assert.Equal(t, int64(500), rows[0]["http_status"])
+	assert.Contains(t, rows[0], "missing")
+	assert.Nil(t, rows[0]["missing"])
+	// The attributes stay in the JSON columns.
+	assert.Contains(t, rows[0]["span_attributes"], "http.status")
+}
+
+func TestLogsToRowsPromotedAttributes(t *testing.T) {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	lr := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.Attributes().PutStr("http.status", "404")
+
+	rows := Logs(ld, LogsOptions{PromotedAttributes: testPromoted})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "checkout", rows[0]["service_name"])
+	assert.Equal(t, int64(404), rows[0]["http_status"])
+	assert.Nil(t, rows[0]["missing"])
+}
+
+func TestMetricsToRowsPromotedAttributes(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+	gauge := metrics.AppendEmpty().SetEmptyGauge()
+	for _, status := range []int64{200, 500} {
+		gauge.DataPoints().AppendEmpty().Attributes().PutInt("http.status", status)
+	}
+	histogram := metrics.AppendEmpty().SetEmptyHistogram()
+	histogram.DataPoints().AppendEmpty().Attributes().PutInt("http.status", 404)
+	summary := metrics.AppendEmpty().SetEmptySummary()
+	summary.DataPoints().AppendEmpty()
+
+	rows := Metrics(md, MetricsOptions{PromotedAttributes: testPromoted})
+	require.Len(t, rows, 4)
+	for i, want := range []bigquery.Value{int64(200), int64(500), int64(404), nil} {
+		assert.Equal(t, "checkout", rows[i]["service_name"])
+		assert.Equal(t, want, rows[i]["http_status"], "row %d", i)
+	}
+}
+
+func TestCardinalityGuard(t *testing.T) {
+	exceeded := 0
+	guard := NewCardinalityGuard(2, func() { exceeded++ })
+	assert.True(t, guard.allow("a"))
+	assert.True(t, guard.allow("b"))
+	assert.True(t, guard.allow("a"), "seen values stay allowed")
+	assert.False(t, guard.Stopped())
+
+	assert.False(t, guard.allow("c"))
+	assert.True(t, guard.Stopped())
+	assert.False(t, guard.allow("a"), "stopped columns hold no values")
+	assert.Equal(t, 1, exceeded)
+}
+
+func TestTracesToRowsPromotedAttributesCardinalityGuard(t *testing.T) {
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for _, id := range []string{"r1", "r2", "r1", "r3", "r1"} {
+		spans.AppendEmpty().Attributes().PutStr("request.id", id)
+	}
+	promoted := []PromotedAttribute{{Source: "record", Key: "request.id", Column: "request_id", Type: bigquery.StringFieldType, Guard: NewCardinalityGuard(2, nil)}}
+
+	rows := Traces(td, TracesOptions{PromotedAttributes: promoted})
+	require.Len(t, rows, 5)
+	for i, want := range []bigquery.Value{"r1", "r2", "r1", nil, nil} {
+		assert.Equal(t, want, rows[i]["request_id"], "row %d", i)
+	}
+	// The attribute falls back to the JSON column.
+	assert.Contains(t, rows[4]["span_attributes"], `"request.id":"r1"`)
+
+	// The guard keeps the column stopped in later batches.
+	rows = Traces(td, TracesOptions{PromotedAttributes: promoted})
+	assert.Nil(t, rows[0]["request_id"])
+}
@@ -0,0 +1,117 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// RawOTLPField is the optional column holding the record a row was
+// converted from as OTLP protobuf: a TracesData, MetricsData or LogsData
+// message with the resource and scope of the record and the record alone.
+// A metric holds the data point of the row alone.
+var RawOTLPField = &bigquery.FieldSchema{Name: "raw_otlp", Type: bigquery.BytesFieldType, Required: false}
+
+var (
+	tracesMarshaler  = &ptrace.ProtoMarshaler{}
+	metricsMarshaler = &pmetric.ProtoMarshaler{}
+	logsMarshaler    = &plog.ProtoMarshaler{}
+)
+
+// rawSpan returns span with its resource and scope as OTLP protobuf.
+func rawSpan(rs ptrace.ResourceSpans, ss ptrace.ScopeSpans, span ptrace.Span) []byte {
+	td := ptrace.NewTraces()
+	outResource := td.ResourceSpans().AppendEmpty()
+	rs.Resource().CopyTo(outResource.Resource())
+	outResource.SetSchemaUrl(rs.SchemaUrl())
+	outScope := outResource.ScopeSpans().AppendEmpty()
+	ss.Scope().CopyTo(outScope.Scope())
+	outScope.SetSchemaUrl(ss.SchemaUrl())
+	span.CopyTo(outScope.Spans().AppendEmpty())
+	b, _ := tracesMarshaler.MarshalTraces(td)
+	return b
+}
+
+// rawLogRecord returns lr with its resource and scope as OTLP protobuf.
+func rawLogRecord(rl plog.ResourceLogs, sl plog.ScopeLogs, lr plog.LogRecord) []byte {
+	ld := plog.NewLogs()
+	outResource := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().CopyTo(outResource.Resource())
+	outResource.SetSchemaUrl(rl.SchemaUrl())
+	outScope := outResource.ScopeLogs().AppendEmpty()
+	sl.Scope().CopyTo(outScope.Scope())
+	outScope.SetSchemaUrl(sl.SchemaUrl())
+	lr.CopyTo(outScope.LogRecords().AppendEmpty())
+	b, _ := logsMarshaler.MarshalLogs(ld)
+	return b
+}
+
+// rawDataPoints returns every data point of metric, in order, as OTLP
+// protobuf of the metric with its resource and scope and that data point
+// alone.
+func rawDataPoints(rm pmetric.ResourceMetrics, sm pmetric.ScopeMetrics, metric pmetric.Metric) [][]byte {
+	md := pmetric.NewMetrics()
+	outResource := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().CopyTo(outResource.Resource())
+	outResource.SetSchemaUrl(rm.SchemaUrl())
+	outScope := outResource.ScopeMetrics().AppendEmpty()
+	sm.Scope().CopyTo(outScope.Scope())
+	outScope.SetSchemaUrl(sm.SchemaUrl())
+	out := outScope.Metrics().AppendEmpty()
+	out.SetName(metric.Name())
+	out.SetDescription(metric.Description())
+	out.SetUnit(metric.Unit())
+	metric.Metadata().CopyTo(out.Metadata())
+
+	var raw [][]byte
+	marshal := func() {
+		b, _ := metricsMarshaler.MarshalMetrics(md)
+		raw = append(raw, b)
+	}
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := out.SetEmptyGauge().DataPoints()
+		for _, dp := range metric.Gauge().DataPoints().All() {
+			dp.CopyTo(dps.AppendEmpty())
+			marshal()
+			dps.RemoveIf(func(pmetric.NumberDataPoint) bool { return true })
+		}
+	case pmetric.MetricTypeSum:
+		sum := out.SetEmptySum()
+		sum.SetAggregationTemporality(metric.Sum().AggregationTemporality())
+		sum.SetIsMonotonic(metric.Sum().IsMonotonic())
+		for _, dp := range metric.Sum().DataPoints().All() {
+			dp.CopyTo(sum.DataPoints().AppendEmpty())
+			marshal()
+			sum.DataPoints().RemoveIf(func(pmetric.NumberDataPoint) bool { return true })
+		}
+	case pmetric.MetricTypeHistogram:
+		hist := out.SetEmptyHistogram()
+		hist.SetAggregationTemporality(metric.Histogram().AggregationTemporality())
+		for _, dp := range metric.Histogram().DataPoints().All() {
+			dp.CopyTo(hist.DataPoints().AppendEmpty())
+			marshal()
+			hist.DataPoints().RemoveIf(func(pmetric.HistogramDataPoint) bool { return true })
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		hist := out.SetEmptyExponentialHistogram()
+		hist.SetAggregationTemporality(metric.ExponentialHistogram().AggregationTemporality())
+		for _, dp := range metric.ExponentialHistogram().DataPoints().All() {
+			dp.CopyTo(hist.DataPoints().AppendEmpty())
+			marshal()
+			hist.DataPoints().RemoveIf(func(pmetric.ExponentialHistogramDataPoint) bool { return true })
+		}
+	case pmetric.MetricTypeSummary:
+		dps := out.SetEmptySummary().DataPoints()
+		for _, dp := range metric.Summary().DataPoints().All() {
+			dp.CopyTo(dps.AppendEmpty())
+			marshal()
+			dps.RemoveIf(func(pmetric.SummaryDataPoint) bool { return true })
+		}
+	}
+	return raw
+}
@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestTracesToRowsRawOTLP(t *testing.T) {
+	td := testdata.GenerateTracesTwoSpansSameResourceOneDifferent()
+	rows := Traces(td, TracesOptions{RawOTLP: true})
+	require.Len(t, rows, 3)
+
+	i := 0
+	for _, rs := range td.ResourceSpans().All() {
+		for _, ss := range rs.ScopeSpans().All() {
+			for _, span := range ss.Spans().All() {
+				raw, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(rows[i]["raw_otlp"].([]byte))
+				require.NoError(t, err)
+				require.Equal(t, 1, raw.SpanCount())
+				got := raw.ResourceSpans().At(0)
+				assert.Equal(t, rs.Resource().Attributes().AsRaw(), got.Resource().Attributes().AsRaw())
+				assert.Equal(t, ss.Scope().Name(), got.ScopeSpans().At(0).Scope().Name())
+				assert.Equal(t, span.Name(), got.ScopeSpans().At(0).Spans().At(0).Name())
+				i++
+			}
+		}
+	}
+	assert.NotContains(t, Traces(td, TracesOptions{})[0], "raw_otlp")
+}
+
+func TestLogsToRowsRawOTLP(t *testing.T) {
+	ld := testdata.GenerateLogsTwoLogRecordsSameResource()
+	rows := Logs(ld, LogsOptions{RawOTLP: true})
+	require.Len(t, rows, 2)
+
+	records := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i, r := range rows {
+		raw, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(r["raw_otlp"].([]byte))
+		require.NoError(t, err)
+		require.Equal(t, 1, raw.LogRecordCount())
+		want := plog.NewLogs()
+		ld.ResourceLogs().At(0).Resource().CopyTo(want.ResourceLogs().AppendEmpty().Resource())
+		scope := want.ResourceLogs().At(0).ScopeLogs().AppendEmpty()
+		ld.ResourceLogs().At(0).ScopeLogs().At(0).Scope().CopyTo(scope.Scope())
+		records.At(i).CopyTo(scope.LogRecords().AppendEmpty())
+		assert.Equal(t, want, raw)
+	}
+}
+
+func TestMetricsToRowsRawOTLP(t *testing.T) {
+	md := testdata.GeneratMetricsAllTypesWithSampleDatapoints()
+	rows := Metrics(md, MetricsOptions{RawOTLP: true})
+	require.Len(t, rows, md.DataPointCount())
+
+	// Merging the single data points back yields the original metrics.
+	merged := pmetric.NewMetrics()
+	for _, r := range rows {
+		raw, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(r["raw_otlp"].([]byte))
+		require.NoError(t, err)
+		require.Equal(t, 1, raw.DataPointCount())
+		metric := raw.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+		assert.Equal(t, r["metric_name"], metric.Name())
+		if merged.ResourceMetrics().Len() == 0 {
+			raw.CopyTo(merged)
+			continue
+		}
+		metrics := merged.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		last := metrics.At(metrics.Len() - 1)
+		if last.Name() != metric.Name() {
+			metric.CopyTo(metrics.AppendEmpty())
+			continue
+		}
+		switch metric.Type() {
+		case pmetric.MetricTypeGauge:
+			metric.Gauge().DataPoints().At(0).CopyTo(last.Gauge().DataPoints().AppendEmpty())
+		case pmetric.MetricTypeSum:
+			metric.Sum().DataPoints().At(0).CopyTo(last.Sum().DataPoints().AppendEmpty())
+		case pmetric.MetricTypeHistogram:
+			metric.Histogram().DataPoints().At(0).CopyTo(last.Histogram().DataPoints().AppendEmpty())
+		case pmetric.MetricTypeExponentialHistogram:
+			metric.ExponentialHistogram().DataPoints().At(0).CopyTo(last.ExponentialHistogram().DataPoints().AppendEmpty())
+		case pmetric.MetricTypeSummary:
+			metric.Summary().DataPoints().At(0).CopyTo(last.Summary().DataPoints().AppendEmpty())
+		}
+	}
+	assert.Equal(t, md, merged)
+}
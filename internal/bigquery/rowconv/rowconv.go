@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rowconv converts OTLP traces, metrics and logs into BigQuery rows
+// and defines the schemas of their tables. It is the single definition of
+// the mapping between OTLP and the tables, shared by the BigQuery exporter,
+// the integration tests in rowconvtest reading the tables back and other
+// BigQuery components of this repository, so that all of them stay in sync.
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Row is a table row keyed by column name. JSON columns hold serialized
+// JSON strings, so rows can be written to tables whose JSON columns were
+// created as STRING as well.
+type Row = map[string]bigquery.Value
+
+// marshalJSON serializes v for a JSON column. NaN and infinite values, which
+// JSON cannot represent, are written as the strings "NaN", "+Inf" and
+// "-Inf" instead of failing the row.
+func marshalJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		if b, err = json.Marshal(finiteJSONValue(v)); err != nil {
+			return "null"
+		}
+	}
+	return string(b)
+}
+
+// finiteJSONValue returns v with non-finite floats replaced by strings. It
+// covers the value types produced by pcommon AsRaw and the converters.
+func finiteJSONValue(v any) any {
+	switch t := v.(type) {
+	case float64:
+		switch {
+		case math.IsNaN(t):
+			return "NaN"
+		case math.IsInf(t, 1):
+			return "+Inf"
+		case math.IsInf(t, -1):
+			return "-Inf"
+		}
+		return t
+	case []float64:
+		out := make([]any, len(t))
+		for i, f := range t {
+			out[i] = finiteJSONValue(f)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = finiteJSONValue(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, e := range t {
+			out[k] = finiteJSONValue(e)
+		}
+		return out
+	case []map[string]any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = finiteJSONValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func traceIDToHex(id pcommon.TraceID) string {
+	return hex.EncodeToString(id[:])
+}
+
+func spanIDToHex(id pcommon.SpanID) string {
+	if id.IsEmpty() {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
+func attributesToJSON(attrs pcommon.Map) string {
+	if attrs.Len() == 0 {
+		return "{}"
+	}
+	return marshalJSON(attrs.AsRaw())
+}
+
+func scopeToJSON(scope pcommon.InstrumentationScope) string {
+	m := map[string]any{
+		"name":    scope.Name(),
+		"version": scope.Version(),
+	}
+	if scope.Attributes().Len() > 0 {
+		m["attributes"] = scope.Attributes().AsRaw()
+	}
+	return marshalJSON(m)
+}
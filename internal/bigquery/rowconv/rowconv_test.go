@@ -0,0 +1,54 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestMarshalJSONNonFinite(t *testing.T) {
+	assert.JSONEq(t, `{"a":"NaN","b":["+Inf",1],"c":[{"d":"-Inf"}]}`, marshalJSON(map[string]any{
+		"a": math.NaN(),
+		"b": []any{math.Inf(1), 1.0},
+		"c": []map[string]any{{"d": math.Inf(-1)}},
+	}))
+	assert.Equal(t, `["NaN",2]`, explicitBoundsToJSON([]float64{math.NaN(), 2}))
+}
+
+// TestRowsMatchSchemas checks that every value a converter produces has a
+// column in the schema of its table.
+func TestRowsMatchSchemas(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema bigquery.Schema
+		rows   []Row
+	}{
+		{name: "traces", schema: append(append(slices.Clip(TracesSchema), StatusClassField), SpanHierarchyFields...), rows: Traces(testdata.GenerateTracesTwoSpansSameResource(), TracesOptions{StatusClass: true, SpanHierarchy: true})},
+		{name: "metrics", schema: MetricsSchema, rows: Metrics(testdata.GenerateMetricsAllTypesEmptyDataPoint(), MetricsOptions{})},
+		{name: "logs", schema: append(slices.Clip(LogsSchema), PartitionTimestampField), rows: Logs(testdata.GenerateLogsTwoLogRecordsSameResource(), LogsOptions{PartitionTimestamp: PartitionTimestampEvent})},
+		{name: "entities", schema: EntitiesSchema, rows: EntityEvents(generateEntityEvents())},
+		{name: "span_events", schema: SpanEventsSchema, rows: SpanEvents(testdata.GenerateTracesTwoSpansSameResource(), nil, nil)},
+		{name: "span_links", schema: SpanLinksSchema, rows: SpanLinks(testdata.GenerateTracesTwoSpansSameResource(), nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NotEmpty(t, tt.rows)
+			for _, r := range tt.rows {
+				for column := range r {
+					assert.True(t, slices.ContainsFunc(tt.schema, func(field *bigquery.FieldSchema) bool {
+						return field.Name == column
+					}), "column %s is not in the schema", column)
+				}
+			}
+		})
+	}
+}
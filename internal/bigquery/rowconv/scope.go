@@ -0,0 +1,23 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// ScopeFields are the optional columns holding the name and version of the
+// instrumentation scope, which is otherwise only in the
+// instrumentation_scope column.
+var ScopeFields = bigquery.Schema{
+	{Name: "scope_name", Type: bigquery.StringFieldType, Required: false},
+	{Name: "scope_version", Type: bigquery.StringFieldType, Required: false},
+}
+
+// setScopeColumns sets the ScopeFields columns of row from scope.
+func setScopeColumns(row Row, scope pcommon.InstrumentationScope) {
+	row["scope_name"] = scope.Name()
+	row["scope_version"] = scope.Version()
+}
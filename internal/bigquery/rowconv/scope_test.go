@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestToRowsScopeColumns(t *testing.T) {
+	td := ptrace.NewTraces()
+	ss := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("io.opentelemetry.jdbc")
+	ss.Scope().SetVersion("2.1.0")
+	ss.Spans().AppendEmpty()
+	rows := Traces(td, TracesOptions{ScopeColumns: true})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "io.opentelemetry.jdbc", rows[0]["scope_name"])
+	assert.Equal(t, "2.1.0", rows[0]["scope_version"])
+	assert.NotContains(t, Traces(td, TracesOptions{})[0], "scope_name")
+
+	ld := plog.NewLogs()
+	sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("logger")
+	sl.LogRecords().AppendEmpty()
+	logRows := Logs(ld, LogsOptions{ScopeColumns: true})
+	require.Len(t, logRows, 1)
+	assert.Equal(t, "logger", logRows[0]["scope_name"])
+	assert.Empty(t, logRows[0]["scope_version"])
+
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("meter")
+	sm.Scope().SetVersion("1.0")
+	dps := sm.Metrics().AppendEmpty().SetEmptySum().DataPoints()
+	dps.AppendEmpty()
+	dps.AppendEmpty()
+	metricRows := Metrics(md, MetricsOptions{ScopeColumns: true})
+	require.Len(t, metricRows, 2)
+	for _, r := range metricRows {
+		assert.Equal(t, "meter", r["scope_name"])
+		assert.Equal(t, "1.0", r["scope_version"])
+	}
+}
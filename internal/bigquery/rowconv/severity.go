@@ -0,0 +1,74 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// SeverityField is the optional column holding the normalized severity of
+// a log record, written with LogsOptions.Severity.
+var SeverityField = &bigquery.FieldSchema{Name: "severity", Type: bigquery.StringFieldType, Required: false}
+
+// SeverityLevels are the values of the SeverityField column, in order of
+// increasing severity.
+var SeverityLevels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// severityAliases maps lowercase severity texts commonly emitted by
+// logging libraries and syslog to their level.
+var severityAliases = map[string]string{
+	"trace":         "TRACE",
+	"verbose":       "TRACE",
+	"finest":        "TRACE",
+	"debug":         "DEBUG",
+	"dbg":           "DEBUG",
+	"fine":          "DEBUG",
+	"finer":         "DEBUG",
+	"info":          "INFO",
+	"information":   "INFO",
+	"informational": "INFO",
+	"notice":        "INFO",
+	"config":        "INFO",
+	"warn":          "WARN",
+	"warning":       "WARN",
+	"error":         "ERROR",
+	"err":           "ERROR",
+	"severe":        "ERROR",
+	"fatal":         "FATAL",
+	"critical":      "FATAL",
+	"crit":          "FATAL",
+	"alert":         "FATAL",
+	"emerg":         "FATAL",
+	"emergency":     "FATAL",
+	"panic":         "FATAL",
+}
+
+// SeverityOptions configures the SeverityField column.
+type SeverityOptions struct {
+	// TextMapping maps lowercase severity texts to one of SeverityLevels.
+	// It takes precedence over the severity number and the built-in
+	// aliases.
+	TextMapping map[string]string
+}
+
+// normalizedSeverity returns the level of lr: the level TextMapping maps its
+// severity text to, the range of its severity number or the level of a
+// well-known severity text, in this order. It returns nil when none applies.
+func (o *SeverityOptions) normalizedSeverity(lr plog.LogRecord) any {
+	text := strings.ToLower(strings.TrimSpace(lr.SeverityText()))
+	if level, ok := o.TextMapping[text]; ok {
+		return level
+	}
+	if n := lr.SeverityNumber(); n >= plog.SeverityNumberTrace && n <= plog.SeverityNumberFatal4 {
+		// Severity numbers come in ranges of four per level.
+		return SeverityLevels[(n-plog.SeverityNumberTrace)/4]
+	}
+	if level, ok := severityAliases[text]; ok {
+		return level
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestLogsToRowsSeverity(t *testing.T) {
+	opts := &SeverityOptions{TextMapping: map[string]string{"sev-3": "ERROR", "warn": "ERROR"}}
+	tests := []struct {
+		name   string
+		number plog.SeverityNumber
+		text   string
+		want   any
+	}{
+		{name: "trace number", number: plog.SeverityNumberTrace, want: "TRACE"},
+		{name: "debug4 number", number: plog.SeverityNumberDebug4, want: "DEBUG"},
+		{name: "info2 number", number: plog.SeverityNumberInfo2, want: "INFO"},
+		{name: "warn number", number: plog.SeverityNumberWarn3, text: "W", want: "WARN"},
+		{name: "error number", number: plog.SeverityNumberError, want: "ERROR"},
+		{name: "fatal4 number", number: plog.SeverityNumberFatal4, want: "FATAL"},
+		{name: "alias", text: "Warning", want: "WARN"},
+		{name: "syslog alias", text: "crit", want: "FATAL"},
+		{name: "alias with spaces", text: " notice ", want: "INFO"},
+		{name: "mapping", text: "SEV-3", want: "ERROR"},
+		{name: "mapping before number", number: plog.SeverityNumberWarn, text: "warn", want: "ERROR"},
+		{name: "unknown text", text: "loud", want: nil},
+		{name: "unspecified", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ld := plog.NewLogs()
+			lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			lr.SetSeverityNumber(tt.number)
+			lr.SetSeverityText(tt.text)
+
+			rows := Logs(ld, LogsOptions{Severity: opts})
+			require.Len(t, rows, 1)
+			assert.Equal(t, tt.want, rows[0]["severity"])
+			assert.Equal(t, tt.text, rows[0]["severity_text"])
+		})
+	}
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().SetSeverityNumber(plog.SeverityNumberInfo)
+	assert.NotContains(t, Logs(ld, LogsOptions{})[0], "severity")
+}
@@ -0,0 +1,290 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestTracesToRows(t *testing.T) {
+	td := testdata.GenerateTracesOneSpan()
+	rows := Traces(td, TracesOptions{})
+	require.Len(t, rows, 1)
+
+	row := rows[0]
+	assert.Equal(t, "operationA", row["name"])
+	assert.Equal(t, "ERROR", row["status_code"])
+	assert.Equal(t, "status-cancelled", row["status_message"])
+	assert.Contains(t, row["resource_attributes"].(string), "resource-attr")
+	assert.Contains(t, row["events"].(string), "event-with-attr")
+	// New fields — testdata generators set dropped counts to 1
+	assert.Equal(t, int64(0), row["flags"])
+	assert.IsType(t, int64(0), row["dropped_attributes_count"])
+	assert.IsType(t, int64(0), row["dropped_events_count"])
+	assert.IsType(t, int64(0), row["dropped_links_count"])
+	assert.Empty(t, row["resource_schema_url"])
+	assert.Empty(t, row["scope_schema_url"])
+	assert.Contains(t, row["events"].(string), "dropped_attributes_count")
+}
+
+func TestTracesToRowsMultipleSpans(t *testing.T) {
+	td := testdata.GenerateTracesTwoSpansSameResource()
+	rows := Traces(td, TracesOptions{})
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "operationA", rows[0]["name"])
+	assert.Equal(t, "operationB", rows[1]["name"])
+	assert.Contains(t, rows[1]["links"].(string), "trace_id")
+}
+
+func TestTracesToRowsMultipleResources(t *testing.T) {
+	td := testdata.GenerateTracesTwoSpansSameResourceOneDifferent()
+	rows := Traces(td, TracesOptions{})
+	require.Len(t, rows, 3)
+}
+
+func TestTracesToRowsEmpty(t *testing.T) {
+	assert.Empty(t, Traces(testdata.GenerateTracesNoLibraries(), TracesOptions{}))
+}
+
+func TestTracesToRowsIncludeEventNames(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("operation")
+	span.Events().AppendEmpty().SetName("exception")
+	span.Events().AppendEmpty().SetName("gc.pause")
+	span.Events().AppendEmpty().SetName("message")
+
+	rows := Traces(td, TracesOptions{})
+	require.Len(t, rows, 1)
+	assert.Contains(t, rows[0]["events"], "gc.pause")
+
+	rows = Traces(td, TracesOptions{IncludeEventNames: []string{"exception", "message"}})
+	require.Len(t, rows, 1)
+	events := rows[0]["events"].(string)
+	assert.Contains(t, events, "exception")
+	assert.Contains(t, events, "message")
+	assert.NotContains(t, events, "gc.pause")
+
+	rows = Traces(td, TracesOptions{IncludeEventNames: []string{"retry"}})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "[]", rows[0]["events"])
+}
+
+func TestTracesToRowsStatusClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]any
+		want  any
+	}{
+		{name: "current semantic conventions", attrs: map[string]any{"http.response.status_code": 503}, want: "5xx"},
+		{name: "older semantic conventions", attrs: map[string]any{"http.status_code": 201}, want: "2xx"},
+		{name: "current convention takes precedence", attrs: map[string]any{"http.response.status_code": 404, "http.status_code": 200}, want: "4xx"},
+		{name: "string status code", attrs: map[string]any{"http.response.status_code": "302"}, want: "3xx"},
+		{name: "out of range", attrs: map[string]any{"http.response.status_code": 999}, want: nil},
+		{name: "not a number", attrs: map[string]any{"http.response.status_code": "ok"}, want: nil},
+		{name: "no status code", attrs: map[string]any{"rpc.system": "grpc"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			td := ptrace.NewTraces()
+			span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			require.NoError(t, span.Attributes().FromRaw(tt.attrs))
+
+			rows := Traces(td, TracesOptions{StatusClass: true})
+			require.Len(t, rows, 1)
+			assert.Equal(t, tt.want, rows[0]["status_class"])
+		})
+	}
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().Attributes().PutInt("http.response.status_code", 500)
+	assert.NotContains(t, Traces(td, TracesOptions{})[0], "status_class")
+}
+
+func TestTracesToRowsIntegerEnums(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetKind(ptrace.SpanKindClient)
+	span.Status().SetCode(ptrace.StatusCodeError)
+
+	row := Traces(td, TracesOptions{IntegerEnums: true})[0]
+	assert.Equal(t, int64(3), row["kind"])
+	assert.Equal(t, int64(2), row["status_code"])
+
+	row = Traces(td, TracesOptions{})[0]
+	assert.Equal(t, "CLIENT", row["kind"])
+	assert.Equal(t, "ERROR", row["status_code"])
+}
+
+func TestTracesToRowsFlagColumns(t *testing.T) {
+	tests := []struct {
+		name                     string
+		flags                    uint32
+		sampled, hasRemoteParent any
+	}{
+		{name: "no flags", flags: 0, sampled: false, hasRemoteParent: nil},
+		{name: "sampled", flags: 0x01, sampled: true, hasRemoteParent: nil},
+		{name: "local parent", flags: 0x101, sampled: true, hasRemoteParent: false},
+		{name: "remote parent", flags: 0x300, sampled: false, hasRemoteParent: true},
+		{name: "is remote without has is remote", flags: 0x200, sampled: false, hasRemoteParent: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			td := ptrace.NewTraces()
+			td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetFlags(tt.flags)
+
+			rows := Traces(td, TracesOptions{FlagColumns: true})
+			require.Len(t, rows, 1)
+			assert.Equal(t, tt.sampled, rows[0]["sampled"])
+			assert.Equal(t, tt.hasRemoteParent, rows[0]["has_remote_parent"])
+			assert.Equal(t, int64(tt.flags), rows[0]["flags"])
+		})
+	}
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetFlags(1)
+	assert.NotContains(t, Traces(td, TracesOptions{})[0], "sampled")
+}
+
+func TestTracesToRowsCountColumns(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.Events().AppendEmpty().SetName("exception")
+	span.Events().AppendEmpty().SetName("message")
+	span.Links().AppendEmpty()
+
+	row := Traces(td, TracesOptions{CountColumns: true, IncludeEventNames: []string{"exception"}})[0]
+	assert.Equal(t, int64(2), row["events_count"])
+	assert.Equal(t, int64(1), row["links_count"])
+
+	td = ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	row = Traces(td, TracesOptions{CountColumns: true})[0]
+	assert.Equal(t, int64(0), row["events_count"])
+	assert.Equal(t, int64(0), row["links_count"])
+	assert.NotContains(t, Traces(td, TracesOptions{})[0], "events_count")
+}
+
+func TestTracesToRowsSpanHierarchy(t *testing.T) {
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	addSpan := func(traceID byte, spanID, parentID byte) {
+		span := spans.AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{traceID})
+		span.SetSpanID(pcommon.SpanID{spanID})
+		if parentID != 0 {
+			span.SetParentSpanID(pcommon.SpanID{parentID})
+		}
+	}
+	// A complete trace: 1 -> 2 -> 3 and 1 -> 4.
+	addSpan(1, 1, 0)
+	addSpan(1, 2, 1)
+	addSpan(1, 3, 2)
+	addSpan(1, 4, 1)
+	// A trace whose root span is in another batch.
+	addSpan(2, 2, 1)
+	// Spans with the same IDs in another trace do not mix.
+	addSpan(3, 3, 0)
+	// Parents forming a cycle.
+	addSpan(4, 1, 2)
+	addSpan(4, 2, 1)
+
+	rows := Traces(td, TracesOptions{SpanHierarchy: true})
+	require.Len(t, rows, 8)
+	type hierarchy struct {
+		depth, isLeaf any
+	}
+	var got []hierarchy
+	for _, r := range rows {
+		got = append(got, hierarchy{r["depth"], r["is_leaf"]})
+	}
+	assert.Equal(t, []hierarchy{
+		{int64(0), false},
+		{int64(1), false},
+		{int64(2), true},
+		{int64(1), true},
+		{nil, nil},
+		{int64(0), true},
+		{nil, nil},
+		{nil, nil},
+	}, got)
+
+	assert.NotContains(t, Traces(td, TracesOptions{})[0], "depth")
+}
+
+func TestTracesToRowsOmitEventsAndLinks(t *testing.T) {
+	rows := Traces(testdata.GenerateTracesTwoSpansSameResource(), TracesOptions{OmitEventsAndLinks: true})
+	require.Len(t, rows, 2)
+	for _, r := range rows {
+		assert.NotContains(t, r, "events")
+		assert.NotContains(t, r, "links")
+	}
+}
+
+func TestSpanEvents(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1})
+	span.SetSpanID(pcommon.SpanID{2})
+	span.Events().AppendEmpty().SetName("gc.pause")
+	exception := span.Events().AppendEmpty()
+	exception.SetName("exception")
+	exception.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(10, 0)))
+	exception.SetDroppedAttributesCount(1)
+	exception.Attributes().PutStr("exception.type", "IOError")
+
+	rows := SpanEvents(td, nil, nil)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "gc.pause", rows[0]["event_name"])
+
+	rows = SpanEvents(td, []string{"exception"}, nil)
+	require.Len(t, rows, 1)
+	assert.Equal(t, Row{
+		"trace_id":                 "01000000000000000000000000000000",
+		"span_id":                  "0200000000000000",
+		"event_index":              int64(1),
+		"event_timestamp":          time.Unix(10, 0).UTC(),
+		"event_name":               "exception",
+		"event_attributes":         `{"exception.type":"IOError"}`,
+		"dropped_attributes_count": int64(1),
+	}, rows[0])
+}
+
+func TestSpanLinks(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1})
+	span.SetSpanID(pcommon.SpanID{2})
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Unix(10, 0)))
+	link := span.Links().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID{3})
+	link.SetSpanID(pcommon.SpanID{4})
+	link.TraceState().FromRaw("vendor=value")
+	link.SetFlags(1)
+	link.Attributes().PutStr("messaging.operation", "receive")
+
+	rows := SpanLinks(td, nil)
+	require.Len(t, rows, 1)
+	assert.Equal(t, Row{
+		"trace_id":                 "01000000000000000000000000000000",
+		"span_id":                  "0200000000000000",
+		"span_start_time":          time.Unix(10, 0).UTC(),
+		"link_index":               int64(0),
+		"linked_trace_id":          "03000000000000000000000000000000",
+		"linked_span_id":           "0400000000000000",
+		"linked_trace_state":       "vendor=value",
+		"link_attributes":          `{"messaging.operation":"receive"}`,
+		"dropped_attributes_count": int64(0),
+		"flags":                    int64(1),
+	}, rows[0])
+}
@@ -0,0 +1,452 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// TracesSchema is the schema of the table of spans.
+var TracesSchema = bigquery.Schema{
+	{Name: "trace_id", Type: bigquery.StringFieldType, Required: true},
+	{Name: "span_id", Type: bigquery.StringFieldType, Required: true},
+	{Name: "parent_span_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "trace_state", Type: bigquery.StringFieldType, Required: false},
+	{Name: "name", Type: bigquery.StringFieldType, Required: true},
+	{Name: "kind", Type: bigquery.StringFieldType, Required: false},
+	{Name: "start_time", Type: bigquery.TimestampFieldType, Required: true},
+	{Name: "end_time", Type: bigquery.TimestampFieldType, Required: true},
+	{Name: "status_code", Type: bigquery.StringFieldType, Required: false},
+	{Name: "status_message", Type: bigquery.StringFieldType, Required: false},
+	{Name: "flags", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "dropped_events_count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "dropped_links_count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "resource_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "resource_schema_url", Type: bigquery.StringFieldType, Required: false},
+	{Name: "span_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "events", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "links", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "instrumentation_scope", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "scope_schema_url", Type: bigquery.StringFieldType, Required: false},
+}
+
+// StatusClassField is the optional column holding the HTTP status class of
+// a span, enabled with TracesOptions.StatusClass.
+var StatusClassField = &bigquery.FieldSchema{Name: "status_class", Type: bigquery.StringFieldType, Required: false}
+
+// FlagFields are the optional columns holding the decoded flags of a span,
+// enabled with TracesOptions.FlagColumns.
+var FlagFields = bigquery.Schema{
+	{Name: "sampled", Type: bigquery.BooleanFieldType, Required: false},
+	{Name: "has_remote_parent", Type: bigquery.BooleanFieldType, Required: false},
+}
+
+// CountFields are the optional columns holding the number of events and
+// links of a span, enabled with TracesOptions.CountColumns.
+var CountFields = bigquery.Schema{
+	{Name: "events_count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "links_count", Type: bigquery.IntegerFieldType, Required: false},
+}
+
+// Bits of span flags, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto.
+const (
+	spanFlagsSampled             = 0x01
+	spanFlagsContextHasIsRemote  = 0x100
+	spanFlagsContextIsRemoteMask = 0x200
+)
+
+// SpanHierarchyFields are the optional columns holding the position of a
+// span in its trace, enabled with TracesOptions.SpanHierarchy.
+var SpanHierarchyFields = bigquery.Schema{
+	{Name: "depth", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "is_leaf", Type: bigquery.BooleanFieldType, Required: false},
+}
+
+// SpanEventsSchema is the schema of the table of span events, written
+// instead of the events column with TracesOptions.OmitEventsAndLinks.
+var SpanEventsSchema = bigquery.Schema{
+	{Name: "trace_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "span_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "event_index", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "event_timestamp", Type: bigquery.TimestampFieldType, Required: false},
+	{Name: "event_name", Type: bigquery.StringFieldType, Required: false},
+	{Name: "event_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType, Required: false},
+}
+
+// SpanLinksSchema is the schema of the table of span links, written instead
+// of the links column with TracesOptions.OmitEventsAndLinks.
+var SpanLinksSchema = bigquery.Schema{
+	{Name: "trace_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "span_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "span_start_time", Type: bigquery.TimestampFieldType, Required: false},
+	{Name: "link_index", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "linked_trace_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "linked_span_id", Type: bigquery.StringFieldType, Required: false},
+	{Name: "linked_trace_state", Type: bigquery.StringFieldType, Required: false},
+	{Name: "link_attributes", Type: bigquery.JSONFieldType, Required: false},
+	{Name: "dropped_attributes_count", Type: bigquery.IntegerFieldType, Required: false},
+	{Name: "flags", Type: bigquery.IntegerFieldType, Required: false},
+}
+
+// TracesOptions configures the conversion of spans.
+type TracesOptions struct {
+	// IncludeEventNames limits the events column to span events with one of
+	// these names. All events are kept when empty.
+	IncludeEventNames []string
+	// StatusClass sets the StatusClassField column.
+	StatusClass bool
+	// FlagColumns sets the FlagFields columns.
+	FlagColumns bool
+	// CountColumns sets the CountFields columns.
+	CountColumns bool
+	// SpanHierarchy sets the SpanHierarchyFields columns of spans whose
+	// parents up to the root span are in the same batch.
+	SpanHierarchy bool
+	// OmitEventsAndLinks leaves out the events and links columns, which are
+	// then written with SpanEvents and SpanLinks.
+	OmitEventsAndLinks bool
+	// PromotedAttributes are copied from the resource or span attributes
+	// into their columns.
+	PromotedAttributes []PromotedAttribute
+	// AttributeFilters select the resource, scope and span attributes
+	// written to the attribute columns. The span filter applies to the
+	// attributes of span events and links as well.
+	AttributeFilters AttributeFilters
+	// KeyValueAttributes writes the KeyValueAttributeColumns as repeated
+	// KeyValueFields records rather than JSON objects.
+	KeyValueAttributes bool
+	// ScopeColumns sets the ScopeFields columns.
+	ScopeColumns bool
+	// RawOTLP sets the RawOTLPField column.
+	RawOTLP bool
+	// IntegerEnums writes the EnumColumns as the INT64 values of their OTLP
+	// enums rather than their names.
+	IntegerEnums bool
+	// ComputedColumns are set to the values ComputedValues returns for
+	// every span. They are not set when ComputedValues is nil.
+	ComputedColumns []ComputedColumn
+	ComputedValues  SpanValues
+}
+
+// Traces converts spans into rows of the TracesSchema table.
+func Traces(td ptrace.Traces, opts TracesOptions) []Row {
+	var rows []Row
+	var hierarchy *spanHierarchy
+	if opts.SpanHierarchy {
+		hierarchy = newSpanHierarchy(td)
+	}
+	for _, rs := range td.ResourceSpans().All() {
+		for _, ss := range rs.ScopeSpans().All() {
+			for _, span := range ss.Spans().All() {
+				r := Row{
+					"trace_id":                 traceIDToHex(span.TraceID()),
+					"span_id":                  spanIDToHex(span.SpanID()),
+					"parent_span_id":           spanIDToHex(span.ParentSpanID()),
+					"trace_state":              span.TraceState().AsRaw(),
+					"name":                     span.Name(),
+					"kind":                     spanKindToString(span.Kind()),
+					"start_time":               span.StartTimestamp().AsTime(),
+					"end_time":                 span.EndTimestamp().AsTime(),
+					"status_code":              statusCodeToString(span.Status().Code()),
+					"status_message":           span.Status().Message(),
+					"flags":                    int64(span.Flags()),
+					"dropped_attributes_count": int64(span.DroppedAttributesCount()),
+					"dropped_events_count":     int64(span.DroppedEventsCount()),
+					"dropped_links_count":      int64(span.DroppedLinksCount()),
+					"resource_attributes":      opts.AttributeFilters.Resource.attributesColumn(rs.Resource().Attributes(), opts.KeyValueAttributes),
+					"resource_schema_url":      rs.SchemaUrl(),
+					"span_attributes":          opts.AttributeFilters.Record.attributesColumn(span.Attributes(), opts.KeyValueAttributes),
+					"events":                   eventsToJSON(span.Events(), opts.IncludeEventNames, opts.AttributeFilters.Record),
+					"links":                    linksToJSON(span.Links(), opts.AttributeFilters.Record),
+					"instrumentation_scope":    opts.AttributeFilters.Scope.scopeToJSON(ss.Scope()),
+					"scope_schema_url":         ss.SchemaUrl(),
+				}
+				if opts.IntegerEnums {
+					r["kind"] = int64(span.Kind())
+					r["status_code"] = int64(span.Status().Code())
+				}
+				if opts.StatusClass {
+					r["status_class"] = httpStatusClass(span.Attributes())
+				}
+				if opts.ScopeColumns {
+					setScopeColumns(r, ss.Scope())
+				}
+				if opts.FlagColumns {
+					setFlagColumns(r, span.Flags())
+				}
+				if opts.CountColumns {
+					// All events are counted, including those
+					// IncludeEventNames leaves out.
+					r["events_count"] = int64(span.Events().Len())
+					r["links_count"] = int64(span.Links().Len())
+				}
+				if opts.OmitEventsAndLinks {
+					delete(r, "events")
+					delete(r, "links")
+				}
+				if hierarchy != nil {
+					hierarchy.set(r, span)
+				}
+				setPromotedAttributes(r, opts.PromotedAttributes, rs.Resource().Attributes(), span.Attributes())
+				if len(opts.ComputedColumns) > 0 && opts.ComputedValues != nil {
+					setComputedColumns(r, opts.ComputedColumns, opts.ComputedValues(rs, ss, span))
+				}
+				if opts.RawOTLP {
+					r["raw_otlp"] = rawSpan(rs, ss, span)
+				}
+				rows = append(rows, r)
+			}
+		}
+	}
+
+	return rows
+}
+
+// spanKey identifies a span across the traces of a batch.
+type spanKey struct {
+	traceID pcommon.TraceID
+	spanID  pcommon.SpanID
+}
+
+// spanHierarchy holds the parent of every span of a batch, so the depth of
+// spans and whether they are leaves can be derived without querying other
+// rows.
+type spanHierarchy struct {
+	parents     map[spanKey]pcommon.SpanID
+	hasChildren map[spanKey]bool
+}
+
+func newSpanHierarchy(td ptrace.Traces) *spanHierarchy {
+	h := &spanHierarchy{parents: map[spanKey]pcommon.SpanID{}, hasChildren: map[spanKey]bool{}}
+	for _, rs := range td.ResourceSpans().All() {
+		for _, ss := range rs.ScopeSpans().All() {
+			for _, span := range ss.Spans().All() {
+				h.parents[spanKey{span.TraceID(), span.SpanID()}] = span.ParentSpanID()
+				if !span.ParentSpanID().IsEmpty() {
+					h.hasChildren[spanKey{span.TraceID(), span.ParentSpanID()}] = true
+				}
+			}
+		}
+	}
+	return h
+}
+
+// depth returns the number of ancestors of a span, 0 for root spans, or
+// false when a span on the way to the root is not in the batch.
+func (h *spanHierarchy) depth(key spanKey) (int64, bool) {
+	var depth int64
+	// A chain longer than the batch has a cycle.
+	for range len(h.parents) {
+		parent, ok := h.parents[key]
+		if !ok {
+			return 0, false
+		}
+		if parent.IsEmpty() {
+			return depth, true
+		}
+		key = spanKey{key.traceID, parent}
+		depth++
+	}
+	return 0, false
+}
+
+// set sets the depth and is_leaf columns of the row of span. They are left
+// NULL when its trace is incomplete in the batch. Children arriving in a
+// later batch are not known, so is_leaf is a hint.
+func (h *spanHierarchy) set(r Row, span ptrace.Span) {
+	key := spanKey{span.TraceID(), span.SpanID()}
+	depth, ok := h.depth(key)
+	if !ok {
+		return
+	}
+	r["depth"] = depth
+	r["is_leaf"] = !h.hasChildren[key]
+}
+
+// SpanEvents converts the events of spans into rows of the SpanEventsSchema
+// table. When includeNames is not empty, only events with one of those
+// names are converted. event_index is the position of the event in its
+// span before filtering. filter selects the event attributes written.
+func SpanEvents(td ptrace.Traces, includeNames []string, filter *AttributeFilter) []Row {
+	var rows []Row
+	for _, rs := range td.ResourceSpans().All() {
+		for _, ss := range rs.ScopeSpans().All() {
+			for _, span := range ss.Spans().All() {
+				for i, e := range span.Events().All() {
+					if len(includeNames) > 0 && !slices.Contains(includeNames, e.Name()) {
+						continue
+					}
+					rows = append(rows, Row{
+						"trace_id":                 traceIDToHex(span.TraceID()),
+						"span_id":                  spanIDToHex(span.SpanID()),
+						"event_index":              int64(i),
+						"event_timestamp":          e.Timestamp().AsTime(),
+						"event_name":               e.Name(),
+						"event_attributes":         filter.attributesToJSON(e.Attributes()),
+						"dropped_attributes_count": int64(e.DroppedAttributesCount()),
+					})
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// SpanLinks converts the links of spans into rows of the SpanLinksSchema
+// table. filter selects the link attributes written.
+func SpanLinks(td ptrace.Traces, filter *AttributeFilter) []Row {
+	var rows []Row
+	for _, rs := range td.ResourceSpans().All() {
+		for _, ss := range rs.ScopeSpans().All() {
+			for _, span := range ss.Spans().All() {
+				for i, l := range span.Links().All() {
+					rows = append(rows, Row{
+						"trace_id":                 traceIDToHex(span.TraceID()),
+						"span_id":                  spanIDToHex(span.SpanID()),
+						"span_start_time":          span.StartTimestamp().AsTime(),
+						"link_index":               int64(i),
+						"linked_trace_id":          traceIDToHex(l.TraceID()),
+						"linked_span_id":           spanIDToHex(l.SpanID()),
+						"linked_trace_state":       l.TraceState().AsRaw(),
+						"link_attributes":          filter.attributesToJSON(l.Attributes()),
+						"dropped_attributes_count": int64(l.DroppedAttributesCount()),
+						"flags":                    int64(l.Flags()),
+					})
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// EnumColumns are the columns of the TracesSchema holding OTLP enums, written
+// as INT64 values when IntegerEnums is set.
+var EnumColumns = []string{"kind", "status_code"}
+
+func spanKindToString(kind ptrace.SpanKind) string {
+	switch kind {
+	case ptrace.SpanKindInternal:
+		return "INTERNAL"
+	case ptrace.SpanKindServer:
+		return "SERVER"
+	case ptrace.SpanKindClient:
+		return "CLIENT"
+	case ptrace.SpanKindProducer:
+		return "PRODUCER"
+	case ptrace.SpanKindConsumer:
+		return "CONSUMER"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+func statusCodeToString(code ptrace.StatusCode) string {
+	switch code {
+	case ptrace.StatusCodeOk:
+		return "OK"
+	case ptrace.StatusCodeError:
+		return "ERROR"
+	default:
+		return "UNSET"
+	}
+}
+
+// httpStatusClassAttributes are the attributes holding the HTTP response
+// status code in current and older semantic conventions, by precedence.
+var httpStatusClassAttributes = []string{"http.response.status_code", "http.status_code"}
+
+// setFlagColumns sets the FlagFields columns of r from the flags of a span.
+// has_remote_parent is NULL when the flags do not record whether the parent
+// is remote.
+func setFlagColumns(r Row, flags uint32) {
+	r["sampled"] = flags&spanFlagsSampled != 0
+	if flags&spanFlagsContextHasIsRemote != 0 {
+		r["has_remote_parent"] = flags&spanFlagsContextIsRemoteMask != 0
+	} else {
+		r["has_remote_parent"] = nil
+	}
+}
+
+// httpStatusClass returns the class of the HTTP response status code of a
+// span, e.g. "4xx", or nil when the span has no valid status code.
+func httpStatusClass(attrs pcommon.Map) any {
+	for _, key := range httpStatusClassAttributes {
+		v, ok := attrs.Get(key)
+		if !ok {
+			continue
+		}
+		var code int64
+		switch v.Type() {
+		case pcommon.ValueTypeInt:
+			code = v.Int()
+		case pcommon.ValueTypeStr:
+			parsed, err := strconv.ParseInt(v.Str(), 10, 64)
+			if err != nil {
+				return nil
+			}
+			code = parsed
+		default:
+			return nil
+		}
+		if code < 100 || code > 599 {
+			return nil
+		}
+		return strconv.FormatInt(code/100, 10) + "xx"
+	}
+	return nil
+}
+
+// eventsToJSON serializes span events. When includeNames is not empty, only
+// events with one of those names are serialized. filter selects the event
+// attributes serialized.
+func eventsToJSON(events ptrace.SpanEventSlice, includeNames []string, filter *AttributeFilter) string {
+	if events.Len() == 0 {
+		return "[]"
+	}
+	result := make([]map[string]any, 0, events.Len())
+	for _, e := range events.All() {
+		if len(includeNames) > 0 && !slices.Contains(includeNames, e.Name()) {
+			continue
+		}
+		result = append(result, map[string]any{
+			"timestamp":                e.Timestamp().AsTime().Format(time.RFC3339Nano),
+			"name":                     e.Name(),
+			"attributes":               json.RawMessage(filter.attributesToJSON(e.Attributes())),
+			"dropped_attributes_count": e.DroppedAttributesCount(),
+		})
+	}
+	if len(result) == 0 {
+		return "[]"
+	}
+	return marshalJSON(result)
+}
+
+// linksToJSON serializes span links with the attributes filter selects.
+func linksToJSON(links ptrace.SpanLinkSlice, filter *AttributeFilter) string {
+	if links.Len() == 0 {
+		return "[]"
+	}
+	result := make([]map[string]any, 0, links.Len())
+	for _, l := range links.All() {
+		result = append(result, map[string]any{
+			"trace_id":                 traceIDToHex(l.TraceID()),
+			"span_id":                  spanIDToHex(l.SpanID()),
+			"trace_state":              l.TraceState().AsRaw(),
+			"attributes":               json.RawMessage(filter.attributesToJSON(l.Attributes())),
+			"dropped_attributes_count": l.DroppedAttributesCount(),
+			"flags":                    int64(l.Flags()),
+		})
+	}
+	return marshalJSON(result)
+}
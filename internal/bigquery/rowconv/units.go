@@ -0,0 +1,91 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+
+import (
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// UnitFields are the columns written with MetricsOptions.NormalizeUnits:
+// the unit of a metric normalized to the UCUM base unit of its dimension,
+// the factor converting values to it and the converted value of gauge and
+// sum data points.
+var UnitFields = bigquery.Schema{
+	{Name: "normalized_unit", Type: bigquery.StringFieldType, Required: false},
+	{Name: "unit_scale", Type: bigquery.FloatFieldType, Required: false},
+	{Name: "normalized_value", Type: bigquery.FloatFieldType, Required: false},
+}
+
+// unitScale is the base unit of a unit and the factor converting values to
+// it.
+type unitScale struct {
+	unit  string
+	scale float64
+}
+
+// unitScales maps the UCUM units of time, information and ratios, and the
+// names SDKs commonly use instead, to their base unit.
+var unitScales = map[string]unitScale{
+	"ns":           {"s", 1e-9},
+	"us":           {"s", 1e-6},
+	"μs":           {"s", 1e-6},
+	"ms":           {"s", 1e-3},
+	"s":            {"s", 1},
+	"min":          {"s", 60},
+	"h":            {"s", 3600},
+	"d":            {"s", 86400},
+	"nanoseconds":  {"s", 1e-9},
+	"microseconds": {"s", 1e-6},
+	"milliseconds": {"s", 1e-3},
+	"seconds":      {"s", 1},
+	"By":           {"By", 1},
+	"kBy":          {"By", 1e3},
+	"MBy":          {"By", 1e6},
+	"GBy":          {"By", 1e9},
+	"TBy":          {"By", 1e12},
+	"KiBy":         {"By", 1 << 10},
+	"MiBy":         {"By", 1 << 20},
+	"GiBy":         {"By", 1 << 30},
+	"TiBy":         {"By", 1 << 40},
+	"bytes":        {"By", 1},
+	"bit":          {"By", 0.125},
+	"kbit":         {"By", 125},
+	"Mbit":         {"By", 125e3},
+	"Gbit":         {"By", 125e6},
+	"1":            {"1", 1},
+	"%":            {"1", 0.01},
+}
+
+// normalizeUnit returns the base unit of unit and the factor converting
+// values to it. Units of the form a/b are normalized part by part, e.g.
+// KiBy/min to By/s. Unknown units, including annotations such as
+// {request}, are kept with a factor of 1.
+func normalizeUnit(unit string) (string, float64) {
+	if numerator, denominator, ok := strings.Cut(unit, "/"); ok && numerator != "" && denominator != "" {
+		numUnit, numScale := normalizeUnit(numerator)
+		denUnit, denScale := normalizeUnit(denominator)
+		return numUnit + "/" + denUnit, numScale / denScale
+	}
+	if s, ok := unitScales[unit]; ok {
+		return s.unit, s.scale
+	}
+	return unit, 1
+}
+
+// setNormalizedUnit sets the UnitFields columns of the rows of a metric
+// with unit.
+func setNormalizedUnit(rows []Row, unit string) {
+	normalized, scale := normalizeUnit(unit)
+	for _, r := range rows {
+		r["normalized_unit"] = normalized
+		r["unit_scale"] = scale
+		if v, ok := r["value_double"].(float64); ok {
+			r["normalized_value"] = v * scale
+		} else if v, ok := r["value_int"].(int64); ok {
+			r["normalized_value"] = float64(v) * scale
+		}
+	}
+}
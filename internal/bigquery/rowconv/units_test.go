@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestNormalizeUnit(t *testing.T) {
+	tests := []struct {
+		unit      string
+		wantUnit  string
+		wantScale float64
+	}{
+		{unit: "ms", wantUnit: "s", wantScale: 1e-3},
+		{unit: "s", wantUnit: "s", wantScale: 1},
+		{unit: "milliseconds", wantUnit: "s", wantScale: 1e-3},
+		{unit: "MiBy", wantUnit: "By", wantScale: 1 << 20},
+		{unit: "kBy", wantUnit: "By", wantScale: 1000},
+		{unit: "%", wantUnit: "1", wantScale: 0.01},
+		{unit: "KiBy/min", wantUnit: "By/s", wantScale: 1024.0 / 60},
+		{unit: "{request}/min", wantUnit: "{request}/s", wantScale: 1.0 / 60},
+		{unit: "{request}", wantUnit: "{request}", wantScale: 1},
+		{unit: "", wantUnit: "", wantScale: 1},
+		{unit: "/s", wantUnit: "/s", wantScale: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			unit, scale := normalizeUnit(tt.unit)
+			assert.Equal(t, tt.wantUnit, unit)
+			assert.InDelta(t, tt.wantScale, scale, 1e-12)
+		})
+	}
+}
+
+func TestMetricsToRowsNormalizeUnits(t *testing.T) {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	gauge := metrics.AppendEmpty()
+	gauge.SetUnit("ms")
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(250)
+	sum := metrics.AppendEmpty()
+	sum.SetUnit("KiBy")
+	sum.SetEmptySum().DataPoints().AppendEmpty().SetIntValue(2)
+	histogram := metrics.AppendEmpty()
+	histogram.SetUnit("s")
+	histogram.SetEmptyHistogram().DataPoints().AppendEmpty().SetSum(1)
+
+	rows := Metrics(md, MetricsOptions{NormalizeUnits: true})
+	require.Len(t, rows, 3)
+	assert.Equal(t, "s", rows[0]["normalized_unit"])
+	assert.InDelta(t, 1e-3, rows[0]["unit_scale"], 1e-12)
+	assert.InDelta(t, 0.25, rows[0]["normalized_value"], 1e-12)
+	assert.Equal(t, "By", rows[1]["normalized_unit"])
+	assert.InDelta(t, 2048, rows[1]["normalized_value"], 1e-12)
+	assert.Equal(t, "s", rows[2]["normalized_unit"])
+	assert.NotContains(t, rows[2], "normalized_value")
+
+	assert.NotContains(t, Metrics(md, MetricsOptions{})[0], "normalized_unit")
+}
@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconvtest // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconvtest"
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// Logs reconstructs log records from rows of the rowconv.LogsSchema table.
+// Bodies come back as strings, since the body column stores every body
+// type as text.
+func Logs(rows []rowconv.Row) (plog.Logs, error) {
+	ld := plog.NewLogs()
+	h := newHierarchy(
+		func(f *fields, schemaURL string) plog.ResourceLogs {
+			rl := ld.ResourceLogs().AppendEmpty()
+			f.attributes("resource_attributes", rl.Resource().Attributes())
+			rl.SetSchemaUrl(schemaURL)
+			return rl
+		},
+		func(f *fields, rl plog.ResourceLogs, schemaURL string) plog.ScopeLogs {
+			sl := rl.ScopeLogs().AppendEmpty()
+			f.setScope(sl.Scope())
+			sl.SetSchemaUrl(schemaURL)
+			return sl
+		},
+	)
+	for i, r := range rows {
+		f := &fields{row: r}
+		setLogRecord(f, h.scope(f, "resource_attributes").LogRecords().AppendEmpty())
+		if f.err != nil {
+			return plog.Logs{}, fmt.Errorf("row %d: %w", i, f.err)
+		}
+	}
+	return ld, nil
+}
+
+func setLogRecord(f *fields, lr plog.LogRecord) {
+	lr.SetObservedTimestamp(f.timestamp("observed_timestamp"))
+	lr.SetTimestamp(f.timestamp("log_timestamp"))
+	lr.SetTraceID(f.traceID("trace_id"))
+	lr.SetSpanID(f.spanID("span_id"))
+	severity, _ := f.int("severity_number")
+	lr.SetSeverityNumber(plog.SeverityNumber(severity))
+	lr.SetSeverityText(f.string("severity_text"))
+	lr.Body().SetStr(f.string("body"))
+	flags, _ := f.int("flags")
+	lr.SetFlags(plog.LogRecordFlags(uint32(flags)))
+	dropped, _ := f.int("dropped_attributes_count")
+	lr.SetDroppedAttributesCount(uint32(dropped))
+	f.attributes("log_attributes", lr.Attributes())
+}
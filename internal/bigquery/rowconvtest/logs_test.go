@@ -0,0 +1,34 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconvtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestLogsRoundTrip(t *testing.T) {
+	ld := testdata.GenerateLogsTwoLogRecordsSameResource()
+	ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(1).Body().SetEmptyMap().PutStr("k", "v")
+
+	want := Normalize(rowconv.LogsSchema, rowconv.Logs(ld, rowconv.LogsOptions{}))
+	got, err := Logs(want)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.ResourceLogs().Len())
+	assert.Equal(t, ld.LogRecordCount(), got.LogRecordCount())
+	assert.Equal(t, want, Normalize(rowconv.LogsSchema, rowconv.Logs(got, rowconv.LogsOptions{})))
+
+	lr := got.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(1)
+	assert.JSONEq(t, `{"k":"v"}`, lr.Body().Str())
+}
+
+func TestLogsInvalidRow(t *testing.T) {
+	_, err := Logs([]rowconv.Row{{"log_attributes": "{"}})
+	assert.ErrorContains(t, err, "row 0: column log_attributes")
+}
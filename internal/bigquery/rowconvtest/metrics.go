@@ -0,0 +1,268 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconvtest // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconvtest"
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+var aggregationTemporalities = map[string]pmetric.AggregationTemporality{
+	"CUMULATIVE": pmetric.AggregationTemporalityCumulative,
+	"DELTA":      pmetric.AggregationTemporalityDelta,
+}
+
+// exemplarJSON mirrors an element of the exemplars column.
+type exemplarJSON struct {
+	Timestamp          string         `json:"timestamp"`
+	TraceID            string         `json:"trace_id"`
+	SpanID             string         `json:"span_id"`
+	FilteredAttributes map[string]any `json:"filtered_attributes"`
+	ValueInt           *int64         `json:"value_int"`
+	ValueDouble        *jsonFloat     `json:"value_double"`
+}
+
+// quantileJSON mirrors an element of the quantiles column.
+type quantileJSON struct {
+	Quantile jsonFloat `json:"quantile"`
+	Value    jsonFloat `json:"value"`
+}
+
+// exponentialBucketsJSON mirrors the bucket_counts column of exponential
+// histograms.
+type exponentialBucketsJSON struct {
+	Scale     int32  `json:"scale"`
+	ZeroCount uint64 `json:"zero_count"`
+	Positive  struct {
+		Offset       int32    `json:"offset"`
+		BucketCounts []uint64 `json:"bucket_counts"`
+	} `json:"positive"`
+	Negative struct {
+		Offset       int32    `json:"offset"`
+		BucketCounts []uint64 `json:"bucket_counts"`
+	} `json:"negative"`
+}
+
+// metricKey identifies the metric a row belongs to within its scope.
+type metricKey struct {
+	name, description, unit, typ, temporality string
+	monotonic                                 bool
+}
+
+// Metrics reconstructs metrics from rows of the rowconv.MetricsSchema
+// table. Rows of the same scope and metric identity become data points of
+// one metric.
+func Metrics(rows []rowconv.Row) (pmetric.Metrics, error) {
+	md := pmetric.NewMetrics()
+	type scope struct {
+		sm      pmetric.ScopeMetrics
+		metrics map[metricKey]pmetric.Metric
+	}
+	h := newHierarchy(
+		func(f *fields, schemaURL string) pmetric.ResourceMetrics {
+			rm := md.ResourceMetrics().AppendEmpty()
+			f.attributes("resource_attributes", rm.Resource().Attributes())
+			rm.SetSchemaUrl(schemaURL)
+			return rm
+		},
+		func(f *fields, rm pmetric.ResourceMetrics, schemaURL string) *scope {
+			sm := rm.ScopeMetrics().AppendEmpty()
+			f.setScope(sm.Scope())
+			sm.SetSchemaUrl(schemaURL)
+			return &scope{sm: sm, metrics: map[metricKey]pmetric.Metric{}}
+		},
+	)
+	for i, r := range rows {
+		f := &fields{row: r}
+		s := h.scope(f, "resource_attributes")
+		key := metricKey{
+			name:        f.string("metric_name"),
+			description: f.string("metric_description"),
+			unit:        f.string("metric_unit"),
+			typ:         f.string("metric_type"),
+			temporality: f.string("aggregation_temporality"),
+			monotonic:   f.bool("is_monotonic"),
+		}
+		metric, ok := s.metrics[key]
+		if !ok {
+			metric = s.sm.Metrics().AppendEmpty()
+			if err := initMetric(metric, key); err != nil {
+				return pmetric.Metrics{}, fmt.Errorf("row %d: %w", i, err)
+			}
+			s.metrics[key] = metric
+		}
+		addDataPoint(f, metric)
+		if f.err != nil {
+			return pmetric.Metrics{}, fmt.Errorf("row %d: %w", i, f.err)
+		}
+	}
+	return md, nil
+}
+
+func initMetric(metric pmetric.Metric, key metricKey) error {
+	metric.SetName(key.name)
+	metric.SetDescription(key.description)
+	metric.SetUnit(key.unit)
+	temporality := aggregationTemporalities[key.temporality]
+	switch key.typ {
+	case "GAUGE":
+		metric.SetEmptyGauge()
+	case "SUM":
+		sum := metric.SetEmptySum()
+		sum.SetAggregationTemporality(temporality)
+		sum.SetIsMonotonic(key.monotonic)
+	case "HISTOGRAM":
+		metric.SetEmptyHistogram().SetAggregationTemporality(temporality)
+	case "EXPONENTIAL_HISTOGRAM":
+		metric.SetEmptyExponentialHistogram().SetAggregationTemporality(temporality)
+	case "SUMMARY":
+		metric.SetEmptySummary()
+	default:
+		return fmt.Errorf("column metric_type: unknown metric type %q", key.typ)
+	}
+	return nil
+}
+
+func addDataPoint(f *fields, metric pmetric.Metric) {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		setNumberDataPoint(f, metric.Gauge().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSum:
+		setNumberDataPoint(f, metric.Sum().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeHistogram:
+		setHistogramDataPoint(f, metric.Histogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeExponentialHistogram:
+		setExponentialHistogramDataPoint(f, metric.ExponentialHistogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSummary:
+		setSummaryDataPoint(f, metric.Summary().DataPoints().AppendEmpty())
+	}
+}
+
+// dataPoint is implemented by every data point type.
+type dataPoint interface {
+	SetTimestamp(pcommon.Timestamp)
+	SetStartTimestamp(pcommon.Timestamp)
+	SetFlags(pmetric.DataPointFlags)
+	Attributes() pcommon.Map
+}
+
+func setCommonDataPointFields(f *fields, dp dataPoint) {
+	dp.SetTimestamp(f.timestamp("datapoint_timestamp"))
+	dp.SetStartTimestamp(f.timestamp("start_timestamp"))
+	flags, _ := f.int("flags")
+	dp.SetFlags(pmetric.DataPointFlags(uint32(flags)))
+	f.attributes("datapoint_attributes", dp.Attributes())
+}
+
+func setNumberDataPoint(f *fields, dp pmetric.NumberDataPoint) {
+	setCommonDataPointFields(f, dp)
+	if v, ok := f.int("value_int"); ok {
+		dp.SetIntValue(v)
+	} else if v, ok := f.float("value_double"); ok {
+		dp.SetDoubleValue(v)
+	}
+	setExemplars(f, dp.Exemplars())
+}
+
+func setHistogramDataPoint(f *fields, dp pmetric.HistogramDataPoint) {
+	setCommonDataPointFields(f, dp)
+	count, _ := f.int("count")
+	dp.SetCount(uint64(count))
+	if f.bool("has_sum") {
+		sum, _ := f.float("sum")
+		dp.SetSum(sum)
+	}
+	if f.bool("has_min") {
+		minValue, _ := f.float("min")
+		dp.SetMin(minValue)
+	}
+	if f.bool("has_max") {
+		maxValue, _ := f.float("max")
+		dp.SetMax(maxValue)
+	}
+	var counts []uint64
+	f.json("bucket_counts", &counts)
+	dp.BucketCounts().FromRaw(counts)
+	var bounds []jsonFloat
+	f.json("explicit_bounds", &bounds)
+	for _, b := range bounds {
+		dp.ExplicitBounds().Append(float64(b))
+	}
+	setExemplars(f, dp.Exemplars())
+}
+
+func setExponentialHistogramDataPoint(f *fields, dp pmetric.ExponentialHistogramDataPoint) {
+	setCommonDataPointFields(f, dp)
+	count, _ := f.int("count")
+	dp.SetCount(uint64(count))
+	if f.bool("has_sum") {
+		sum, _ := f.float("sum")
+		dp.SetSum(sum)
+	}
+	if f.bool("has_min") {
+		minValue, _ := f.float("min")
+		dp.SetMin(minValue)
+	}
+	if f.bool("has_max") {
+		maxValue, _ := f.float("max")
+		dp.SetMax(maxValue)
+	}
+	zeroThreshold, _ := f.float("zero_threshold")
+	dp.SetZeroThreshold(zeroThreshold)
+	var buckets exponentialBucketsJSON
+	f.json("bucket_counts", &buckets)
+	dp.SetScale(buckets.Scale)
+	dp.SetZeroCount(buckets.ZeroCount)
+	dp.Positive().SetOffset(buckets.Positive.Offset)
+	dp.Positive().BucketCounts().FromRaw(buckets.Positive.BucketCounts)
+	dp.Negative().SetOffset(buckets.Negative.Offset)
+	dp.Negative().BucketCounts().FromRaw(buckets.Negative.BucketCounts)
+	setExemplars(f, dp.Exemplars())
+}
+
+func setSummaryDataPoint(f *fields, dp pmetric.SummaryDataPoint) {
+	setCommonDataPointFields(f, dp)
+	count, _ := f.int("count")
+	dp.SetCount(uint64(count))
+	sum, _ := f.float("sum")
+	dp.SetSum(sum)
+	var quantiles []quantileJSON
+	f.json("quantiles", &quantiles)
+	for _, q := range quantiles {
+		qv := dp.QuantileValues().AppendEmpty()
+		qv.SetQuantile(float64(q.Quantile))
+		qv.SetValue(float64(q.Value))
+	}
+}
+
+func setExemplars(f *fields, exemplars pmetric.ExemplarSlice) {
+	var decoded []exemplarJSON
+	f.json("exemplars", &decoded)
+	for _, e := range decoded {
+		ex := exemplars.AppendEmpty()
+		ts, tsErr := parseTimestamp(e.Timestamp)
+		traceID, traceErr := parseTraceID(e.TraceID)
+		spanID, spanErr := parseSpanID(e.SpanID)
+		if err := errors.Join(tsErr, traceErr, spanErr); err != nil {
+			f.fail("exemplars", err)
+		}
+		ex.SetTimestamp(ts)
+		ex.SetTraceID(traceID)
+		ex.SetSpanID(spanID)
+		switch {
+		case e.ValueInt != nil:
+			ex.SetIntValue(*e.ValueInt)
+		case e.ValueDouble != nil:
+			ex.SetDoubleValue(float64(*e.ValueDouble))
+		}
+		if err := ex.FilteredAttributes().FromRaw(jsonToRaw(e.FilteredAttributes).(map[string]any)); err != nil {
+			f.fail("exemplars", err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconvtest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestMetricsRoundTrip(t *testing.T) {
+	md := testdata.GeneratMetricsAllTypesWithSampleDatapoints()
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	exp := metrics.AppendEmpty()
+	exp.SetName("exponential")
+	dp := exp.SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+	dp.SetScale(2)
+	dp.SetCount(7)
+	dp.SetMin(1)
+	dp.Positive().SetOffset(-1)
+	dp.Positive().BucketCounts().FromRaw([]uint64{3, 4})
+	ex := dp.Exemplars().AppendEmpty()
+	ex.SetDoubleValue(2.5)
+	ex.FilteredAttributes().PutStr("k", "v")
+	hist := metrics.AppendEmpty()
+	hist.SetName("bounds")
+	hist.SetEmptyHistogram().DataPoints().AppendEmpty().ExplicitBounds().FromRaw([]float64{math.Inf(1)})
+
+	want := Normalize(rowconv.MetricsSchema, rowconv.Metrics(md, rowconv.MetricsOptions{}))
+	got, err := Metrics(want)
+	require.NoError(t, err)
+	assert.Equal(t, md.MetricCount(), got.MetricCount())
+	assert.Equal(t, md.DataPointCount(), got.DataPointCount())
+	assert.Equal(t, want, Normalize(rowconv.MetricsSchema, rowconv.Metrics(got, rowconv.MetricsOptions{})))
+
+	gotMetrics := got.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	gotDP := gotMetrics.At(gotMetrics.Len() - 2).ExponentialHistogram().DataPoints().At(0)
+	assert.Equal(t, int32(-1), gotDP.Positive().Offset())
+	assert.True(t, gotDP.HasMin())
+	assert.False(t, gotDP.HasMax())
+	assert.Equal(t, pmetric.ExemplarValueTypeDouble, gotDP.Exemplars().At(0).ValueType())
+}
+
+func TestMetricsInvalidRow(t *testing.T) {
+	_, err := Metrics([]rowconv.Row{{"metric_type": "TABLE"}})
+	assert.ErrorContains(t, err, `row 0: column metric_type: unknown metric type "TABLE"`)
+
+	_, err = Metrics([]rowconv.Row{{"metric_type": "SUMMARY", "quantiles": `[{"quantile":"high"}]`}})
+	assert.ErrorContains(t, err, "column quantiles")
+}
@@ -0,0 +1,504 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rowconvtest reads rows written by the exporter back from BigQuery
+// and reconstructs the OTLP data they were converted from, so tests can
+// check that data round-trips through the tables for every schema mode.
+//
+// The reconstruction is as faithful as the tables: attribute values whose
+// types JSON does not distinguish, such as integral doubles, come back with
+// the type JSON implies, and values the schema omits come back empty.
+// Reconstructed data therefore equals the original when both are converted
+// with package rowconv and normalized, not necessarily field by field.
+package rowconvtest // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconvtest"
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"google.golang.org/api/iterator"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+// ReadRows reads every row of table. The client of table may point at
+// BigQuery or at an emulator.
+func ReadRows(ctx context.Context, table *bigquery.Table) ([]rowconv.Row, error) {
+	it := table.Read(ctx)
+	var rows []rowconv.Row
+	for {
+		r := rowconv.Row{}
+		err := it.Next(&r)
+		if errors.Is(err, iterator.Done) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s.%s: %w", table.DatasetID, table.TableID, err)
+		}
+		rows = append(rows, r)
+	}
+}
+
+// Normalize returns rows as a table with the given schema stores them, so
+// rows converted by package rowconv compare equal to rows read back:
+// columns missing from schema are dropped and missing columns are NULL,
+// timestamps are truncated to microseconds, integers are int64 and JSON
+// columns hold canonical JSON with sorted keys and Go formatted numbers. JSON columns are recognized by their type
+// in schema, so pass the schema with JSON columns typed as JSON even when
+// the table stores them as STRING. RECORD columns hold the canonical JSON
+// of their value, so rows read from nested tables can be reconstructed
+// like rows holding JSON.
+func Normalize(schema bigquery.Schema, rows []rowconv.Row) []rowconv.Row {
+	out := make([]rowconv.Row, 0, len(rows))
+	for _, r := range rows {
+		normalized := make(rowconv.Row, len(schema))
+		for _, field := range schema {
+			if field.Type == bigquery.RecordFieldType {
+				normalized[field.Name] = normalizeRecord(field, r[field.Name])
+				continue
+			}
+			if field.Repeated {
+				normalized[field.Name] = normalizeRepeated(field.Type, r[field.Name])
+				continue
+			}
+			normalized[field.Name] = normalizeValue(field.Type, r[field.Name])
+		}
+		out = append(out, normalized)
+	}
+	return out
+}
+
+func normalizeValue(typ bigquery.FieldType, v bigquery.Value) bigquery.Value {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Truncate(time.Microsecond)
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case string:
+		if typ != bigquery.JSONFieldType {
+			return v
+		}
+		var decoded any
+		if err := decodeJSON(v, &decoded); err != nil {
+			return v
+		}
+		b, err := json.Marshal(jsonToRaw(decoded))
+		if err != nil {
+			return v
+		}
+		return string(b)
+	default:
+		return v
+	}
+}
+
+// normalizeRepeated returns a REPEATED scalar column as a slice of
+// normalized values, whether v is the typed slice the converters write or
+// the values read back from the table. NULL and empty are the same.
+func normalizeRepeated(typ bigquery.FieldType, v bigquery.Value) bigquery.Value {
+	var elements []bigquery.Value
+	switch v := v.(type) {
+	case []int64:
+		for _, e := range v {
+			elements = append(elements, e)
+		}
+	case []bigquery.Value:
+		elements = v
+	}
+	out := make([]bigquery.Value, len(elements))
+	for i, e := range elements {
+		out[i] = normalizeValue(typ, e)
+	}
+	return out
+}
+
+// normalizeRecord returns a RECORD column as the canonical JSON the
+// converters write for it, whether v is that JSON or the value read back
+// from the table: every subfield is present, timestamps are truncated to
+// microseconds and non-finite floats are the strings rowconv writes.
+func normalizeRecord(field *bigquery.FieldSchema, v bigquery.Value) bigquery.Value {
+	if s, ok := v.(string); ok {
+		var decoded any
+		if err := decodeJSON(s, &decoded); err != nil {
+			return v
+		}
+		v = decoded
+	}
+	b, err := json.Marshal(normalizeNested(field, v))
+	if err != nil {
+		return v
+	}
+	return string(b)
+}
+
+func normalizeNested(field *bigquery.FieldSchema, v any) any {
+	if field.Repeated {
+		var elements []any
+		switch v := v.(type) {
+		case []any:
+			elements = v
+		case []bigquery.Value:
+			for _, e := range v {
+				elements = append(elements, e)
+			}
+		}
+		element := *field
+		element.Repeated = false
+		out := make([]any, len(elements))
+		for i, e := range elements {
+			out[i] = normalizeNested(&element, e)
+		}
+		return out
+	}
+	if v == nil {
+		return nil
+	}
+	switch field.Type {
+	case bigquery.RecordFieldType:
+		var object map[string]any
+		switch v := v.(type) {
+		case map[string]any:
+			object = v
+		case map[string]bigquery.Value:
+			object = make(map[string]any, len(v))
+			for k, e := range v {
+				object[k] = e
+			}
+		}
+		out := make(map[string]any, len(field.Schema))
+		for _, sub := range field.Schema {
+			out[sub.Name] = normalizeNested(sub, object[sub.Name])
+		}
+		return out
+	case bigquery.TimestampFieldType:
+		t, ok := v.(time.Time)
+		if s, isString := v.(string); isString {
+			parsed, err := time.Parse(time.RFC3339Nano, s)
+			t, ok = parsed, err == nil
+		}
+		if !ok {
+			return v
+		}
+		return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+	case bigquery.JSONFieldType:
+		if s, ok := v.(string); ok {
+			var decoded any
+			if err := decodeJSON(s, &decoded); err == nil {
+				v = decoded
+			}
+		}
+		return jsonToRaw(v)
+	case bigquery.FloatFieldType:
+		f, ok := jsonToRaw(v).(float64)
+		if i, isInt := jsonToRaw(v).(int64); isInt {
+			f, ok = float64(i), true
+		}
+		switch {
+		case !ok:
+			return v
+		case math.IsNaN(f):
+			return "NaN"
+		case math.IsInf(f, 1):
+			return "+Inf"
+		case math.IsInf(f, -1):
+			return "-Inf"
+		}
+		return f
+	default:
+		return jsonToRaw(v)
+	}
+}
+
+// decodeJSON decodes s into dst keeping numbers as json.Number, so integers
+// are not turned into doubles.
+func decodeJSON(s string, dst any) error {
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+	return dec.Decode(dst)
+}
+
+// jsonToRaw converts numbers decoded by decodeJSON into int64 or float64,
+// the types pcommon accepts for raw values.
+func jsonToRaw(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, e := range v {
+			v[k] = jsonToRaw(e)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = jsonToRaw(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// jsonFloat is a JSON number that may also be one of the strings "NaN",
+// "+Inf" and "-Inf" rowconv writes for non-finite values.
+type jsonFloat float64
+
+func (f *jsonFloat) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return fmt.Errorf("invalid non-finite number %q", s)
+		}
+		*f = jsonFloat(v)
+		return nil
+	}
+	var v float64
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*f = jsonFloat(v)
+	return nil
+}
+
+// fields reads typed column values from a row and keeps the first error.
+type fields struct {
+	row rowconv.Row
+	err error
+}
+
+func (f *fields) fail(column string, err error) {
+	if f.err == nil {
+		f.err = fmt.Errorf("column %s: %w", column, err)
+	}
+}
+
+func (f *fields) string(column string) string {
+	switch v := f.row[column].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		f.fail(column, fmt.Errorf("unexpected type %T", v))
+		return ""
+	}
+}
+
+// int returns the value of an INT64 column, or false when it is NULL.
+func (f *fields) int(column string) (int64, bool) {
+	switch v := normalizeValue(bigquery.IntegerFieldType, f.row[column]).(type) {
+	case nil:
+		return 0, false
+	case int64:
+		return v, true
+	default:
+		f.fail(column, fmt.Errorf("unexpected type %T", v))
+		return 0, false
+	}
+}
+
+// float returns the value of a FLOAT64 column, or false when it is NULL.
+func (f *fields) float(column string) (float64, bool) {
+	switch v := normalizeValue(bigquery.FloatFieldType, f.row[column]).(type) {
+	case nil:
+		return 0, false
+	case float64:
+		return v, true
+	default:
+		f.fail(column, fmt.Errorf("unexpected type %T", v))
+		return 0, false
+	}
+}
+
+func (f *fields) bool(column string) bool {
+	switch v := f.row[column].(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		f.fail(column, fmt.Errorf("unexpected type %T", v))
+		return false
+	}
+}
+
+func (f *fields) timestamp(column string) pcommon.Timestamp {
+	switch v := f.row[column].(type) {
+	case nil:
+		return 0
+	case time.Time:
+		return pcommon.NewTimestampFromTime(v)
+	default:
+		f.fail(column, fmt.Errorf("unexpected type %T", v))
+		return 0
+	}
+}
+
+// json decodes a JSON column into dst. NULL and empty values leave dst
+// unchanged.
+func (f *fields) json(column string, dst any) {
+	s := f.string(column)
+	if s == "" {
+		return
+	}
+	if err := decodeJSON(s, dst); err != nil {
+		f.fail(column, err)
+	}
+}
+
+// attributes decodes a JSON column of attributes into attrs.
+func (f *fields) attributes(column string, attrs pcommon.Map) {
+	var raw map[string]any
+	f.json(column, &raw)
+	if err := attrs.FromRaw(jsonToRaw(raw).(map[string]any)); err != nil {
+		f.fail(column, err)
+	}
+}
+
+func (f *fields) traceID(column string) pcommon.TraceID {
+	id, err := parseTraceID(f.string(column))
+	if err != nil {
+		f.fail(column, err)
+	}
+	return id
+}
+
+func (f *fields) spanID(column string) pcommon.SpanID {
+	id, err := parseSpanID(f.string(column))
+	if err != nil {
+		f.fail(column, err)
+	}
+	return id
+}
+
+func parseTraceID(s string) (pcommon.TraceID, error) {
+	var id pcommon.TraceID
+	if s == "" {
+		return id, nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return id, fmt.Errorf("invalid trace ID %q", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+func parseSpanID(s string) (pcommon.SpanID, error) {
+	var id pcommon.SpanID
+	if s == "" {
+		return id, nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return id, fmt.Errorf("invalid span ID %q", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+func parseTimestamp(s string) (pcommon.Timestamp, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, err
+	}
+	return pcommon.NewTimestampFromTime(t), nil
+}
+
+// origin identifies the resource and scope a row was converted from.
+type origin struct {
+	resource, resourceSchemaURL, scope, scopeSchemaURL string
+}
+
+// rowOrigin returns the origin of a row from its canonical resource and
+// scope columns.
+func rowOrigin(f *fields, resourceColumn string) origin {
+	return origin{
+		resource:          canonicalJSON(f, resourceColumn),
+		resourceSchemaURL: f.string("resource_schema_url"),
+		scope:             canonicalJSON(f, "instrumentation_scope"),
+		scopeSchemaURL:    f.string("scope_schema_url"),
+	}
+}
+
+func canonicalJSON(f *fields, column string) string {
+	s, _ := normalizeValue(bigquery.JSONFieldType, f.string(column)).(string)
+	return s
+}
+
+// scopeJSON mirrors the instrumentation_scope column.
+type scopeJSON struct {
+	Name       string         `json:"name"`
+	Version    string         `json:"version"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// setScope fills scope from the instrumentation_scope column.
+func (f *fields) setScope(scope pcommon.InstrumentationScope) {
+	var s scopeJSON
+	f.json("instrumentation_scope", &s)
+	scope.SetName(s.Name)
+	scope.SetVersion(s.Version)
+	if err := scope.Attributes().FromRaw(jsonToRaw(s.Attributes).(map[string]any)); err != nil {
+		f.fail("instrumentation_scope", err)
+	}
+}
+
+// hierarchy adds the resource and scope of each row to a signal the first
+// time they appear, so rows of the same origin share them.
+type hierarchy[R, S any] struct {
+	resources   map[[2]string]R
+	scopes      map[origin]S
+	addResource func(f *fields, schemaURL string) R
+	addScope    func(f *fields, resource R, schemaURL string) S
+}
+
+func newHierarchy[R, S any](addResource func(*fields, string) R, addScope func(*fields, R, string) S) *hierarchy[R, S] {
+	return &hierarchy[R, S]{
+		resources:   map[[2]string]R{},
+		scopes:      map[origin]S{},
+		addResource: addResource,
+		addScope:    addScope,
+	}
+}
+
+// scope returns the scope of the row read by f.
+func (h *hierarchy[R, S]) scope(f *fields, resourceColumn string) S {
+	o := rowOrigin(f, resourceColumn)
+	if s, ok := h.scopes[o]; ok {
+		return s
+	}
+	key := [2]string{o.resource, o.resourceSchemaURL}
+	r, ok := h.resources[key]
+	if !ok {
+		r = h.addResource(f, o.resourceSchemaURL)
+		h.resources[key] = r
+	}
+	s := h.addScope(f, r, o.scopeSchemaURL)
+	h.scopes[o] = s
+	return s
+}
@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconvtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+func TestNormalize(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "ts", Type: bigquery.TimestampFieldType},
+		{Name: "count", Type: bigquery.IntegerFieldType},
+		{Name: "attrs", Type: bigquery.JSONFieldType},
+		{Name: "text", Type: bigquery.StringFieldType},
+		{Name: "missing", Type: bigquery.StringFieldType},
+	}
+	rows := []rowconv.Row{{
+		"ts":    time.Date(2024, 1, 2, 3, 4, 5, 6789, time.FixedZone("CET", 3600)),
+		"count": uint64(3),
+		"attrs": `{"b": 1.50, "a": [1, 2]}`,
+		"text":  `{"b": 1}`,
+		"extra": "dropped",
+	}}
+	assert.Equal(t, []rowconv.Row{{
+		"ts":      time.Date(2024, 1, 2, 2, 4, 5, 6000, time.UTC),
+		"count":   int64(3),
+		"attrs":   `{"a":[1,2],"b":1.5}`,
+		"text":    `{"b": 1}`,
+		"missing": nil,
+	}}, Normalize(schema, rows))
+}
+
+func TestNormalizeRecord(t *testing.T) {
+	schema := bigquery.Schema{rowconv.NestedFields["events"], rowconv.NestedFields["quantiles"], rowconv.NestedFields["instrumentation_scope"]}
+	written := []rowconv.Row{{
+		"events":                `[{"timestamp":"2024-01-02T03:04:05.000006789Z","name":"e","attributes":{"k":1},"dropped_attributes_count":0}]`,
+		"quantiles":             `[{"quantile":1,"value":"NaN"}]`,
+		"instrumentation_scope": `{"name":"lib","version":""}`,
+	}}
+	read := []rowconv.Row{{
+		"events": []bigquery.Value{map[string]bigquery.Value{
+			"timestamp":                time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC),
+			"name":                     "e",
+			"attributes":               `{"k": 1}`,
+			"dropped_attributes_count": int64(0),
+		}},
+		"quantiles":             []bigquery.Value{map[string]bigquery.Value{"quantile": 1.0, "value": math.NaN()}},
+		"instrumentation_scope": map[string]bigquery.Value{"name": "lib", "version": "", "attributes": nil},
+	}}
+	want := []rowconv.Row{{
+		"events":                `[{"attributes":{"k":1},"dropped_attributes_count":0,"name":"e","timestamp":"2024-01-02T03:04:05.000006Z"}]`,
+		"quantiles":             `[{"quantile":1,"value":"NaN"}]`,
+		"instrumentation_scope": `{"attributes":null,"name":"lib","version":""}`,
+	}}
+	assert.Equal(t, want, Normalize(schema, written))
+	assert.Equal(t, want, Normalize(schema, read))
+}
+
+func TestNormalizeRepeated(t *testing.T) {
+	schema := bigquery.Schema{{Name: "counts", Type: bigquery.IntegerFieldType, Repeated: true}}
+	want := []rowconv.Row{{"counts": []bigquery.Value{int64(1), int64(2)}}}
+	assert.Equal(t, want, Normalize(schema, []rowconv.Row{{"counts": []int64{1, 2}}}))
+	assert.Equal(t, want, Normalize(schema, []rowconv.Row{{"counts": []bigquery.Value{int64(1), int64(2)}}}))
+	assert.Equal(t, []rowconv.Row{{"counts": []bigquery.Value{}}}, Normalize(schema, []rowconv.Row{{}}))
+}
@@ -0,0 +1,130 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconvtest // import "github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconvtest"
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+)
+
+var spanKinds = map[string]ptrace.SpanKind{
+	"INTERNAL": ptrace.SpanKindInternal,
+	"SERVER":   ptrace.SpanKindServer,
+	"CLIENT":   ptrace.SpanKindClient,
+	"PRODUCER": ptrace.SpanKindProducer,
+	"CONSUMER": ptrace.SpanKindConsumer,
+}
+
+var statusCodes = map[string]ptrace.StatusCode{
+	"OK":    ptrace.StatusCodeOk,
+	"ERROR": ptrace.StatusCodeError,
+}
+
+// eventJSON mirrors an element of the events column.
+type eventJSON struct {
+	Timestamp              string         `json:"timestamp"`
+	Name                   string         `json:"name"`
+	Attributes             map[string]any `json:"attributes"`
+	DroppedAttributesCount uint32         `json:"dropped_attributes_count"`
+}
+
+// linkJSON mirrors an element of the links column.
+type linkJSON struct {
+	TraceID                string         `json:"trace_id"`
+	SpanID                 string         `json:"span_id"`
+	TraceState             string         `json:"trace_state"`
+	Attributes             map[string]any `json:"attributes"`
+	DroppedAttributesCount uint32         `json:"dropped_attributes_count"`
+	Flags                  uint32         `json:"flags"`
+}
+
+// Traces reconstructs spans from rows of the rowconv.TracesSchema table.
+func Traces(rows []rowconv.Row) (ptrace.Traces, error) {
+	td := ptrace.NewTraces()
+	h := newHierarchy(
+		func(f *fields, schemaURL string) ptrace.ResourceSpans {
+			rs := td.ResourceSpans().AppendEmpty()
+			f.attributes("resource_attributes", rs.Resource().Attributes())
+			rs.SetSchemaUrl(schemaURL)
+			return rs
+		},
+		func(f *fields, rs ptrace.ResourceSpans, schemaURL string) ptrace.ScopeSpans {
+			ss := rs.ScopeSpans().AppendEmpty()
+			f.setScope(ss.Scope())
+			ss.SetSchemaUrl(schemaURL)
+			return ss
+		},
+	)
+	for i, r := range rows {
+		f := &fields{row: r}
+		setSpan(f, h.scope(f, "resource_attributes").Spans().AppendEmpty())
+		if f.err != nil {
+			return ptrace.Traces{}, fmt.Errorf("row %d: %w", i, f.err)
+		}
+	}
+	return td, nil
+}
+
+func setSpan(f *fields, span ptrace.Span) {
+	span.SetTraceID(f.traceID("trace_id"))
+	span.SetSpanID(f.spanID("span_id"))
+	span.SetParentSpanID(f.spanID("parent_span_id"))
+	span.TraceState().FromRaw(f.string("trace_state"))
+	span.SetName(f.string("name"))
+	span.SetKind(spanKinds[f.string("kind")])
+	span.SetStartTimestamp(f.timestamp("start_time"))
+	span.SetEndTimestamp(f.timestamp("end_time"))
+	span.Status().SetCode(statusCodes[f.string("status_code")])
+	span.Status().SetMessage(f.string("status_message"))
+	flags, _ := f.int("flags")
+	span.SetFlags(uint32(flags))
+	dropped, _ := f.int("dropped_attributes_count")
+	span.SetDroppedAttributesCount(uint32(dropped))
+	dropped, _ = f.int("dropped_events_count")
+	span.SetDroppedEventsCount(uint32(dropped))
+	dropped, _ = f.int("dropped_links_count")
+	span.SetDroppedLinksCount(uint32(dropped))
+	f.attributes("span_attributes", span.Attributes())
+
+	var events []eventJSON
+	f.json("events", &events)
+	for _, e := range events {
+		event := span.Events().AppendEmpty()
+		ts, err := parseTimestamp(e.Timestamp)
+		if err != nil {
+			f.fail("events", err)
+		}
+		event.SetTimestamp(ts)
+		event.SetName(e.Name)
+		event.SetDroppedAttributesCount(e.DroppedAttributesCount)
+		if err := event.Attributes().FromRaw(jsonToRaw(e.Attributes).(map[string]any)); err != nil {
+			f.fail("events", err)
+		}
+	}
+
+	var links []linkJSON
+	f.json("links", &links)
+	for _, l := range links {
+		link := span.Links().AppendEmpty()
+		traceID, err := parseTraceID(l.TraceID)
+		if err != nil {
+			f.fail("links", err)
+		}
+		spanID, err := parseSpanID(l.SpanID)
+		if err != nil {
+			f.fail("links", err)
+		}
+		link.SetTraceID(traceID)
+		link.SetSpanID(spanID)
+		link.TraceState().FromRaw(l.TraceState)
+		link.SetDroppedAttributesCount(l.DroppedAttributesCount)
+		link.SetFlags(l.Flags)
+		if err := link.Attributes().FromRaw(jsonToRaw(l.Attributes).(map[string]any)); err != nil {
+			f.fail("links", err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package rowconvtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/bigquery/rowconv"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+func TestTracesRoundTrip(t *testing.T) {
+	td := testdata.GenerateTracesTwoSpansSameResourceOneDifferent()
+	span := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	span.SetKind(ptrace.SpanKindServer)
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Attributes().PutDouble("ratio", 0.5)
+	span.Attributes().PutEmptySlice("values").AppendEmpty().SetInt(3)
+
+	want := Normalize(rowconv.TracesSchema, rowconv.Traces(td, rowconv.TracesOptions{}))
+	got, err := Traces(want)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.ResourceSpans().Len())
+	assert.Equal(t, td.SpanCount(), got.SpanCount())
+	assert.Equal(t, want, Normalize(rowconv.TracesSchema, rowconv.Traces(got, rowconv.TracesOptions{})))
+
+	gotSpan := got.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, span.TraceID(), gotSpan.TraceID())
+	assert.Equal(t, ptrace.SpanKindServer, gotSpan.Kind())
+	assert.Equal(t, ptrace.StatusCodeError, gotSpan.Status().Code())
+	assert.Equal(t, span.Events().Len(), gotSpan.Events().Len())
+}
+
+func TestTracesInvalidRow(t *testing.T) {
+	_, err := Traces([]rowconv.Row{{"trace_id": "not hex"}})
+	assert.ErrorContains(t, err, "row 0: column trace_id")
+
+	_, err = Traces([]rowconv.Row{{"name": int64(1)}})
+	assert.ErrorContains(t, err, "column name: unexpected type int64")
+}